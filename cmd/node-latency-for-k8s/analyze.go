@@ -0,0 +1,255 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/analyze"
+	"github.com/awslabs/node-latency-for-k8s/pkg/logging"
+)
+
+// runAnalyze runs an analyze subcommand on a directory of JSON results of --output json
+func runAnalyze(args []string) error {
+	usage := fmt.Sprintf("usage: %s analyze export|breakdown|trend|serve [flags] <results dir>", filepath.Base(os.Args[0]))
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	switch args[0] {
+	case "export":
+		return analyzeExport(args[1:])
+	case "breakdown":
+		return analyzeBreakdown(args[1:])
+	case "trend":
+		return analyzeTrend(args[1:])
+	case "serve":
+		return analyzeServe(args[1:])
+	}
+	return fmt.Errorf("unknown analyze command \"%s\", %s", args[0], usage)
+}
+
+// analyzeExport pivots a directory of results into a wide table with one row per node and one column per event
+func analyzeExport(args []string) error {
+	f := flag.NewFlagSet("analyze export", flag.ExitOnError)
+	format := f.String("format", analyze.FormatCSVWide, fmt.Sprintf("Export format, one of %s, default: %s", strings.Join(analyze.ExportFormats, ", "), analyze.FormatCSVWide))
+	out := f.String("out", "", "Path to write the table to, default: stdout")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	dir := "."
+	if f.NArg() > 0 {
+		dir = f.Arg(0)
+	}
+	results, err := analyze.Load(dir)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("unable to create %s: %w", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	return analyze.Pivot(results).WriteTable(w, *format)
+}
+
+// analyzeBreakdown breaks the latency of an event metric down by the grouping dimensions, by default the node group and launch template version
+func analyzeBreakdown(args []string) error {
+	f := flag.NewFlagSet("analyze breakdown", flag.ExitOnError)
+	metric := f.String("metric", analyze.DefaultBreakdownMetric, fmt.Sprintf("Event metric to break down, default: %s", analyze.DefaultBreakdownMetric))
+	groupBy, percentiles := breakdownFlags(f)
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	breakdown, err := parseBreakdown(*groupBy, *percentiles)
+	if err != nil {
+		return err
+	}
+	dir := "."
+	if f.NArg() > 0 {
+		dir = f.Arg(0)
+	}
+	results, err := analyze.Load(dir)
+	if err != nil {
+		return err
+	}
+	return breakdown.WriteCSV(os.Stdout, breakdown.Compute(results, *metric))
+}
+
+// analyzeTrend prints the weekly trend of each event metric, including the daily summaries of downsampled results
+func analyzeTrend(args []string) error {
+	f := flag.NewFlagSet("analyze trend", flag.ExitOnError)
+	weeks := f.Int("weeks", analyze.DefaultTrendWeeks, fmt.Sprintf("Number of weeks up to the current week to show, default: %d", analyze.DefaultTrendWeeks))
+	p := f.Float64("percentile", analyze.DefaultTrendPercentile, fmt.Sprintf("Percentile of each week, default: %.0f", analyze.DefaultTrendPercentile))
+	format := f.String("format", "text", "Output format, text (sparklines) or json, default: text")
+	markerFile := f.String("markers", "", fmt.Sprintf("JSON file of a marker or a list of markers to overlay in addition to the markers in %s/, i.e. AMI release times, default: <none>", analyze.MarkersDir))
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if *weeks < 1 {
+		return fmt.Errorf("--weeks must be at least 1")
+	}
+	if *p <= 0 || *p > 100 {
+		return fmt.Errorf("--percentile must be in (0, 100]")
+	}
+	dir := "."
+	if f.NArg() > 0 {
+		dir = f.Arg(0)
+	}
+	summaries, err := analyze.LoadSummaries(dir)
+	if err != nil {
+		return err
+	}
+	results, err := analyze.Load(dir)
+	// a results directory that was fully downsampled only holds daily summaries
+	if err != nil && len(summaries) == 0 {
+		return err
+	}
+	markers, err := analyze.LoadMarkers(dir)
+	if err != nil {
+		return err
+	}
+	if *markerFile != "" {
+		fileMarkers, err := analyze.LoadMarkerFile(*markerFile)
+		if err != nil {
+			return err
+		}
+		markers = append(markers, fileMarkers...)
+		sort.SliceStable(markers, func(i, j int) bool { return markers[i].Time.Before(markers[j].Time) })
+	}
+	report := analyze.NewTrendReport(analyze.Trends(results, summaries, *p, *weeks, time.Now()), markers)
+	switch *format {
+	case "json":
+		return json.NewEncoder(os.Stdout).Encode(report)
+	case "text":
+		return report.WriteTrends(os.Stdout)
+	}
+	return fmt.Errorf("unknown format \"%s\", must be text or json", *format)
+}
+
+// analyzeServe runs an aggregator that accepts results pushed by the nodes of many clusters with --aggregator-url into a results directory,
+// which the other analyze commands read like the results of a single cluster
+func analyzeServe(args []string) error {
+	f := flag.NewFlagSet("analyze serve", flag.ExitOnError)
+	addr := f.String("listen", ":8080", "Address to accept pushed results on, default: :8080")
+	tokens := f.String("tokens", strEnv("AGGREGATOR_TOKENS", ""), "Comma separated cluster=token pairs, a cluster pushes with its bearer token (env AGGREGATOR_TOKENS), required")
+	tlsCert := f.String("tls-cert", "", "TLS certificate file to serve HTTPS with, default: <plain HTTP>")
+	tlsKey := f.String("tls-key", "", "TLS key file of --tls-cert")
+	retentionDays := f.Int("retention-days", 0, "Remove results and daily summaries older than this many days, default: <keep all>")
+	retentionMaxResults := f.Int("retention-max-results", 0, "Keep only the newest N results, default: <keep all>")
+	downsampleAfterDays := f.Int("downsample-after-days", 0, fmt.Sprintf("Replace results older than this many days with daily per-metric percentiles in %s/, default: <disabled>", analyze.SummaryDir))
+	groupBy, percentiles := breakdownFlags(f)
+	logFormat := f.String("log-format", strEnv("LOG_FORMAT", "console"), fmt.Sprintf("Log output format, one of %s, default: console", strings.Join(logging.Formats, ", ")))
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	breakdown, err := parseBreakdown(*groupBy, *percentiles)
+	if err != nil {
+		return err
+	}
+	if err := logging.Configure("info", *logFormat, nil); err != nil {
+		return err
+	}
+	dir := "."
+	if f.NArg() > 0 {
+		dir = f.Arg(0)
+	}
+	clusterTokens, err := parseKeyValues(*tokens)
+	if err != nil {
+		return fmt.Errorf("invalid --tokens: %w", err)
+	}
+	if len(clusterTokens) == 0 {
+		return fmt.Errorf("--tokens is required to authenticate the clusters")
+	}
+	for cluster := range clusterTokens {
+		if err := analyze.ValidClusterName(cluster); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("unable to create %s: %w", dir, err)
+	}
+	retention := analyze.Retention{
+		MaxAge:          time.Duration(*retentionDays) * 24 * time.Hour,
+		MaxResults:      *retentionMaxResults,
+		DownsampleAfter: time.Duration(*downsampleAfterDays) * 24 * time.Hour,
+		Percentiles:     breakdown.Percentiles,
+	}
+	if retention.MaxAge > 0 || retention.MaxResults > 0 || retention.DownsampleAfter > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for {
+				removed, err := retention.Apply(dir, time.Now())
+				if err != nil {
+					zap.S().Errorf("Unable to apply the results retention: %s", err)
+				} else if removed > 0 {
+					zap.S().Infof("Removed %d results by the retention policy", removed)
+				}
+				<-ticker.C
+			}
+		}()
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/results", &analyze.Ingest{Dir: dir, Tokens: clusterTokens})
+	mux.Handle("/markers", &analyze.MarkerIngest{Dir: dir, Tokens: clusterTokens})
+	mux.Handle("/views/launch-templates", &analyze.LaunchTemplateView{Dir: dir, Tokens: clusterTokens})
+	mux.Handle("/views/breakdown", &analyze.BreakdownView{Dir: dir, Tokens: clusterTokens, Breakdown: breakdown})
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	zap.S().Infof("Accepting results from %d clusters on %s/results into %s", len(clusterTokens), *addr, dir)
+	if *tlsCert != "" {
+		return srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+	}
+	return srv.ListenAndServe()
+}
+
+// breakdownFlags registers the grouping dimension and percentile flags of a breakdown
+func breakdownFlags(f *flag.FlagSet) (*string, *string) {
+	groupBy := f.String("group-by", strings.Join(analyze.LaunchTemplateDimensions, ","), fmt.Sprintf("Comma separated grouping dimensions, any of %s or an instance tag dimension of --imds-tag-dimensions, i.e. nodepool, default: %s",
+		strings.Join(analyze.BuiltinDimensions(), ", "), strings.Join(analyze.LaunchTemplateDimensions, ",")))
+	defaultPercentiles := strings.Join(lo.Map(analyze.SummaryPercentiles, func(p float64, _ int) string { return strconv.FormatFloat(p, 'f', -1, 64) }), ",")
+	percentiles := f.String("percentiles", defaultPercentiles, fmt.Sprintf("Comma separated percentiles to compute, i.e. 95 or 50,99.9, default: %s", defaultPercentiles))
+	return groupBy, percentiles
+}
+
+// parseBreakdown parses the breakdown flags
+func parseBreakdown(groupBy string, percentiles string) (analyze.Breakdown, error) {
+	parsed, err := analyze.ParsePercentiles(percentiles)
+	if err != nil {
+		return analyze.Breakdown{}, fmt.Errorf("invalid --percentiles: %w", err)
+	}
+	return analyze.Breakdown{Dimensions: analyze.ParseDimensions(groupBy), Percentiles: parsed}, nil
+}
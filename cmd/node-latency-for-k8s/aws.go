@@ -0,0 +1,144 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+)
+
+// awsEndpointServices are the AWS services whose endpoints can be overridden with --aws-endpoints, by their normalized service ID
+var awsEndpointServices = []string{"autoscaling", "cloudwatch", "dynamodb", "ec2", "kms", "s3", "sts"}
+
+// awsRoleSessionName is the session name of the assumed --aws-role-arn, which shows up in CloudTrail
+const awsRoleSessionName = "node-latency-for-k8s"
+
+var logAWSCredentials sync.Once
+
+// loadAWSConfig loads the AWS SDK config that is shared by all AWS sources and emitters. The HTTP client goes through the configured proxy,
+// the region and service endpoints are overridden, and the --aws-role-arn is assumed with the credentials of the default chain.
+// EKS Pod Identity credentials are resolved explicitly since the default chain only allows loopback container credential endpoints.
+func loadAWSConfig(ctx context.Context, options Options, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+	httpClient := awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) { t.Proxy = proxy.Func })
+	loadOptions := []func(*config.LoadOptions) error{config.WithHTTPClient(httpClient)}
+	if options.AWSRegion != "" {
+		loadOptions = append(loadOptions, config.WithRegion(options.AWSRegion))
+	}
+	if options.AWSEndpoints != "" {
+		endpoints, err := parseAWSEndpoints(options.AWSEndpoints)
+		if err != nil {
+			return aws.Config{}, err
+		}
+		loadOptions = append(loadOptions, config.WithEndpointResolverWithOptions(awsEndpointResolver(endpoints)))
+	}
+	credentialSource := "the default credential chain"
+	if podIdentity := podIdentityCredentials(httpClient); podIdentity != nil {
+		loadOptions = append(loadOptions, config.WithCredentialsProvider(podIdentity))
+		credentialSource = "EKS Pod Identity"
+	} else if os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" {
+		credentialSource = "IRSA"
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, append(loadOptions, optFns...)...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if options.AWSRoleARN != "" {
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), options.AWSRoleARN, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = awsRoleSessionName
+		}))
+		credentialSource = fmt.Sprintf("role %s assumed with %s", options.AWSRoleARN, credentialSource)
+	}
+	logAWSCredentials.Do(func() {
+		zap.S().Infof("Using AWS credentials of %s in region %s", credentialSource, lo.Ternary(cfg.Region != "", cfg.Region, "<none>"))
+	})
+	return cfg, nil
+}
+
+// podIdentityCredentials returns the credentials of the EKS Pod Identity agent if the pod is associated with a role, nil otherwise.
+// The token file is rotated by the kubelet, so it is read on every refresh.
+func podIdentityCredentials(httpClient endpointcreds.HTTPClient) aws.CredentialsProvider {
+	endpoint, tokenFile := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"), os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE")
+	if endpoint == "" || tokenFile == "" {
+		return nil
+	}
+	return aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return aws.Credentials{}, fmt.Errorf("unable to read the EKS Pod Identity token: %w", err)
+		}
+		return endpointcreds.New(endpoint, func(o *endpointcreds.Options) {
+			o.HTTPClient = httpClient
+			o.AuthorizationToken = strings.TrimSpace(string(token))
+		}).Retrieve(ctx)
+	}))
+}
+
+// parseAWSEndpoints parses comma separated service=url endpoint overrides, i.e. for VPC endpoints or partitions the SDK does not know
+func parseAWSEndpoints(s string) (map[string]string, error) {
+	kvs, err := parseKeyValues(s)
+	if err != nil {
+		return nil, err
+	}
+	endpoints := map[string]string{}
+	for service, url := range kvs {
+		service = normalizeAWSService(service)
+		if !lo.Contains(awsEndpointServices, service) {
+			return nil, fmt.Errorf("invalid AWS endpoint service \"%s\", must be one of %s", service, strings.Join(awsEndpointServices, ", "))
+		}
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return nil, fmt.Errorf("invalid AWS endpoint \"%s\" of %s, must be a URL", url, service)
+		}
+		endpoints[service] = url
+	}
+	return endpoints, nil
+}
+
+// awsEndpointResolver resolves the overridden service endpoints, the other services fall back to the endpoints of the SDK
+func awsEndpointResolver(endpoints map[string]string) aws.EndpointResolverWithOptions {
+	return aws.EndpointResolverWithOptionsFunc(func(service string, region string, _ ...interface{}) (aws.Endpoint, error) {
+		if url, ok := endpoints[normalizeAWSService(service)]; ok {
+			return aws.Endpoint{URL: url, SigningRegion: region, HostnameImmutable: true}, nil
+		}
+		return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+	})
+}
+
+// normalizeAWSService normalizes an SDK service ID, i.e. "Auto Scaling" is autoscaling
+func normalizeAWSService(service string) string {
+	return strings.ToLower(strings.ReplaceAll(service, " ", ""))
+}
+
+// awsEndpointServicesUsage lists the services of --aws-endpoints
+func awsEndpointServicesUsage() string {
+	services := append([]string{}, awsEndpointServices...)
+	sort.Strings(services)
+	return strings.Join(services, ", ")
+}
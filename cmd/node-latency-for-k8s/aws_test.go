@@ -0,0 +1,72 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestParseAWSEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		s       string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "single endpoint",
+			s:    "sts=https://sts.vpce.example.com",
+			want: map[string]string{"sts": "https://sts.vpce.example.com"},
+		},
+		{
+			name: "SDK service IDs are normalized",
+			s:    "Auto Scaling=https://autoscaling.example.com, CloudWatch=http://localhost:4566",
+			want: map[string]string{"autoscaling": "https://autoscaling.example.com", "cloudwatch": "http://localhost:4566"},
+		},
+		{name: "unknown service", s: "lambda=https://lambda.example.com", wantErr: true},
+		{name: "not a URL", s: "s3=s3.example.com", wantErr: true},
+		{name: "missing URL", s: "s3", wantErr: true},
+		{name: "empty service", s: "=https://s3.example.com", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseAWSEndpoints(tc.s)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseAWSEndpoints(%q) error = %v, wantErr %v", tc.s, err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseAWSEndpoints(%q) = %v, want %v", tc.s, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAWSEndpointResolver(t *testing.T) {
+	resolver := awsEndpointResolver(map[string]string{"autoscaling": "https://autoscaling.example.com"})
+	endpoint, err := resolver.ResolveEndpoint("Auto Scaling", "eu-west-1")
+	if err != nil {
+		t.Fatalf("ResolveEndpoint() error = %v", err)
+	}
+	if want := (aws.Endpoint{URL: "https://autoscaling.example.com", SigningRegion: "eu-west-1", HostnameImmutable: true}); endpoint != want {
+		t.Errorf("ResolveEndpoint() = %+v, want %+v", endpoint, want)
+	}
+	var notFound *aws.EndpointNotFoundError
+	if _, err := resolver.ResolveEndpoint("EC2", "eu-west-1"); !errors.As(err, &notFound) {
+		t.Errorf("ResolveEndpoint() of a service without an endpoint error = %v, want an EndpointNotFoundError", err)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+)
+
+// runEvents runs an events subcommand on the event catalog of the binary
+func runEvents(args []string) error {
+	usage := fmt.Sprintf("usage: %s events docs [flags]", filepath.Base(os.Args[0]))
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	switch args[0] {
+	case "docs":
+		return eventsDocs(args[1:])
+	}
+	return fmt.Errorf("unknown events command \"%s\", %s", args[0], usage)
+}
+
+// eventsDocs generates the documentation of the default events of a profile and mode, and the custom events of an events file
+func eventsDocs(args []string) error {
+	f := flag.NewFlagSet("events docs", flag.ExitOnError)
+	format := f.String("format", "md", "Output format, md or json, default: md")
+	profile := f.String("profile", latency.ProfileEKS, fmt.Sprintf("Node profile of the default events (%s), default: %s", strings.Join(latency.Profiles, ", "), latency.ProfileEKS))
+	mode := f.String("mode", latency.ModeLaunch, fmt.Sprintf("Measurement mode of the default events (%s), default: %s", strings.Join(latency.Modes, ", "), latency.ModeLaunch))
+	eventsFile := f.String("events-file", "", "Path to a JSON file of custom regex event groups to document along with the default events, the events of all groups are documented, default: <none>")
+	examples := f.String("examples", "", "Evidence artifact of a recording (--evidence-dir) whose matched lines replace the built-in examples, default: <none>")
+	out := f.String("out", "", "Path to write the documentation to, default: stdout")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if *format != "md" && *format != "json" {
+		return fmt.Errorf("invalid format \"%s\", must be md or json", *format)
+	}
+	if !lo.Contains(latency.Profiles, *profile) {
+		return fmt.Errorf("invalid profile \"%s\", must be one of %s", *profile, strings.Join(latency.Profiles, ", "))
+	}
+	if !lo.Contains(latency.Modes, *mode) {
+		return fmt.Errorf("invalid mode \"%s\", must be one of %s", *mode, strings.Join(latency.Modes, ", "))
+	}
+	latencyClient := latency.New().WithEventCatalog().WithProfile(*profile).WithMode(*mode)
+	// the events are documented with the log source the profile reads on most nodes
+	if lo.Contains(latency.JournalProfiles, *profile) {
+		latencyClient = latencyClient.WithLogSource(journal.Name).WithJournal("/", "")
+	} else {
+		latencyClient = latencyClient.WithLogSource(messages.Name)
+	}
+	latencyClient, err := latencyClient.RegisterDefaultSources().RegisterDefaultEvents()
+	if err != nil {
+		return fmt.Errorf("unable to register the default events: %w", err)
+	}
+	if *eventsFile != "" {
+		groups, err := latency.LoadEventGroups(*eventsFile)
+		if err != nil {
+			return err
+		}
+		if latencyClient, err = latencyClient.RegisterEventGroups(context.Background(), groups); err != nil {
+			return fmt.Errorf("unable to register event groups: %w", err)
+		}
+	}
+	docs := latencyClient.EventDocs()
+	if *examples != "" {
+		captured, err := latency.LoadEvidenceExamples(*examples)
+		if err != nil {
+			return err
+		}
+		docs = latency.WithExamples(docs, captured)
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("unable to create %s: %w", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if *format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(docs)
+	}
+	return latency.WriteEventDocsMarkdown(w, fmt.Sprintf("node-latency-for-k8s events (%s profile, %s mode)", *profile, *mode), docs)
+}
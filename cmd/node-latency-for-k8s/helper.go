@@ -0,0 +1,95 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/logging"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/cloudinit"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/dockerd"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+)
+
+// defaultHelperSocket is where the helper listens and where --helper-socket usually points
+const defaultHelperSocket = "/run/node-latency-for-k8s/helper.sock"
+
+// defaultHelperAllow are the log files of the built-in log sources
+var defaultHelperAllow = strings.Join(lo.Uniq([]string{messages.DefaultPath, dockerd.DefaultPath, awsnode.DefaultPath, cloudinit.DefaultPath}), ",")
+
+// runHelper serves host logs over a unix socket to a measuring container whose reads are denied by SELinux or AppArmor
+// The helper is meant to run in a privileged or unconfined sidecar that shares the socket directory with the measurer
+func runHelper(args []string) error {
+	f := flag.NewFlagSet("helper", flag.ExitOnError)
+	socket := f.String("socket", strEnv("HELPER_SOCKET", defaultHelperSocket), fmt.Sprintf("Unix socket to serve log reads on, default: %s", defaultHelperSocket))
+	allow := f.String("allow", strEnv("HELPER_ALLOW", defaultHelperAllow), fmt.Sprintf("Comma separated globs of the log files that may be read, default: %s", defaultHelperAllow))
+	allowUID := f.String("allow-uid", strEnv("HELPER_ALLOW_UID", strconv.Itoa(os.Getuid())), "Comma separated UIDs of the processes that may read through the socket, default: <the helper's UID>")
+	maxBytes := f.Int64("max-bytes", int64(intEnv("HELPER_MAX_BYTES", int(sources.DefaultHelperMaxBytes))), fmt.Sprintf("Largest log file that is served, default: %d", sources.DefaultHelperMaxBytes))
+	logFormat := f.String("log-format", strEnv("LOG_FORMAT", "console"), fmt.Sprintf("Log output format, one of %s, default: console", strings.Join(logging.Formats, ", ")))
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if err := logging.Configure("info", *logFormat, nil); err != nil {
+		return err
+	}
+	allowed := lo.Compact(strings.Split(*allow, ","))
+	if len(allowed) == 0 {
+		return fmt.Errorf("--allow requires at least one glob")
+	}
+	var allowedUIDs []uint32
+	for _, rawUID := range lo.Compact(strings.Split(*allowUID, ",")) {
+		uid, err := strconv.ParseUint(strings.TrimSpace(rawUID), 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid --allow-uid \"%s\": %w", rawUID, err)
+		}
+		allowedUIDs = append(allowedUIDs, uint32(uid))
+	}
+	if err := os.MkdirAll(filepath.Dir(*socket), 0o750); err != nil {
+		return fmt.Errorf("unable to create the socket directory: %w", err)
+	}
+	if err := os.Remove(*socket); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("unable to remove the stale socket %s: %w", *socket, err)
+	}
+	listener, err := net.Listen("unix", *socket)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s: %w", *socket, err)
+	}
+	defer listener.Close()
+	// the peer UID is checked on every request, the mode keeps other users from connecting at all
+	if err := os.Chmod(*socket, 0o660); err != nil {
+		return fmt.Errorf("unable to restrict the permissions of %s: %w", *socket, err)
+	}
+	srv := &http.Server{
+		Handler:           sources.HelperHandler{Allowed: allowed, AllowedUIDs: allowedUIDs, MaxBytes: *maxBytes},
+		ConnContext:       sources.HelperConnContext,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	zap.S().Infof("Serving reads of %s to uids %v on %s", strings.Join(allowed, ", "), allowedUIDs, *socket)
+	return srv.Serve(listener)
+}
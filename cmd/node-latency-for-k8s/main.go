@@ -16,31 +16,59 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+	// embed the zoneinfo database for --timezone since the container image does not include it
+	_ "time/tzdata"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
-	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/samber/lo"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 
+	"github.com/awslabs/node-latency-for-k8s/pkg/analyze"
 	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+	"github.com/awslabs/node-latency-for-k8s/pkg/logging"
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	asgsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/asg"
+	azuresrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/azure"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/chaos"
+	crisrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/cri"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/dockerd"
+	egresssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/egress"
+	gcesrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/gce"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+	kubeletsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/kubelet"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
 )
 
 var (
@@ -49,24 +77,181 @@ var (
 )
 
 type Options struct {
-	CloudWatch          bool
-	Prometheus          bool
-	ExperimentDimension string
-	TimeoutSeconds      int
-	RetryDelaySeconds   int
-	MetricsPort         int
-	IMDSEndpoint        string
-	Kubeconfig          string
-	PodNamespace        string
-	NodeName            string
-	NoIMDS              bool
-	Output              string
-	NoComments          bool
-	Version             bool
+	CloudWatch           bool
+	CloudWatchBatchSize  int
+	CloudWatchJitter     int
+	CloudWatchAttempts   int
+	Prometheus           bool
+	PodMonitor           bool
+	ExperimentDimension  string
+	TimeoutSeconds       int
+	DeadlineSeconds      int
+	RetryDelaySeconds    int
+	MetricsPort          int
+	IMDSEndpoint         string
+	Kubeconfig           string
+	PodNamespace         string
+	NamespaceAllow       string
+	NamespaceDeny        string
+	NodeName             string
+	ClusterName          string
+	AggregatorURL        string
+	AggregatorToken      string
+	NoIMDS               bool
+	Output               string
+	NoComments           bool
+	SearchWindowStart    string
+	SearchWindowEnd      string
+	ReadCacheTTLSeconds  int
+	ReadCacheMaxBytes    int
+	ReadStateFile        string
+	EmitStateFile        string
+	DryRunDir            string
+	HelperSocket         string
+	EmitAliases          bool
+	Preflight            bool
+	MmapMinBytes         int
+	MaxScanBytes         int
+	MaxFindTimeMillis    int
+	GOMAXPROCS           int
+	MemoryLimitBytes     int
+	LogSource            string
+	JournalGatewayURL    string
+	JournalRoot          string
+	JournalNamespace     string
+	JournalBootWindow    int
+	Dockerd              bool
+	DockerdLogPath       string
+	Profile              string
+	KubeletEndpoint      string
+	CRIEndpoint          string
+	EgressProbeURL       string
+	StaticManifestDir    string
+	GCEMetadataEndpoint  string
+	EventOwners          string
+	DedupEvents          bool
+	SourcePriority       string
+	Timezone             string
+	SourceTimezones      string
+	StaleSeconds         int
+	StaleAction          string
+	AllBoots             bool
+	Mode                 string
+	PodSampleRate        float64
+	ImagePullReport      bool
+	AdmissionReport      bool
+	AdmissionAnnotation  string
+	ContainerdHostsDir   string
+	ImageCache           bool
+	ContainerdContentDir string
+	PriceTable           string
+	SpotPricing          bool
+	SpotSignals          bool
+	IMDSTagDimensions    string
+	ASG                  bool
+	ASGLifecycleHook     string
+	ASGAbandonOnTimeout  bool
+	OTLPMetricsEndpoint  string
+	OTLPTracesEndpoint   string
+	HTTPProxy            string
+	HTTPSProxy           string
+	NoProxy              string
+	AWSRoleARN           string
+	AWSRegion            string
+	AWSEndpoints         string
+	OTLPInsecure         bool
+	XRayDaemonAddress    string
+	TraceParent          string
+	TraceAnnotation      string
+	NodeClaimBaseline    bool
+	HoneycombDataset     string
+	HoneycombAPIKey      string
+	HoneycombAPIHost     string
+	DynamoDBTable        string
+	DynamoDBPartKey      string
+	DynamoDBSortKey      string
+	DynamoDBTTLAttr      string
+	DynamoDBTTLDays      int
+	BigQueryTable        string
+	NodeAnnotations      bool
+	PodAnnotations       bool
+	StartupTaint         string
+	StartupMilestones    string
+	StartupResources     string
+	StartupTaintTimeout  bool
+	Consolidation        bool
+	AmortizationTarget   float64
+	ProbeInterval        int
+	ProbeImage           string
+	Interval             int
+	IntervalJitter       int
+	Schedule             string
+	SoakTracks           string
+	HostPathFree         bool
+	RBACMinimized        bool
+	Standalone           bool
+	NodeBucketLabel      bool
+	SLOSeconds           int
+	SLOConditionType     string
+	Integrity            bool
+	SigningKey           string
+	SigningKMSKeyID      string
+	SigningKMSAlgorithm  string
+	LogLevel             string
+	LogFormat            string
+	LogDebugSources      string
+	Explain              string
+	FlamegraphFile       string
+	HTMLReport           string
+	EvidenceDir          string
+	CompletionFile       string
+	CompletionHook       string
+	HTMLReportS3URI      string
+	Budgets              string
+	ScoreWeights         string
+	Baseline             string
+	BaselineTolerance    string
+	BaselineTolerances   string
+	BakeGate             bool
+	BakeArtifactsDir     string
+	BakeArtifactsS3URI   string
+	EventsFile           string
+	ChaosConfig          string
+	MaxRegexProgram      int
+	OSReleasePath        string
+	Version              bool
 }
 
 //nolint:gocyclo
 func main() {
+	// Analyze subcommands work on results and do not measure
+	if len(os.Args) > 1 && os.Args[1] == "analyze" {
+		if err := runAnalyze(os.Args[2:]); err != nil {
+			log.Fatalf("Unable to analyze results: %s", err)
+		}
+		return
+	}
+	// Events subcommands document the event catalog and do not measure
+	if len(os.Args) > 1 && os.Args[1] == "events" {
+		if err := runEvents(os.Args[2:]); err != nil {
+			log.Fatalf("Unable to document events: %s", err)
+		}
+		return
+	}
+	// Verify checks the signature of a signed result and does not measure
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := runVerify(os.Args[2:]); err != nil {
+			log.Fatalf("Unable to verify the measurement: %s", err)
+		}
+		return
+	}
+	// The helper serves host logs to a measurer whose reads are denied by SELinux or AppArmor
+	if len(os.Args) > 1 && os.Args[1] == "helper" {
+		if err := runHelper(os.Args[2:]); err != nil {
+			log.Fatalf("Unable to serve log reads: %s", err)
+		}
+		return
+	}
 	root := flag.NewFlagSet(path.Base(os.Args[0]), flag.ExitOnError)
 	root.Usage = HelpFunc(root)
 	options := MustParseFlags(root)
@@ -75,51 +260,512 @@ func main() {
 		fmt.Printf("Git Commit: %s\n", commit)
 		os.Exit(0)
 	}
+	if err := logging.Configure(options.LogLevel, options.LogFormat, lo.Compact(strings.Split(options.LogDebugSources, ","))); err != nil {
+		log.Fatalf("Invalid logging configuration: %s", err)
+	}
+	defer func() { _ = zap.L().Sync() }()
 	ctx := context.Background()
-	var err error
+
+	// Configure the proxy before any network client is created, the metadata endpoints and the in-cluster API server are never proxied
+	if err := proxy.Configure(options.HTTPProxy, options.HTTPSProxy, options.NoProxy, endpointHost(options.IMDSEndpoint), endpointHost(options.GCEMetadataEndpoint), os.Getenv("KUBERNETES_SERVICE_HOST")); err != nil {
+		zap.S().Fatalf("Invalid proxy configuration: %s", err)
+	}
+	if proxy.Enabled() {
+		zap.S().Infof("Using %s", proxy.String())
+	}
+
+	// Apply self-limits so the tool can run with small resource requests
+	if options.GOMAXPROCS > 0 {
+		runtime.GOMAXPROCS(options.GOMAXPROCS)
+	}
+	if options.MemoryLimitBytes > 0 {
+		debug.SetMemoryLimit(int64(options.MemoryLimitBytes))
+	}
+	sources.DefaultMaxScanBytes = int64(options.MaxScanBytes)
+	sources.DefaultMaxFindDuration = time.Duration(options.MaxFindTimeMillis) * time.Millisecond
+	sources.DefaultHelperSocket = options.HelperSocket
+	sources.DefaultChecksumScans = options.Integrity || options.SigningKey != "" || options.SigningKMSKeyID != ""
+	journal.DefaultBootWindow = time.Duration(options.JournalBootWindow) * time.Second
+
 	latencyClient := latency.New()
 
-	// Setup K8s clientset
-	var k8sConfig *rest.Config
-	if options.Kubeconfig != "" {
-		k8sConfig, err = clientcmd.BuildConfigFromFlags("", options.Kubeconfig)
+	// Configure the read cache shared by all log sources
+	sources.DefaultReadCache.Configure(time.Duration(options.ReadCacheTTLSeconds)*time.Second, options.ReadCacheMaxBytes)
+	sources.DefaultMmapMinBytes = int64(options.MmapMinBytes)
+	if options.ReadStateFile != "" {
+		offsetStore, err := sources.NewOffsetStore(options.ReadStateFile)
 		if err != nil {
-			log.Fatalf("Unable to create K8s clientset from kubeconfig: %s", err)
+			zap.S().Fatalf("Unable to load read state file: %s", err)
 		}
+		sources.DefaultOffsetStore = offsetStore
+	}
+
+	// Restrict time-sorted log searches to the current boot and the measurement timeout or deadline, unless the flags override the bounds
+	windowStart, windowEnd, err := parseSearchWindow(options.SearchWindowStart, options.SearchWindowEnd)
+	if err != nil {
+		zap.S().Fatalf("Invalid search window: %s", err)
+	}
+	latencyClient = latencyClient.WithSearchWindow(windowStart, windowEnd)
+	if bootTime, err := hostBootTime(); err != nil {
+		zap.S().Debugf("Unable to determine the boot time, the search window does not start at boot: %s", err)
 	} else {
-		k8sConfig, err = rest.InClusterConfig()
+		latencyClient = latencyClient.WithBootTime(bootTime)
+	}
+
+	// Finalize the measurement at the deadline after boot even if the measurement is restarted
+	if options.DeadlineSeconds > 0 {
+		bootTime, err := hostBootTime()
+		if err != nil {
+			zap.S().Fatalf("Unable to determine the boot time for the deadline: %s", err)
+		}
+		latencyClient = latencyClient.WithDeadline(bootTime.Add(time.Duration(options.DeadlineSeconds) * time.Second))
+	}
+
+	// Attribute the default events to the owners of their components
+	if options.EventOwners != "" {
+		owners, err := parseKeyValues(options.EventOwners)
+		if err != nil {
+			zap.S().Fatalf("Invalid event owners: %s", err)
+		}
+		latencyClient = latencyClient.WithEventOwners(owners)
+	}
+
+	// Set the location of log timestamps that do not include a zone
+	if options.Timezone != "" {
+		loc, err := time.LoadLocation(options.Timezone)
+		if err != nil {
+			zap.S().Fatalf("Invalid timezone: %s", err)
+		}
+		sources.DefaultLocation = loc
+	}
+	if options.SourceTimezones != "" {
+		timezones, err := parseKeyValues(options.SourceTimezones)
+		if err != nil {
+			zap.S().Fatalf("Invalid source timezones: %s", err)
+		}
+		locations := map[string]*time.Location{}
+		for srcName, timezone := range timezones {
+			if locations[srcName], err = time.LoadLocation(timezone); err != nil {
+				zap.S().Fatalf("Invalid timezone of source \"%s\": %s", srcName, err)
+			}
+		}
+		latencyClient = latencyClient.WithSourceLocations(locations)
+	}
+
+	// Keep one timing of events found in multiple sources
+	if options.DedupEvents {
+		var priority []string
+		if options.SourcePriority != "" {
+			priority = lo.Map(strings.Split(options.SourcePriority, ","), func(name string, _ int) string { return strings.TrimSpace(name) })
+		}
+		latencyClient = latencyClient.WithDeduplication(priority)
+	}
+
+	// Guard dashboards against nodes that booted long before NLK started
+	if options.StaleSeconds > 0 {
+		if options.StaleAction != "skip" && options.StaleAction != "label" {
+			zap.S().Fatalf("Invalid stale action \"%s\", must be one of skip or label", options.StaleAction)
+		}
+		bootTime, err := hostBootTime()
+		if err != nil {
+			zap.S().Fatalf("Unable to determine the boot time for the stale threshold: %s", err)
+		}
+		latencyClient = latencyClient.WithStaleThreshold(bootTime, time.Duration(options.StaleSeconds)*time.Second)
+	}
+
+	// Select the node profile of the default events
+	if !lo.Contains(latency.Profiles, options.Profile) {
+		zap.S().Fatalf("Invalid profile \"%s\", must be one of %s", options.Profile, strings.Join(latency.Profiles, ", "))
+	}
+	latencyClient = latencyClient.WithProfile(options.Profile)
+	if !lo.Contains(latency.Modes, options.Mode) {
+		zap.S().Fatalf("Invalid mode \"%s\", must be one of %s", options.Mode, strings.Join(latency.Modes, ", "))
+	}
+	latencyClient = latencyClient.WithMode(options.Mode)
+	if options.Consolidation && (options.AmortizationTarget <= 0 || options.AmortizationTarget > 1) {
+		zap.S().Fatalf("Invalid amortization target %g, must be in (0, 1]", options.AmortizationTarget)
 	}
-	if err == nil {
-		clientset, err := kubernetes.NewForConfig(k8sConfig)
+	if options.BakeGate && options.Prometheus {
+		zap.S().Fatalf("--bake-gate exits once the measurement is emitted and can not be used with --prometheus-metrics")
+	}
+	soakTracks, soakSchedule := soakConfig(options)
+	if options.BakeArtifactsS3URI != "" && options.BakeArtifactsDir == "" {
+		zap.S().Fatalf("--bake-artifacts-s3-uri uploads the artifacts of --bake-artifacts-dir, which is not set")
+	}
+	if options.LogSource == "" {
+		options.LogSource = defaultLogSource(options.Profile)
+	}
+
+	// Select the source for the default log events
+	switch options.LogSource {
+	case "messages":
+		latencyClient = latencyClient.WithLogSource(messages.Name)
+	case "journal":
+		latencyClient = latencyClient.WithLogSource(journal.Name).WithJournal(options.JournalRoot, options.JournalNamespace)
+	case "journal-gateway":
+		if options.JournalGatewayURL == "" {
+			options.JournalGatewayURL = journal.DefaultGatewayURL
+		}
+		latencyClient = latencyClient.WithLogSource(journal.GatewayName)
+	default:
+		zap.S().Fatalf("Invalid log source \"%s\", must be one of messages, journal, or journal-gateway", options.LogSource)
+	}
+	if options.JournalGatewayURL != "" {
+		latencyClient = latencyClient.WithJournalGateway(options.JournalGatewayURL)
+	}
+	if options.HostPathFree {
+		for flagName, set := range map[string]bool{"dockerd": options.Dockerd, "image-cache": options.ImageCache, "containerd-hosts-dir": options.ContainerdHostsDir != ""} {
+			if set {
+				zap.S().Fatalf("--%s reads host paths and can not be used with --host-path-free", flagName)
+			}
+		}
+		latencyClient = latencyClient.WithHostPathFree()
+		if options.JournalGatewayURL != "" {
+			latencyClient = latencyClient.WithLogSource(journal.GatewayName)
+		} else {
+			zap.S().Infof("Measuring the reduced event set of the API sources without host paths, set --journal-gateway-url to measure the log events")
+		}
+	}
+	if options.Dockerd {
+		latencyClient = latencyClient.WithDockerd(options.DockerdLogPath)
+	}
+	if options.ChaosConfig != "" {
+		if options.EventsFile != "" {
+			zap.S().Fatalf("--chaos-config replaces the sources of the --events-file events, which can not be used with it")
+		}
+		chaosConfig, err := chaos.LoadConfig(options.ChaosConfig)
+		if err != nil {
+			zap.S().Fatalf("Unable to load chaos scenario: %s", err)
+		}
+		zap.S().Infof("Measuring the %d fake events of chaos scenario %s instead of the default events", len(chaosConfig.Events), options.ChaosConfig)
+		latencyClient = latencyClient.WithChaos(chaos.New(chaosConfig))
+	}
+	if options.PriceTable != "" {
+		priceTable, err := latency.LoadPriceTable(options.PriceTable)
+		if err != nil {
+			zap.S().Fatalf("Invalid price table: %s", err)
+		}
+		latencyClient = latencyClient.WithPriceTable(priceTable)
+	}
+	if options.SpotPricing {
+		latencyClient = latencyClient.WithSpotPricing()
+	}
+	if options.ScoreWeights != "" {
+		weights, err := parseScoreWeights(options.ScoreWeights)
+		if err != nil {
+			zap.S().Fatalf("Invalid bootstrap score weights: %s", err)
+		}
+		latencyClient = latencyClient.WithBootstrapScore(weights)
+	}
+	if options.Baseline != "" {
+		baseline, err := latency.LoadBaseline(options.Baseline)
+		if err != nil {
+			zap.S().Fatalf("Unable to load the baseline: %s", err)
+		}
+		tolerance, tolerances, err := parseTolerances(options.BaselineTolerance, options.BaselineTolerances)
 		if err != nil {
-			log.Fatalf("Unable to create K8s clientset: %s", err)
+			zap.S().Fatalf("Invalid baseline tolerances: %s", err)
 		}
-		latencyClient = latencyClient.WithK8sClientset(clientset).WithPodNamespace(options.PodNamespace).WithNodeName(options.NodeName)
+		latencyClient = latencyClient.WithBaseline(baseline, tolerance, tolerances)
+	}
+	if options.SpotSignals {
+		latencyClient = latencyClient.WithSpotSignals()
+	}
+	if options.TraceParent != "" {
+		traceContext, err := latency.ParseTraceContext(options.TraceParent)
+		if err != nil {
+			zap.S().Fatalf("Invalid trace parent: %s", err)
+		}
+		latencyClient = latencyClient.WithTraceContext(traceContext)
+	}
+	if options.TraceAnnotation != "" {
+		latencyClient = latencyClient.WithTraceContextAnnotation(options.TraceAnnotation)
+	}
+	if options.ImagePullReport {
+		latencyClient = latencyClient.WithImagePullReport()
+	}
+	if options.AdmissionReport {
+		latencyClient = latencyClient.WithAdmissionReport(options.AdmissionAnnotation)
+	}
+	if options.ImageCache {
+		bootTime, err := hostBootTime()
+		if err != nil {
+			zap.S().Fatalf("Unable to determine the boot time for the image cache detection: %s", err)
+		}
+		latencyClient = latencyClient.WithImageCache(options.ContainerdContentDir, bootTime)
+	}
+	if options.ContainerdHostsDir != "" {
+		hosts, err := latency.LoadRegistryHosts(options.ContainerdHostsDir)
+		if err != nil {
+			zap.S().Fatalf("Unable to read the containerd registry hosts: %s", err)
+		}
+		latencyClient = latencyClient.WithRegistryHosts(hosts)
+	}
+	if options.RBACMinimized {
+		if options.NodeName == "" {
+			zap.S().Fatalf("--rbac-minimized requires the node name from the downward API in NODE_NAME, or --node-name")
+		}
+		for flagName, set := range k8sAPIOptions(options) {
+			if set {
+				zap.S().Fatalf("--%s needs the K8s API and can not be used with --rbac-minimized", flagName)
+			}
+		}
+		if options.KubeletEndpoint == "" {
+			options.KubeletEndpoint = kubeletsrc.DefaultEndpoint
+		}
+	}
+	if options.Standalone {
+		for flagName, set := range lo.Assign(k8sAPIOptions(options), map[string]bool{"rbac-minimized": options.RBACMinimized}) {
+			if set {
+				zap.S().Fatalf("--%s needs K8s and can not be used with --standalone", flagName)
+			}
+		}
+		if options.NodeName == "" {
+			if options.NodeName, err = os.Hostname(); err != nil {
+				zap.S().Fatalf("Unable to determine the host name for the node name, set --node-name: %s", err)
+			}
+		}
+	}
+	if options.KubeletEndpoint != "" {
+		latencyClient = latencyClient.WithKubelet(options.KubeletEndpoint, options.StaticManifestDir)
+	}
+	if options.CRIEndpoint != "" {
+		latencyClient = latencyClient.WithCRI(options.CRIEndpoint, options.ContainerdContentDir)
+	}
+	if options.EgressProbeURL != "" {
+		latencyClient = latencyClient.WithEgressProbe(options.EgressProbeURL)
+	}
+
+	// Setup K8s clientset
+	var k8sConfig *rest.Config
+	var clientset *kubernetes.Clientset
+	if options.Standalone {
+		// a host service outside of K8s, there is no cluster to query
+		latencyClient = latencyClient.WithNodeName(options.NodeName)
+	} else if options.RBACMinimized {
+		// the pods are read from the local kubelet, the K8s API is not queried at all
+		latencyClient = latencyClient.WithPodNamespace(options.PodNamespace).WithNodeName(options.NodeName)
 	} else {
-		log.Printf("Unable to find in-cluster K8s config: %s\n", err)
+		if options.Kubeconfig != "" {
+			k8sConfig, err = clientcmd.BuildConfigFromFlags("", options.Kubeconfig)
+			if err != nil {
+				zap.S().Fatalf("Unable to create K8s clientset from kubeconfig: %s", err)
+			}
+		} else {
+			k8sConfig, err = rest.InClusterConfig()
+		}
+		if err == nil {
+			k8sConfig.Proxy = proxy.Func
+			clientset, err = kubernetes.NewForConfig(k8sConfig)
+			if err != nil {
+				zap.S().Fatalf("Unable to create K8s clientset: %s", err)
+			}
+			latencyClient = latencyClient.WithK8sClientset(clientset).WithPodNamespace(options.PodNamespace).WithNodeName(options.NodeName)
+		} else {
+			zap.S().Warnf("Unable to find in-cluster K8s config: %s", err)
+		}
+	}
+	// The emit state records what was already done for this boot, i.e. before the pod restarted
+	var emissions *latency.EmissionStore
+	if options.EmitStateFile != "" {
+		bootID, err := hostBootID()
+		if err != nil {
+			zap.S().Fatalf("Unable to determine the boot id for the emit state file: %s", err)
+		}
+		if emissions, err = latency.NewEmissionStore(options.EmitStateFile, bootID); err != nil {
+			zap.S().Fatalf("Unable to load emit state file: %s", err)
+		}
+	}
+	if options.StartupTaint != "" {
+		if clientset == nil {
+			zap.S().Fatalf("The startup taint requires the K8s API")
+		}
+		latencyClient = latencyClient.WithStartupGate(&latency.StartupGate{
+			Taint:           corev1.Taint{Key: options.StartupTaint, Effect: corev1.TaintEffectNoSchedule},
+			Milestones:      lo.Compact(strings.Split(options.StartupMilestones, ",")),
+			Resources:       lo.Map(lo.Compact(strings.Split(options.StartupResources, ",")), func(r string, _ int) corev1.ResourceName { return corev1.ResourceName(r) }),
+			RemoveOnTimeout: options.StartupTaintTimeout,
+			Opened:          emissions.StartupGateOpened(),
+		})
+	}
+	if options.NamespaceAllow != "" || options.NamespaceDeny != "" {
+		latencyClient, err = latencyClient.WithNamespaceFilter(k8ssrc.NamespaceFilter{
+			Allow: lo.Compact(strings.Split(options.NamespaceAllow, ",")),
+			Deny:  lo.Compact(strings.Split(options.NamespaceDeny, ",")),
+		})
+		if err != nil {
+			zap.S().Fatalf("Invalid namespace filter: %s", err)
+		}
+	}
+	if options.NodeClaimBaseline {
+		if clientset == nil {
+			zap.S().Fatalf("The NodeClaim baseline requires the K8s API")
+		}
+		latencyClient = latencyClient.WithNodeClaimBaseline()
 	}
 
-	// Setup AWS Config and Clients
-	cfg, err := config.LoadDefaultConfig(ctx, withIMDSEndpoint(options.IMDSEndpoint))
-	if err != nil {
-		log.Fatalf("unable to load AWS SDK config, %s", err)
+	if options.AggregatorURL != "" {
+		if err := analyze.ValidClusterName(options.ClusterName); err != nil {
+			zap.S().Fatalf("Invalid --cluster-name for --aggregator-url: %s", err)
+		}
 	}
-	if !options.NoIMDS {
-		latencyClient = latencyClient.WithIMDS(imds.NewFromConfig(cfg))
+	if options.ClusterName != "" {
+		latencyClient = latencyClient.WithClusterName(options.ClusterName)
+	}
+
+	// Setup AWS Config and Clients, or the cloud metadata source of the GKE and AKS profiles
+	switch options.Profile {
+	case latency.ProfileGKECOS:
+		latencyClient = latencyClient.WithGCE(gcesrc.New(options.GCEMetadataEndpoint))
+	case latency.ProfileAKS:
+		if !options.NoIMDS {
+			latencyClient = latencyClient.WithAzure(azuresrc.New(options.IMDSEndpoint))
+		}
+	default:
+		cfg, err := loadAWSConfig(ctx, options, withIMDSEndpoint(options.IMDSEndpoint))
+		if err != nil {
+			zap.S().Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		if !options.NoIMDS {
+			latencyClient = latencyClient.WithIMDS(imds.NewFromConfig(cfg))
+		}
+		if options.IMDSTagDimensions != "" {
+			tagDimensions, err := parseKeyValues(options.IMDSTagDimensions)
+			if err != nil {
+				zap.S().Fatalf("Invalid IMDS tag dimensions: %s", err)
+			}
+			if latencyClient, err = latencyClient.WithIMDSTagDimensions(tagDimensions); err != nil {
+				zap.S().Fatalf("Invalid IMDS tag dimensions: %s", err)
+			}
+		}
+		latencyClient = latencyClient.WithEC2Client(ec2.NewFromConfig(cfg))
+		if options.ASG || options.ASGLifecycleHook != "" {
+			latencyClient = latencyClient.WithAutoScalingClient(autoscaling.NewFromConfig(cfg))
+		}
 	}
-	latencyClient = latencyClient.WithEC2Client(ec2.NewFromConfig(cfg))
 
 	// Register the Default Sources and Events
 	latencyClient, err = latencyClient.RegisterDefaultSources().RegisterDefaultEvents()
 	if err != nil {
-		log.Println("Unable to instantiate the latency timing client: ")
-		log.Printf("    %s", err)
+		zap.S().Errorf("Unable to instantiate the latency timing client: %s", err)
+	}
+
+	// Register the custom event groups that match the node
+	if options.EventsFile != "" {
+		groups, err := latency.LoadEventGroups(options.EventsFile)
+		if err != nil {
+			zap.S().Fatalf("Unable to load events file: %s", err)
+		}
+		latency.OSReleasePath = options.OSReleasePath
+		sources.Regexes.MaxProgramSize = options.MaxRegexProgram
+		if latencyClient, err = latencyClient.RegisterEventGroups(ctx, groups); err != nil {
+			zap.S().Fatalf("Unable to register event groups: %s", err)
+		}
+	}
+
+	// Verify the host paths and API permissions of the configuration before measuring
+	if options.Preflight {
+		report := latencyClient.Preflight(ctx, preflightPermissions(options)...)
+		for _, check := range report.Failed() {
+			if check.Remediation != "" {
+				zap.S().Warnf("Preflight %s (%s): %s, fix with:\n%s", check.Check, check.Source, check.Problem, check.Remediation)
+			} else {
+				zap.S().Warnf("Preflight %s (%s): %s", check.Check, check.Source, check.Problem)
+			}
+		}
+		zap.S().Infof("Preflight passed %d of %d checks", len(report.Checks)-len(report.Failed()), len(report.Checks))
+	}
+
+	// Explain the matches of a single event instead of measuring
+	if options.Explain != "" {
+		explanations, err := latencyClient.Explain(options.Explain)
+		if err != nil {
+			zap.S().Fatalf("Unable to explain event: %s", err)
+		}
+		if options.Output == "json" {
+			fmt.Println(string(lo.Must(json.MarshalIndent(explanations, "", "    "))))
+			return
+		}
+		for _, explanation := range explanations {
+			explanation.Print(os.Stdout)
+		}
+		return
+	}
+
+	// Measure each boot in the journal history once and only emit the output
+	if options.AllBoots {
+		measurements, err := latencyClient.MeasureBoots(ctx)
+		if err != nil {
+			zap.S().Fatalf("Unable to measure all boots: %s", err)
+		}
+		if options.EvidenceDir != "" {
+			for _, measurement := range measurements {
+				writeEvidence(measurement, options.EvidenceDir, latencyClient.NodeName())
+			}
+		}
+		emitBoots(measurements, options)
+		return
+	}
+
+	// Keep the node tainted until the startup gate milestones are met
+	if options.StartupTaint != "" {
+		if err := latencyClient.ApplyStartupTaint(ctx); err != nil {
+			zap.S().Errorf("Error applying the startup taint: %s", err)
+		}
 	}
 
 	// Take measurements
 	measurement, err := latencyClient.MeasureUntil(ctx, time.Duration(options.TimeoutSeconds)*time.Second, time.Duration(options.RetryDelaySeconds)*time.Second)
 	if err != nil {
-		log.Println(err)
+		zap.S().Warn(err)
+	}
+	completed := err == nil
+	if latencyClient.StartupGateOpened() {
+		if err := emissions.MarkStartupGateOpened(); err != nil {
+			zap.S().Warnf("Unable to record the opened startup gate in the emit state file: %s", err)
+		}
+	}
+	if sources.DefaultOffsetStore != nil {
+		if err := sources.DefaultOffsetStore.Flush(); err != nil {
+			zap.S().Warnf("Unable to write the read state file: %s", err)
+		}
+	}
+
+	// Complete the launching lifecycle hook once the terminal events are measured, or abandon the launch if they were not
+	if options.ASGLifecycleHook != "" && (err == nil || options.ASGAbandonOnTimeout) {
+		result := lo.Ternary(err == nil, asgsrc.LifecycleActionContinue, asgsrc.LifecycleActionAbandon)
+		if err := latencyClient.CompleteLifecycleHook(ctx, options.ASGLifecycleHook, result); err != nil {
+			zap.S().Errorf("Error completing the lifecycle hook: %s", err)
+		} else {
+			zap.S().Infof("Successfully completed lifecycle hook %s with %s", options.ASGLifecycleHook, result)
+		}
+	}
+
+	// Export the matched log lines before signing so the signature covers the evidence checksum
+	if options.EvidenceDir != "" {
+		writeEvidence(measurement, options.EvidenceDir, latencyClient.NodeName())
+	}
+
+	// Attach the integrity metadata and sign the measurement if enabled
+	if options.Integrity || options.SigningKey != "" || options.SigningKMSKeyID != "" {
+		measurement.Integrity = latencyClient.Integrity(version, configHash(options))
+		var signer latency.Signer
+		if options.SigningKey != "" {
+			if signer, err = latency.NewFileSigner(options.SigningKey); err != nil {
+				zap.S().Fatalf("Unable to load the signing key: %s", err)
+			}
+		} else if options.SigningKMSKeyID != "" {
+			cfg, err := loadAWSConfig(ctx, options)
+			if err != nil {
+				zap.S().Fatalf("unable to load AWS SDK config, %s", err)
+			}
+			signer = latency.NewKMSSigner(kms.NewFromConfig(cfg), options.SigningKMSKeyID, options.SigningKMSAlgorithm)
+		}
+		if signer != nil {
+			if err := measurement.Sign(ctx, signer); err != nil {
+				zap.S().Errorf("Error signing the measurement: %s", err)
+			}
+		}
 	}
 
 	// Emit Measurement to stdout based on output type
@@ -127,10 +773,12 @@ func main() {
 	case "json":
 		jsonMeasurement, err := json.MarshalIndent(measurement, "", "    ")
 		if err != nil {
-			log.Printf("unable to marshal json output: %v", err)
+			zap.S().Errorf("unable to marshal json output: %v", err)
 		} else {
 			fmt.Println(string(jsonMeasurement))
 		}
+	case "timeline":
+		measurement.Timeline(latency.TimelineOptions{Color: colorOutput()})
 	default:
 		fallthrough
 	case "markdown":
@@ -141,29 +789,329 @@ func main() {
 		measurement.Chart(latency.ChartOptions{HiddenColumns: hiddenColumns})
 	}
 
+	// Signal the completion to wrapper scripts and systemd units once the terminal events are measured
+	if completed && (options.CompletionFile != "" || options.CompletionHook != "") {
+		signalCompletion(ctx, measurement, options, latencyClient.NodeName())
+	}
+
+	// Gate the candidate AMI of an image pipeline and write its artifacts if enabled
+	var bakeResult *latency.BakeResult
+	if options.BakeGate || options.BakeArtifactsDir != "" {
+		bakeResult = bake(ctx, measurement, options, completed, latencyClient.NodeName())
+	}
+
+	// Do not emit metrics of stale nodes
+	if measurement.Stale && options.StaleAction == "skip" {
+		zap.S().Infof("Skipping metrics since the node booted more than %d seconds ago", options.StaleSeconds)
+		if options.BakeGate {
+			os.Exit(bakeResult.ExitCode)
+		}
+		return
+	}
+
+	// Write the emitter payloads to files instead of sending them if dry run is enabled
+	if options.DryRunDir != "" {
+		latency.DryRunDir = options.DryRunDir
+		zap.S().Infof("Dry run: the emitters write their payloads to %s instead of sending them", options.DryRunDir)
+	}
+
+	// Skip emitters that already emitted a measurement of this boot, i.e. before the pod restarted
+	if emitted := emissions.Emitters(); len(emitted) > 0 {
+		zap.S().Infof("Skipping emitters that already emitted a measurement of this boot: %s", strings.Join(emitted, ", "))
+	}
+
+	// Metric emitters keep emitting the previous metric names of renamed events unless disabled
+	metricsMeasurement := measurement
+	if options.EmitAliases {
+		metricsMeasurement = measurement.WithAliasTimings()
+	}
+
 	// Emit CloudWatch Metrics if flag is enabled
-	if options.CloudWatch {
-		cfg, err := config.LoadDefaultConfig(ctx)
+	if options.CloudWatch && !emissions.Emitted("cloudwatch") {
+		cfg, err := loadAWSConfig(ctx, options)
+		if err != nil {
+			zap.S().Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		cw := latency.NewCloudWatchClient(cfg, options.CloudWatchAttempts)
+		if err := metricsMeasurement.EmitCloudWatchMetrics(ctx, cw, options.ExperimentDimension, latency.CloudWatchOptions{
+			BatchSize: options.CloudWatchBatchSize,
+			Jitter:    time.Duration(options.CloudWatchJitter) * time.Second,
+		}); err != nil {
+			zap.S().Errorf("Error emitting CloudWatch metrics: %s", err)
+		} else {
+			zap.S().Info("Successfully emitted CloudWatch metrics")
+			markEmitted(emissions, "cloudwatch")
+		}
+	}
+
+	// Export OTLP Metrics if an endpoint is configured
+	if options.OTLPMetricsEndpoint != "" && !emissions.Emitted("otlp") {
+		otlpClient, err := latency.NewOTLPMetricsClient(options.OTLPMetricsEndpoint, options.OTLPInsecure)
+		if err != nil {
+			zap.S().Fatalf("unable to create the OTLP metrics client, %s", err)
+		}
+		if err := metricsMeasurement.EmitOTLPMetrics(ctx, otlpClient, options.ExperimentDimension, latencyClient.NodeName()); err != nil {
+			zap.S().Errorf("Error emitting OTLP metrics: %s", err)
+		} else {
+			zap.S().Info("Successfully emitted OTLP metrics")
+			markEmitted(emissions, "otlp")
+		}
+	}
+
+	// Export the OTLP bootstrap trace if an endpoint is configured
+	if options.OTLPTracesEndpoint != "" && !emissions.Emitted("otlp-traces") {
+		otlpClient, err := latency.NewOTLPTracesClient(options.OTLPTracesEndpoint, options.OTLPInsecure)
+		if err != nil {
+			zap.S().Fatalf("unable to create the OTLP traces client, %s", err)
+		}
+		if err := measurement.EmitOTLPTraces(ctx, otlpClient, latencyClient.NodeName()); err != nil {
+			zap.S().Errorf("Error emitting the OTLP trace: %s", err)
+		} else {
+			zap.S().Info("Successfully emitted the OTLP trace")
+			markEmitted(emissions, "otlp-traces")
+		}
+	}
+
+	// Emit the X-Ray bootstrap segment if a daemon address is configured
+	if options.XRayDaemonAddress != "" && !emissions.Emitted("xray") {
+		if err := measurement.EmitXRaySegment(options.XRayDaemonAddress, latencyClient.NodeName()); err != nil {
+			zap.S().Errorf("Error emitting the X-Ray segment: %s", err)
+		} else {
+			zap.S().Info("Successfully emitted the X-Ray segment")
+			markEmitted(emissions, "xray")
+		}
+	}
+
+	// Write the collapsed stacks for flamegraph tooling if a file is configured
+	if options.FlamegraphFile != "" {
+		if err := measurement.WriteFlamegraph(options.FlamegraphFile, latencyClient.NodeName()); err != nil {
+			zap.S().Errorf("Error writing the flamegraph file: %s", err)
+		} else {
+			zap.S().Infof("Successfully wrote the flamegraph file %s", options.FlamegraphFile)
+		}
+	}
+
+	// Write and upload the HTML report if enabled
+	if options.HTMLReport != "" || options.HTMLReportS3URI != "" {
+		reportOptions := latency.ReportOptions{NodeName: latencyClient.NodeName()}
+		if options.Budgets != "" {
+			if reportOptions.Budgets, err = parseBudgets(options.Budgets); err != nil {
+				zap.S().Fatalf("Invalid budgets: %s", err)
+			}
+		}
+		if options.HTMLReport != "" {
+			if err := measurement.WriteHTMLReportFile(options.HTMLReport, reportOptions); err != nil {
+				zap.S().Errorf("Error writing the HTML report: %s", err)
+			} else {
+				zap.S().Infof("Successfully wrote the HTML report %s", options.HTMLReport)
+			}
+		}
+		if options.HTMLReportS3URI != "" {
+			cfg, err := loadAWSConfig(ctx, options)
+			if err != nil {
+				zap.S().Fatalf("unable to load AWS SDK config, %s", err)
+			}
+			if uri, err := measurement.UploadHTMLReport(ctx, s3.NewFromConfig(cfg), options.HTMLReportS3URI, reportOptions); err != nil {
+				zap.S().Errorf("Error uploading the HTML report: %s", err)
+			} else {
+				zap.S().Infof("Successfully uploaded the HTML report to %s", uri)
+			}
+		}
+	}
+
+	// Send the wide event to Honeycomb if a dataset is configured
+	if options.HoneycombDataset != "" && !emissions.Emitted("honeycomb") {
+		if err := metricsMeasurement.EmitHoneycombEvent(ctx, options.HoneycombAPIHost, options.HoneycombAPIKey, options.HoneycombDataset, options.ExperimentDimension, latencyClient.NodeName()); err != nil {
+			zap.S().Errorf("Error emitting the Honeycomb event: %s", err)
+		} else {
+			zap.S().Info("Successfully emitted the Honeycomb event")
+			markEmitted(emissions, "honeycomb")
+		}
+	}
+
+	// Push the measurement to a multi-cluster aggregator if one is configured
+	if options.AggregatorURL != "" && !emissions.Emitted("aggregator") {
+		if err := measurement.PushToAggregator(ctx, options.AggregatorURL, options.AggregatorToken, options.ClusterName); err != nil {
+			zap.S().Errorf("Error pushing the measurement to the aggregator: %s", err)
+		} else {
+			zap.S().Info("Successfully pushed the measurement to the aggregator")
+			markEmitted(emissions, "aggregator")
+		}
+	}
+
+	// Write the measurement to DynamoDB if a table is configured
+	if options.DynamoDBTable != "" && !emissions.Emitted("dynamodb") {
+		cfg, err := loadAWSConfig(ctx, options)
 		if err != nil {
-			log.Fatalf("unable to load AWS SDK config, %s", err)
+			zap.S().Fatalf("unable to load AWS SDK config, %s", err)
 		}
-		cw := cloudwatch.NewFromConfig(cfg)
-		if err := measurement.EmitCloudWatchMetrics(ctx, cw, options.ExperimentDimension); err != nil {
-			log.Printf("Error emitting CloudWatch metrics: %s\n", err)
+		if err := metricsMeasurement.WriteDynamoDBItem(ctx, dynamodb.NewFromConfig(cfg), latency.DynamoDBOptions{
+			Table:        options.DynamoDBTable,
+			PartitionKey: options.DynamoDBPartKey,
+			SortKey:      options.DynamoDBSortKey,
+			TTLAttribute: options.DynamoDBTTLAttr,
+			TTL:          time.Duration(options.DynamoDBTTLDays) * 24 * time.Hour,
+		}, options.ExperimentDimension, latencyClient.NodeName()); err != nil {
+			zap.S().Errorf("Error writing the DynamoDB item: %s", err)
 		} else {
-			log.Println("Successfully emitted CloudWatch metrics")
+			zap.S().Info("Successfully wrote the DynamoDB item")
+			markEmitted(emissions, "dynamodb")
+		}
+	}
+
+	// Stream the measurement to BigQuery if a table is configured
+	if options.BigQueryTable != "" && !emissions.Emitted("bigquery") {
+		if err := metricsMeasurement.InsertBigQueryRow(ctx, gcesrc.New(options.GCEMetadataEndpoint), options.BigQueryTable, options.ExperimentDimension, latencyClient.NodeName()); err != nil {
+			zap.S().Errorf("Error inserting the BigQuery row: %s", err)
+		} else {
+			zap.S().Info("Successfully inserted the BigQuery row")
+			markEmitted(emissions, "bigquery")
+		}
+	}
+
+	// Annotate the node with the measured durations if enabled
+	if options.NodeAnnotations && clientset != nil {
+		if err := metricsMeasurement.PatchNode(ctx, clientset, latencyClient.NodeName(), options.NodeBucketLabel); err != nil {
+			zap.S().Errorf("Error annotating the node: %s", err)
+		} else {
+			zap.S().Info("Successfully annotated the node")
+		}
+	}
+
+	// Annotate the node with the time its bootstrap is amortized for consolidation feedback if enabled
+	if options.Consolidation && clientset != nil {
+		if err := metricsMeasurement.PatchAmortizedAt(ctx, clientset, latencyClient.NodeName(), options.AmortizationTarget); err != nil {
+			zap.S().Errorf("Error annotating the node with the bootstrap amortization: %s", err)
+		} else {
+			zap.S().Info("Successfully annotated the node with the bootstrap amortization")
+		}
+	}
+
+	// Annotate the measured pods with their startup breakdown if enabled
+	if options.PodAnnotations && clientset != nil {
+		if patched, err := measurement.PatchPods(ctx, clientset, latencyClient.NodeName(), options.PodNamespace); err != nil {
+			zap.S().Errorf("Error annotating the pods: %s", err)
+		} else {
+			zap.S().Infof("Successfully annotated %d pods", patched)
+		}
+	}
+
+	// Publish the SLO node condition if an SLO is configured
+	if options.SLOSeconds > 0 && clientset != nil {
+		if err := measurement.PublishSLOCondition(ctx, clientset, latencyClient.NodeName(), options.SLOConditionType, time.Duration(options.SLOSeconds)*time.Second); err != nil {
+			zap.S().Errorf("Error publishing the node condition: %s", err)
+		} else {
+			zap.S().Infof("Successfully published the %s node condition", options.SLOConditionType)
+		}
+	}
+
+	// Exit with the gating status code of the image pipeline once the measurement is emitted
+	if options.BakeGate {
+		os.Exit(bakeResult.ExitCode)
+	}
+
+	// Re-run the non-boot measurements of the soak tracks on their schedule
+	var soakTrackList []*latency.SoakTrack
+	for _, name := range latency.SoakTracks {
+		emitters, ok := soakTracks[name]
+		if !ok {
+			continue
+		}
+		var track *latency.SoakTrack
+		var err error
+		switch name {
+		case latency.SoakTrackPodChurn:
+			track, err = latencyClient.PodChurnTrack(options.PodSampleRate, soakSchedule, emitters)
+		case latency.SoakTrackSyntheticProbe:
+			track, err = latencyClient.SyntheticProbeTrack(latency.SyntheticProbe{
+				Namespace: options.PodNamespace,
+				Image:     options.ProbeImage,
+				Timeout:   time.Duration(options.TimeoutSeconds) * time.Second,
+			}, soakSchedule, emitters)
+		}
+		if err != nil {
+			zap.S().Fatalf("Unable to configure soak track %s: %s", name, err)
+		}
+		soakTrackList = append(soakTrackList, track)
+	}
+	var soakOptions latency.SoakOptions
+	if len(soakTrackList) > 0 {
+		soakOptions.ExperimentDimension = options.ExperimentDimension
+		if lo.SomeBy(soakTrackList, func(track *latency.SoakTrack) bool { return lo.Contains(track.Emitters, latency.SoakEmitterCloudWatch) }) {
+			cfg, err := loadAWSConfig(ctx, options)
+			if err != nil {
+				zap.S().Fatalf("unable to load AWS SDK config, %s", err)
+			}
+			soakOptions.CloudWatch = latency.NewCloudWatchClient(cfg, options.CloudWatchAttempts)
+		}
+		zap.S().Infof("Running soak tracks %s", strings.Join(lo.Map(soakTrackList, func(track *latency.SoakTrack, _ int) string { return track.Name }), ", "))
+		if !options.Prometheus {
+			if err := latencyClient.RunSoak(ctx, metricsMeasurement, soakTrackList, soakOptions); err != nil {
+				zap.S().Fatalf("Unable to run the soak tracks: %s", err)
+			}
+			return
 		}
 	}
 
 	// Serve Prometheus Metrics if flag is enabled
 	if options.Prometheus {
 		registry := prometheus.NewRegistry()
-		measurement.RegisterMetrics(registry, options.ExperimentDimension)
+		metricsMeasurement.RegisterMetrics(registry, options.ExperimentDimension)
+		if options.Consolidation {
+			if err := metricsMeasurement.RegisterConsolidationMetrics(registry, options.ExperimentDimension, options.AmortizationTarget); err != nil {
+				zap.S().Errorf("Unable to register the consolidation feedback metrics: %s", err)
+			}
+		}
+		if len(soakTrackList) > 0 {
+			soakOptions.Registerer = registry
+			go func() {
+				if err := latencyClient.RunSoak(ctx, metricsMeasurement, soakTrackList, soakOptions); err != nil {
+					zap.S().Fatalf("Unable to run the soak tracks: %s", err)
+				}
+			}()
+		}
+		if options.PodSampleRate > 0 && len(soakTrackList) == 0 {
+			go func() {
+				if err := latencyClient.SamplePodStartups(ctx, registry, options.PodSampleRate, time.Duration(options.RetryDelaySeconds)*time.Second); err != nil {
+					zap.S().Warnf("Unable to sample pod startups: %s", err)
+				}
+			}()
+		}
+		if options.ProbeInterval > 0 {
+			go func() {
+				if err := latencyClient.RunSyntheticProbes(ctx, registry, latency.SyntheticProbe{
+					Namespace: options.PodNamespace,
+					Image:     options.ProbeImage,
+					Interval:  time.Duration(options.ProbeInterval) * time.Second,
+					Timeout:   time.Duration(options.TimeoutSeconds) * time.Second,
+				}); err != nil {
+					zap.S().Warnf("Unable to run synthetic probes: %s", err)
+				}
+			}()
+		}
+		if options.SpotSignals {
+			go func() {
+				if err := latencyClient.PollSpotSignals(ctx, registry, measurement, options.ExperimentDimension, time.Duration(options.RetryDelaySeconds)*time.Second); err != nil {
+					zap.S().Warnf("Unable to poll spot signals: %s", err)
+				}
+			}()
+		}
+		if options.PodMonitor && clientset != nil {
+			// the hostname of a pod is its name
+			podName, _ := os.Hostname()
+			if created, err := latency.EnsurePodMonitor(ctx, k8sConfig, clientset, podName, options.MetricsPort); err != nil {
+				zap.S().Errorf("Unable to create the PodMonitor: %s", err)
+			} else if !created {
+				zap.S().Infof("The PodMonitor CRD is not installed, skipping the PodMonitor")
+			} else {
+				zap.S().Infof("Applied the PodMonitor for the metrics endpoint")
+			}
+		}
 		http.Handle("/metrics", promhttp.HandlerFor(
 			registry,
 			promhttp.HandlerOpts{EnableOpenMetrics: false},
 		))
-		log.Printf("Serving Prometheus metrics on :%d", options.MetricsPort)
+		zap.S().Infof("Serving Prometheus metrics on :%d", options.MetricsPort)
 		srv := &http.Server{
 			ReadTimeout:       1 * time.Second,
 			WriteTimeout:      1 * time.Second,
@@ -175,20 +1123,264 @@ func main() {
 	}
 }
 
+// emitBoots emits the measurements of all boots to stdout based on the output type
+func emitBoots(measurements []*latency.Measurement, options Options) {
+	if options.Output == "json" {
+		jsonMeasurements, err := json.MarshalIndent(measurements, "", "    ")
+		if err != nil {
+			zap.S().Errorf("unable to marshal json output: %v", err)
+			return
+		}
+		fmt.Println(string(jsonMeasurements))
+		return
+	}
+	var hiddenColumns []string
+	if options.NoComments {
+		hiddenColumns = append(hiddenColumns, latency.ChartColumnComment)
+	}
+	for i, measurement := range measurements {
+		if i > 0 {
+			fmt.Println()
+		}
+		if options.Output == "timeline" {
+			measurement.Timeline(latency.TimelineOptions{Color: colorOutput()})
+			continue
+		}
+		measurement.Chart(latency.ChartOptions{HiddenColumns: hiddenColumns})
+	}
+}
+
+// writeEvidence writes the evidence artifact of a measurement of the current boot, or of the boot it measured
+func writeEvidence(measurement *latency.Measurement, dir string, nodeName string) {
+	bootID, err := hostBootID()
+	if err != nil && measurement.BootID == "" {
+		zap.S().Warnf("Unable to read the boot id for the evidence file name: %s", err)
+	}
+	if err := measurement.WriteEvidence(dir, nodeName, bootID); err != nil {
+		zap.S().Errorf("Error writing the evidence: %s", err)
+	} else {
+		zap.S().Infof("Successfully wrote %d matched lines to %s", measurement.Evidence.Lines, measurement.Evidence.Path)
+	}
+}
+
+// signalCompletion writes the summary and completion marker and runs the completion hook, a hook is not run if the summary could not be written
+func signalCompletion(ctx context.Context, measurement *latency.Measurement, options Options, nodeName string) {
+	bootID, err := hostBootID()
+	if err != nil && measurement.BootID == "" {
+		zap.S().Warnf("Unable to read the boot id for the summary file name: %s", err)
+	}
+	completion, err := measurement.WriteCompletion(options.CompletionFile, nodeName, bootID)
+	if err != nil {
+		zap.S().Errorf("Error writing the completion marker: %s", err)
+		return
+	}
+	zap.S().Infof("Successfully wrote the summary to %s", completion.Summary)
+	if options.CompletionHook != "" {
+		if err := completion.RunHook(ctx, options.CompletionHook); err != nil {
+			zap.S().Errorf("Error running the completion hook: %s", err)
+		} else {
+			zap.S().Info("Successfully ran the completion hook")
+		}
+	}
+}
+
+// bake evaluates the image pipeline gate of the measurement and writes and uploads its artifacts
+func bake(ctx context.Context, measurement *latency.Measurement, options Options, completed bool, nodeName string) *latency.BakeResult {
+	reportOptions := latency.ReportOptions{NodeName: nodeName}
+	if options.Budgets != "" {
+		var err error
+		if reportOptions.Budgets, err = parseBudgets(options.Budgets); err != nil {
+			zap.S().Fatalf("Invalid budgets: %s", err)
+		}
+	}
+	result := measurement.EvaluateBake(reportOptions.Budgets, completed, nodeName)
+	zap.S().Infof("Image pipeline gate %s with exit code %d", lo.Ternary(result.Passed, "passed", "failed"), result.ExitCode)
+	if options.BakeArtifactsDir == "" {
+		return result
+	}
+	paths, err := measurement.WriteBakeArtifacts(options.BakeArtifactsDir, result, reportOptions)
+	if err != nil {
+		zap.S().Errorf("Error writing the image pipeline artifacts: %s", err)
+		recordBakeArtifactError(options.BakeArtifactsDir, result, err)
+		return result
+	}
+	zap.S().Infof("Successfully wrote the image pipeline artifacts to %s", options.BakeArtifactsDir)
+	if options.BakeArtifactsS3URI != "" {
+		cfg, err := loadAWSConfig(ctx, options)
+		if err != nil {
+			zap.S().Fatalf("unable to load AWS SDK config, %s", err)
+		}
+		if uri, err := latency.UploadBakeArtifacts(ctx, s3.NewFromConfig(cfg), options.BakeArtifactsS3URI, result, paths); err != nil {
+			zap.S().Errorf("Error uploading the image pipeline artifacts: %s", err)
+			recordBakeArtifactError(options.BakeArtifactsDir, result, err)
+		} else {
+			zap.S().Infof("Successfully uploaded the image pipeline artifacts to %s", uri)
+		}
+	}
+	return result
+}
+
+// recordBakeArtifactError records an artifact error in the bake result and rewrites the result artifact with it, if the directory is writable
+func recordBakeArtifactError(dir string, result *latency.BakeResult, err error) {
+	result.RecordArtifactError(err)
+	zap.S().Errorf("Image pipeline gate exit code is %d since its artifacts were not written or uploaded", result.ExitCode)
+	if _, err := latency.WriteBakeResult(dir, result); err != nil {
+		zap.S().Errorf("Error writing the image pipeline result: %s", err)
+	}
+}
+
+// colorOutput returns true if stdout is a terminal and colors are not disabled with NO_COLOR
+func colorOutput() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	stat, err := os.Stdout.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
 func MustParseFlags(f *flag.FlagSet) Options {
 	options := Options{}
 	f.BoolVar(&options.CloudWatch, "cloudwatch-metrics", boolEnv("CLOUDWATCH_METRICS", false), "Emit metrics to CloudWatch, default: false")
+	f.IntVar(&options.CloudWatchBatchSize, "cloudwatch-batch-size", intEnv("CLOUDWATCH_BATCH_SIZE", latency.CloudWatchMaxBatchSize), fmt.Sprintf("Number of metric data per CloudWatch PutMetricData request, at most %d, default: %d", latency.CloudWatchMaxBatchSize, latency.CloudWatchMaxBatchSize))
+	f.IntVar(&options.CloudWatchJitter, "cloudwatch-jitter", intEnv("CLOUDWATCH_JITTER", 0), "Upper bound in seconds of a random delay before emitting CloudWatch metrics, spreading the requests of nodes that launched together, default: 0")
+	f.IntVar(&options.CloudWatchAttempts, "cloudwatch-max-attempts", intEnv("CLOUDWATCH_MAX_ATTEMPTS", 10), "Attempts of a throttled CloudWatch request, the client rate limits itself adaptively while throttled, default: 10")
 	f.BoolVar(&options.Prometheus, "prometheus-metrics", boolEnv("PROMETHEUS_METRICS", false), "Expose a Prometheus metrics endpoint (this runs as a daemon), default: false")
+	f.BoolVar(&options.PodMonitor, "pod-monitor", boolEnv("POD_MONITOR", false), "Apply a Prometheus Operator PodMonitor for the metrics endpoint, owned by the DaemonSet, when the PodMonitor CRD is installed (requires --prometheus-metrics), default: false")
 	f.IntVar(&options.MetricsPort, "metrics-port", intEnv("METRICS_PORT", 2112), "The port to serve prometheus metrics from, default: 2112")
 	f.StringVar(&options.ExperimentDimension, "experiment-dimension", strEnv("EXPERIMENT_DIMENSION", "none"), "Custom dimension to add to experiment metrics, default: none")
 	f.IntVar(&options.TimeoutSeconds, "timeout", intEnv("TIMEOUT", 600), "Timeout in seconds for how long event timings will try to be retrieved, default: 600")
+	f.IntVar(&options.DeadlineSeconds, "deadline", intEnv("DEADLINE", 0), "Deadline in seconds after the node booted at which the measurement is finalized regardless of missing events and incomplete tracks are reported as timed out, 0 disables the deadline, default: 0")
 	f.IntVar(&options.RetryDelaySeconds, "retry-delay", intEnv("RETRY_DELAY", 5), "Delay in seconds in-between timing retrievals, default: 5")
-	f.StringVar(&options.IMDSEndpoint, "imds-endpoint", strEnv("IMDS_ENDPOINT", "http://169.254.169.254"), "IMDS endpoint for testing, default: http://169.254.169.254")
-	f.BoolVar(&options.NoIMDS, "no-imds", boolEnv("NO_IMDS", false), "Do not use EC2 Instance Metadata Service (IMDS), default: false")
+	f.StringVar(&options.IMDSEndpoint, "imds-endpoint", strEnv("IMDS_ENDPOINT", "http://169.254.169.254"), "IMDS endpoint for testing (EC2 IMDS, or Azure IMDS with the aks profile), default: http://169.254.169.254")
+	f.BoolVar(&options.NoIMDS, "no-imds", boolEnv("NO_IMDS", false), "Do not use the EC2 (or Azure with the aks profile) Instance Metadata Service (IMDS), default: false")
 	f.StringVar(&options.PodNamespace, "pod-namespace", strEnv("POD_NAMESPACE", "default"), "namespace of the pods that will be measured from creation to running, default: default")
+	f.StringVar(&options.NamespaceAllow, "pod-namespace-allow", strEnv("POD_NAMESPACE_ALLOW", ""), "Comma separated namespace globs, i.e. team-*, whose pods are measured across namespaces by the workload pod events and pod startup sampling, default: <all namespaces>")
+	f.StringVar(&options.NamespaceDeny, "pod-namespace-deny", strEnv("POD_NAMESPACE_DENY", ""), "Comma separated namespace globs whose pods are never measured across namespaces, default: <none>")
 	f.StringVar(&options.NodeName, "node-name", strEnv("NODE_NAME", ""), "ndoe name to query for the first pod creation time in the pod namespace, default: <auto-discovered via IMDS>")
-	f.StringVar(&options.Output, "output", strEnv("OUTPUT", "markdown"), "output type (markdown or json), default: markdown")
+	f.StringVar(&options.ClusterName, "cluster-name", strEnv("CLUSTER_NAME", ""), "Name of the cluster the node belongs to, added to the measurement metadata and required to push to an aggregator, default: <none>")
+	f.StringVar(&options.AggregatorURL, "aggregator-url", strEnv("AGGREGATOR_URL", ""), "Results URL of a multi-cluster aggregator (node-latency-for-k8s analyze serve), i.e. https://aggregator:8080/results, to push the measurement to, default: <disabled>")
+	f.StringVar(&options.AggregatorToken, "aggregator-token", strEnv("AGGREGATOR_TOKEN", ""), "Bearer token of the cluster at the aggregator, default: <none>")
+	f.StringVar(&options.Output, "output", strEnv("OUTPUT", "markdown"), "output type (markdown, json or timeline, an ASCII Gantt chart colored by phase on terminals), default: markdown")
 	f.BoolVar(&options.NoComments, "no-comments", boolEnv("NO_COMMENTS", false), "Hide the comments column in the markdown chart output, default: false")
+	f.StringVar(&options.SearchWindowStart, "search-window-start", strEnv("SEARCH_WINDOW_START", ""), "RFC3339 timestamp before which time-sorted logs are not searched, or unbounded, default: <the boot time>")
+	f.StringVar(&options.SearchWindowEnd, "search-window-end", strEnv("SEARCH_WINDOW_END", ""), "RFC3339 timestamp after which time-sorted logs are not searched, or unbounded, default: <the timeout or deadline>")
+	f.IntVar(&options.ReadCacheTTLSeconds, "read-cache-ttl", intEnv("READ_CACHE_TTL", 60), "Time in seconds that log file contents are cached and shared across sources, 0 disables expiry, default: 60")
+	f.IntVar(&options.ReadCacheMaxBytes, "read-cache-max-bytes", intEnv("READ_CACHE_MAX_BYTES", sources.DefaultReadCacheMaxBytes), fmt.Sprintf("Maximum total bytes of log file contents to cache, default: %d", sources.DefaultReadCacheMaxBytes))
+	f.StringVar(&options.EmitStateFile, "emit-state-file", strEnv("EMIT_STATE_FILE", ""), "Path to a state file (usually on a hostPath) where the emitters that emitted a measurement of the current boot and the opening of the startup gate are recorded so a restarted measurement is not emitted again and does not taint the node again, default: <disabled>")
+	f.StringVar(&options.DryRunDir, "dry-run-dir", strEnv("DRY_RUN_DIR", ""), "Directory every configured emitter writes its would-be payload to as <emitter>.json instead of sending it, i.e. for air-gapped clusters, default: <disabled>")
+	f.StringVar(&options.HelperSocket, "helper-socket", strEnv("HELPER_SOCKET", ""), fmt.Sprintf("Unix socket of a node-latency-for-k8s helper to read logs through when direct reads are denied by SELinux or AppArmor, i.e. %s, default: <disabled>", defaultHelperSocket))
+	f.BoolVar(&options.EmitAliases, "emit-aliases", boolEnv("EMIT_ALIASES", true), "Keep emitting the previous metric names (aliases) of renamed events alongside their canonical names, default: true")
+	f.StringVar(&options.ReadStateFile, "read-state-file", strEnv("READ_STATE_FILE", ""), "Path to a state file (usually on a hostPath) where log read offsets are persisted, which enables incremental reads of only the appended bytes on subsequent cycles and detects logs truncated across restarts, default: <disabled>")
+	f.IntVar(&options.MmapMinBytes, "mmap-min-bytes", intEnv("MMAP_MIN_BYTES", 0), "Memory map rotated uncompressed log files (i.e. messages.1) of at least this many bytes instead of reading them into memory, live logs are always read since they may be truncated, 0 disables mmap, default: 0")
+	f.IntVar(&options.MaxScanBytes, "max-scan-bytes", intEnv("MAX_SCAN_BYTES", 0), "Maximum bytes of each log searched per event, results of limited searches are flagged as truncated, 0 is unlimited, default: 0")
+	f.IntVar(&options.MaxFindTimeMillis, "max-find-time-ms", intEnv("MAX_FIND_TIME_MS", 0), "Maximum time in milliseconds spent matching an event's regex per pass, results of limited searches are flagged as truncated, 0 is unlimited, default: 0")
+	f.IntVar(&options.GOMAXPROCS, "gomaxprocs", intEnv("GOMAXPROCS", 0), "Maximum number of CPUs used at once, 0 uses the go runtime default, default: 0")
+	f.IntVar(&options.MemoryLimitBytes, "memory-limit-bytes", intEnv("MEMORY_LIMIT_BYTES", 0), "Soft memory limit in bytes for the go runtime, 0 is unlimited, default: 0")
+	f.StringVar(&options.LogSource, "log-source", strEnv("LOG_SOURCE", ""), "Source of the default log events (messages, journal, or journal-gateway), default: messages, or journal with the gke-cos, aks, and openshift profiles or when /var/log/messages does not exist")
+	f.StringVar(&options.JournalGatewayURL, "journal-gateway-url", strEnv("JOURNAL_GATEWAY_URL", ""), fmt.Sprintf("URL of systemd-journal-gatewayd to read the journal over HTTP without host path mounts, default: <disabled, or %s with --log-source=journal-gateway>", journal.DefaultGatewayURL))
+	f.StringVar(&options.JournalRoot, "journal-root", strEnv("JOURNAL_ROOT", "/"), "Root the journal directories (/var/log/journal and /run/log/journal) are resolved under when reading the journal, default: /")
+	f.IntVar(&options.JournalBootWindow, "journal-boot-window", intEnv("JOURNAL_BOOT_WINDOW", 0), "Seconds after each boot started whose journal entries are read, later entries are dropped while reading so multi-GB journals of long-lived nodes fit in memory, 0 reads all entries, default: 0")
+	f.StringVar(&options.JournalNamespace, "journal-namespace", strEnv("JOURNAL_NAMESPACE", ""), "Journal namespace to read, default: <default namespace>")
+	f.BoolVar(&options.RBACMinimized, "rbac-minimized", boolEnv("RBAC_MINIMIZED", false), fmt.Sprintf("Run without any K8s API permissions, the node name and pod namespace come from the downward API and the pods from the local kubelet (--kubelet-endpoint, default: %s), default: false", kubeletsrc.DefaultEndpoint))
+	f.BoolVar(&options.Standalone, "standalone", boolEnv("STANDALONE", false), "Run as a host service outside of K8s, i.e. with the systemd unit in packaging/systemd on hosts without a cluster, the K8s API is not used and the node name defaults to the host name, default: false")
+	f.BoolVar(&options.HostPathFree, "host-path-free", boolEnv("HOST_PATH_FREE", false), "Run without host mounts from the K8s API, kubelet API, journal gateway, EC2 and IMDS only, the log events are read from --journal-gateway-url if it is set, otherwise a reduced event set is measured, default: false")
+	f.BoolVar(&options.Dockerd, "dockerd", boolEnv("DOCKERD", false), "Measure Docker daemon (dockerd) events on nodes using the Docker runtime, default: false")
+	f.StringVar(&options.DockerdLogPath, "dockerd-log-path", strEnv("DOCKERD_LOG_PATH", dockerd.DefaultPath), fmt.Sprintf("Path (glob) of the dockerd logs, default: %s", dockerd.DefaultPath))
+	f.StringVar(&options.KubeletEndpoint, "kubelet-endpoint", strEnv("KUBELET_ENDPOINT", ""), fmt.Sprintf("Local kubelet endpoint to read pods from when the kubelet runs standalone without an API server, i.e. %s, default: <disabled>", kubeletsrc.DefaultEndpoint))
+	f.StringVar(&options.CRIEndpoint, "cri-endpoint", strEnv("CRI_ENDPOINT", crisrc.DefaultEndpoint), fmt.Sprintf("CRI socket to time the pod sandboxes, first containers and image pulls of the measured pods, the CRI events are not measured if it does not exist, an empty endpoint disables them, default: %s", crisrc.DefaultEndpoint))
+	f.StringVar(&options.EgressProbeURL, "egress-probe-url", strEnv("EGRESS_PROBE_URL", ""), fmt.Sprintf("External HTTPS URL that is probed until the first connection succeeds to measure egress delays, i.e. %s, default: <disabled>", egresssrc.ExampleURL))
+	f.StringVar(&options.StaticManifestDir, "static-pod-manifest-dir", strEnv("STATIC_POD_MANIFEST_DIR", kubeletsrc.DefaultManifestDir), fmt.Sprintf("Static pod manifest directory read with --kubelet-endpoint, default: %s", kubeletsrc.DefaultManifestDir))
+	f.StringVar(&options.Profile, "profile", strEnv("PROFILE", latency.ProfileEKS), fmt.Sprintf("Node profile that selects the default events (%s), default: %s", strings.Join(latency.Profiles, ", "), latency.ProfileEKS))
+	f.StringVar(&options.GCEMetadataEndpoint, "gce-metadata-endpoint", strEnv("GCE_METADATA_ENDPOINT", gcesrc.DefaultEndpoint), fmt.Sprintf("GCE metadata server endpoint used with the gke-cos profile, default: %s", gcesrc.DefaultEndpoint))
+	f.StringVar(&options.EventOwners, "event-owners", strEnv("EVENT_OWNERS", ""), "Comma separated component=owner pairs that set the owner of the default events by their component label, i.e. cni=networking,containerd=runtime, default: <none>")
+	f.BoolVar(&options.DedupEvents, "dedup-events", boolEnv("DEDUP_EVENTS", false), "Keep only the most precise timing of events with the same metric found in multiple sources and record the alternates in the comment, default: false")
+	f.StringVar(&options.SourcePriority, "source-priority", strEnv("SOURCE_PRIORITY", ""), "Comma separated source names, i.e. Journal,Messages, that break ties between timings with the same precision when deduplicating events, default: <none>")
+	f.StringVar(&options.Timezone, "timezone", strEnv("TIMEZONE", "UTC"), "IANA timezone, i.e. America/New_York or Local, of log timestamps that do not include a zone like syslog timestamps, default: UTC")
+	f.StringVar(&options.SourceTimezones, "source-timezones", strEnv("SOURCE_TIMEZONES", ""), "Comma separated source=timezone pairs, i.e. Messages=Europe/Berlin, that override --timezone for a source, default: <none>")
+	f.IntVar(&options.StaleSeconds, "stale-threshold", intEnv("STALE_THRESHOLD", 0), "Seconds after boot at which a node is considered stale when NLK starts, i.e. after a DaemonSet rollout onto an existing fleet, 0 disables the guard, default: 0")
+	f.StringVar(&options.StaleAction, "stale-action", strEnv("STALE_ACTION", "label"), "Action for stale nodes, skip the metrics or label them stale=true (skip or label), default: label")
+	f.BoolVar(&options.AllBoots, "all-boots", boolEnv("ALL_BOOTS", false), "Measure each boot in the journal (--log-source=journal or journal-gateway) once and output one chart per boot, i.e. for offloaded logs of nodes that rebooted during provisioning, no metrics are emitted, default: false")
+	f.StringVar(&options.Mode, "mode", strEnv("MODE", latency.ModeLaunch), fmt.Sprintf("Measurement mode of the default events (%s), upgrade measures an in-place node upgrade from the drain or runtime restart until the node is Ready and the workloads are rescheduled, default: %s", strings.Join(latency.Modes, ", "), latency.ModeLaunch))
+	f.Float64Var(&options.PodSampleRate, "pod-sample-rate", floatEnv("POD_SAMPLE_RATE", 0), "Fraction (0-1] of the pods created on the node after the measurement whose scheduled to ready latency is exposed in the pod_startup_latency_seconds histogram with --prometheus-metrics, 0 disables sampling, default: 0")
+	f.IntVar(&options.ProbeInterval, "synthetic-probe-interval", intEnv("SYNTHETIC_PROBE_INTERVAL", 0), "Seconds between synthetic probes that create a tiny pod pinned to the node in the pod namespace and expose its scheduled, ready and deleted times in the synthetic_pod_startup_seconds histogram with --prometheus-metrics, 0 disables the probe, default: 0")
+	f.IntVar(&options.Interval, "interval", intEnv("INTERVAL", 0), "Seconds between the rounds of the --soak-tracks after the boot measurement, 0 disables the interval, default: 0")
+	f.IntVar(&options.IntervalJitter, "interval-jitter", intEnv("INTERVAL_JITTER", 0), "Upper bound in seconds of a random delay of each soak round, spreading the rounds of nodes that share a schedule, default: 0")
+	f.StringVar(&options.Schedule, "schedule", strEnv("SCHEDULE", ""), "Cron expression (minute hour day-of-month month day-of-week, in UTC) of the rounds of the --soak-tracks instead of --interval, i.e. */15 * * * *, default: <none>")
+	f.StringVar(&options.SoakTracks, "soak-tracks", strEnv("SOAK_TRACKS", ""), fmt.Sprintf("Comma separated track=emitter+emitter non-boot measurements re-run on the --interval or --schedule, each with its own emitters, tracks: %s, emitters: %s, i.e. pod-churn=prometheus,synthetic-probe=cloudwatch+log, default: <disabled>", strings.Join(latency.SoakTracks, ", "), strings.Join(latency.SoakEmitters, ", ")))
+	f.StringVar(&options.ProbeImage, "synthetic-probe-image", strEnv("SYNTHETIC_PROBE_IMAGE", latency.DefaultSyntheticProbeImage), fmt.Sprintf("Image of the synthetic probe pods, default: %s", latency.DefaultSyntheticProbeImage))
+	f.StringVar(&options.ContainerdHostsDir, "containerd-hosts-dir", strEnv("CONTAINERD_HOSTS_DIR", ""), fmt.Sprintf("Containerd registry hosts directory whose hosts.toml mirrors and upstreams are matched in the containerd resolver debug logs, i.e. %s, default: <disabled>", latency.DefaultContainerdHostsDir))
+	f.BoolVar(&options.ImageCache, "image-cache", boolEnv("IMAGE_CACHE", false), "Detect images that were cached in the containerd content store before boot, i.e. pre-pulled into the AMI, and add the warmImageCache dimension, default: false")
+	f.StringVar(&options.ContainerdContentDir, "containerd-content-dir", strEnv("CONTAINERD_CONTENT_DIR", latency.DefaultContainerdContentDir), fmt.Sprintf("Containerd content store scanned with --image-cache and read for the image pull times of --cri-endpoint, default: %s", latency.DefaultContainerdContentDir))
+	f.BoolVar(&options.AdmissionReport, "admission-report", boolEnv("ADMISSION_REPORT", false), "Estimate the time the measured pods spent in admission webhooks before creation was persisted, separate from the node cost (requires the K8s API), default: false")
+	f.StringVar(&options.AdmissionAnnotation, "admission-requested-annotation", strEnv("ADMISSION_REQUESTED_ANNOTATION", ""), "Pod annotation holding the RFC3339 time the client sent the create request, otherwise the owning controller's SuccessfulCreate events are used, default: <none>")
+	f.BoolVar(&options.ImagePullReport, "image-pull-report", boolEnv("IMAGE_PULL_REPORT", false), "Classify the image pulls of the measured pods as cache hits or network pulls from the containerd records and report the time pre-pulling saved or could save, default: false")
+	f.StringVar(&options.PriceTable, "price-table", strEnv("PRICE_TABLE", ""), "Path to a JSON table of hourly prices in dollars by instance type, i.e. {\"m5.large\": 0.096}, to annotate measurements with the cost of the bootstrap window, default: <none>")
+	f.BoolVar(&options.ASG, "asg", boolEnv("ASG", false), "Measure the end of the EC2 Auto Scaling launch activity, which is when the launching lifecycle hooks were completed, default: false")
+	f.StringVar(&options.ASGLifecycleHook, "asg-lifecycle-hook", strEnv("ASG_LIFECYCLE_HOOK", ""), "Launching lifecycle hook of the node's Auto Scaling group to complete with CONTINUE once the terminal events are measured, default: <disabled>")
+	f.BoolVar(&options.ASGAbandonOnTimeout, "asg-abandon-on-timeout", boolEnv("ASG_ABANDON_ON_TIMEOUT", false), "Complete the --asg-lifecycle-hook with ABANDON if the terminal events are not measured by the timeout, otherwise the hook's default result applies when it times out, default: false")
+	f.StringVar(&options.IMDSTagDimensions, "imds-tag-dimensions", strEnv("IMDS_TAG_DIMENSIONS", ""), "Comma separated tag=dimension pairs of instance tags read from IMDS that are added as metric dimensions and record fields, i.e. Team=team,Environment=environment, requires instance tags in the instance metadata, default: <disabled>")
+	f.BoolVar(&options.SpotSignals, "spot-signals", boolEnv("SPOT_SIGNALS", false), "Measure the spot interruption notice and rebalance recommendation from IMDS, and poll for them after the measurement with --prometheus-metrics, default: false")
+	f.BoolVar(&options.SpotPricing, "spot-pricing", boolEnv("SPOT_PRICING", false), "Annotate measurements with the cost of the bootstrap window using the current EC2 spot price for instance types not in the price table, default: false")
+	f.StringVar(&options.OTLPMetricsEndpoint, "otlp-metrics-endpoint", strEnv("OTLP_METRICS_ENDPOINT", ""), "OTLP/gRPC endpoint, i.e. an OpenTelemetry Collector at localhost:4317, to export the metrics to, default: <disabled>")
+	f.StringVar(&options.AWSRoleARN, "aws-role-arn", strEnv("ASSUME_ROLE_ARN", ""), "IAM role that all AWS sources and emitters assume with the credentials of the default chain (IRSA, EKS Pod Identity or the instance profile), i.e. to emit to another account, default: <none>")
+	f.StringVar(&options.AWSRegion, "aws-region", strEnv("AWS_REGION", ""), "Region of all AWS sources and emitters, i.e. us-gov-west-1 or cn-north-1, default: the region of the SDK config")
+	f.StringVar(&options.AWSEndpoints, "aws-endpoints", strEnv("AWS_ENDPOINTS", ""), fmt.Sprintf("Comma separated service=url endpoint overrides of the AWS sources and emitters, i.e. sts=https://sts.us-gov-west-1.amazonaws.com, the services are %s, default: <none>", awsEndpointServicesUsage()))
+	f.StringVar(&options.HTTPProxy, "http-proxy", "", "Proxy of the plain HTTP requests of all sources and emitters, i.e. http://proxy:3128, default: $HTTP_PROXY")
+	f.StringVar(&options.HTTPSProxy, "https-proxy", "", "Proxy of the HTTPS and gRPC requests of all sources and emitters, i.e. the AWS APIs, K8s API, OTLP and Honeycomb, default: $HTTPS_PROXY")
+	f.StringVar(&options.NoProxy, "no-proxy", "", "Comma separated hosts, domains and CIDRs that bypass the proxy, the instance metadata endpoints and loopback always do, default: $NO_PROXY")
+	f.StringVar(&options.OTLPTracesEndpoint, "otlp-traces-endpoint", strEnv("OTLP_TRACES_ENDPOINT", ""), "OTLP/gRPC endpoint, i.e. an OpenTelemetry Collector, Tempo or Jaeger at localhost:4317, to export the bootstrap trace to, default: <disabled>")
+	f.BoolVar(&options.OTLPInsecure, "otlp-insecure", boolEnv("OTLP_INSECURE", false), "Connect to the OTLP endpoints without TLS, default: false")
+	f.StringVar(&options.XRayDaemonAddress, "xray-daemon-address", strEnv("AWS_XRAY_DAEMON_ADDRESS", ""), fmt.Sprintf("UDP address of the X-Ray daemon to send the bootstrap segment to, i.e. %s, default: <disabled>", latency.DefaultXRayDaemonAddress))
+	f.StringVar(&options.TraceParent, "trace-parent", strEnv("TRACEPARENT", ""), "Incoming W3C traceparent or X-Ray trace header the bootstrap trace is parented under, default: <none>")
+	f.BoolVar(&options.NodeClaimBaseline, "nodeclaim-baseline", boolEnv("NODECLAIM_BASELINE", false), "Measure the creation of the Karpenter NodeClaim, or Machine, that owns the node as the first event, so all timings are relative to Karpenter's launch decision, default: false")
+	f.StringVar(&options.TraceAnnotation, "trace-context-annotation", strEnv("TRACE_CONTEXT_ANNOTATION", ""), "Annotation on the Node, or its Karpenter NodeClaim, holding the incoming trace context when --trace-parent is not set, default: <none>")
+	f.StringVar(&options.HoneycombDataset, "honeycomb-dataset", strEnv("HONEYCOMB_DATASET", ""), "Honeycomb dataset to send one wide event per measurement to, default: <disabled>")
+	f.StringVar(&options.HoneycombAPIKey, "honeycomb-api-key", strEnv("HONEYCOMB_API_KEY", ""), "Honeycomb API key, preferably set with the HONEYCOMB_API_KEY env var, default: <none>")
+	f.StringVar(&options.HoneycombAPIHost, "honeycomb-api-host", strEnv("HONEYCOMB_API_HOST", latency.DefaultHoneycombAPIHost), fmt.Sprintf("Honeycomb API host, i.e. for the EU instance or a Honeycomb compatible event store, default: %s", latency.DefaultHoneycombAPIHost))
+	f.StringVar(&options.DynamoDBTable, "dynamodb-table", strEnv("DYNAMODB_TABLE", ""), "DynamoDB table to write one item per measurement to, default: <disabled>")
+	f.StringVar(&options.DynamoDBPartKey, "dynamodb-partition-key", strEnv("DYNAMODB_PARTITION_KEY", "node_name"), "Partition key field of the DynamoDB items, default: node_name")
+	f.StringVar(&options.DynamoDBSortKey, "dynamodb-sort-key", strEnv("DYNAMODB_SORT_KEY", "timestamp"), "Sort key field of the DynamoDB items, empty if the table does not have a sort key, default: timestamp")
+	f.StringVar(&options.DynamoDBTTLAttr, "dynamodb-ttl-attribute", strEnv("DYNAMODB_TTL_ATTRIBUTE", "ttl"), "TTL attribute of the DynamoDB table, default: ttl")
+	f.IntVar(&options.DynamoDBTTLDays, "dynamodb-ttl-days", intEnv("DYNAMODB_TTL_DAYS", 0), "Days after which DynamoDB expires the items, 0 keeps them, default: 0")
+	f.StringVar(&options.BigQueryTable, "bigquery-table", strEnv("BIGQUERY_TABLE", ""), "BigQuery table (project.dataset.table, or dataset.table in the project of the GCE instance) to stream one row per measurement into, default: <disabled>")
+	f.BoolVar(&options.NodeAnnotations, "node-annotations", boolEnv("NODE_ANNOTATIONS", false), "Annotate the node with the seconds of every measured event, i.e. node-latency.k8s.aws/pod-ready-seconds, default: false")
+	f.BoolVar(&options.PodAnnotations, "pod-annotations", boolEnv("POD_ANNOTATIONS", false), "Annotate the pods in the pod namespace on the node with the breakdown of their startup time, i.e. node-latency.k8s.aws/image-pull-seconds, default: false")
+	f.StringVar(&options.StartupTaint, "startup-taint", strEnv("STARTUP_TAINT", ""), fmt.Sprintf("NoSchedule taint key to keep on the node until the startup milestones are met, i.e. %s, default: <disabled>", latency.DefaultStartupTaint))
+	f.StringVar(&options.StartupMilestones, "startup-taint-milestones", strEnv("STARTUP_TAINT_MILESTONES", ""), "Comma separated event metrics that must be measured before the startup taint is removed, i.e. image_cache_warmed,node_ready, default: <none>")
+	f.StringVar(&options.StartupResources, "startup-taint-resources", strEnv("STARTUP_TAINT_RESOURCES", ""), "Comma separated resources that must be allocatable before the startup taint is removed, i.e. nvidia.com/gpu, default: <none>")
+	f.BoolVar(&options.StartupTaintTimeout, "startup-taint-remove-on-timeout", boolEnv("STARTUP_TAINT_REMOVE_ON_TIMEOUT", false), "Remove the startup taint if the milestones are not met by the timeout, otherwise the node keeps it, default: false")
+	f.BoolVar(&options.Consolidation, "consolidation-feedback", boolEnv("CONSOLIDATION_FEEDBACK", false), "Expose the node lifetime and bootstrap amortization metrics with --prometheus-metrics and annotate the node with the time its bootstrap is amortized, i.e. to tune Karpenter consolidation, default: false")
+	f.Float64Var(&options.AmortizationTarget, "amortization-target", floatEnv("AMORTIZATION_TARGET", 0.05), "Fraction (0-1] of the node lifetime at which the bootstrap is considered amortized with --consolidation-feedback, default: 0.05")
+	f.BoolVar(&options.NodeBucketLabel, "node-bucket-label", boolEnv("NODE_BUCKET_LABEL", false), "Also label the node with the bucketed bootstrap time with --node-annotations, i.e. node-latency.k8s.aws/bootstrap=lt-60s, default: false")
+	f.IntVar(&options.SLOSeconds, "slo", intEnv("SLO", 0), "Bootstrap latency SLO in seconds, the node condition --slo-condition-type is published with whether the measurement is within it, 0 disables the condition, default: 0")
+	f.StringVar(&options.SLOConditionType, "slo-condition-type", strEnv("SLO_CONDITION_TYPE", "BootstrapLatencyWithinSLO"), "Type of the node condition published with --slo, default: BootstrapLatencyWithinSLO")
+	f.BoolVar(&options.Integrity, "integrity", boolEnv("INTEGRITY", false), "Add the tool version, config hash and sha256 checksums of the log sources to the measurement, implied by signing, default: false")
+	f.StringVar(&options.SigningKey, "signing-key", strEnv("SIGNING_KEY", ""), "Path to a PEM encoded ECDSA, Ed25519 or RSA private key to sign the measurement with, default: <disabled>")
+	f.StringVar(&options.SigningKMSKeyID, "signing-kms-key-id", strEnv("SIGNING_KMS_KEY_ID", ""), "Id, ARN or alias of an asymmetric AWS KMS key to sign the measurement with, default: <disabled>")
+	f.StringVar(&options.SigningKMSAlgorithm, "signing-kms-algorithm", strEnv("SIGNING_KMS_ALGORITHM", "ECDSA_SHA_256"), "KMS signing algorithm supported by the --signing-kms-key-id key, default: ECDSA_SHA_256")
+	f.StringVar(&options.FlamegraphFile, "flamegraph-file", strEnv("FLAMEGRAPH_FILE", ""), "Path to write the phase/event hierarchy to in the collapsed stack format of flamegraph.pl and speedscope, weighted in milliseconds, default: <disabled>")
+	f.StringVar(&options.HTMLReport, "html-report", strEnv("HTML_REPORT", ""), "Path to write a self-contained HTML report with the metadata, a timeline, the timings and the budget results to, default: <disabled>")
+	f.StringVar(&options.CompletionFile, "completion-file", strEnv("COMPLETION_FILE", ""), "Marker file written once the terminal events are measured, it holds the path of the measurement JSON written to <node name>-<boot id>.summary.json next to it, default: <disabled>")
+	f.StringVar(&options.CompletionHook, "completion-hook", strEnv("COMPLETION_HOOK", ""), "Command run with sh -c once the terminal events are measured, the summary JSON path is passed as $1 and in NLK_SUMMARY_PATH, default: <disabled>")
+	f.StringVar(&options.EvidenceDir, "evidence-dir", strEnv("EVIDENCE_DIR", ""), "Directory to write the matched log lines of all events to as <node name>-<boot id>.evidence.jsonl.gz, which the result references with its checksum, default: <disabled>")
+	f.StringVar(&options.HTMLReportS3URI, "html-report-s3-uri", strEnv("HTML_REPORT_S3_URI", ""), "S3 URI prefix (s3://bucket/prefix) to upload the HTML report to as <node name>-<unix time>.html, default: <disabled>")
+	f.BoolVar(&options.BakeGate, "bake-gate", boolEnv("BAKE_GATE", false), fmt.Sprintf("Exit with a gating status code for image pipelines once the measurement is emitted, %d if the terminal events were measured and the --budgets passed, %d if a budget failed, %d if the terminal events were not measured by the timeout, %d if a metric regressed from the --baseline, default: false", latency.BakeExitPassed, latency.BakeExitBudgetFailed, latency.BakeExitIncomplete, latency.BakeExitBaselineRegressed))
+	f.StringVar(&options.BakeArtifactsDir, "bake-artifacts-dir", strEnv("BAKE_ARTIFACTS_DIR", ""), fmt.Sprintf("Directory to write the image pipeline artifacts to, the pass or fail result (%s), the measurement (%s) and the HTML report with the budgets (%s), default: <disabled>", latency.BakeResultFile, latency.BakeMeasurementFile, latency.BakeReportFile))
+	f.StringVar(&options.BakeArtifactsS3URI, "bake-artifacts-s3-uri", strEnv("BAKE_ARTIFACTS_S3_URI", ""), "S3 URI prefix (s3://bucket/prefix) to upload the --bake-artifacts-dir artifacts to under <ami id>/<node name>/, default: <disabled>")
+	f.StringVar(&options.ScoreWeights, "bootstrap-score", strEnv("BOOTSTRAP_SCORE", ""), fmt.Sprintf("Comma separated metric=weight:seconds weights and targets of the event metrics combined into the %s metric from 0 to 100, i.e. node_ready=3:60,kubelet_start=1:20, default: <disabled>", latency.BootstrapScoreMetric))
+	f.StringVar(&options.Baseline, "baseline", strEnv("BASELINE", ""), "Path to a golden measurement (the --output json of a known good boot, i.e. committed to git per AMI) that the measurement is compared to with per event deltas, default: <none>")
+	f.StringVar(&options.BaselineTolerance, "baseline-tolerance", strEnv("BASELINE_TOLERANCE", "10%"), "Tolerance of the event metrics compared to the --baseline in seconds (5), percent of the golden time (10%) or both (2+10%), default: 10%")
+	f.StringVar(&options.BaselineTolerances, "baseline-tolerances", strEnv("BASELINE_TOLERANCES", ""), "Comma separated metric=tolerance overrides of the --baseline-tolerance, i.e. node_ready=5,kubelet_start=20%, default: <none>")
+	f.StringVar(&options.Budgets, "budgets", strEnv("BUDGETS", ""), "Comma separated latency budgets in seconds by event metric evaluated in the HTML report, i.e. node_ready=60,pod_ready=90, default: <none>")
+	f.StringVar(&options.ChaosConfig, "chaos-config", strEnv("CHAOS_CONFIG", ""), "Path to a JSON chaos scenario of fake events, delays and failures that replace the default sources and events, to test the emitters, budgets and alerting without a node bootstrap, default: <disabled>")
+	f.StringVar(&options.EventsFile, "events-file", strEnv("EVENTS_FILE", ""), "Path to a JSON file of custom regex event groups, each only registered on nodes matching its instance type, AMI, OS release and architecture regexes, default: <none>")
+	f.IntVar(&options.MaxRegexProgram, "max-regex-program-size", intEnv("MAX_REGEX_PROGRAM_SIZE", sources.DefaultMaxRegexProgramSize), fmt.Sprintf("Largest number of instructions a regex of the events file may compile to, larger regexes are rejected, 0 disables the check, default: %d", sources.DefaultMaxRegexProgramSize))
+	f.StringVar(&options.OSReleasePath, "os-release-path", strEnv("OS_RELEASE_PATH", latency.OSReleasePath), fmt.Sprintf("Path of the node's os-release file which event groups select the OS release from, default: %s", latency.OSReleasePath))
+	f.StringVar(&options.Explain, "explain", strEnv("EXPLAIN", ""), "Name of an event to search once and explain instead of measuring, prints the searched source and bytes, all candidate matches with their parsed timestamps and the match selector decision, default: <disabled>")
+	f.BoolVar(&options.Preflight, "preflight", boolEnv("PREFLIGHT", true), "Verify the host paths of the sources and the K8s API permissions of the configuration at startup and log the missing mounts and RBAC rules, default: true")
+	f.StringVar(&options.LogLevel, "log-level", strEnv("LOG_LEVEL", "info"), fmt.Sprintf("Log level, one of %s, default: info", strings.Join(logging.Levels, ", ")))
+	f.StringVar(&options.LogFormat, "log-format", strEnv("LOG_FORMAT", "console"), fmt.Sprintf("Log output format, one of %s, default: console", strings.Join(logging.Formats, ", ")))
+	f.StringVar(&options.LogDebugSources, "log-debug-sources", strEnv("LOG_DEBUG_SOURCES", ""), "Comma separated source names, i.e. Messages,Journal, whose regex searches are logged at debug level regardless of --log-level, default: <none>")
 	f.BoolVar(&options.Version, "version", false, "version information")
 	f.StringVar(&options.Kubeconfig, "kubeconfig", defaultKubeconfig(), "(optional) absolute path to the kubeconfig file")
 	lo.Must0(f.Parse(os.Args[1:]))
@@ -219,6 +1411,127 @@ func defaultKubeconfig() string {
 	return ""
 }
 
+// parseKeyValues parses comma separated key=value pairs
+func parseKeyValues(s string) (map[string]string, error) {
+	kvs := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("\"%s\" is not a key=value pair", pair)
+		}
+		kvs[key] = value
+	}
+	return kvs, nil
+}
+
+// soakConfig validates the soak options and returns the emitters by soak track and their schedule, which are empty without soak tracks
+func soakConfig(options Options) (map[string][]string, latency.Schedule) {
+	schedule := latency.Schedule{
+		Interval: time.Duration(options.Interval) * time.Second,
+		Jitter:   time.Duration(options.IntervalJitter) * time.Second,
+	}
+	if options.SoakTracks == "" {
+		if options.Interval > 0 || options.Schedule != "" {
+			zap.S().Fatalf("--interval and --schedule re-run the --soak-tracks, which are not set")
+		}
+		return nil, schedule
+	}
+	tracks, err := parseSoakTracks(options.SoakTracks)
+	if err != nil {
+		zap.S().Fatalf("Invalid soak tracks: %s", err)
+	}
+	if (options.Interval > 0) == (options.Schedule != "") {
+		zap.S().Fatalf("--soak-tracks need either --interval or --schedule")
+	}
+	if options.Interval < 0 || options.IntervalJitter < 0 {
+		zap.S().Fatalf("--interval and --interval-jitter must not be negative")
+	}
+	if options.Schedule != "" {
+		if schedule.Cron, err = latency.ParseCron(options.Schedule); err != nil {
+			zap.S().Fatalf("Invalid schedule: %s", err)
+		}
+	}
+	if _, ok := tracks[latency.SoakTrackPodChurn]; ok && options.PodSampleRate <= 0 {
+		zap.S().Fatalf("The %s soak track samples --pod-sample-rate of the pods, which is not set", latency.SoakTrackPodChurn)
+	}
+	if _, ok := tracks[latency.SoakTrackSyntheticProbe]; ok && options.ProbeInterval > 0 {
+		zap.S().Fatalf("The %s soak track runs the probes on the soak schedule and can not be used with --synthetic-probe-interval", latency.SoakTrackSyntheticProbe)
+	}
+	if options.BakeGate {
+		zap.S().Fatalf("--bake-gate exits once the measurement is emitted and can not be used with --soak-tracks")
+	}
+	for name, emitters := range tracks {
+		if lo.Contains(emitters, latency.SoakEmitterPrometheus) && !options.Prometheus {
+			zap.S().Fatalf("The prometheus emitter of soak track %s requires --prometheus-metrics", name)
+		}
+	}
+	return tracks, schedule
+}
+
+// parseSoakTracks parses comma separated track=emitter+emitter soak tracks
+func parseSoakTracks(s string) (map[string][]string, error) {
+	tracks := map[string][]string{}
+	if s == "" {
+		return tracks, nil
+	}
+	kvs, err := parseKeyValues(s)
+	if err != nil {
+		return nil, err
+	}
+	for track, value := range kvs {
+		if !lo.Contains(latency.SoakTracks, track) {
+			return nil, fmt.Errorf("unknown soak track \"%s\", must be one of %s", track, strings.Join(latency.SoakTracks, ", "))
+		}
+		emitters := lo.Map(strings.Split(value, "+"), func(emitter string, _ int) string { return strings.TrimSpace(emitter) })
+		for _, emitter := range emitters {
+			if !lo.Contains(latency.SoakEmitters, emitter) {
+				return nil, fmt.Errorf("unknown emitter \"%s\" of soak track %s, must be one of %s", emitter, track, strings.Join(latency.SoakEmitters, ", "))
+			}
+		}
+		tracks[track] = lo.Uniq(emitters)
+	}
+	return tracks, nil
+}
+
+// parseTolerances parses the default baseline tolerance and comma separated metric=tolerance baseline tolerances
+func parseTolerances(defaultTolerance string, s string) (latency.Tolerance, map[string]latency.Tolerance, error) {
+	tolerance, err := latency.ParseTolerance(defaultTolerance)
+	if err != nil {
+		return latency.Tolerance{}, nil, err
+	}
+	tolerances := map[string]latency.Tolerance{}
+	if s == "" {
+		return tolerance, tolerances, nil
+	}
+	kvs, err := parseKeyValues(s)
+	if err != nil {
+		return latency.Tolerance{}, nil, err
+	}
+	for metric, value := range kvs {
+		if tolerances[metric], err = latency.ParseTolerance(value); err != nil {
+			return latency.Tolerance{}, nil, fmt.Errorf("metric %s: %w", metric, err)
+		}
+	}
+	return tolerance, tolerances, nil
+}
+
+// parseBudgets parses comma separated metric=seconds budgets
+func parseBudgets(s string) (map[string]time.Duration, error) {
+	kvs, err := parseKeyValues(s)
+	if err != nil {
+		return nil, err
+	}
+	budgets := map[string]time.Duration{}
+	for metric, value := range kvs {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("budget \"%s\" of metric %s is not a positive number of seconds", value, metric)
+		}
+		budgets[metric] = time.Duration(seconds * float64(time.Second))
+	}
+	return budgets, nil
+}
+
 // strEnv retrieves the env var key or defaults to fallback value
 func strEnv(key string, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
@@ -243,6 +1556,20 @@ func intEnv(key string, fallback int) int {
 	return envIntValue
 }
 
+// floatEnv parses env var to a float64 if the key exists
+// panics if a parse error occurs
+func floatEnv(key string, fallback float64) float64 {
+	envStrValue := strEnv(key, "")
+	if envStrValue == "" {
+		return fallback
+	}
+	envFloatValue, err := strconv.ParseFloat(envStrValue, 64)
+	if err != nil {
+		panic("Env Var " + key + " must be a number")
+	}
+	return envFloatValue
+}
+
 // boolEnv parses env var to a boolean if the key exists
 // panics if the string cannot be parsed to a boolean
 // nolint:unparam
@@ -258,6 +1585,241 @@ func boolEnv(key string, fallback bool) bool {
 	return envBoolValue
 }
 
+// unboundedWindow disables the automatic bound of a search window flag, i.e. for offloaded logs of another boot
+const unboundedWindow = "unbounded"
+
+// parseSearchWindow parses the optional RFC3339 search window bounds, empty strings are returned as zero times for the automatic
+// bounds and unbounded as the earliest and latest time
+func parseSearchWindow(start string, end string) (time.Time, time.Time, error) {
+	var startTime, endTime time.Time
+	var err error
+	if start == unboundedWindow {
+		startTime, start = time.Unix(0, 0).UTC(), ""
+	}
+	if end == unboundedWindow {
+		endTime, end = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), ""
+	}
+	if start != "" {
+		if startTime, err = time.Parse(time.RFC3339, start); err != nil {
+			return startTime, endTime, fmt.Errorf("unable to parse search window start: %w", err)
+		}
+	}
+	if end != "" {
+		if endTime, err = time.Parse(time.RFC3339, end); err != nil {
+			return startTime, endTime, fmt.Errorf("unable to parse search window end: %w", err)
+		}
+	}
+	return startTime, endTime, nil
+}
+
+// hostBootTime reads the boot time of the host from /proc/stat
+func hostBootTime() (time.Time, error) {
+	stat, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(string(stat), "\n") {
+		if strings.HasPrefix(line, "btime ") {
+			secs, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "btime ")), 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("unable to parse btime: %w", err)
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("btime not found in /proc/stat")
+}
+
+// measurementConfig are the options that change which events are measured and how, it is the allowlist of options covered by configHash.
+// Secrets, endpoints, outputs and node specific options like the node name are left out, so nodes measured with the same configuration have the same hash.
+type measurementConfig struct {
+	Profile           string
+	Mode              string
+	LogSource         string
+	Dockerd           bool
+	JournalNamespace  string
+	JournalBootWindow int
+	NoIMDS            bool
+	HostPathFree      bool
+	RBACMinimized     bool
+	Standalone        bool
+	TimeoutSeconds    int
+	DeadlineSeconds   int
+	RetryDelaySeconds int
+	SearchWindowStart string
+	SearchWindowEnd   string
+	MaxScanBytes      int
+	MaxFindTimeMillis int
+	MaxRegexProgram   int
+	PodNamespace      string
+	NamespaceAllow    string
+	NamespaceDeny     string
+	PodSampleRate     float64
+	EventOwners       string
+	DedupEvents       bool
+	SourcePriority    string
+	Timezone          string
+	SourceTimezones   string
+	StaleSeconds      int
+	StaleAction       string
+	AllBoots          bool
+	ImagePullReport   bool
+	AdmissionReport   bool
+	ImageCache        bool
+	NodeClaimBaseline bool
+	StartupMilestones string
+	SoakTracks        string
+	EventsFile        string
+	ChaosConfig       string
+	Budgets           string
+	ScoreWeights      string
+}
+
+// configHash is the sha256 checksum of the measurementConfig of the options, which identifies the configuration a measurement was taken with
+func configHash(options Options) string {
+	configJSON := lo.Must(json.Marshal(measurementConfig{
+		Profile:           options.Profile,
+		Mode:              options.Mode,
+		LogSource:         options.LogSource,
+		Dockerd:           options.Dockerd,
+		JournalNamespace:  options.JournalNamespace,
+		JournalBootWindow: options.JournalBootWindow,
+		NoIMDS:            options.NoIMDS,
+		HostPathFree:      options.HostPathFree,
+		RBACMinimized:     options.RBACMinimized,
+		Standalone:        options.Standalone,
+		TimeoutSeconds:    options.TimeoutSeconds,
+		DeadlineSeconds:   options.DeadlineSeconds,
+		RetryDelaySeconds: options.RetryDelaySeconds,
+		SearchWindowStart: options.SearchWindowStart,
+		SearchWindowEnd:   options.SearchWindowEnd,
+		MaxScanBytes:      options.MaxScanBytes,
+		MaxFindTimeMillis: options.MaxFindTimeMillis,
+		MaxRegexProgram:   options.MaxRegexProgram,
+		PodNamespace:      options.PodNamespace,
+		NamespaceAllow:    options.NamespaceAllow,
+		NamespaceDeny:     options.NamespaceDeny,
+		PodSampleRate:     options.PodSampleRate,
+		EventOwners:       options.EventOwners,
+		DedupEvents:       options.DedupEvents,
+		SourcePriority:    options.SourcePriority,
+		Timezone:          options.Timezone,
+		SourceTimezones:   options.SourceTimezones,
+		StaleSeconds:      options.StaleSeconds,
+		StaleAction:       options.StaleAction,
+		AllBoots:          options.AllBoots,
+		ImagePullReport:   options.ImagePullReport,
+		AdmissionReport:   options.AdmissionReport,
+		ImageCache:        options.ImageCache,
+		NodeClaimBaseline: options.NodeClaimBaseline,
+		StartupMilestones: options.StartupMilestones,
+		SoakTracks:        options.SoakTracks,
+		EventsFile:        options.EventsFile,
+		ChaosConfig:       options.ChaosConfig,
+		Budgets:           options.Budgets,
+		ScoreWeights:      options.ScoreWeights,
+	}))
+	return fmt.Sprintf("%x", sha256.Sum256(configJSON))
+}
+
+// defaultLogSource is the journal for profiles of node OSes without /var/log/messages, and for AMIs that only
+// log to the journal like AL2023 on both x86_64 and arm64, otherwise messages
+func defaultLogSource(profile string) string {
+	if lo.Contains(latency.JournalProfiles, profile) {
+		return "journal"
+	}
+	// the windows profile reads its own sources
+	if profile == latency.ProfileWindows {
+		return "messages"
+	}
+	if logs, err := filepath.Glob(messages.DefaultPath); err == nil && len(logs) == 0 {
+		zap.S().Infof("No logs found at %s, reading the default log events from the journal", messages.DefaultPath)
+		return "journal"
+	}
+	return "messages"
+}
+
+// hostBootID reads the boot id of the host kernel
+func hostBootID() (string, error) {
+	bootID, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bootID)), nil
+}
+
+// k8sAPIOptions are the options that need the K8s API by flag name, with whether they are set
+func k8sAPIOptions(options Options) map[string]bool {
+	return map[string]bool{
+		"node-annotations": options.NodeAnnotations, "pod-annotations": options.PodAnnotations, "startup-taint": options.StartupTaint != "",
+		"synthetic-probe-interval": options.ProbeInterval > 0, "pod-sample-rate": options.PodSampleRate > 0, "slo": options.SLOSeconds > 0, "soak-tracks": options.SoakTracks != "",
+		"nodeclaim-baseline": options.NodeClaimBaseline, "trace-context-annotation": options.TraceAnnotation != "",
+		"admission-report": options.AdmissionReport, "image-pull-report": options.ImagePullReport, "pod-monitor": options.PodMonitor,
+	}
+}
+
+// preflightPermissions are the K8s API permissions of the configured emitters and reports checked by the preflight
+func preflightPermissions(options Options) []latency.Permission {
+	var permissions []latency.Permission
+	if options.NodeAnnotations {
+		permissions = append(permissions, latency.Permission{Resource: "nodes", Verb: "patch"})
+	}
+	soakTracks, _ := parseSoakTracks(options.SoakTracks)
+	if _, ok := soakTracks[latency.SoakTrackSyntheticProbe]; ok || (options.Prometheus && options.ProbeInterval > 0) {
+		permissions = append(permissions,
+			latency.Permission{Resource: "pods", Verb: "create", Namespace: options.PodNamespace},
+			latency.Permission{Resource: "pods", Verb: "delete", Namespace: options.PodNamespace},
+		)
+	}
+	if options.Consolidation || options.StartupTaint != "" {
+		permissions = append(permissions, latency.Permission{Resource: "nodes", Verb: "patch"})
+	}
+	if options.PodAnnotations {
+		permissions = append(permissions, latency.Permission{Resource: "pods", Verb: "patch", Namespace: options.PodNamespace})
+	}
+	if options.SLOSeconds > 0 {
+		permissions = append(permissions, latency.Permission{Resource: "nodes", Subresource: "status", Verb: "patch"})
+	}
+	if options.TraceAnnotation != "" {
+		permissions = append(permissions, latency.Permission{Group: "karpenter.sh", Resource: "nodeclaims", Verb: "list"})
+	}
+	if options.NodeClaimBaseline {
+		permissions = append(permissions, latency.Permission{Group: "karpenter.sh", Resource: "nodeclaims", Verb: "get"})
+	}
+	if options.AdmissionReport {
+		permissions = append(permissions,
+			latency.Permission{Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations", Verb: "list"},
+			latency.Permission{Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations", Verb: "list"},
+		)
+	}
+	if options.Prometheus && options.PodMonitor {
+		permissions = append(permissions,
+			latency.Permission{Resource: "pods", Verb: "get"},
+			latency.Permission{Group: "monitoring.coreos.com", Resource: "podmonitors", Verb: "patch"},
+		)
+	}
+	return permissions
+}
+
+// markEmitted records a successful emission in the emission store and logs failures since the measurement was already emitted
+func markEmitted(emissions *latency.EmissionStore, emitter string) {
+	// a dry run did not emit the measurement
+	if latency.DryRunDir != "" {
+		return
+	}
+	if err := emissions.MarkEmitted(emitter); err != nil {
+		zap.S().Warnf("Unable to record the %s emission: %s", emitter, err)
+	}
+}
+
+// endpointHost is the host of an endpoint URL, or the endpoint if it is not a URL
+func endpointHost(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return endpoint
+}
+
 func withIMDSEndpoint(imdsEndpoint string) func(*config.LoadOptions) error {
 	return func(lo *config.LoadOptions) error {
 		lo.EC2IMDSEndpoint = imdsEndpoint
@@ -268,3 +1830,23 @@ func withIMDSEndpoint(imdsEndpoint string) func(*config.LoadOptions) error {
 		return nil
 	}
 }
+
+// parseScoreWeights parses comma separated metric=weight:seconds bootstrap score weights, sorted by metric
+func parseScoreWeights(s string) ([]latency.ScoreWeight, error) {
+	kvs, err := parseKeyValues(s)
+	if err != nil {
+		return nil, err
+	}
+	var weights []latency.ScoreWeight
+	for metric, value := range kvs {
+		weightValue, targetValue, ok := strings.Cut(value, ":")
+		weight, weightErr := strconv.ParseFloat(weightValue, 64)
+		seconds, targetErr := strconv.ParseFloat(targetValue, 64)
+		if !ok || weightErr != nil || targetErr != nil || weight <= 0 || seconds <= 0 {
+			return nil, fmt.Errorf("\"%s\" of metric %s is not a positive weight and target seconds, i.e. 2:60", value, metric)
+		}
+		weights = append(weights, latency.ScoreWeight{Metric: metric, Weight: weight, Target: time.Duration(seconds * float64(time.Second))})
+	}
+	sort.Slice(weights, func(i, j int) bool { return weights[i].Metric < weights[j].Metric })
+	return weights, nil
+}
@@ -0,0 +1,51 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// runVerify verifies the signature of signed measurement JSON files with a public key
+func runVerify(args []string) error {
+	f := flag.NewFlagSet("verify", flag.ExitOnError)
+	key := f.String("key", "", "Path to the PEM encoded public key, or the private key, the measurement was signed with")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if *key == "" || f.NArg() == 0 {
+		return fmt.Errorf("usage: %s verify --key <public key> <measurement json>...", filepath.Base(os.Args[0]))
+	}
+	publicKey, err := latency.LoadPublicKey(*key)
+	if err != nil {
+		return err
+	}
+	for _, path := range f.Args() {
+		measurementJSON, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", path, err)
+		}
+		if err := latency.VerifyMeasurement(measurementJSON, publicKey); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Printf("%s: signature is valid\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package analyze loads directories of measurement results and aggregates them for offline analysis, i.e. in notebooks
+package analyze
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// Result is a measurement loaded from a JSON result file of the --output json flag
+type Result struct {
+	// File is the result file the measurement was loaded from
+	File     string            `json:"-"`
+	Metadata *latency.Metadata `json:"metadata"`
+	BootID   string            `json:"bootID,omitempty"`
+	Timings  []*Timing         `json:"timings"`
+}
+
+// Timing is a timing of a result, the error is kept raw since errors do not unmarshal
+type Timing struct {
+	Event struct {
+		Name   string `json:"name"`
+		Metric string `json:"metric"`
+	} `json:"event"`
+	Timestamp time.Time       `json:"timestamp"`
+	T         time.Duration   `json:"seconds"`
+	Error     json.RawMessage `json:"error"`
+}
+
+// Failed returns true if the timing has an error
+func (t *Timing) Failed() bool {
+	return len(t.Error) > 0 && string(t.Error) != "null"
+}
+
+// Load loads all *.json result files in a directory, a file holds a measurement or a list of measurements, i.e. of --all-boots
+func Load(dir string) ([]*Result, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list results in %s: %w", dir, err)
+	}
+	sort.Strings(files)
+	var results []*Result
+	for _, file := range files {
+		fileResults, err := loadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, fileResults...)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no results in %s", dir)
+	}
+	return results, nil
+}
+
+// loadFile loads the measurement or list of measurements of a result file
+func loadFile(file string) ([]*Result, error) {
+	resultBytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read result %s: %w", file, err)
+	}
+	var fileResults []*Result
+	if trimmed := bytes.TrimSpace(resultBytes); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &fileResults)
+	} else {
+		var result Result
+		err = json.Unmarshal(trimmed, &result)
+		fileResults = []*Result{&result}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse result %s: %w", file, err)
+	}
+	for _, result := range fileResults {
+		result.File = filepath.Base(file)
+	}
+	return fileResults, nil
+}
+
+// WideTable is a pivot of results with one row per node boot and one column per event metric holding the seconds since the first event
+type WideTable struct {
+	// Columns are the node columns followed by the sorted event metrics
+	Columns []string
+	// Rows hold the node columns as strings and the event metrics as float64 seconds, or nil if the event was not measured
+	Rows [][]interface{}
+}
+
+// NodeColumns are the leading columns of a WideTable that identify the node boot
+var NodeColumns = []string{"file", "cluster", "instance_id", "instance_type", "architecture", "region", "availability_zone", "ami_id",
+	"node_group", "launch_template_id", "launch_template_version", "boot_id"}
+
+// Pivot builds the wide table of the results, the first successful timing of a metric is used if an event matched more than once
+func Pivot(results []*Result) *WideTable {
+	metrics := lo.Uniq(lo.FlatMap(results, func(r *Result, _ int) []string {
+		return lo.Map(r.Timings, func(t *Timing, _ int) string { return t.Event.Metric })
+	}))
+	sort.Strings(metrics)
+	table := &WideTable{Columns: append(append([]string{}, NodeColumns...), lo.Map(metrics, func(m string, _ int) string { return columnName(m) })...)}
+	for _, result := range results {
+		metadata := lo.FromPtrOr(result.Metadata, latency.Metadata{})
+		launchTemplate := lo.FromPtrOr(metadata.LaunchTemplate, latency.LaunchTemplate{})
+		row := []interface{}{result.File, metadata.Cluster, metadata.InstanceID, metadata.InstanceType, metadata.Architecture, metadata.Region,
+			metadata.AvailabilityZone, metadata.AMIID, metadata.NodeGroup, launchTemplate.ID, launchTemplate.Version, result.BootID}
+		for _, metric := range metrics {
+			var seconds interface{}
+			if timing, ok := lo.Find(result.Timings, func(t *Timing) bool { return t.Event.Metric == metric && !t.Failed() }); ok {
+				seconds = timing.T.Seconds()
+			}
+			row = append(row, seconds)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// columnName makes a metric a valid column name in pandas and SQL
+func columnName(name string) string {
+	return strings.NewReplacer(" ", "_", "-", "_", ".", "_").Replace(name)
+}
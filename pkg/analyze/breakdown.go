@@ -0,0 +1,137 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+)
+
+// LaunchTemplateGroup is the latency of an event metric over the results of a node group that were launched from a launch template version
+type LaunchTemplateGroup struct {
+	NodeGroup        string `json:"nodeGroup"`
+	LaunchTemplateID string `json:"launchTemplateID"`
+	Version          string `json:"version"`
+	Results          int    `json:"results"`
+	// Measured is the number of results that measured the metric, which the percentiles are computed over
+	Measured    int                `json:"measured"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+// BreakdownByLaunchTemplate computes the SummaryPercentiles of the metric by node group and launch template version,
+// so a latency shift can be correlated with the launch template change that rolled out with it.
+// Results without a launch template are grouped with an empty launch template.
+func BreakdownByLaunchTemplate(results []*Result, metric string) []*LaunchTemplateGroup {
+	groups := Breakdown{Dimensions: LaunchTemplateDimensions, Percentiles: SummaryPercentiles}.Compute(results, metric)
+	return lo.Map(groups, func(g *Group, _ int) *LaunchTemplateGroup {
+		return &LaunchTemplateGroup{
+			NodeGroup:        g.Dimensions["node_group"],
+			LaunchTemplateID: g.Dimensions["launch_template_id"],
+			Version:          g.Dimensions["launch_template_version"],
+			Results:          g.Results,
+			Measured:         g.Measured,
+			Percentiles:      g.Percentiles,
+		}
+	})
+}
+
+// DefaultBreakdownMetric is the event metric the launch template breakdown is computed for if none is requested
+const DefaultBreakdownMetric = "node_ready"
+
+// LaunchTemplateView serves the launch template breakdown of the results directory of an aggregator as JSON,
+// i.e. GET /views/launch-templates?metric=pod_ready&cluster=prod. Any cluster's bearer token may read the view.
+type LaunchTemplateView struct {
+	// Dir is the results directory of the aggregator
+	Dir string
+	// Tokens are the bearer tokens of each cluster
+	Tokens map[string]string
+}
+
+// ServeHTTP responds with the LaunchTemplateGroups of the requested metric, optionally restricted to the results of a cluster
+func (v *LaunchTemplateView) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "the view must be read with GET", http.StatusMethodNotAllowed)
+		return
+	}
+	results, metric, ok := viewResults(w, r, v.Dir, v.Tokens)
+	if !ok {
+		return
+	}
+	groups := BreakdownByLaunchTemplate(results, metric)
+	if groups == nil {
+		groups = []*LaunchTemplateGroup{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(groups); err != nil {
+		zap.S().Warnf("Unable to write the launch template view: %s", err)
+	}
+}
+
+// BreakdownView serves the configured Breakdown of the results directory of an aggregator as JSON,
+// i.e. GET /views/breakdown?metric=pod_ready&cluster=prod. Any cluster's bearer token may read the view.
+type BreakdownView struct {
+	// Dir is the results directory of the aggregator
+	Dir string
+	// Tokens are the bearer tokens of each cluster
+	Tokens    map[string]string
+	Breakdown Breakdown
+}
+
+// ServeHTTP responds with the Groups of the requested metric, optionally restricted to the results of a cluster
+func (v *BreakdownView) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "the view must be read with GET", http.StatusMethodNotAllowed)
+		return
+	}
+	results, metric, ok := viewResults(w, r, v.Dir, v.Tokens)
+	if !ok {
+		return
+	}
+	groups := v.Breakdown.Compute(results, metric)
+	if groups == nil {
+		groups = []*Group{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(groups); err != nil {
+		zap.S().Warnf("Unable to write the breakdown view: %s", err)
+	}
+}
+
+// viewResults authenticates the request of a view and loads the results of the requested cluster with the requested metric,
+// it responds with an error and returns false if the request is not authorized or the results can not be loaded
+func viewResults(w http.ResponseWriter, r *http.Request, dir string, tokens map[string]string) ([]*Result, string, bool) {
+	token := []byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if !lo.SomeBy(lo.Values(tokens), func(t string) bool { return subtle.ConstantTimeCompare(token, []byte(t)) == 1 }) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, "", false
+	}
+	metric := lo.Ternary(r.URL.Query().Get("metric") == "", DefaultBreakdownMetric, r.URL.Query().Get("metric"))
+	results, err := Load(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if cluster := r.URL.Query().Get("cluster"); cluster != "" {
+		results = lo.Filter(results, func(result *Result, _ int) bool {
+			return result.Metadata != nil && result.Metadata.Cluster == cluster
+		})
+	}
+	return results, metric, true
+}
@@ -0,0 +1,175 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// LaunchTemplateDimensions are the grouping dimensions of the launch template breakdown
+var LaunchTemplateDimensions = []string{"node_group", "launch_template_id", "launch_template_version"}
+
+// dimensionValues read the built-in grouping dimensions of a result, any other dimension is read from the instance tag dimensions (--imds-tag-dimensions)
+var dimensionValues = map[string]func(metadata latency.Metadata) string{
+	"cluster":            func(m latency.Metadata) string { return m.Cluster },
+	"instance_type":      func(m latency.Metadata) string { return m.InstanceType },
+	"architecture":       func(m latency.Metadata) string { return m.Architecture },
+	"region":             func(m latency.Metadata) string { return m.Region },
+	"availability_zone":  func(m latency.Metadata) string { return m.AvailabilityZone },
+	"ami_id":             func(m latency.Metadata) string { return m.AMIID },
+	"node_group":         func(m latency.Metadata) string { return m.NodeGroup },
+	"launch_template_id": func(m latency.Metadata) string { return lo.FromPtrOr(m.LaunchTemplate, latency.LaunchTemplate{}).ID },
+	"launch_template_version": func(m latency.Metadata) string {
+		return lo.FromPtrOr(m.LaunchTemplate, latency.LaunchTemplate{}).Version
+	},
+}
+
+// BuiltinDimensions returns the sorted names of the built-in grouping dimensions
+func BuiltinDimensions() []string {
+	dimensions := lo.Keys(dimensionValues)
+	sort.Strings(dimensions)
+	return dimensions
+}
+
+// Breakdown configures the grouping dimensions and percentiles of a breakdown of an event metric, i.e. only the p95 by nodepool,
+// so the cardinality of the aggregator output stays bounded on very large fleets. No dimensions compute the percentiles over all results.
+type Breakdown struct {
+	Dimensions  []string
+	Percentiles []float64
+}
+
+// Group is the latency of an event metric over the results with the same values of the grouping dimensions
+type Group struct {
+	Dimensions map[string]string `json:"dimensions"`
+	Results    int               `json:"results"`
+	// Measured is the number of results that measured the metric, which the percentiles are computed over
+	Measured    int                `json:"measured"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+// ParsePercentiles parses comma separated percentiles, i.e. 50,95,99.9
+func ParsePercentiles(s string) ([]float64, error) {
+	var percentiles []float64
+	for _, value := range lo.Compact(strings.Split(s, ",")) {
+		p, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("percentile \"%s\" must be a number in (0, 100]", value)
+		}
+		percentiles = append(percentiles, p)
+	}
+	if len(percentiles) == 0 {
+		return nil, fmt.Errorf("at least one percentile is required")
+	}
+	return lo.Uniq(percentiles), nil
+}
+
+// ParseDimensions parses comma separated grouping dimensions, which are the built-in dimensions or instance tag dimensions
+func ParseDimensions(s string) []string {
+	return lo.Uniq(lo.Compact(lo.Map(strings.Split(s, ","), func(d string, _ int) string { return strings.TrimSpace(d) })))
+}
+
+// Compute groups the results by the dimensions and computes the percentiles of the metric of each group
+func (b Breakdown) Compute(results []*Result, metric string) []*Group {
+	grouped := map[string][]*Result{}
+	values := map[string][]string{}
+	for _, result := range results {
+		metadata := lo.FromPtrOr(result.Metadata, latency.Metadata{})
+		groupValues := lo.Map(b.Dimensions, func(d string, _ int) string {
+			if value, ok := dimensionValues[d]; ok {
+				return value(metadata)
+			}
+			return metadata.Tags[d]
+		})
+		key := strings.Join(groupValues, "\x00")
+		grouped[key] = append(grouped[key], result)
+		values[key] = groupValues
+	}
+	var groups []*Group
+	for key, groupResults := range grouped {
+		var seconds []float64
+		for _, result := range groupResults {
+			if timing, ok := lo.Find(result.Timings, func(t *Timing) bool { return t.Event.Metric == metric && !t.Failed() }); ok {
+				seconds = append(seconds, timing.T.Seconds())
+			}
+		}
+		sort.Float64s(seconds)
+		group := &Group{
+			Dimensions:  lo.SliceToMap(lo.Zip2(b.Dimensions, values[key]), func(t lo.Tuple2[string, string]) (string, string) { return t.A, t.B }),
+			Results:     len(groupResults),
+			Measured:    len(seconds),
+			Percentiles: map[string]float64{},
+		}
+		if len(seconds) > 0 {
+			for _, p := range b.Percentiles {
+				group.Percentiles[percentileName(p)] = percentile(seconds, p)
+			}
+		}
+		groups = append(groups, group)
+	}
+	// numeric values sort as numbers, so launch template version 10 follows version 9
+	sort.Slice(groups, func(i, j int) bool {
+		for _, d := range b.Dimensions {
+			vi, vj := groups[i].Dimensions[d], groups[j].Dimensions[d]
+			if vi == vj {
+				continue
+			}
+			ni, erri := strconv.Atoi(vi)
+			nj, errj := strconv.Atoi(vj)
+			if erri == nil && errj == nil {
+				return ni < nj
+			}
+			return vi < vj
+		}
+		return false
+	})
+	return groups
+}
+
+// WriteCSV writes the groups as CSV with a header row of the dimensions, the counts and the percentiles,
+// the percentiles of groups that did not measure the metric are empty
+func (b Breakdown) WriteCSV(w io.Writer, groups []*Group) error {
+	writer := csv.NewWriter(w)
+	percentileColumns := lo.Map(b.Percentiles, func(p float64, _ int) string { return percentileName(p) })
+	if err := writer.Write(append(append(append([]string{}, b.Dimensions...), "results", "measured"), percentileColumns...)); err != nil {
+		return err
+	}
+	for _, group := range groups {
+		record := lo.Map(b.Dimensions, func(d string, _ int) string { return group.Dimensions[d] })
+		record = append(record, strconv.Itoa(group.Results), strconv.Itoa(group.Measured))
+		for _, column := range percentileColumns {
+			value, ok := group.Percentiles[column]
+			record = append(record, lo.Ternary(ok, strconv.FormatFloat(value, 'f', -1, 64), ""))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// percentileName is the name of a percentile in the summaries and breakdowns, i.e. p95 or p99.9
+func percentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
+}
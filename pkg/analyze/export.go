@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+)
+
+// Export formats of WriteTable
+const (
+	FormatCSVWide = "csv-wide"
+	FormatFeather = "feather"
+)
+
+// ExportFormats are the supported export formats
+var ExportFormats = []string{FormatCSVWide, FormatFeather}
+
+// WriteTable writes the wide table in the export format
+func (t *WideTable) WriteTable(w io.Writer, format string) error {
+	switch format {
+	case FormatCSVWide:
+		return t.WriteCSV(w)
+	case FormatFeather:
+		return t.WriteFeather(w)
+	}
+	return fmt.Errorf("invalid export format \"%s\", must be one of %v", format, ExportFormats)
+}
+
+// WriteCSV writes the wide table as CSV with a header row, unmeasured events are empty
+func (t *WideTable) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(t.Columns); err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			switch v := value.(type) {
+			case string:
+				record[i] = v
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteFeather writes the wide table as a Feather (v2, Arrow IPC file) table that pandas.read_feather reads,
+// node columns are strings and event columns are nullable float64 seconds
+func (t *WideTable) WriteFeather(w io.Writer) error {
+	fields := make([]arrow.Field, len(t.Columns))
+	for i, column := range t.Columns {
+		fields[i] = arrow.Field{Name: column, Type: arrow.BinaryTypes.String}
+		if i >= len(NodeColumns) {
+			fields[i] = arrow.Field{Name: column, Type: arrow.PrimitiveTypes.Float64, Nullable: true}
+		}
+	}
+	schema := arrow.NewSchema(fields, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	for _, row := range t.Rows {
+		for i, value := range row {
+			switch b := builder.Field(i).(type) {
+			case *array.StringBuilder:
+				b.Append(value.(string))
+			case *array.Float64Builder:
+				if seconds, ok := value.(float64); ok {
+					b.Append(seconds)
+				} else {
+					b.AppendNull()
+				}
+			}
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+	writer, err := ipc.NewFileWriter(&positionWriter{w: w}, ipc.WithSchema(schema))
+	if err != nil {
+		return fmt.Errorf("unable to create feather writer: %w", err)
+	}
+	if err := writer.Write(record); err != nil {
+		return fmt.Errorf("unable to write feather table: %w", err)
+	}
+	return writer.Close()
+}
+
+// positionWriter is an io.WriteSeeker that only reports its position, which is all the Arrow file writer seeks for,
+// so that feather tables can be written to pipes like stdout
+type positionWriter struct {
+	w   io.Writer
+	pos int64
+}
+
+func (p *positionWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.pos += int64(n)
+	return n, err
+}
+
+func (p *positionWriter) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekCurrent {
+		return 0, fmt.Errorf("feather output is not seekable")
+	}
+	return p.pos, nil
+}
@@ -0,0 +1,115 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// MaxIngestBytes is the largest result the ingest accepts
+const MaxIngestBytes = 10 << 20
+
+// clusterNameRegex restricts cluster names to characters that are safe in a result file name
+var clusterNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Ingest accepts results pushed by nodes of many clusters and writes them to a results directory, labeled with the cluster,
+// so the directory can be analyzed like the results of a single cluster
+type Ingest struct {
+	// Dir is the results directory the pushed results are written to
+	Dir string
+	// Tokens are the bearer tokens of each cluster, a cluster can only push results labeled with its own name
+	Tokens map[string]string
+}
+
+// ServeHTTP writes a pushed result to <dir>/<cluster>_<instance id>_<unix nano>.json
+func (i *Ingest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "results must be pushed with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	cluster := r.Header.Get(latency.AggregatorClusterHeader)
+	token, ok := i.Tokens[cluster]
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")), []byte(token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	resultBytes, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxIngestBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read result: %s", err), http.StatusRequestEntityTooLarge)
+		return
+	}
+	resultBytes, instanceID, err := labelCluster(resultBytes, cluster)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid result: %s", err), http.StatusBadRequest)
+		return
+	}
+	file := filepath.Join(i.Dir, fmt.Sprintf("%s_%s_%d.json", cluster, instanceID, time.Now().UnixNano()))
+	if err := os.WriteFile(file, resultBytes, 0o600); err != nil {
+		http.Error(w, "unable to store result", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// labelCluster sets the cluster of a result's metadata, keeping all other fields of the result, and returns the instance id of the result
+func labelCluster(resultBytes []byte, cluster string) ([]byte, string, error) {
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(resultBytes, &result); err != nil {
+		return nil, "", err
+	}
+	if _, ok := result["timings"]; !ok {
+		return nil, "", fmt.Errorf("the result has no timings")
+	}
+	metadata := latency.Metadata{}
+	if raw, ok := result["metadata"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return nil, "", err
+		}
+	}
+	metadata.Cluster = cluster
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, "", err
+	}
+	result["metadata"] = metadataBytes
+	labeled, err := json.Marshal(result)
+	if err != nil {
+		return nil, "", err
+	}
+	instanceID := "unknown"
+	if clusterNameRegex.MatchString(metadata.InstanceID) {
+		instanceID = metadata.InstanceID
+	}
+	return labeled, instanceID, nil
+}
+
+// ValidClusterName returns an error if the cluster name cannot label result files
+func ValidClusterName(cluster string) error {
+	if !clusterNameRegex.MatchString(cluster) {
+		return fmt.Errorf("invalid cluster name \"%s\", must match %s", cluster, clusterNameRegex)
+	}
+	return nil
+}
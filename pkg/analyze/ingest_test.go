@@ -0,0 +1,168 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+func TestIngest(t *testing.T) {
+	tokens := map[string]string{"prod": "prod-token", "dev": "dev-token"}
+	result := `{"metadata":{"instanceID":"i-0123456789abcdef0","cluster":"dev"},"timings":[],"extra":{"kept":true}}`
+	for _, tc := range []struct {
+		name        string
+		method      string
+		cluster     string
+		auth        string
+		body        string
+		wantStatus  int
+		wantFile    string
+		wantCluster string
+	}{
+		{name: "results are labeled with the cluster of the token", cluster: "prod", auth: "Bearer prod-token", body: result, wantStatus: http.StatusCreated, wantFile: `^prod_i-0123456789abcdef0_[0-9]+\.json$`, wantCluster: "prod"},
+		{name: "results without metadata are labeled", cluster: "prod", auth: "Bearer prod-token", body: `{"timings":[]}`, wantStatus: http.StatusCreated, wantFile: `^prod_unknown_[0-9]+\.json$`, wantCluster: "prod"},
+		{name: "instance ids that are not safe in a file name are replaced", cluster: "prod", auth: "Bearer prod-token", body: `{"metadata":{"instanceID":"../../etc/passwd"},"timings":[]}`, wantStatus: http.StatusCreated, wantFile: `^prod_unknown_[0-9]+\.json$`, wantCluster: "prod"},
+		{name: "only POST is accepted", method: http.MethodGet, cluster: "prod", auth: "Bearer prod-token", wantStatus: http.StatusMethodNotAllowed},
+		{name: "missing token", cluster: "prod", body: result, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", cluster: "prod", auth: "Bearer wrong", body: result, wantStatus: http.StatusUnauthorized},
+		{name: "the token of another cluster can not push for it", cluster: "prod", auth: "Bearer dev-token", body: result, wantStatus: http.StatusUnauthorized},
+		{name: "unknown cluster", cluster: "staging", auth: "Bearer prod-token", body: result, wantStatus: http.StatusUnauthorized},
+		{name: "cluster names are not paths", cluster: "../prod", auth: "Bearer prod-token", body: result, wantStatus: http.StatusUnauthorized},
+		{name: "missing cluster", auth: "Bearer prod-token", body: result, wantStatus: http.StatusUnauthorized},
+		{name: "too large", cluster: "prod", auth: "Bearer prod-token", body: `{"timings":[],"padding":"` + strings.Repeat("x", MaxIngestBytes) + `"}`, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "not JSON", cluster: "prod", auth: "Bearer prod-token", body: "not json", wantStatus: http.StatusBadRequest},
+		{name: "not a result", cluster: "prod", auth: "Bearer prod-token", body: `{"metadata":{}}`, wantStatus: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/results", strings.NewReader(tc.body))
+			if tc.cluster != "" {
+				req.Header.Set(latency.AggregatorClusterHeader, tc.cluster)
+			}
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			rec := httptest.NewRecorder()
+			(&Ingest{Dir: dir, Tokens: tokens}).ServeHTTP(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("ServeHTTP() status = %d, want %d: %s", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+			files, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantFile == "" {
+				if len(files) != 0 {
+					t.Errorf("ServeHTTP() wrote %d files, want none", len(files))
+				}
+				return
+			}
+			if len(files) != 1 || !regexp.MustCompile(tc.wantFile).MatchString(files[0].Name()) {
+				t.Fatalf("ServeHTTP() wrote %v, want one file matching %s", files, tc.wantFile)
+			}
+			stored, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got struct {
+				Metadata latency.Metadata `json:"metadata"`
+			}
+			if err := json.Unmarshal(stored, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Metadata.Cluster != tc.wantCluster {
+				t.Errorf("ServeHTTP() stored cluster %q, want %q", got.Metadata.Cluster, tc.wantCluster)
+			}
+		})
+	}
+}
+
+func TestLabelCluster(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		result         string
+		wantInstanceID string
+		wantKept       []string
+		wantErr        bool
+	}{
+		{
+			name:           "the pushed cluster is overridden and other fields are kept",
+			result:         `{"metadata":{"cluster":"spoofed","instanceID":"i-0abc","amiID":"ami-0abc"},"timings":[{"seconds":1}],"stale":true}`,
+			wantInstanceID: "i-0abc",
+			wantKept:       []string{`"amiID":"ami-0abc"`, `"timings":[{"seconds":1}]`, `"stale":true`},
+		},
+		{name: "null metadata", result: `{"metadata":null,"timings":[]}`, wantInstanceID: "unknown"},
+		{name: "instance ids with path separators are replaced", result: `{"metadata":{"instanceID":"i-0abc/../x"},"timings":[]}`, wantInstanceID: "unknown"},
+		{name: "instance ids starting with a dot are replaced", result: `{"metadata":{"instanceID":".hidden"},"timings":[]}`, wantInstanceID: "unknown"},
+		{name: "no timings", result: `{"metadata":{}}`, wantErr: true},
+		{name: "invalid metadata", result: `{"metadata":"prod","timings":[]}`, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			labeled, instanceID, err := labelCluster([]byte(tc.result), "prod")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("labelCluster() error = %v, wantErr %t", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if instanceID != tc.wantInstanceID {
+				t.Errorf("labelCluster() instance id = %s, want %s", instanceID, tc.wantInstanceID)
+			}
+			if !strings.Contains(string(labeled), `"cluster":"prod"`) || strings.Contains(string(labeled), "spoofed") {
+				t.Errorf("labelCluster() = %s, want the cluster prod", labeled)
+			}
+			for _, kept := range tc.wantKept {
+				if !strings.Contains(string(labeled), kept) {
+					t.Errorf("labelCluster() = %s, want it to keep %s", labeled, kept)
+				}
+			}
+		})
+	}
+}
+
+func TestValidClusterName(t *testing.T) {
+	for _, tc := range []struct {
+		cluster string
+		wantErr bool
+	}{
+		{cluster: "prod"},
+		{cluster: "eks-prod_us-east-1.v2"},
+		{cluster: "", wantErr: true},
+		{cluster: "../prod", wantErr: true},
+		{cluster: "prod/east", wantErr: true},
+		{cluster: ".prod", wantErr: true},
+		{cluster: "-prod", wantErr: true},
+		{cluster: "prod east", wantErr: true},
+	} {
+		t.Run(tc.cluster, func(t *testing.T) {
+			if err := ValidClusterName(tc.cluster); (err != nil) != tc.wantErr {
+				t.Errorf("ValidClusterName() error = %v, wantErr %t", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// MarkersDir is the directory in a results directory holding the external markers, Load does not read it
+const MarkersDir = "markers"
+
+// MaxMarkerBytes is the largest marker the marker ingest accepts
+const MaxMarkerBytes = 64 << 10
+
+// Marker is an external change, i.e. a control plane upgrade, an AMI release or a config change, that is overlaid on the trend
+// so a latency shift can be attributed to it. Markers without a cluster apply to all clusters.
+type Marker struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	Description string    `json:"description,omitempty"`
+	Cluster     string    `json:"cluster,omitempty"`
+}
+
+// Validate returns an error if the marker has no time or kind
+func (m *Marker) Validate() error {
+	if m.Time.IsZero() {
+		return fmt.Errorf("the marker has no time")
+	}
+	if strings.TrimSpace(m.Kind) == "" {
+		return fmt.Errorf("the marker has no kind")
+	}
+	return nil
+}
+
+// MarkerIngest accepts markers pushed by the deployment pipelines of the clusters and writes them to the markers directory of a
+// results directory, i.e. POST /markers with {"time": "2024-05-01T10:00:00Z", "kind": "control-plane-upgrade", "description": "1.29 to 1.30"}.
+// A cluster's markers are labeled with its name, like its results.
+type MarkerIngest struct {
+	// Dir is the results directory the markers are written to
+	Dir string
+	// Tokens are the bearer tokens of each cluster
+	Tokens map[string]string
+}
+
+// ServeHTTP writes a pushed marker to <dir>/markers/<cluster>_<unix nano>.json
+func (i *MarkerIngest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "markers must be pushed with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	cluster := r.Header.Get(latency.AggregatorClusterHeader)
+	token, ok := i.Tokens[cluster]
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")), []byte(token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	markerBytes, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxMarkerBytes))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read marker: %s", err), http.StatusRequestEntityTooLarge)
+		return
+	}
+	var marker Marker
+	if err := json.Unmarshal(markerBytes, &marker); err != nil {
+		http.Error(w, fmt.Sprintf("invalid marker: %s", err), http.StatusBadRequest)
+		return
+	}
+	if err := marker.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("invalid marker: %s", err), http.StatusBadRequest)
+		return
+	}
+	marker.Cluster = cluster
+	labeled, err := json.Marshal(marker)
+	if err != nil {
+		http.Error(w, "unable to store marker", http.StatusInternalServerError)
+		return
+	}
+	if err := os.MkdirAll(filepath.Join(i.Dir, MarkersDir), 0o755); err != nil {
+		http.Error(w, "unable to store marker", http.StatusInternalServerError)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(i.Dir, MarkersDir, fmt.Sprintf("%s_%d.json", cluster, time.Now().UnixNano())), labeled, 0o600); err != nil {
+		http.Error(w, "unable to store marker", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// LoadMarkers loads the markers of the markers directory of a results directory, sorted by time
+func LoadMarkers(dir string) ([]*Marker, error) {
+	files, err := filepath.Glob(filepath.Join(dir, MarkersDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list markers in %s: %w", dir, err)
+	}
+	var markers []*Marker
+	for _, file := range files {
+		fileMarkers, err := LoadMarkerFile(file)
+		if err != nil {
+			return nil, err
+		}
+		markers = append(markers, fileMarkers...)
+	}
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].Time.Before(markers[j].Time) })
+	return markers, nil
+}
+
+// LoadMarkerFile loads a marker or a list of markers from a JSON file, i.e. the AMI release times exported from a pipeline
+func LoadMarkerFile(file string) ([]*Marker, error) {
+	markerBytes, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read markers %s: %w", file, err)
+	}
+	var markers []*Marker
+	if trimmed := strings.TrimSpace(string(markerBytes)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal([]byte(trimmed), &markers)
+	} else {
+		var marker Marker
+		err = json.Unmarshal([]byte(trimmed), &marker)
+		markers = []*Marker{&marker}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse markers %s: %w", file, err)
+	}
+	for _, marker := range markers {
+		if err := marker.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid marker in %s: %w", file, err)
+		}
+	}
+	return markers, nil
+}
@@ -0,0 +1,120 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+func TestMarkerIngest(t *testing.T) {
+	tokens := map[string]string{"prod": "prod-token", "dev": "dev-token"}
+	marker := `{"time":"2024-05-01T10:00:00Z","kind":"control-plane-upgrade","description":"1.29 to 1.30","cluster":"dev"}`
+	for _, tc := range []struct {
+		name       string
+		method     string
+		cluster    string
+		auth       string
+		body       string
+		wantStatus int
+	}{
+		{name: "markers are labeled with the cluster of the token", cluster: "prod", auth: "Bearer prod-token", body: marker, wantStatus: http.StatusCreated},
+		{name: "only POST is accepted", method: http.MethodGet, cluster: "prod", auth: "Bearer prod-token", wantStatus: http.StatusMethodNotAllowed},
+		{name: "missing token", cluster: "prod", body: marker, wantStatus: http.StatusUnauthorized},
+		{name: "the token of another cluster can not push for it", cluster: "prod", auth: "Bearer dev-token", body: marker, wantStatus: http.StatusUnauthorized},
+		{name: "cluster names are not paths", cluster: "../prod", auth: "Bearer prod-token", body: marker, wantStatus: http.StatusUnauthorized},
+		{name: "too large", cluster: "prod", auth: "Bearer prod-token", body: `{"time":"2024-05-01T10:00:00Z","kind":"` + strings.Repeat("x", MaxMarkerBytes) + `"}`, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "not JSON", cluster: "prod", auth: "Bearer prod-token", body: "not json", wantStatus: http.StatusBadRequest},
+		{name: "no time", cluster: "prod", auth: "Bearer prod-token", body: `{"kind":"ami-release"}`, wantStatus: http.StatusBadRequest},
+		{name: "no kind", cluster: "prod", auth: "Bearer prod-token", body: `{"time":"2024-05-01T10:00:00Z","kind":" "}`, wantStatus: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/markers", strings.NewReader(tc.body))
+			if tc.cluster != "" {
+				req.Header.Set(latency.AggregatorClusterHeader, tc.cluster)
+			}
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			rec := httptest.NewRecorder()
+			(&MarkerIngest{Dir: dir, Tokens: tokens}).ServeHTTP(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("ServeHTTP() status = %d, want %d: %s", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+			markers, err := LoadMarkers(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.wantStatus != http.StatusCreated {
+				if len(markers) != 0 {
+					t.Errorf("ServeHTTP() stored %d markers, want none", len(markers))
+				}
+				return
+			}
+			files, err := filepath.Glob(filepath.Join(dir, MarkersDir, "prod_*.json"))
+			if err != nil || len(files) != 1 {
+				t.Fatalf("ServeHTTP() wrote %v, want one marker file of the cluster prod", files)
+			}
+			want := Marker{Time: time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC), Kind: "control-plane-upgrade", Description: "1.29 to 1.30", Cluster: "prod"}
+			if len(markers) != 1 || *markers[0] != want {
+				t.Errorf("ServeHTTP() stored %+v, want %+v", markers, want)
+			}
+		})
+	}
+}
+
+func TestLoadMarkerFile(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		markers   string
+		wantKinds []string
+		wantErr   bool
+	}{
+		{name: "a marker", markers: `{"time":"2024-05-01T10:00:00Z","kind":"ami-release"}`, wantKinds: []string{"ami-release"}},
+		{name: "a list of markers", markers: ` [{"time":"2024-05-01T10:00:00Z","kind":"ami-release"},{"time":"2024-05-02T10:00:00Z","kind":"config-change"}]`, wantKinds: []string{"ami-release", "config-change"}},
+		{name: "invalid marker in a list", markers: `[{"time":"2024-05-01T10:00:00Z","kind":"ami-release"},{"kind":"config-change"}]`, wantErr: true},
+		{name: "not JSON", markers: "not json", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "markers.json")
+			if err := os.WriteFile(file, []byte(tc.markers), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			markers, err := LoadMarkerFile(file)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("LoadMarkerFile() error = %v, wantErr %t", err, tc.wantErr)
+			}
+			var kinds []string
+			for _, marker := range markers {
+				kinds = append(kinds, marker.Kind)
+			}
+			if !tc.wantErr && strings.Join(kinds, ",") != strings.Join(tc.wantKinds, ",") {
+				t.Errorf("LoadMarkerFile() kinds = %v, want %v", kinds, tc.wantKinds)
+			}
+		})
+	}
+}
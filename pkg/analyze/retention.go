@@ -0,0 +1,207 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/latency"
+)
+
+// SummaryDir is the directory in a results directory holding the daily summaries of downsampled results, Load does not read it
+const SummaryDir = "daily"
+
+// SummaryPercentiles are the default percentiles kept of each event metric when results are downsampled
+var SummaryPercentiles = []float64{50, 90, 99}
+
+// Retention bounds the results directory of a long running aggregator. Results older than DownsampleAfter are replaced by daily summaries
+// of per-metric percentiles, results and summaries older than MaxAge are removed, and only the newest MaxResults results are kept.
+// A zero value disables the bound.
+type Retention struct {
+	MaxAge          time.Duration
+	MaxResults      int
+	DownsampleAfter time.Duration
+	// Percentiles are kept of each event metric in the daily summaries, the SummaryPercentiles if not set
+	Percentiles []float64
+}
+
+// DailySummary holds the percentiles in seconds of each event metric of the results of a cluster that were stored on a day
+type DailySummary struct {
+	Day     string `json:"day"`
+	Cluster string `json:"cluster,omitempty"`
+	Results int    `json:"results"`
+	// Percentiles maps event metrics to percentiles, i.e. "p90", to seconds
+	Percentiles map[string]map[string]float64 `json:"percentiles"`
+}
+
+// resultFile is a result file in a results directory with the time it was stored
+type resultFile struct {
+	path    string
+	modTime time.Time
+}
+
+// Apply downsamples and removes results in the results directory, it returns the number of removed result files
+func (r Retention) Apply(dir string, now time.Time) (int, error) {
+	files, err := resultFiles(dir)
+	if err != nil {
+		return 0, err
+	}
+	var expired []resultFile
+	if r.DownsampleAfter > 0 {
+		downsampled, err := r.downsample(dir, lo.Filter(files, func(f resultFile, _ int) bool { return now.Sub(f.modTime) > r.DownsampleAfter }))
+		if err != nil {
+			return 0, err
+		}
+		expired = append(expired, downsampled...)
+	}
+	if r.MaxAge > 0 {
+		expired = append(expired, lo.Filter(files, func(f resultFile, _ int) bool { return now.Sub(f.modTime) > r.MaxAge })...)
+		summaries, err := filepath.Glob(filepath.Join(dir, SummaryDir, "*.json"))
+		if err != nil {
+			return 0, err
+		}
+		for _, summary := range summaries {
+			if end, ok := summaryEnd(summary); ok && now.Sub(end) > r.MaxAge {
+				if err := os.Remove(summary); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	if r.MaxResults > 0 && len(files) > r.MaxResults {
+		// files are sorted from newest to oldest
+		expired = append(expired, files[r.MaxResults:]...)
+	}
+	removed := 0
+	for _, file := range lo.UniqBy(expired, func(f resultFile) string { return f.path }) {
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// summaryEnd returns the end of the day a daily summary covers, which is when its newest result was stored.
+// The modification time is used for a summary whose day can not be parsed, ok is false if neither is known.
+func summaryEnd(path string) (time.Time, bool) {
+	summaryBytes, err := os.ReadFile(path)
+	if err == nil {
+		var summary DailySummary
+		if err := json.Unmarshal(summaryBytes, &summary); err == nil {
+			if day, err := time.Parse("2006-01-02", summary.Day); err == nil {
+				return day.Add(24 * time.Hour), true
+			}
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+// downsample writes the daily summaries of the results per cluster and returns the results that were summarized
+func (r Retention) downsample(dir string, files []resultFile) ([]resultFile, error) {
+	type dayKey struct{ day, cluster string }
+	results := map[dayKey][]*Result{}
+	var summarized []resultFile
+	for _, file := range files {
+		fileResults, err := loadFile(file.path)
+		if err != nil {
+			// results that cannot be parsed are left for MaxAge
+			continue
+		}
+		for _, result := range fileResults {
+			key := dayKey{day: file.modTime.UTC().Format("2006-01-02"), cluster: lo.FromPtrOr(result.Metadata, latency.Metadata{}).Cluster}
+			results[key] = append(results[key], result)
+		}
+		summarized = append(summarized, file)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(filepath.Join(dir, SummaryDir), 0o755); err != nil {
+		return nil, err
+	}
+	for key, dayResults := range results {
+		summaryBytes, err := json.MarshalIndent(Summarize(key.day, key.cluster, dayResults, lo.Ternary(len(r.Percentiles) > 0, r.Percentiles, SummaryPercentiles)), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		// a day can be summarized more than once if results arrive late, each summary is kept
+		name := fmt.Sprintf("%s_%s_%d.json", key.day, lo.Ternary(key.cluster == "", "unknown", key.cluster), time.Now().UnixNano())
+		if err := os.WriteFile(filepath.Join(dir, SummaryDir, name), summaryBytes, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return summarized, nil
+}
+
+// Summarize computes the percentiles of the successful timings of each event metric of the results
+func Summarize(day string, cluster string, results []*Result, percentiles []float64) *DailySummary {
+	seconds := map[string][]float64{}
+	for _, result := range results {
+		for _, timing := range result.Timings {
+			if !timing.Failed() {
+				seconds[timing.Event.Metric] = append(seconds[timing.Event.Metric], timing.T.Seconds())
+			}
+		}
+	}
+	summary := &DailySummary{Day: day, Cluster: cluster, Results: len(results), Percentiles: map[string]map[string]float64{}}
+	for metric, values := range seconds {
+		sort.Float64s(values)
+		summary.Percentiles[metric] = map[string]float64{}
+		for _, p := range percentiles {
+			summary.Percentiles[metric][percentileName(p)] = percentile(values, p)
+		}
+	}
+	return summary
+}
+
+// percentile is the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[lo.Clamp(rank-1, 0, len(sorted)-1)]
+}
+
+// resultFiles lists the result files of a results directory from newest to oldest
+func resultFiles(dir string) ([]resultFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list results in %s: %w", dir, err)
+	}
+	var files []resultFile
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, resultFile{path: path, modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.After(files[j].modTime) })
+	return files, nil
+}
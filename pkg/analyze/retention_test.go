@@ -0,0 +1,219 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// newTiming is a timing of the metric measured at ts that took seconds, or failed
+func newTiming(metric string, ts time.Time, seconds float64, failed bool) *Timing {
+	timing := &Timing{Timestamp: ts, T: time.Duration(seconds * float64(time.Second))}
+	timing.Event.Metric = metric
+	if failed {
+		timing.Error = json.RawMessage(`"timed out"`)
+	}
+	return timing
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for _, tc := range []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{name: "no values", p: 50, want: 0},
+		{name: "single value", sorted: []float64{4.2}, p: 99, want: 4.2},
+		{name: "p50", sorted: values, p: 50, want: 5},
+		{name: "p90", sorted: values, p: 90, want: 9},
+		{name: "p99 is the nearest rank", sorted: values, p: 99, want: 10},
+		{name: "p100", sorted: values, p: 100, want: 10},
+		{name: "p0 is the minimum", sorted: values, p: 0, want: 1},
+		{name: "p25 rounds the rank up", sorted: []float64{1, 2, 3}, p: 25, want: 1},
+		{name: "p50 of an odd count", sorted: []float64{1, 2, 3}, p: 50, want: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := percentile(tc.sorted, tc.p); got != tc.want {
+				t.Errorf("percentile() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	ts := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name        string
+		results     []*Result
+		percentiles []float64
+		want        *DailySummary
+	}{
+		{
+			name:        "no results",
+			percentiles: SummaryPercentiles,
+			want:        &DailySummary{Day: "2024-01-15", Cluster: "prod", Percentiles: map[string]map[string]float64{}},
+		},
+		{
+			name: "percentiles of each metric",
+			results: []*Result{
+				{Timings: []*Timing{newTiming("node_ready", ts, 30, false), newTiming("pod_ready", ts, 40, false)}},
+				{Timings: []*Timing{newTiming("node_ready", ts, 10, false), newTiming("pod_ready", ts, 60, false)}},
+				{Timings: []*Timing{newTiming("node_ready", ts, 20, false)}},
+			},
+			percentiles: []float64{50, 99},
+			want: &DailySummary{Day: "2024-01-15", Cluster: "prod", Results: 3, Percentiles: map[string]map[string]float64{
+				"node_ready": {"p50": 20, "p99": 30},
+				"pod_ready":  {"p50": 40, "p99": 60},
+			}},
+		},
+		{
+			name: "failed timings are left out",
+			results: []*Result{
+				{Timings: []*Timing{newTiming("node_ready", ts, 30, false), newTiming("pod_ready", ts, 0, true)}},
+			},
+			percentiles: []float64{90},
+			want: &DailySummary{Day: "2024-01-15", Cluster: "prod", Results: 1, Percentiles: map[string]map[string]float64{
+				"node_ready": {"p90": 30},
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Summarize("2024-01-15", "prod", tc.results, tc.percentiles); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Summarize() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetentionMaxAgeSummaries(t *testing.T) {
+	now := time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		day      string
+		modTime  time.Time
+		wantKept bool
+	}{
+		{name: "old day written recently is removed", day: "2024-01-01", modTime: now, wantKept: false},
+		{name: "recent day is kept", day: "2024-01-29", modTime: now, wantKept: true},
+		{name: "the day is kept until its end is older than the max age", day: "2024-01-24", modTime: now.AddDate(0, 0, -30), wantKept: true},
+		{name: "unparsable day falls back to the modification time", day: "yesterday", modTime: now.AddDate(0, 0, -8), wantKept: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, SummaryDir), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, SummaryDir, "summary.json")
+			summaryBytes, err := json.Marshal(&DailySummary{Day: tc.day, Results: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, summaryBytes, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, tc.modTime, tc.modTime); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := (Retention{MaxAge: 7 * 24 * time.Hour}).Apply(dir, now); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if _, err := os.Stat(path); (err == nil) != tc.wantKept {
+				t.Errorf("summary kept = %v, want %v", err == nil, tc.wantKept)
+			}
+		})
+	}
+}
+
+func TestRetentionApply(t *testing.T) {
+	now := time.Date(2024, time.January, 31, 12, 0, 0, 0, time.UTC)
+	// ages of the result files in days
+	ages := map[string]int{"a.json": 0, "b.json": 2, "c.json": 10, "d.json": 40}
+	for _, tc := range []struct {
+		name        string
+		retention   Retention
+		wantRemoved int
+		wantKept    []string
+	}{
+		{name: "no bounds", retention: Retention{}, wantKept: []string{"a.json", "b.json", "c.json", "d.json"}},
+		{name: "max age", retention: Retention{MaxAge: 30 * 24 * time.Hour}, wantRemoved: 1, wantKept: []string{"a.json", "b.json", "c.json"}},
+		{name: "max results keeps the newest", retention: Retention{MaxResults: 2}, wantRemoved: 2, wantKept: []string{"a.json", "b.json"}},
+		{
+			name:        "a result expired by both bounds is removed once",
+			retention:   Retention{MaxAge: 30 * 24 * time.Hour, MaxResults: 3},
+			wantRemoved: 1,
+			wantKept:    []string{"a.json", "b.json", "c.json"},
+		},
+		{
+			name:        "downsampled results are replaced by summaries",
+			retention:   Retention{DownsampleAfter: 7 * 24 * time.Hour},
+			wantRemoved: 2,
+			wantKept:    []string{"a.json", "b.json"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, age := range ages {
+				resultBytes, err := json.Marshal(&Result{Timings: []*Timing{newTiming("node_ready", now, float64(age), false)}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				path := filepath.Join(dir, name)
+				if err := os.WriteFile(path, resultBytes, 0o600); err != nil {
+					t.Fatal(err)
+				}
+				modTime := now.AddDate(0, 0, -age)
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			removed, err := tc.retention.Apply(dir, now)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if removed != tc.wantRemoved {
+				t.Errorf("Apply() removed %d, want %d", removed, tc.wantRemoved)
+			}
+			files, err := resultFiles(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kept []string
+			for _, file := range files {
+				kept = append(kept, filepath.Base(file.path))
+			}
+			sort.Strings(kept)
+			if !reflect.DeepEqual(kept, tc.wantKept) {
+				t.Errorf("kept results = %v, want %v", kept, tc.wantKept)
+			}
+			if tc.retention.DownsampleAfter > 0 {
+				summaries, err := LoadSummaries(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(summaries) != 2 {
+					t.Errorf("LoadSummaries() = %d summaries, want one per day", len(summaries))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// DefaultTrendWeeks is the number of weeks, including the current week, a trend covers
+const DefaultTrendWeeks = 8
+
+// DefaultTrendPercentile is the percentile of each week a trend follows
+const DefaultTrendPercentile = 50.0
+
+// sparkBars are the bars of a sparkline from the lowest to the highest value
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Trend is the weekly percentile of an event metric, a rolling AMI update that regressed the metric shows as a week-over-week change
+type Trend struct {
+	Metric string `json:"metric"`
+	// Weeks are the Mondays (UTC) the weeks start on, from the oldest to the current week
+	Weeks []string `json:"weeks"`
+	// Seconds is the percentile of each week, or nil if no results of the week measured the metric
+	Seconds []*float64 `json:"seconds"`
+	// Change is the percent change of the current week over the previous week, or nil if either week has no value
+	Change *float64 `json:"change,omitempty"`
+}
+
+// LoadSummaries loads the daily summaries of the downsampled results of a results directory
+func LoadSummaries(dir string) ([]*DailySummary, error) {
+	files, err := filepath.Glob(filepath.Join(dir, SummaryDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list daily summaries in %s: %w", dir, err)
+	}
+	var summaries []*DailySummary
+	for _, file := range files {
+		summaryBytes, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read daily summary %s: %w", file, err)
+		}
+		var summary DailySummary
+		if err := json.Unmarshal(summaryBytes, &summary); err != nil {
+			return nil, fmt.Errorf("unable to parse daily summary %s: %w", file, err)
+		}
+		summaries = append(summaries, &summary)
+	}
+	return summaries, nil
+}
+
+// Trends computes the weekly percentile of each event metric over the last weeks up to now. A result belongs to the week of its first
+// successful timing. Downsampled days are only included if the percentile was summarized, weighted by their number
+// of results, which approximates the percentile of the week.
+func Trends(results []*Result, summaries []*DailySummary, p float64, weeks int, now time.Time) []*Trend {
+	current := weekStart(now)
+	starts := lo.Times(weeks, func(i int) time.Time { return current.AddDate(0, 0, -7*(weeks-1-i)) })
+	weekIndex := func(t time.Time) (int, bool) {
+		i := int(weekStart(t).Sub(starts[0]).Hours() / (7 * 24))
+		return i, i >= 0 && i < weeks
+	}
+	type bucket struct {
+		seconds []float64
+		// summarized and weight are the sum of the daily percentiles weighted by their results and the sum of the weights
+		summarized float64
+		weight     float64
+	}
+	buckets := map[string][]bucket{}
+	metricBuckets := func(metric string) []bucket {
+		if _, ok := buckets[metric]; !ok {
+			buckets[metric] = make([]bucket, weeks)
+		}
+		return buckets[metric]
+	}
+	for _, result := range results {
+		measured := lo.Filter(result.Timings, func(t *Timing, _ int) bool { return !t.Failed() && !t.Timestamp.IsZero() })
+		if len(measured) == 0 {
+			continue
+		}
+		i, ok := weekIndex(lo.MinBy(measured, func(a *Timing, b *Timing) bool { return a.Timestamp.Before(b.Timestamp) }).Timestamp)
+		if !ok {
+			continue
+		}
+		for _, timing := range lo.UniqBy(measured, func(t *Timing) string { return t.Event.Metric }) {
+			b := metricBuckets(timing.Event.Metric)
+			b[i].seconds = append(b[i].seconds, timing.T.Seconds())
+		}
+	}
+	key := percentileName(p)
+	for _, summary := range summaries {
+		day, err := time.Parse("2006-01-02", summary.Day)
+		if err != nil {
+			continue
+		}
+		i, ok := weekIndex(day)
+		if !ok {
+			continue
+		}
+		for metric, percentiles := range summary.Percentiles {
+			if value, ok := percentiles[key]; ok {
+				b := metricBuckets(metric)
+				b[i].summarized += value * float64(summary.Results)
+				b[i].weight += float64(summary.Results)
+			}
+		}
+	}
+	var trends []*Trend
+	for metric, metricBuckets := range buckets {
+		trend := &Trend{Metric: metric, Weeks: lo.Map(starts, func(t time.Time, _ int) string { return t.Format("2006-01-02") })}
+		for _, b := range metricBuckets {
+			sort.Float64s(b.seconds)
+			weight := float64(len(b.seconds)) + b.weight
+			if weight == 0 {
+				trend.Seconds = append(trend.Seconds, nil)
+				continue
+			}
+			trend.Seconds = append(trend.Seconds, lo.ToPtr((percentile(b.seconds, p)*float64(len(b.seconds))+b.summarized)/weight))
+		}
+		if weeks > 1 {
+			if previous, last := trend.Seconds[weeks-2], trend.Seconds[weeks-1]; previous != nil && last != nil && *previous > 0 {
+				trend.Change = lo.ToPtr((*last - *previous) / *previous * 100)
+			}
+		}
+		trends = append(trends, trend)
+	}
+	sort.Slice(trends, func(i, j int) bool { return trends[i].Metric < trends[j].Metric })
+	return trends
+}
+
+// TrendReport is the trends with the external markers of their weeks overlaid
+type TrendReport struct {
+	Trends  []*Trend  `json:"trends"`
+	Markers []*Marker `json:"markers,omitempty"`
+}
+
+// NewTrendReport overlays the markers that fall into the weeks of the trends
+func NewTrendReport(trends []*Trend, markers []*Marker) *TrendReport {
+	report := &TrendReport{Trends: trends}
+	if len(trends) > 0 {
+		report.Markers = lo.Filter(markers, func(m *Marker, _ int) bool {
+			return lo.Contains(trends[0].Weeks, weekStart(m.Time).Format("2006-01-02"))
+		})
+	}
+	return report
+}
+
+// WriteTrends writes a table with a sparkline of each trend, its current week and its week-over-week change,
+// followed by a row marking the weeks of the markers and the list of markers
+func (r *TrendReport) WriteTrends(w io.Writer) error {
+	trends := r.Trends
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tTREND\tCURRENT\tWOW")
+	for _, trend := range trends {
+		current := "-"
+		if last := trend.Seconds[len(trend.Seconds)-1]; last != nil {
+			current = fmt.Sprintf("%.1fs", *last)
+		}
+		change := "-"
+		if trend.Change != nil {
+			change = fmt.Sprintf("%+.1f%%", *trend.Change)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", trend.Metric, sparkline(trend.Seconds), current, change)
+	}
+	if len(r.Markers) == 0 {
+		return tw.Flush()
+	}
+	markedWeeks := lo.Map(trends[0].Weeks, func(week string, _ int) string {
+		return lo.Ternary(lo.SomeBy(r.Markers, func(m *Marker) bool { return weekStart(m.Time).Format("2006-01-02") == week }), "▲", " ")
+	})
+	fmt.Fprintf(tw, "markers\t%s\t\t\n", strings.Join(markedWeeks, ""))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	for _, marker := range r.Markers {
+		line := fmt.Sprintf("▲ %s %s", marker.Time.UTC().Format(time.RFC3339), marker.Kind)
+		if marker.Cluster != "" {
+			line += fmt.Sprintf(" (%s)", marker.Cluster)
+		}
+		if marker.Description != "" {
+			line += fmt.Sprintf(": %s", marker.Description)
+		}
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+// sparkline renders the values scaled between their minimum and maximum, a week without a value is a space
+func sparkline(values []*float64) string {
+	measured := lo.Map(lo.Compact(values), func(v *float64, _ int) float64 { return *v })
+	if len(measured) == 0 {
+		return strings.Repeat(" ", len(values))
+	}
+	low, high := lo.Min(measured), lo.Max(measured)
+	var line strings.Builder
+	for _, value := range values {
+		if value == nil {
+			line.WriteRune(' ')
+			continue
+		}
+		bar := 0
+		if high > low {
+			bar = int(math.Round((*value - low) / (high - low) * float64(len(sparkBars)-1)))
+		}
+		line.WriteRune(sparkBars[bar])
+	}
+	return line.String()
+}
+
+// weekStart returns the Monday (UTC) the week of t starts on
+func weekStart(t time.Time) time.Time {
+	day := time.Date(t.UTC().Year(), t.UTC().Month(), t.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
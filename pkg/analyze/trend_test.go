@@ -0,0 +1,154 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyze
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTrends(t *testing.T) {
+	// Wednesday of the week starting on Monday 2024-01-15, the trends cover the weeks of 01-01, 01-08 and 01-15
+	now := time.Date(2024, time.January, 17, 12, 0, 0, 0, time.UTC)
+	week1 := time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC)
+	week2 := time.Date(2024, time.January, 9, 10, 0, 0, 0, time.UTC)
+	week3 := time.Date(2024, time.January, 16, 10, 0, 0, 0, time.UTC)
+	value := func(v float64) *float64 { return &v }
+	result := func(timings ...*Timing) *Result { return &Result{Timings: timings} }
+	for _, tc := range []struct {
+		name       string
+		results    []*Result
+		summaries  []*DailySummary
+		want       map[string][]*float64
+		wantChange map[string]*float64
+	}{
+		{
+			name:       "weekly percentiles and week over week change",
+			results:    []*Result{result(newTiming("node_ready", week2, 10, false)), result(newTiming("node_ready", week3, 15, false))},
+			want:       map[string][]*float64{"node_ready": {nil, value(10), value(15)}},
+			wantChange: map[string]*float64{"node_ready": value(50)},
+		},
+		{
+			name:    "no change without a previous week",
+			results: []*Result{result(newTiming("node_ready", week1, 10, false)), result(newTiming("node_ready", week3, 15, false))},
+			want:    map[string][]*float64{"node_ready": {value(10), nil, value(15)}},
+		},
+		{
+			name: "a result belongs to the week of its first timing",
+			results: []*Result{result(
+				newTiming("pod_ready", time.Date(2024, time.January, 15, 0, 0, 30, 0, time.UTC), 40, false),
+				newTiming("node_ready", time.Date(2024, time.January, 14, 23, 59, 50, 0, time.UTC), 20, false),
+			)},
+			want: map[string][]*float64{"node_ready": {nil, value(20), nil}, "pod_ready": {nil, value(40), nil}},
+		},
+		{
+			name: "failed timings and results outside the weeks are left out",
+			results: []*Result{
+				result(newTiming("node_ready", week3, 10, false), newTiming("pod_ready", week3, 0, true)),
+				result(newTiming("node_ready", week1.AddDate(0, 0, -7), 99, false)),
+			},
+			want: map[string][]*float64{"node_ready": {nil, nil, value(10)}},
+		},
+		{
+			name:    "summaries are weighted by their results",
+			results: []*Result{result(newTiming("node_ready", week2, 10, false))},
+			summaries: []*DailySummary{
+				{Day: "2024-01-10", Results: 3, Percentiles: map[string]map[string]float64{"node_ready": {"p50": 20}}},
+				{Day: "2024-01-11", Results: 5, Percentiles: map[string]map[string]float64{"node_ready": {"p90": 90}}},
+				{Day: "invalid", Results: 5, Percentiles: map[string]map[string]float64{"node_ready": {"p50": 90}}},
+			},
+			want: map[string][]*float64{"node_ready": {nil, value(17.5), nil}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			trends := Trends(tc.results, tc.summaries, 50, 3, now)
+			if len(trends) != len(tc.want) {
+				t.Fatalf("Trends() = %d trends, want %d", len(trends), len(tc.want))
+			}
+			for _, trend := range trends {
+				if want := []string{"2024-01-01", "2024-01-08", "2024-01-15"}; !reflect.DeepEqual(trend.Weeks, want) {
+					t.Errorf("%s weeks = %v, want %v", trend.Metric, trend.Weeks, want)
+				}
+				want, ok := tc.want[trend.Metric]
+				if !ok {
+					t.Errorf("unexpected trend of %s", trend.Metric)
+					continue
+				}
+				for i := range want {
+					if !equalValues(trend.Seconds[i], want[i]) {
+						t.Errorf("%s week %d = %v, want %v", trend.Metric, i, format(trend.Seconds[i]), format(want[i]))
+					}
+				}
+				if !equalValues(trend.Change, tc.wantChange[trend.Metric]) {
+					t.Errorf("%s change = %v, want %v", trend.Metric, format(trend.Change), format(tc.wantChange[trend.Metric]))
+				}
+			}
+		})
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	for _, tc := range []struct {
+		t    time.Time
+		want string
+	}{
+		{t: time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), want: "2024-01-15"},
+		{t: time.Date(2024, time.January, 17, 12, 0, 0, 0, time.UTC), want: "2024-01-15"},
+		{t: time.Date(2024, time.January, 21, 23, 59, 59, 0, time.UTC), want: "2024-01-15"},
+		{t: time.Date(2024, time.January, 1, 0, 30, 0, 0, time.FixedZone("CET", 3600)), want: "2023-12-25"},
+	} {
+		t.Run(tc.t.String(), func(t *testing.T) {
+			if got := weekStart(tc.t).Format("2006-01-02"); got != tc.want {
+				t.Errorf("weekStart() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	for _, tc := range []struct {
+		name   string
+		values []*float64
+		want   string
+	}{
+		{name: "no values", values: []*float64{nil, nil}, want: "  "},
+		{name: "scaled between minimum and maximum", values: []*float64{value(10), value(20), value(30)}, want: "▁▅█"},
+		{name: "missing weeks are spaces", values: []*float64{value(10), nil, value(30)}, want: "▁ █"},
+		{name: "equal values", values: []*float64{value(5), value(5)}, want: "▁▁"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sparkline(tc.values); got != tc.want {
+				t.Errorf("sparkline() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func equalValues(a *float64, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return math.Abs(*a-*b) < 1e-9
+}
+
+func format(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
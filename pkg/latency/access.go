@@ -0,0 +1,70 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+)
+
+// fallbackLogSource switches the default log events to the journal gateway when the host paths of the log source
+// are denied, i.e. by SELinux or AppArmor policy, and no helper socket is configured to read them instead
+func (m *Measurer) fallbackLogSource() {
+	if m.journalGatewayURL == "" || sources.DefaultHelperSocket != "" || m.logSourceName() == journal.GatewayName {
+		return
+	}
+	src, ok := m.sources[m.logSourceName()].(sources.HostPathSource)
+	if !ok {
+		return
+	}
+	for _, path := range src.HostPaths() {
+		if matches, err := filepath.Glob(path); err == nil && len(matches) > 0 {
+			path = matches[0]
+		}
+		file, err := os.Open(path)
+		if err == nil {
+			_ = file.Close()
+			continue
+		}
+		if errors.Is(err, fs.ErrPermission) {
+			zap.S().Warnf("Access to %s is denied, reading the default log events from the journal gateway at %s instead of %s", path, m.journalGatewayURL, src.Name())
+			m.logSource = journal.GatewayName
+			return
+		}
+	}
+}
+
+// accessPaths returns how each registered source that reports it read its logs
+func (m *Measurer) accessPaths() map[string]string {
+	accessPaths := map[string]string{}
+	for name, src := range m.sources {
+		if accessPathSource, ok := src.(sources.AccessPathSource); ok {
+			if accessPath := accessPathSource.AccessPath(); accessPath != "" {
+				accessPaths[name] = accessPath
+			}
+		}
+	}
+	if len(accessPaths) == 0 {
+		return nil
+	}
+	return accessPaths
+}
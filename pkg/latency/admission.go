@@ -0,0 +1,187 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/samber/lo"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+)
+
+// Admission request time sources of a PodAdmission
+const (
+	// AdmissionRequestedAnnotation is a request time the client stamped on the pod in the admission requested annotation
+	AdmissionRequestedAnnotation = "annotation"
+	// AdmissionRequestedOwnerEvent is the SuccessfulCreate event the owning controller recorded once the create call returned
+	AdmissionRequestedOwnerEvent = "owner-event"
+)
+
+// PodAdmission estimates the time a measured pod spent in admission before the node could act on it
+type PodAdmission struct {
+	Pod string `json:"pod"`
+	// Created is the pod creation timestamp, which the API server sets after mutating admission and before validating admission
+	Created time.Time `json:"created"`
+	// Source is where the admission estimate comes from, either AdmissionRequestedAnnotation or AdmissionRequestedOwnerEvent
+	Source string `json:"source,omitempty"`
+	// Admission is the time from the client request to creation (mutating webhooks) with the annotation, or
+	// from creation to the create call returning (validating webhooks and persistence) with the owner event
+	Admission time.Duration `json:"admissionSeconds"`
+	// Scheduling is the time from creation until the pod was bound to the node
+	Scheduling time.Duration `json:"schedulingSeconds"`
+}
+
+// AdmissionReport separates the control plane admission and scheduling cost of the measured pods from the node cost
+type AdmissionReport struct {
+	Pods []*PodAdmission `json:"pods"`
+	// Webhooks are the mutating and validating webhooks that intercept pod creation, empty if they cannot be listed
+	Webhooks []string `json:"webhooks,omitempty"`
+	// AdmissionTime is the longest admission estimate of the measured pods
+	AdmissionTime time.Duration `json:"admissionSeconds"`
+}
+
+// WithAdmissionReport adds the admission latency estimate of the measured pods to measurements. The requested annotation is an optional
+// pod annotation holding the RFC3339 time the client sent the create request, otherwise the owning controller's events are used.
+func (m *Measurer) WithAdmissionReport(requestedAnnotation string) *Measurer {
+	m.admissionReport = true
+	m.admissionRequestedAnnotation = requestedAnnotation
+	return m
+}
+
+// admission builds the admission report of the measured pods from the K8s API
+func (m *Measurer) admission(ctx context.Context) (*AdmissionReport, error) {
+	if m.k8sClientset == nil || m.nodeName == "" {
+		return nil, fmt.Errorf("the admission report requires the K8s API")
+	}
+	pods, err := m.k8sClientset.CoreV1().Pods(m.podNamespace).List(ctx, v1.ListOptions{FieldSelector: fmt.Sprintf("spec.nodeName=%s", m.nodeName)})
+	if err != nil {
+		return nil, err
+	}
+	createdEvents, err := m.ownerCreatedEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &AdmissionReport{Webhooks: m.podWebhooks(ctx)}
+	for _, pod := range pods.Items {
+		if _, ok := pod.Annotations[k8ssrc.MirrorPodAnnotation]; ok {
+			continue
+		}
+		podAdmission := &PodAdmission{Pod: fmt.Sprintf("%s/%s", pod.Namespace, pod.Name), Created: pod.CreationTimestamp.Time}
+		if requested, err := time.Parse(time.RFC3339Nano, pod.Annotations[m.admissionRequestedAnnotation]); m.admissionRequestedAnnotation != "" && err == nil {
+			podAdmission.Source = AdmissionRequestedAnnotation
+			podAdmission.Admission = podAdmission.Created.Sub(requested)
+		} else if returned, ok := createdEvents[pod.Name]; ok {
+			podAdmission.Source = AdmissionRequestedOwnerEvent
+			podAdmission.Admission = returned.Sub(podAdmission.Created)
+		}
+		// timestamps are truncated to seconds in the API, so a negative estimate is below the resolution
+		podAdmission.Admission = lo.Max([]time.Duration{podAdmission.Admission, 0})
+		if scheduled, ok := lo.Find(pod.Status.Conditions, func(c corev1.PodCondition) bool {
+			return c.Type == corev1.PodScheduled && c.Status == corev1.ConditionTrue
+		}); ok {
+			podAdmission.Scheduling = scheduled.LastTransitionTime.Sub(podAdmission.Created)
+		}
+		report.Pods = append(report.Pods, podAdmission)
+		report.AdmissionTime = lo.Max([]time.Duration{report.AdmissionTime, podAdmission.Admission})
+	}
+	sort.Slice(report.Pods, func(i, j int) bool { return report.Pods[i].Pod < report.Pods[j].Pod })
+	return report, nil
+}
+
+// ownerCreatedEvents maps pod names to the time their owning controller recorded the SuccessfulCreate event, i.e. "Created pod: web-7d4b9c-x2x8z"
+func (m *Measurer) ownerCreatedEvents(ctx context.Context) (map[string]time.Time, error) {
+	events, err := m.k8sClientset.CoreV1().Events(m.podNamespace).List(ctx, v1.ListOptions{FieldSelector: "reason=SuccessfulCreate"})
+	if err != nil {
+		return nil, err
+	}
+	created := map[string]time.Time{}
+	for _, event := range events.Items {
+		if !strings.HasPrefix(event.Message, "Created pod: ") {
+			continue
+		}
+		podName := strings.TrimPrefix(event.Message, "Created pod: ")
+		eventTime := lo.Ternary(event.EventTime.IsZero(), event.FirstTimestamp.Time, event.EventTime.Time)
+		if existing, ok := created[podName]; !ok || eventTime.Before(existing) {
+			created[podName] = eventTime
+		}
+	}
+	return created, nil
+}
+
+// podWebhooks lists the mutating and validating webhooks whose rules match pod creation, nil if the webhook configurations cannot be listed
+func (m *Measurer) podWebhooks(ctx context.Context) []string {
+	matchesPodCreate := func(rules []admissionregistrationv1.RuleWithOperations) bool {
+		return lo.SomeBy(rules, func(rule admissionregistrationv1.RuleWithOperations) bool {
+			return lo.Some(rule.Operations, []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.OperationAll}) &&
+				lo.Some(rule.Resources, []string{"pods", "*"})
+		})
+	}
+	var webhooks []string
+	mutating, err := m.k8sClientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	for _, config := range mutating.Items {
+		for _, webhook := range config.Webhooks {
+			if matchesPodCreate(webhook.Rules) {
+				webhooks = append(webhooks, fmt.Sprintf("mutating/%s", webhook.Name))
+			}
+		}
+	}
+	validating, err := m.k8sClientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	for _, config := range validating.Items {
+		for _, webhook := range config.Webhooks {
+			if matchesPodCreate(webhook.Rules) {
+				webhooks = append(webhooks, fmt.Sprintf("validating/%s", webhook.Name))
+			}
+		}
+	}
+	return webhooks
+}
+
+// Chart prints the admission report as a markdown table
+func (r *AdmissionReport) Chart() {
+	fmt.Println("\nAdmission:")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Pod", "Source", "Admission", "Scheduling"})
+	for _, pod := range r.Pods {
+		admission := ""
+		if pod.Source != "" {
+			admission = fmt.Sprintf("%.1fs", pod.Admission.Seconds())
+		}
+		table.Append([]string{pod.Pod, pod.Source, admission, fmt.Sprintf("%.1fs", pod.Scheduling.Seconds())})
+	}
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.Render()
+	fmt.Printf("Admission took up to %.1fs before the node could act on the pods", r.AdmissionTime.Seconds())
+	if len(r.Webhooks) > 0 {
+		fmt.Printf(", webhooks: %s", strings.Join(r.Webhooks, ", "))
+	}
+	fmt.Println()
+}
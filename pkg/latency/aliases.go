@@ -0,0 +1,33 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+// WithAliasTimings returns a copy of the measurement where each timing of an event with aliases is repeated under every alias metric,
+// so emitters keep emitting the previous names of renamed events alongside the canonical names
+func (m *Measurement) WithAliasTimings() *Measurement {
+	withAliases := *m
+	withAliases.Timings = nil
+	for _, timing := range m.Timings {
+		withAliases.Timings = append(withAliases.Timings, timing)
+		for _, alias := range timing.Event.Aliases {
+			aliasEvent := *timing.Event
+			aliasEvent.Metric, aliasEvent.Aliases = alias, nil
+			aliasTiming := *timing
+			aliasTiming.Event = &aliasEvent
+			withAliases.Timings = append(withAliases.Timings, &aliasTiming)
+		}
+	}
+	return &withAliases
+}
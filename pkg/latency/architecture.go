@@ -0,0 +1,34 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"runtime"
+)
+
+// Architectures as reported by the EC2 instance identity document
+const (
+	ArchitectureX8664 = "x86_64"
+	ArchitectureARM64 = "arm64"
+)
+
+// hostArchitecture is the architecture of the running binary in the instance identity document format, which is the
+// node architecture since the image is published for both linux/amd64 and linux/arm64
+func hostArchitecture() string {
+	if runtime.GOARCH == "amd64" {
+		return ArchitectureX8664
+	}
+	return runtime.GOARCH
+}
@@ -0,0 +1,172 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+)
+
+// The exit codes of an image pipeline gate, 1 is left for errors of the tool itself
+const (
+	BakeExitPassed       = 0
+	BakeExitBudgetFailed = 2
+	BakeExitIncomplete   = 3
+	// BakeExitBaselineRegressed is returned if a metric regressed from or is missing in the golden baseline
+	BakeExitBaselineRegressed = 4
+	// BakeExitArtifactsFailed is returned if the gate passed but its artifacts could not be written or uploaded
+	BakeExitArtifactsFailed = 5
+)
+
+// The files written to the artifact directory of an image pipeline gate
+const (
+	BakeResultFile      = "result.json"
+	BakeMeasurementFile = "measurement.json"
+	BakeReportFile      = "report.html"
+)
+
+// BakeResult is the pass or fail result of an image pipeline, i.e. EC2 Image Builder or Packer, that booted a candidate AMI and measured it
+type BakeResult struct {
+	AMIID    string `json:"amiID,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
+	Passed   bool   `json:"passed"`
+	// Complete is whether the terminal events were measured before the timeout
+	Complete bool            `json:"complete"`
+	Budgets  []*BudgetResult `json:"budgets,omitempty"`
+	// Baseline is the comparison to the golden baseline if one is configured
+	Baseline *BaselineComparison `json:"baseline,omitempty"`
+	// ArtifactErrors are the errors writing or uploading the artifacts, which fail a passing gate with BakeExitArtifactsFailed
+	ArtifactErrors []string `json:"artifactErrors,omitempty"`
+	ExitCode       int      `json:"exitCode"`
+}
+
+// EvaluateBake gates the measurement of a candidate AMI, it passes if the terminal events were measured, all budgets passed,
+// and no metric regressed from the golden baseline if one is configured
+func (m *Measurement) EvaluateBake(budgets map[string]time.Duration, complete bool, nodeName string) *BakeResult {
+	result := &BakeResult{NodeName: nodeName, Complete: complete, Budgets: m.EvaluateBudgets(budgets), Baseline: m.Baseline}
+	if m.Metadata != nil {
+		result.AMIID = m.Metadata.AMIID
+	}
+	switch {
+	case !complete:
+		result.ExitCode = BakeExitIncomplete
+	case lo.SomeBy(result.Budgets, func(b *BudgetResult) bool { return !b.Passed }):
+		result.ExitCode = BakeExitBudgetFailed
+	case result.Baseline != nil && !result.Baseline.Passed:
+		result.ExitCode = BakeExitBaselineRegressed
+	default:
+		result.ExitCode = BakeExitPassed
+	}
+	result.Passed = result.ExitCode == BakeExitPassed
+	return result
+}
+
+// RecordArtifactError records an error writing or uploading the artifacts, the exit code of a passing gate becomes BakeExitArtifactsFailed
+// so the pipeline does not pass without its artifacts. Passed is still whether the AMI passed the gate.
+func (r *BakeResult) RecordArtifactError(err error) {
+	r.ArtifactErrors = append(r.ArtifactErrors, err.Error())
+	if r.ExitCode == BakeExitPassed {
+		r.ExitCode = BakeExitArtifactsFailed
+	}
+}
+
+// WriteBakeResult writes the result to the artifact directory and returns its path, i.e. to rewrite it with the artifact errors
+func WriteBakeResult(dir string, result *BakeResult) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("unable to create artifact directory %s: %w", dir, err)
+	}
+	resultBytes, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal the bake result: %w", err)
+	}
+	path := filepath.Join(dir, BakeResultFile)
+	//nolint:gosec
+	if err := os.WriteFile(path, resultBytes, 0644); err != nil {
+		return "", fmt.Errorf("unable to write artifact %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// WriteBakeArtifacts writes the result, the measurement JSON and the HTML report with the budgets to the directory and returns their paths,
+// so the pipeline can collect them as artifacts, i.e. with a Packer file provisioner in the download direction
+func (m *Measurement) WriteBakeArtifacts(dir string, result *BakeResult, opts ReportOptions) ([]string, error) {
+	resultPath, err := WriteBakeResult(dir, result)
+	if err != nil {
+		return nil, err
+	}
+	measurementBytes, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal the measurement: %w", err)
+	}
+	var report bytes.Buffer
+	if err := m.WriteHTMLReport(&report, opts); err != nil {
+		return nil, fmt.Errorf("unable to render the HTML report: %w", err)
+	}
+	paths := []string{resultPath}
+	for _, artifact := range []struct {
+		name string
+		data []byte
+	}{{name: BakeMeasurementFile, data: measurementBytes}, {name: BakeReportFile, data: report.Bytes()}} {
+		path := filepath.Join(dir, artifact.name)
+		//nolint:gosec
+		if err := os.WriteFile(path, artifact.data, 0644); err != nil {
+			return nil, fmt.Errorf("unable to write artifact %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// UploadBakeArtifacts uploads the artifact files to an S3 URI prefix (s3://bucket/prefix) under <ami id>/<node name>/ and returns the URI of the uploaded prefix
+func UploadBakeArtifacts(ctx context.Context, client *s3.Client, s3URI string, result *BakeResult, paths []string) (string, error) {
+	uri, err := url.Parse(s3URI)
+	if err != nil || uri.Scheme != "s3" || uri.Host == "" {
+		return "", fmt.Errorf("\"%s\" is not an S3 URI (s3://bucket/prefix)", s3URI)
+	}
+	prefix := strings.TrimPrefix(fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(uri.Path, "/"), lo.Ternary(result.AMIID != "", result.AMIID, "ami"), lo.Ternary(result.NodeName != "", result.NodeName, "node")), "/")
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read artifact %s: %w", path, err)
+		}
+		key := fmt.Sprintf("%s/%s", prefix, filepath.Base(path))
+		if ok, err := dryRun("s3", fmt.Sprintf("s3://%s/%s", uri.Host, key), string(data)); ok {
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+		if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(uri.Host),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(data),
+			ContentType: aws.String(lo.Ternary(filepath.Ext(path) == ".html", "text/html; charset=utf-8", "application/json")),
+		}); err != nil {
+			return "", fmt.Errorf("unable to upload artifact to s3://%s/%s: %w", uri.Host, key, err)
+		}
+	}
+	return fmt.Sprintf("s3://%s/%s/", uri.Host, prefix), nil
+}
@@ -0,0 +1,70 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBakeResultRecordArtifactError(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		exitCode     int
+		wantExitCode int
+	}{
+		{name: "a passing gate fails", exitCode: BakeExitPassed, wantExitCode: BakeExitArtifactsFailed},
+		{name: "a failed budget is kept", exitCode: BakeExitBudgetFailed, wantExitCode: BakeExitBudgetFailed},
+		{name: "an incomplete measurement is kept", exitCode: BakeExitIncomplete, wantExitCode: BakeExitIncomplete},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			result := &BakeResult{Passed: tc.exitCode == BakeExitPassed, ExitCode: tc.exitCode}
+			result.RecordArtifactError(errors.New("unable to upload artifact"))
+			if result.ExitCode != tc.wantExitCode {
+				t.Errorf("RecordArtifactError() exit code = %d, want %d", result.ExitCode, tc.wantExitCode)
+			}
+			path, err := WriteBakeResult(dir, result)
+			if err != nil {
+				t.Fatalf("WriteBakeResult() error = %v", err)
+			}
+			resultBytes, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var written BakeResult
+			if err := json.Unmarshal(resultBytes, &written); err != nil {
+				t.Fatal(err)
+			}
+			if len(written.ArtifactErrors) != 1 || written.ArtifactErrors[0] != "unable to upload artifact" || written.ExitCode != tc.wantExitCode {
+				t.Errorf("WriteBakeResult() wrote %+v, want the artifact error and exit code %d", written, tc.wantExitCode)
+			}
+		})
+	}
+}
+
+func TestWriteBakeResultUnwritable(t *testing.T) {
+	// the artifact directory is a file
+	dir := filepath.Join(t.TempDir(), "artifacts")
+	if err := os.WriteFile(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteBakeResult(dir, &BakeResult{}); err == nil {
+		t.Error("WriteBakeResult() error = nil, want an error")
+	}
+}
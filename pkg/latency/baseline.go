@@ -0,0 +1,247 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Statuses of an event metric compared to the golden baseline
+const (
+	// BaselineStatusPass is within the tolerance of the golden baseline
+	BaselineStatusPass = "pass"
+	// BaselineStatusRegressed is slower than the golden baseline by more than the tolerance
+	BaselineStatusRegressed = "regressed"
+	// BaselineStatusImproved is faster than the golden baseline by more than the tolerance, which does not fail the comparison
+	BaselineStatusImproved = "improved"
+	// BaselineStatusMissing was measured in the golden baseline but not in the measurement
+	BaselineStatusMissing = "missing"
+	// BaselineStatusNew was measured but is not in the golden baseline, which does not fail the comparison
+	BaselineStatusNew = "new"
+)
+
+// Baseline is a golden measurement, i.e. the --output json of a known good boot of an AMI that is committed to git
+type Baseline struct {
+	Path  string `json:"path"`
+	AMIID string `json:"amiID,omitempty"`
+	// Timings are the latest times of the successful event metrics since the first event
+	Timings map[string]time.Duration `json:"-"`
+	// events are the event names of the metrics
+	events map[string]string
+}
+
+// goldenMeasurement is the part of the JSON output of a measurement that is compared, the errors are kept raw since errors do not unmarshal
+type goldenMeasurement struct {
+	Metadata *Metadata `json:"metadata"`
+	Timings  []struct {
+		Event struct {
+			Name   string `json:"name"`
+			Metric string `json:"metric"`
+		} `json:"event"`
+		T     time.Duration   `json:"seconds"`
+		Error json.RawMessage `json:"error"`
+	} `json:"timings"`
+}
+
+// LoadBaseline loads a golden measurement from the JSON output of a single measurement
+func LoadBaseline(path string) (*Baseline, error) {
+	goldenBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read baseline %s: %w", path, err)
+	}
+	if trimmed := bytes.TrimSpace(goldenBytes); len(trimmed) > 0 && trimmed[0] == '[' {
+		return nil, fmt.Errorf("baseline %s must be a single measurement, not a list of boots", path)
+	}
+	var golden goldenMeasurement
+	if err := json.Unmarshal(goldenBytes, &golden); err != nil {
+		return nil, fmt.Errorf("unable to parse baseline %s: %w", path, err)
+	}
+	baseline := &Baseline{Path: path, Timings: map[string]time.Duration{}, events: map[string]string{}}
+	if golden.Metadata != nil {
+		baseline.AMIID = golden.Metadata.AMIID
+	}
+	for _, timing := range golden.Timings {
+		if len(timing.Error) > 0 && string(timing.Error) != "null" {
+			continue
+		}
+		if timing.T >= baseline.Timings[timing.Event.Metric] {
+			baseline.Timings[timing.Event.Metric] = timing.T
+		}
+		baseline.events[timing.Event.Metric] = timing.Event.Name
+	}
+	if len(baseline.Timings) == 0 {
+		return nil, fmt.Errorf("baseline %s has no successful timings", path)
+	}
+	return baseline, nil
+}
+
+// Tolerance is how much an event metric may be slower or faster than the golden baseline, in seconds and as a percent of the golden time.
+// Both are added if both are set, i.e. 2s+10%.
+type Tolerance struct {
+	Seconds float64 `json:"seconds,omitempty"`
+	Percent float64 `json:"percent,omitempty"`
+}
+
+// ParseTolerance parses a tolerance in seconds (5), percent (10%) or both (5+10%)
+func ParseTolerance(s string) (Tolerance, error) {
+	var tolerance Tolerance
+	for _, part := range strings.Split(s, "+") {
+		part = strings.TrimSpace(part)
+		percent := strings.HasSuffix(part, "%")
+		value, err := strconv.ParseFloat(strings.TrimSuffix(part, "%"), 64)
+		if err != nil || value < 0 {
+			return Tolerance{}, fmt.Errorf("tolerance \"%s\" is not a non-negative number of seconds or percent", s)
+		}
+		if percent {
+			tolerance.Percent += value
+		} else {
+			tolerance.Seconds += value
+		}
+	}
+	return tolerance, nil
+}
+
+// Of is the tolerated difference from a golden time
+func (t Tolerance) Of(golden time.Duration) time.Duration {
+	return time.Duration(t.Seconds*float64(time.Second)) + time.Duration(float64(golden)*t.Percent/100)
+}
+
+// BaselineDelta is the difference of an event metric from the golden baseline
+type BaselineDelta struct {
+	Metric string `json:"metric"`
+	Event  string `json:"event"`
+	// Golden and Actual are the latest times of the metric's events since the first event
+	Golden time.Duration `json:"golden"`
+	Actual time.Duration `json:"actual"`
+	// Delta is the actual minus the golden time, positive if the metric is slower than the golden baseline
+	Delta     time.Duration `json:"delta"`
+	Tolerance time.Duration `json:"tolerance"`
+	Status    string        `json:"status"`
+}
+
+// BaselineComparison compares a measurement to a golden baseline, it fails if a metric regressed or is missing
+type BaselineComparison struct {
+	Baseline *Baseline        `json:"baseline"`
+	Deltas   []*BaselineDelta `json:"deltas"`
+	Passed   bool             `json:"passed"`
+}
+
+// WithBaseline compares measurements to the golden baseline, the tolerance of a metric is its tolerance or the default tolerance
+func (m *Measurer) WithBaseline(baseline *Baseline, defaultTolerance Tolerance, tolerances map[string]Tolerance) *Measurer {
+	m.baseline = baseline
+	m.baselineTolerance = defaultTolerance
+	m.baselineTolerances = tolerances
+	return m
+}
+
+// compareBaseline compares the timings to the golden baseline
+func (m *Measurer) compareBaseline(timings []*sources.Timing) *BaselineComparison {
+	comparison := &BaselineComparison{Baseline: m.baseline, Passed: true}
+	successful := lo.Filter(timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	actuals := map[string]time.Duration{}
+	events := map[string]string{}
+	for _, timing := range successful {
+		actuals[timing.Event.Metric] = bootstrapTime(lo.Filter(successful, func(t *sources.Timing, _ int) bool { return t.Event.Metric == timing.Event.Metric }))
+		events[timing.Event.Metric] = timing.Event.Name
+	}
+	for metric, golden := range m.baseline.Timings {
+		tolerance, ok := m.baselineTolerances[metric]
+		if !ok {
+			tolerance = m.baselineTolerance
+		}
+		delta := &BaselineDelta{Metric: metric, Event: m.baseline.events[metric], Golden: golden, Tolerance: tolerance.Of(golden)}
+		actual, ok := actuals[metric]
+		switch {
+		case !ok:
+			delta.Status = BaselineStatusMissing
+		case actual-golden > delta.Tolerance:
+			delta.Status = BaselineStatusRegressed
+		case golden-actual > delta.Tolerance:
+			delta.Status = BaselineStatusImproved
+		default:
+			delta.Status = BaselineStatusPass
+		}
+		if ok {
+			delta.Actual = actual
+			delta.Delta = actual - golden
+		}
+		comparison.Passed = comparison.Passed && delta.Status != BaselineStatusMissing && delta.Status != BaselineStatusRegressed
+		comparison.Deltas = append(comparison.Deltas, delta)
+	}
+	for metric, actual := range actuals {
+		if _, ok := m.baseline.Timings[metric]; !ok {
+			comparison.Deltas = append(comparison.Deltas, &BaselineDelta{Metric: metric, Event: events[metric], Actual: actual, Status: BaselineStatusNew})
+		}
+	}
+	// the new metrics are sorted by their actual time since they have no golden time
+	sortTime := func(d *BaselineDelta) time.Duration {
+		return lo.Ternary(d.Status == BaselineStatusNew, d.Actual, d.Golden)
+	}
+	sort.Slice(comparison.Deltas, func(i, j int) bool {
+		if sortTime(comparison.Deltas[i]) != sortTime(comparison.Deltas[j]) {
+			return sortTime(comparison.Deltas[i]) < sortTime(comparison.Deltas[j])
+		}
+		return comparison.Deltas[i].Metric < comparison.Deltas[j].Metric
+	})
+	return comparison
+}
+
+// Regressions are the metrics that regressed or are missing
+func (c *BaselineComparison) Regressions() []*BaselineDelta {
+	return lo.Filter(c.Deltas, func(d *BaselineDelta, _ int) bool {
+		return d.Status == BaselineStatusRegressed || d.Status == BaselineStatusMissing
+	})
+}
+
+// Chart prints the deltas of the event metrics from the golden baseline
+func (c *BaselineComparison) Chart() {
+	fmt.Printf("\nBaseline: %s\n", c.Baseline.Path)
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Event", "Golden", "Actual", "Delta", "Tolerance", "Status"})
+	for _, delta := range c.Deltas {
+		golden, actual, diff, tolerance := "-", "-", "-", "-"
+		if delta.Status != BaselineStatusNew {
+			golden = fmt.Sprintf("%.1fs", delta.Golden.Seconds())
+			tolerance = fmt.Sprintf("±%.1fs", delta.Tolerance.Seconds())
+		}
+		if delta.Status != BaselineStatusMissing {
+			actual = fmt.Sprintf("%.1fs", delta.Actual.Seconds())
+		}
+		if delta.Status != BaselineStatusNew && delta.Status != BaselineStatusMissing {
+			diff = fmt.Sprintf("%+.1fs", delta.Delta.Seconds())
+		}
+		table.Append([]string{delta.Event, golden, actual, diff, tolerance, delta.Status})
+	}
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.Render()
+	if regressions := c.Regressions(); len(regressions) > 0 {
+		fmt.Printf("%d of %d baseline metrics regressed or are missing\n", len(regressions), len(c.Baseline.Timings))
+	} else {
+		fmt.Printf("All %d baseline metrics are within their tolerances\n", len(c.Baseline.Timings))
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+func TestParseTolerance(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		s       string
+		want    Tolerance
+		wantErr bool
+	}{
+		{name: "seconds", s: "5", want: Tolerance{Seconds: 5}},
+		{name: "fractional seconds", s: "0.5", want: Tolerance{Seconds: 0.5}},
+		{name: "percent", s: "10%", want: Tolerance{Percent: 10}},
+		{name: "seconds and percent", s: "2+10%", want: Tolerance{Seconds: 2, Percent: 10}},
+		{name: "percent and seconds with spaces", s: "10% + 2", want: Tolerance{Seconds: 2, Percent: 10}},
+		{name: "zero", s: "0", want: Tolerance{}},
+		{name: "negative", s: "-1", wantErr: true},
+		{name: "not a number", s: "5s", wantErr: true},
+		{name: "empty", s: "", wantErr: true},
+		{name: "empty part", s: "5+", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseTolerance(tc.s)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseTolerance(%q) error = %v, wantErr %v", tc.s, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseTolerance(%q) = %+v, want %+v", tc.s, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestToleranceOf(t *testing.T) {
+	tolerance := Tolerance{Seconds: 2, Percent: 10}
+	if got, want := tolerance.Of(30*time.Second), 5*time.Second; got != want {
+		t.Errorf("Of() = %s, want %s", got, want)
+	}
+}
+
+func baselineTiming(metric string, seconds float64, err error) *sources.Timing {
+	return &sources.Timing{
+		Event: &sources.Event{Name: metric + "-event", Metric: metric},
+		T:     time.Duration(seconds * float64(time.Second)),
+		Error: err,
+	}
+}
+
+func TestCompareBaseline(t *testing.T) {
+	baseline := &Baseline{
+		Path:    "golden.json",
+		Timings: map[string]time.Duration{"kubelet": 10 * time.Second, "node-ready": 30 * time.Second},
+		events:  map[string]string{"kubelet": "kubelet-event", "node-ready": "node-ready-event"},
+	}
+	for _, tc := range []struct {
+		name        string
+		timings     []*sources.Timing
+		tolerance   Tolerance
+		tolerances  map[string]Tolerance
+		wantStatus  map[string]string
+		wantPassed  bool
+		wantMetrics []string
+	}{
+		{
+			name:        "within the tolerance",
+			timings:     []*sources.Timing{baselineTiming("kubelet", 11, nil), baselineTiming("node-ready", 29, nil)},
+			tolerance:   Tolerance{Seconds: 2},
+			wantStatus:  map[string]string{"kubelet": BaselineStatusPass, "node-ready": BaselineStatusPass},
+			wantPassed:  true,
+			wantMetrics: []string{"kubelet", "node-ready"},
+		},
+		{
+			name:        "regressed past the tolerance",
+			timings:     []*sources.Timing{baselineTiming("kubelet", 10, nil), baselineTiming("node-ready", 40, nil)},
+			tolerance:   Tolerance{Seconds: 2, Percent: 10},
+			wantStatus:  map[string]string{"kubelet": BaselineStatusPass, "node-ready": BaselineStatusRegressed},
+			wantMetrics: []string{"kubelet", "node-ready"},
+		},
+		{
+			name:        "improved does not fail the comparison",
+			timings:     []*sources.Timing{baselineTiming("kubelet", 4, nil), baselineTiming("node-ready", 30, nil)},
+			tolerance:   Tolerance{Seconds: 2},
+			wantStatus:  map[string]string{"kubelet": BaselineStatusImproved, "node-ready": BaselineStatusPass},
+			wantPassed:  true,
+			wantMetrics: []string{"kubelet", "node-ready"},
+		},
+		{
+			name:        "a metric tolerance overrides the default",
+			timings:     []*sources.Timing{baselineTiming("kubelet", 10, nil), baselineTiming("node-ready", 40, nil)},
+			tolerance:   Tolerance{Seconds: 2},
+			tolerances:  map[string]Tolerance{"node-ready": {Seconds: 10}},
+			wantStatus:  map[string]string{"kubelet": BaselineStatusPass, "node-ready": BaselineStatusPass},
+			wantPassed:  true,
+			wantMetrics: []string{"kubelet", "node-ready"},
+		},
+		{
+			name:        "failed timings are missing",
+			timings:     []*sources.Timing{baselineTiming("kubelet", 10, nil), baselineTiming("node-ready", 30, errors.New("not found"))},
+			tolerance:   Tolerance{Seconds: 2},
+			wantStatus:  map[string]string{"kubelet": BaselineStatusPass, "node-ready": BaselineStatusMissing},
+			wantMetrics: []string{"kubelet", "node-ready"},
+		},
+		{
+			name: "new metrics are sorted by their actual time and do not fail the comparison",
+			timings: []*sources.Timing{
+				baselineTiming("kubelet", 10, nil), baselineTiming("cni", 20, nil), baselineTiming("node-ready", 30, nil),
+			},
+			tolerance:   Tolerance{Seconds: 2},
+			wantStatus:  map[string]string{"kubelet": BaselineStatusPass, "cni": BaselineStatusNew, "node-ready": BaselineStatusPass},
+			wantPassed:  true,
+			wantMetrics: []string{"kubelet", "cni", "node-ready"},
+		},
+		{
+			name: "the latest timing of a metric is compared",
+			timings: []*sources.Timing{
+				baselineTiming("kubelet", 5, nil), baselineTiming("kubelet", 15, nil), baselineTiming("node-ready", 30, nil),
+			},
+			tolerance:   Tolerance{Seconds: 2},
+			wantStatus:  map[string]string{"kubelet": BaselineStatusRegressed, "node-ready": BaselineStatusPass},
+			wantMetrics: []string{"kubelet", "node-ready"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			comparison := New().WithBaseline(baseline, tc.tolerance, tc.tolerances).compareBaseline(tc.timings)
+			if comparison.Passed != tc.wantPassed {
+				t.Errorf("compareBaseline() passed = %v, want %v", comparison.Passed, tc.wantPassed)
+			}
+			gotStatus := lo.SliceToMap(comparison.Deltas, func(d *BaselineDelta) (string, string) { return d.Metric, d.Status })
+			if !reflect.DeepEqual(gotStatus, tc.wantStatus) {
+				t.Errorf("compareBaseline() statuses = %v, want %v", gotStatus, tc.wantStatus)
+			}
+			gotMetrics := lo.Map(comparison.Deltas, func(d *BaselineDelta, _ int) string { return d.Metric })
+			if !reflect.DeepEqual(gotMetrics, tc.wantMetrics) {
+				t.Errorf("compareBaseline() metrics = %v, want %v", gotMetrics, tc.wantMetrics)
+			}
+		})
+	}
+}
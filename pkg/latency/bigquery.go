@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+	gcesrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/gce"
+)
+
+// DefaultBigQueryEndpoint is the BigQuery REST API
+var DefaultBigQueryEndpoint = "https://bigquery.googleapis.com/bigquery/v2"
+
+// InsertBigQueryRow streams the wide event of the measurement as one row into a BigQuery table with the insertAll API.
+// The table is "project.dataset.table", or "dataset.table" in the project of the GCE instance, and the access token is retrieved from the GCE metadata server.
+func (m *Measurement) InsertBigQueryRow(ctx context.Context, gce *gcesrc.Source, table string, experimentDimension string, nodeName string) error {
+	row := m.WideEvent(experimentDimension, nodeName)
+	// the insert ID lets BigQuery drop duplicate rows of retried inserts
+	insertID := fmt.Sprintf("%v-%v", row["instance_id"], row["timestamp"])
+	body, err := json.Marshal(map[string]interface{}{
+		"rows": []map[string]interface{}{{"insertId": insertID, "json": row}},
+	})
+	if err != nil {
+		return err
+	}
+	if ok, err := dryRun("bigquery", table, json.RawMessage(body)); ok {
+		return err
+	}
+	parts := strings.Split(table, ".")
+	if len(parts) == 2 {
+		projectID, err := gce.GetMetadata(ctx, "project/project-id")
+		if err != nil {
+			return err
+		}
+		parts = append([]string{projectID}, parts...)
+	}
+	if len(parts) != 3 {
+		return fmt.Errorf("table \"%s\" must be project.dataset.table or dataset.table", table)
+	}
+	token, err := gce.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/datasets/%s/tables/%s/insertAll", DefaultBigQueryEndpoint, parts[0], parts[1], parts[2])
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create BigQuery request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	resp, err := (&http.Client{Timeout: 30 * time.Second, Transport: proxy.Transport()}).Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to insert the BigQuery row: %w", err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		InsertErrors []struct {
+			Errors []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("unable to parse the BigQuery response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the BigQuery API returned status %s", resp.Status)
+	}
+	if len(result.InsertErrors) > 0 && len(result.InsertErrors[0].Errors) > 0 {
+		insertErr := result.InsertErrors[0].Errors[0]
+		return fmt.Errorf("the BigQuery API rejected the row: %s: %s", insertErr.Reason, insertErr.Message)
+	}
+	return nil
+}
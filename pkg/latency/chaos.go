@@ -0,0 +1,26 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	chaossrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/chaos"
+)
+
+// WithChaos replaces the default sources and events with the fake events of a chaos scenario, so the emitters, budgets,
+// aggregation and alerting can be tested end-to-end without a real node bootstrap
+func (m *Measurer) WithChaos(chaos *chaossrc.Source) *Measurer {
+	m.chaos = chaos
+	return m
+}
@@ -0,0 +1,112 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Completion is the marker of a measurement that reached its terminal events, so wrapper scripts and systemd units can sequence follow-on actions without polling logs
+type Completion struct {
+	NodeName string `json:"nodeName,omitempty"`
+	BootID   string `json:"bootID,omitempty"`
+	// Summary is the path of the measurement JSON
+	Summary        string    `json:"summary"`
+	TerminalEvents []string  `json:"terminalEvents"`
+	Completed      time.Time `json:"completed"`
+}
+
+// WriteCompletion writes the measurement JSON to <node name>-<boot id>.summary.json in the directory of the marker file and then the marker pointing to it.
+// Both are written to a temporary file first and renamed, so a waiting script never reads a partial file. Without a marker file, the summary is written to the temp directory.
+func (m *Measurement) WriteCompletion(markerPath string, nodeName string, bootID string) (*Completion, error) {
+	if m.BootID != "" {
+		bootID = m.BootID
+	}
+	dir := lo.Ternary(markerPath == "", os.TempDir(), filepath.Dir(markerPath))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create completion directory %s: %w", dir, err)
+	}
+	completion := &Completion{
+		NodeName: nodeName,
+		BootID:   bootID,
+		Summary:  filepath.Join(dir, fmt.Sprintf("%s-%s.summary.json", lo.Ternary(nodeName == "", "node", nodeName), lo.Ternary(bootID == "", "boot", bootID))),
+		TerminalEvents: lo.FilterMap(m.Timings, func(t *sources.Timing, _ int) (string, bool) {
+			return t.Event.Name, t.Event.Terminal && t.Error == nil
+		}),
+		Completed: time.Now().UTC(),
+	}
+	summary, err := json.MarshalIndent(m, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal the summary: %w", err)
+	}
+	if err := writeFileAtomic(completion.Summary, summary); err != nil {
+		return nil, err
+	}
+	if markerPath != "" {
+		marker, err := json.MarshalIndent(completion, "", "    ")
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal the completion marker: %w", err)
+		}
+		if err := writeFileAtomic(markerPath, marker); err != nil {
+			return nil, err
+		}
+	}
+	return completion, nil
+}
+
+// RunHook runs the hook command with sh -c, the summary path is passed as the first argument and in NLK_SUMMARY_PATH, along with NLK_NODE_NAME and NLK_BOOT_ID
+func (c *Completion) RunHook(ctx context.Context, command string) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command, "sh", c.Summary)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("NLK_SUMMARY_PATH=%s", c.Summary),
+		fmt.Sprintf("NLK_NODE_NAME=%s", c.NodeName),
+		fmt.Sprintf("NLK_BOOT_ID=%s", c.BootID),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("completion hook failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// writeFileAtomic writes the file to a temporary file in the same directory and renames it
+func writeFileAtomic(path string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), fmt.Sprintf(".%s-*", filepath.Base(path)))
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %w", path, err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	return nil
+}
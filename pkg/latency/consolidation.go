@@ -0,0 +1,146 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Consolidation feedback metrics combine the bootstrap time with the node lifetime, so Karpenter operators can see whether
+// consolidation replaces nodes faster than their bootstrap cost amortizes
+const (
+	// NodeLifetimeMetric is the time since the first event of the measurement, usually the instance launch
+	NodeLifetimeMetric = "node_lifetime_seconds"
+	// BootstrapAmortizationMetric is the fraction of the node lifetime spent bootstrapping, it falls as the node lives on
+	BootstrapAmortizationMetric = "bootstrap_amortization_ratio"
+	// BootstrapAmortizedAfterMetric is the node lifetime after which the bootstrap is at most the amortization target of the lifetime
+	BootstrapAmortizedAfterMetric = "bootstrap_amortized_after_seconds"
+	// BootstrapCostMetric is the dollar cost of the bootstrap window if pricing is configured
+	BootstrapCostMetric = "bootstrap_cost_dollars"
+)
+
+// BootstrapAmortizedAtAnnotation is the node annotation with the RFC3339 time after which the bootstrap is amortized
+var BootstrapAmortizedAtAnnotation = NodeAnnotationPrefix + "bootstrap-amortized-at"
+
+// Amortization is how far the bootstrap time of the node is amortized over its lifetime
+type Amortization struct {
+	// Launched is the time of the first event of the measurement
+	Launched time.Time
+	// Bootstrap is the bootstrap time of the measurement
+	Bootstrap time.Duration
+	// AmortizedAfter is the lifetime after which the bootstrap is at most the target fraction of the lifetime
+	AmortizedAfter time.Duration
+}
+
+// Amortization returns the amortization of the bootstrap time for the target fraction of the lifetime, i.e. 0.05 for 5%
+func (m *Measurement) Amortization(target float64) (*Amortization, error) {
+	if target <= 0 || target > 1 {
+		return nil, fmt.Errorf("the amortization target %g must be in (0, 1]", target)
+	}
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	if len(timings) == 0 {
+		return nil, fmt.Errorf("there are no timings")
+	}
+	bootstrap := bootstrapTime(timings)
+	return &Amortization{
+		Launched:       lo.MinBy(timings, func(a *sources.Timing, b *sources.Timing) bool { return a.Timestamp.Before(b.Timestamp) }).Timestamp,
+		Bootstrap:      bootstrap,
+		AmortizedAfter: time.Duration(float64(bootstrap) / target),
+	}, nil
+}
+
+// Ratio is the fraction of the lifetime until now spent bootstrapping
+func (a *Amortization) Ratio(now time.Time) float64 {
+	lifetime := now.Sub(a.Launched)
+	if lifetime <= 0 {
+		return 1
+	}
+	return lo.Clamp(a.Bootstrap.Seconds()/lifetime.Seconds(), 0, 1)
+}
+
+// RegisterConsolidationMetrics registers the node lifetime and bootstrap amortization metrics, which are computed on every scrape
+func (m *Measurement) RegisterConsolidationMetrics(register prometheus.Registerer, experimentDimension string, target float64) error {
+	amortization, err := m.Amortization(target)
+	if err != nil {
+		return err
+	}
+	dimensions := m.metricDimensions(experimentDimension)
+	collectors := []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        NodeLifetimeMetric,
+			Help:        "Time since the first event of the measurement, usually the instance launch",
+			ConstLabels: dimensions,
+		}, func() float64 { return time.Since(amortization.Launched).Seconds() }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        BootstrapAmortizationMetric,
+			Help:        "Fraction of the node lifetime spent bootstrapping",
+			ConstLabels: dimensions,
+		}, func() float64 { return amortization.Ratio(time.Now()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        BootstrapAmortizedAfterMetric,
+			Help:        fmt.Sprintf("Node lifetime after which the bootstrap is at most %g of the lifetime", target),
+			ConstLabels: dimensions,
+		}, func() float64 { return amortization.AmortizedAfter.Seconds() }),
+	}
+	if m.Cost != nil {
+		collectors = append(collectors, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        BootstrapCostMetric,
+			Help:        "Dollar cost of the bootstrap window",
+			ConstLabels: dimensions,
+		}, func() float64 { return m.Cost.BootstrapCost }))
+	}
+	for _, collector := range collectors {
+		if err := register.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PatchAmortizedAt annotates the node with the time after which its bootstrap is amortized, i.e. node-latency.k8s.aws/bootstrap-amortized-at,
+// so it can be compared with the consolidation and expiry settings of the NodePool
+func (m *Measurement) PatchAmortizedAt(ctx context.Context, clientset kubernetes.Interface, nodeName string, target float64) error {
+	if nodeName == "" {
+		return fmt.Errorf("the node name is not known")
+	}
+	amortization, err := m.Amortization(target)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]string{
+		BootstrapAmortizedAtAnnotation: amortization.Launched.Add(amortization.AmortizedAfter).UTC().Format(time.RFC3339),
+	}}})
+	if err != nil {
+		return err
+	}
+	if ok, err := dryRun("amortization-annotation", nodeName, json.RawMessage(patch)); ok {
+		return err
+	}
+	if _, err := clientset.CoreV1().Nodes().Patch(ctx, nodeName, k8stypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("unable to patch node %s: %w", nodeName, err)
+	}
+	return nil
+}
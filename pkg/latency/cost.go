@@ -0,0 +1,133 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Price sources of the cost annotation
+const (
+	PriceSourceTable = "price table"
+	PriceSourceSpot  = "spot price history"
+)
+
+// Cost is the dollar cost of the bootstrap window of a measurement, the time the node is paid for but not yet doing useful work
+type Cost struct {
+	InstanceType  string        `json:"instanceType"`
+	HourlyPrice   float64       `json:"hourlyPrice"`
+	PriceSource   string        `json:"priceSource"`
+	BootstrapTime time.Duration `json:"bootstrapSeconds"`
+	BootstrapCost float64       `json:"bootstrapCost"`
+}
+
+// LoadPriceTable reads a JSON price table of hourly prices in dollars by instance type, i.e. {"m5.large": 0.096}
+func LoadPriceTable(path string) (map[string]float64, error) {
+	tableBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read price table: %w", err)
+	}
+	var table map[string]float64
+	if err := json.Unmarshal(tableBytes, &table); err != nil {
+		return nil, fmt.Errorf("unable to parse price table %s: %w", path, err)
+	}
+	return table, nil
+}
+
+// WithPriceTable annotates measurements with the bootstrap cost using the hourly prices by instance type
+func (m *Measurer) WithPriceTable(table map[string]float64) *Measurer {
+	m.priceTable = table
+	return m
+}
+
+// WithSpotPricing annotates measurements with the bootstrap cost using the current EC2 spot price of the instance type in its availability zone
+// when the instance type is not in the price table. The EC2 client needs the ec2:DescribeSpotPriceHistory permission.
+func (m *Measurer) WithSpotPricing() *Measurer {
+	m.spotPricing = true
+	return m
+}
+
+// cost calculates the cost of the bootstrap window from the first to the last successful timing
+func (m *Measurer) cost(ctx context.Context, metadata *Metadata, timings []*sources.Timing) (*Cost, error) {
+	if metadata == nil || metadata.InstanceType == "" {
+		return nil, fmt.Errorf("the instance type is not known")
+	}
+	successful := lo.Filter(timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	if len(successful) == 0 {
+		return nil, fmt.Errorf("there are no timings")
+	}
+	cost := &Cost{
+		InstanceType:  metadata.InstanceType,
+		BootstrapTime: bootstrapTime(successful),
+	}
+	if price, ok := m.priceTable[metadata.InstanceType]; ok {
+		cost.HourlyPrice, cost.PriceSource = price, PriceSourceTable
+	} else if m.spotPricing && m.ec2Client != nil {
+		price, err := m.spotPrice(ctx, metadata.InstanceType, metadata.AvailabilityZone)
+		if err != nil {
+			return nil, err
+		}
+		cost.HourlyPrice, cost.PriceSource = price, PriceSourceSpot
+	} else {
+		return nil, fmt.Errorf("no price for instance type %s", metadata.InstanceType)
+	}
+	cost.BootstrapCost = cost.HourlyPrice * cost.BootstrapTime.Hours()
+	return cost, nil
+}
+
+// spotPrice retrieves the current Linux spot price of the instance type in the availability zone, it is cached for subsequent measurement passes
+func (m *Measurer) spotPrice(ctx context.Context, instanceType string, availabilityZone string) (float64, error) {
+	if price, ok := m.spotPrices[instanceType]; ok {
+		return price, nil
+	}
+	out, err := m.ec2Client.DescribeSpotPriceHistory(ctx, &ec2.DescribeSpotPriceHistoryInput{
+		InstanceTypes:       []types.InstanceType{types.InstanceType(instanceType)},
+		AvailabilityZone:    aws.String(availabilityZone),
+		ProductDescriptions: []string{"Linux/UNIX"},
+		StartTime:           aws.Time(time.Now()),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to retrieve the spot price of %s: %w", instanceType, err)
+	}
+	if len(out.SpotPriceHistory) == 0 {
+		return 0, fmt.Errorf("no spot price for %s in %s", instanceType, availabilityZone)
+	}
+	price, err := strconv.ParseFloat(aws.ToString(out.SpotPriceHistory[0].SpotPrice), 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse the spot price of %s: %w", instanceType, err)
+	}
+	if m.spotPrices == nil {
+		m.spotPrices = map[string]float64{}
+	}
+	m.spotPrices[instanceType] = price
+	return price, nil
+}
+
+// String is a human readable summary of the cost
+func (c *Cost) String() string {
+	return fmt.Sprintf("$%.4f for %.0fs of bootstrap on %s at $%.4f/hr (%s)", c.BootstrapCost, c.BootstrapTime.Seconds(), c.InstanceType, c.HourlyPrice, c.PriceSource)
+}
@@ -0,0 +1,71 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	crisrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/cri"
+)
+
+// WithCRI is a builder func that adds the CRI socket source which times the pod sandboxes, first containers and image pulls
+// of the measured pods, the image pulls are read from the containerd content store
+func (m *Measurer) WithCRI(endpoint string, contentDir string) *Measurer {
+	m.criEndpoint = endpoint
+	m.criContentDir = contentDir
+	return m
+}
+
+// criEvents returns the pod sandbox, image pull and first container events of the measured pods if the CRI source is registered
+func (m *Measurer) criEvents() []*sources.Event {
+	src, ok := m.GetSource(crisrc.Name)
+	if !ok {
+		return nil
+	}
+	criSrc := src.(*crisrc.Source)
+	return []*sources.Event{
+		{
+			Name:          "Sandbox Created",
+			Metric:        "sandbox_created",
+			SrcName:       crisrc.Name,
+			MatchSelector: sources.EventMatchSelectorAll,
+			CommentFn:     crisrc.Comment,
+			FindFn:        criSrc.FindSandboxCreated(),
+		},
+		{
+			Name:          "Image Pull Started (Estimate)",
+			Metric:        "image_pull_started_estimate",
+			SrcName:       crisrc.Name,
+			MatchSelector: sources.EventMatchSelectorAll,
+			CommentFn:     crisrc.Comment,
+			FindFn:        criSrc.FindImagePullStarted(),
+		},
+		{
+			Name:          "Image Pulled (Estimate)",
+			Metric:        "image_pulled_estimate",
+			SrcName:       crisrc.Name,
+			MatchSelector: sources.EventMatchSelectorAll,
+			CommentFn:     crisrc.Comment,
+			FindFn:        criSrc.FindImagePulled(),
+		},
+		{
+			Name:          "First Container Started",
+			Metric:        "first_container_started",
+			SrcName:       crisrc.Name,
+			MatchSelector: sources.EventMatchSelectorAll,
+			CommentFn:     crisrc.Comment,
+			FindFn:        criSrc.FindFirstContainerStarted(),
+		},
+	}
+}
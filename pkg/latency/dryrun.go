@@ -0,0 +1,54 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DryRunDir is the directory the emitters write their payloads to instead of sending them, i.e. in air-gapped clusters.
+// The emitters send their payloads when it is empty.
+var DryRunDir = ""
+
+// DryRunPayload is the payload an emitter would have sent to its target
+type DryRunPayload struct {
+	Emitter string      `json:"emitter"`
+	Target  string      `json:"target"`
+	Time    time.Time   `json:"time"`
+	Payload interface{} `json:"payload"`
+}
+
+// dryRun writes the payload of an emitter to <DryRunDir>/<emitter>.json if dry run is enabled, it returns false if the payload must be sent
+func dryRun(emitter string, target string, payload interface{}) (bool, error) {
+	if DryRunDir == "" {
+		return false, nil
+	}
+	payloadBytes, err := json.MarshalIndent(DryRunPayload{Emitter: emitter, Target: target, Time: time.Now(), Payload: payload}, "", "  ")
+	if err != nil {
+		return true, err
+	}
+	if err := os.MkdirAll(DryRunDir, 0o755); err != nil {
+		return true, err
+	}
+	path := filepath.Join(DryRunDir, fmt.Sprintf("%s.json", emitter))
+	if err := os.WriteFile(path, payloadBytes, 0o600); err != nil {
+		return true, fmt.Errorf("unable to write the %s payload to %s: %w", emitter, path, err)
+	}
+	return true, nil
+}
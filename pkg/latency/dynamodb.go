@@ -0,0 +1,71 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBOptions configures the table and keys of the items written by WriteDynamoDBItem
+type DynamoDBOptions struct {
+	Table string
+	// PartitionKey and SortKey are wide event fields, i.e. node_name and timestamp, the SortKey is optional
+	PartitionKey string
+	SortKey      string
+	// TTLAttribute is set to the epoch seconds at which DynamoDB expires the item if TTL is not 0
+	TTLAttribute string
+	TTL          time.Duration
+}
+
+// WriteDynamoDBItem writes the wide event of the measurement as one item to a DynamoDB table
+func (m *Measurement) WriteDynamoDBItem(ctx context.Context, client *dynamodb.Client, opts DynamoDBOptions, experimentDimension string, nodeName string) error {
+	event := m.WideEvent(experimentDimension, nodeName)
+	for _, key := range []string{opts.PartitionKey, opts.SortKey} {
+		if _, ok := event[key]; key != "" && !ok {
+			return fmt.Errorf("the measurement does not have the key field \"%s\"", key)
+		}
+	}
+	item := map[string]types.AttributeValue{}
+	for field, value := range event {
+		switch v := value.(type) {
+		case string:
+			item[field] = &types.AttributeValueMemberS{Value: v}
+		case float64:
+			item[field] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(v, 'f', -1, 64)}
+		case bool:
+			item[field] = &types.AttributeValueMemberBOOL{Value: v}
+		}
+	}
+	if opts.TTL > 0 && opts.TTLAttribute != "" {
+		item[opts.TTLAttribute] = &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(opts.TTL).Unix(), 10)}
+	}
+	if ok, err := dryRun("dynamodb", opts.Table, event); ok {
+		return err
+	}
+	if _, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(opts.Table),
+		Item:      item,
+	}); err != nil {
+		return fmt.Errorf("unable to write the DynamoDB item: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/samber/lo"
+)
+
+// EmissionStore records which emitters already emitted a measurement of the current boot and persists them to a state file,
+// so a restarted measurement does not emit a second record of the same node boot
+type EmissionStore struct {
+	mu    sync.Mutex
+	path  string
+	state emissionState
+}
+
+type emissionState struct {
+	BootID   string   `json:"bootID"`
+	Emitters []string `json:"emitters"`
+	// StartupGateOpened is set once the startup gate opened during the boot, so a restarted measurer does not taint the node again
+	StartupGateOpened bool `json:"startupGateOpened,omitempty"`
+}
+
+// NewEmissionStore creates an EmissionStore persisted at path for the boot, emissions recorded for a previous boot are discarded
+func NewEmissionStore(path string, bootID string) (*EmissionStore, error) {
+	store := &EmissionStore{
+		path:  path,
+		state: emissionState{BootID: bootID},
+	}
+	stateBytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read emission state file %s: %w", path, err)
+	}
+	var state emissionState
+	if err := json.Unmarshal(stateBytes, &state); err != nil {
+		return nil, fmt.Errorf("unable to parse emission state file %s: %w", path, err)
+	}
+	if state.BootID == bootID {
+		store.state = state
+	}
+	return store, nil
+}
+
+// Emitted returns true if the emitter already emitted a measurement of the boot, a nil store never has
+func (e *EmissionStore) Emitted(emitter string) bool {
+	if e == nil {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return lo.Contains(e.state.Emitters, emitter)
+}
+
+// Emitters returns the emitters that already emitted a measurement of the boot
+func (e *EmissionStore) Emitters() []string {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.state.Emitters...)
+}
+
+// MarkEmitted records that the emitter emitted a measurement of the boot and persists it to the state file, a nil store is a noop
+func (e *EmissionStore) MarkEmitted(emitter string) error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if lo.Contains(e.state.Emitters, emitter) {
+		return nil
+	}
+	e.state.Emitters = append(e.state.Emitters, emitter)
+	return e.save()
+}
+
+// StartupGateOpened returns true if the startup gate already opened during the boot, a nil store never has
+func (e *EmissionStore) StartupGateOpened() bool {
+	if e == nil {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.state.StartupGateOpened
+}
+
+// MarkStartupGateOpened records that the startup gate opened during the boot and persists it to the state file, a nil store is a noop
+func (e *EmissionStore) MarkStartupGateOpened() error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state.StartupGateOpened {
+		return nil
+	}
+	e.state.StartupGateOpened = true
+	return e.save()
+}
+
+// save writes the state to a temp file and renames it over the state file so a crash never leaves a partial state file, the lock must be held
+func (e *EmissionStore) save() error {
+	stateBytes, err := json.Marshal(e.state)
+	if err != nil {
+		return fmt.Errorf("unable to marshal emission state: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(e.path), filepath.Base(e.path))
+	if err != nil {
+		return fmt.Errorf("unable to create emission state file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(stateBytes); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("unable to write emission state file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to write emission state file: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), e.path); err != nil {
+		return fmt.Errorf("unable to replace emission state file %s: %w", e.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEmissionStoreStartupGate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		bootID string
+		want   bool
+	}{
+		{name: "the same boot keeps the opened gate", bootID: "boot-1", want: true},
+		{name: "a new boot discards the opened gate", bootID: "boot-2", want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "emit-state.json")
+			store, err := NewEmissionStore(path, "boot-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if store.StartupGateOpened() {
+				t.Fatal("StartupGateOpened() of a new store = true, want false")
+			}
+			if err := store.MarkStartupGateOpened(); err != nil {
+				t.Fatalf("MarkStartupGateOpened() error = %v", err)
+			}
+			restarted, err := NewEmissionStore(path, tc.bootID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := restarted.StartupGateOpened(); got != tc.want {
+				t.Errorf("StartupGateOpened() after a restart = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,469 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	asgsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/asg"
+	crisrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/cri"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/dockerd"
+	ec2src "github.com/awslabs/node-latency-for-k8s/pkg/sources/ec2"
+	egresssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/egress"
+	imdssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/imds"
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+	kubeletsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/kubelet"
+)
+
+// EventDoc documents a registered event in the generated event documentation
+type EventDoc struct {
+	Name          string            `json:"name"`
+	Metric        string            `json:"metric"`
+	Source        string            `json:"source"`
+	MatchSelector string            `json:"matchSelector"`
+	Terminal      bool              `json:"terminal,omitempty"`
+	Track         string            `json:"track,omitempty"`
+	OnlyIf        string            `json:"onlyIf,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Severity      string            `json:"severity,omitempty"`
+	Owner         string            `json:"owner,omitempty"`
+	Aliases       []string          `json:"aliases,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	Regex         string            `json:"regex,omitempty"`
+	Example       string            `json:"example,omitempty"`
+}
+
+// WithEventCatalog registers the API and log sources without clients, so the default events of all sources are registered
+// to generate the event documentation without a node. The events can not be measured.
+func (m *Measurer) WithEventCatalog() *Measurer {
+	m.eventCatalog = true
+	return m
+}
+
+// registerCatalogSources registers the sources that need clients or flags without them
+func (m *Measurer) registerCatalogSources() {
+	if m.podNamespace == "" {
+		m.podNamespace = "default"
+	}
+	m.RegisterSources(
+		k8ssrc.New(nil, "node", m.podNamespace),
+		kubeletsrc.New("http://localhost:10255", "", m.podNamespace),
+		crisrc.New(crisrc.DefaultEndpoint, DefaultContainerdContentDir, m.podNamespace),
+		egresssrc.New(egresssrc.ExampleURL),
+		imdssrc.New(nil),
+		ec2src.New(nil, "", ""),
+		asgsrc.New(nil, ""),
+	)
+	if m.profile == ProfileEKS {
+		m.dockerdLogPath = dockerd.DefaultPath
+	}
+}
+
+// describeEvent adds the description and the first example of the built-in catalog that matches the event's regex to an event that
+// does not set its own, the regex of an event metric can differ by source and profile
+func describeEvent(e *sources.Event) {
+	doc, ok := eventCatalog[e.Metric]
+	if !ok {
+		return
+	}
+	if e.Description == "" {
+		e.Description = doc.description
+	}
+	if e.Example != "" || e.Regex == "" {
+		return
+	}
+	re, err := regexp.Compile(e.Regex)
+	if err != nil {
+		return
+	}
+	if example, ok := lo.Find(doc.examples, re.MatchString); ok {
+		e.Example = example
+	}
+}
+
+// EventDocs documents the registered events in their registration order
+func (m *Measurer) EventDocs() []*EventDoc {
+	return lo.Map(m.events, func(e *sources.Event, _ int) *EventDoc {
+		return &EventDoc{
+			Name:          e.Name,
+			Metric:        e.Metric,
+			Source:        e.SrcName,
+			MatchSelector: e.MatchSelector,
+			Terminal:      e.Terminal,
+			Track:         e.Track,
+			OnlyIf:        e.OnlyIf,
+			Labels:        e.Labels,
+			Severity:      e.Severity,
+			Owner:         e.Owner,
+			Aliases:       e.Aliases,
+			Description:   e.Description,
+			Regex:         e.Regex,
+			Example:       e.Example,
+		}
+	})
+}
+
+// LoadEvidenceExamples reads the first matched line of each metric from an evidence artifact (--evidence-dir) of a recording
+func LoadEvidenceExamples(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open evidence %s: %w", path, err)
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read evidence %s: %w", path, err)
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	examples := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var line EvidenceLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("unable to parse evidence %s: %w", path, err)
+		}
+		if _, ok := examples[line.Metric]; !ok {
+			examples[line.Metric] = line.Line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read evidence %s: %w", path, err)
+	}
+	return examples, nil
+}
+
+// WithExamples replaces the examples of the regex events with the lines captured by metric, i.e. from LoadEvidenceExamples
+func WithExamples(docs []*EventDoc, examples map[string]string) []*EventDoc {
+	for _, doc := range docs {
+		if example, ok := examples[doc.Metric]; ok && doc.Regex != "" {
+			doc.Example = strings.TrimSpace(example)
+		}
+	}
+	return docs
+}
+
+// WriteEventDocsMarkdown writes the event docs as a markdown catalog with a summary table and a section per event
+func WriteEventDocsMarkdown(w io.Writer, title string, docs []*EventDoc) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "| Event | Metric | Source | Terminal |\n|---|---|---|---|\n")
+	for _, doc := range docs {
+		fmt.Fprintf(&b, "| [%s](#%s) | `%s` | %s | %s |\n", doc.Name, doc.Metric, doc.Metric, doc.Source, lo.Ternary(doc.Terminal, "yes", ""))
+	}
+	for _, doc := range docs {
+		fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n## %s\n\n", doc.Metric, doc.Name)
+		if doc.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", doc.Description)
+		}
+		fmt.Fprintf(&b, "- Metric: `%s`\n", doc.Metric)
+		if len(doc.Aliases) > 0 {
+			fmt.Fprintf(&b, "- Aliases: %s\n", strings.Join(lo.Map(doc.Aliases, func(alias string, _ int) string { return fmt.Sprintf("`%s`", alias) }), ", "))
+		}
+		fmt.Fprintf(&b, "- Source: %s\n", doc.Source)
+		fmt.Fprintf(&b, "- Match: %s\n", doc.MatchSelector)
+		if doc.Terminal {
+			fmt.Fprintf(&b, "- Terminal: yes\n")
+		}
+		if doc.Track != "" {
+			fmt.Fprintf(&b, "- Track: %s\n", doc.Track)
+		}
+		if doc.OnlyIf != "" {
+			fmt.Fprintf(&b, "- Only if: %s\n", doc.OnlyIf)
+		}
+		if len(doc.Labels) > 0 {
+			keys := lo.Keys(doc.Labels)
+			sort.Strings(keys)
+			fmt.Fprintf(&b, "- Labels: %s\n", strings.Join(lo.Map(keys, func(k string, _ int) string { return fmt.Sprintf("`%s=%s`", k, doc.Labels[k]) }), ", "))
+		}
+		if doc.Severity != "" {
+			fmt.Fprintf(&b, "- Severity: %s\n", doc.Severity)
+		}
+		if doc.Owner != "" {
+			fmt.Fprintf(&b, "- Owner: %s\n", doc.Owner)
+		}
+		if doc.Regex != "" {
+			fmt.Fprintf(&b, "\nRegex:\n\n```\n%s\n```\n", doc.Regex)
+		}
+		if doc.Example != "" {
+			fmt.Fprintf(&b, "\nExample:\n\n```\n%s\n```\n", doc.Example)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// eventDoc is the built-in description and example lines of an event metric
+type eventDoc struct {
+	description string
+	examples    []string
+}
+
+// eventCatalog documents the default events by metric
+var eventCatalog = map[string]eventDoc{
+	"fleet_requested":               {description: "The EC2 Fleet or RunInstances request that launched the instance, read from the EC2 API. It is the earliest event and usually the baseline."},
+	"instance_pending":              {description: "The instance entered the pending state, read from the pending time in IMDS."},
+	"asg_launch_completed":          {description: "The EC2 Auto Scaling launch activity of the instance finished, which is when all launching lifecycle hooks were completed."},
+	"nodeclaim_created":             {description: "The Karpenter NodeClaim that owns the node was created, which precedes the instance launch."},
+	"spot_interruption_notice":      {description: "The spot interruption notice was given, which is the instance action time minus the two-minute warning, read from IMDS."},
+	"spot_rebalance_recommendation": {description: "The spot rebalance recommendation was given, read from IMDS."},
+	"vm_initialized": {description: "The kernel logged its version, the first line of the boot.", examples: []string{
+		"Oct 15 10:00:00 ip-192-168-1-1 kernel: Linux version 6.1.109-118.189.amzn2023.x86_64 (mockbuild@ip-10-0-58-213) (gcc (GCC) 11.4.1 20230605 (Red Hat 11.4.1-2)) #1 SMP PREEMPT_DYNAMIC Tue Sep 10 22:52:33 UTC 2024",
+	}},
+	"vm_arm64_cpu_boot": {description: "The arm64 kernel booted on the boot CPU, which it logs before the kernel version.", examples: []string{
+		"Oct 15 10:00:00 ip-192-168-1-1 kernel: Booting Linux on physical CPU 0x0000000000 [0x413fd0c1]",
+	}},
+	"vm_arm64_gic_initialized": {description: "The arm64 kernel initialized the GIC interrupt controller.", examples: []string{
+		"Oct 15 10:00:00 ip-192-168-1-1 kernel: GICv3: 96 SPIs implemented",
+	}},
+	"vm_arm64_smp_ready": {description: "The arm64 kernel brought up all CPUs.", examples: []string{
+		"Oct 15 10:00:00 ip-192-168-1-1 kernel: smp: Brought up 1 node, 2 CPUs",
+	}},
+	"imds_first_fetch": {description: "cloud-init first read from the instance metadata service, the warnings of the failed attempts before it are in cloud-init.log.", examples: []string{
+		"2026-10-15 10:00:04,812 - url_helper.py[DEBUG]: Read from http://169.254.169.254/latest/api/token (200, 56b) after 1 attempts",
+	}},
+	"egress_first_connection": {description: "The first HTTPS connection to the egress probe URL succeeded, which is delayed by NAT gateway, proxy or firewall misconfigurations."},
+	"network_start": {description: "systemd reached the network-pre target, before the network is configured.", examples: []string{
+		"Oct 15 10:00:03 ip-192-168-1-1 systemd[1]: Reached target Network (Pre).",
+	}},
+	"network_ready": {description: "systemd reached the network target, the network interfaces are configured.", examples: []string{
+		"Oct 15 10:00:04 ip-192-168-1-1 systemd[1]: Reached target Network.",
+	}},
+	"cloudinit_initial_start": {description: "cloud-init started its init stage, which fetches the user data.", examples: []string{
+		"Oct 15 10:00:05 ip-192-168-1-1 cloud-init: Cloud-init v. 22.2.2 running 'init' at Thu, 15 Oct 2026 10:00:05 +0000. Up 6.12 seconds.",
+	}},
+	"cloudinit_config_start": {description: "cloud-init started its config modules.", examples: []string{
+		"Oct 15 10:00:09 ip-192-168-1-1 cloud-init: Cloud-init v. 22.2.2 running 'modules:config' at Thu, 15 Oct 2026 10:00:09 +0000. Up 10.02 seconds.",
+	}},
+	"cloudinit_final_start": {description: "cloud-init started its final modules, which run the user data scripts, i.e. the node bootstrap.", examples: []string{
+		"Oct 15 10:00:10 ip-192-168-1-1 cloud-init: Cloud-init v. 22.2.2 running 'modules:final' at Thu, 15 Oct 2026 10:00:10 +0000. Up 11.35 seconds.",
+	}},
+	"cloudinit_final_finish": {description: "cloud-init finished, the user data scripts completed.", examples: []string{
+		"Oct 15 10:00:21 ip-192-168-1-1 cloud-init: Cloud-init v. 22.2.2 finished at Thu, 15 Oct 2026 10:00:21 +0000. Datasource DataSourceEc2Local.  Up 22.41 seconds",
+	}},
+	"containerd_start":                {description: "systemd started the containerd unit.", examples: containerdStartExamples},
+	"containerd_initialized":          {description: "The containerd unit is up and serving the CRI.", examples: containerdStartedExamples},
+	"containerd_restart":              {description: "systemd restarted the containerd unit during the in-place upgrade.", examples: containerdStartExamples},
+	"containerd_restarted":            {description: "The restarted containerd unit is up during the in-place upgrade.", examples: containerdStartedExamples},
+	"containerd_mirror_first_fetch":   {description: "containerd received the first content from a registry mirror of the hosts directory, which needs the containerd debug log level."},
+	"containerd_upstream_first_fetch": {description: "containerd received the first content from an upstream registry of the hosts directory, which needs the containerd debug log level."},
+	"containerd_mirror_fallback":      {description: "containerd fell back from a registry mirror to the next host, the error is in the comment."},
+	"dockerd_start": {description: "dockerd started.", examples: []string{
+		`Oct 15 10:00:21 ip-192-168-1-1 dockerd[3012]: time="2026-10-15T10:00:21.401Z" level=info msg="Starting up"`,
+	}},
+	"dockerd_containerd_ready": {description: "The containerd that dockerd manages booted.", examples: []string{
+		`Oct 15 10:00:21 ip-192-168-1-1 dockerd[3012]: time="2026-10-15T10:00:21.512Z" level=info msg="containerd successfully booted in 0.021s"`,
+	}},
+	"dockerd_initialized": {description: "dockerd is listening on its API socket.", examples: []string{
+		`Oct 15 10:00:22 ip-192-168-1-1 dockerd[3012]: time="2026-10-15T10:00:22.104Z" level=info msg="API listen on /run/docker.sock"`,
+	}},
+	"dockerd_first_container_start": {description: "The first container of dockerd started.", examples: []string{
+		`Oct 15 10:00:35 ip-192-168-1-1 containerd[2870]: time="2026-10-15T10:00:35.210Z" level=info msg="starting signal loop" namespace=moby path=/run/containerd/io.containerd.runtime.v2.task/moby/3c1d pid=3301`,
+	}},
+	"crio_start": {description: "systemd started the CRI-O unit.", examples: []string{
+		"Oct 15 10:01:40 ip-10-0-1-1 systemd[1]: Starting crio.service - Container Runtime Interface for OCI (CRI-O)...",
+	}},
+	"crio_initialized": {description: "The CRI-O unit is up and serving the CRI.", examples: []string{
+		"Oct 15 10:01:41 ip-10-0-1-1 systemd[1]: Started crio.service - Container Runtime Interface for OCI (CRI-O).",
+	}},
+	"kubelet_start":       {description: "systemd started the kubelet unit.", examples: kubeletStartExamples},
+	"kubelet_initialized": {description: "The kubelet unit is up.", examples: kubeletStartedExamples},
+	"kubelet_restart":     {description: "systemd restarted the kubelet unit during the in-place upgrade.", examples: kubeletStartExamples},
+	"kubelet_restarted":   {description: "The restarted kubelet unit is up during the in-place upgrade.", examples: kubeletStartedExamples},
+	"kubelet_volume_manager_started": {description: "The kubelet started its volume manager.", examples: []string{
+		`Oct 15 10:00:30 ip-192-168-1-1 kubelet[2951]: I1015 10:00:30.912034    2951 volume_manager.go:291] "Starting Kubelet Volume Manager"`,
+	}},
+	"kubelet_registered": {description: "The kubelet registered the node with the API server.", examples: []string{
+		`Oct 15 10:00:31 ip-192-168-1-1 kubelet[2951]: I1015 10:00:31.402519    2951 kubelet_node_status.go:76] "Successfully registered node" node="ip-192-168-1-1.us-west-2.compute.internal"`,
+	}},
+	"kubelet_first_pod_sync": {description: "The kubelet received its first pods from the API server.", examples: []string{
+		`Oct 15 10:00:31 ip-192-168-1-1 kubelet[2951]: I1015 10:00:31.690242    2951 kubelet.go:2398] "SyncLoop ADD" source="api" pods=["kube-system/aws-node-x7k2p","kube-system/kube-proxy-9zq4d"]`,
+	}},
+	"kubelet_pleg_first_event": {description: "The kubelet's pod lifecycle event generator reported its first container event.", examples: []string{
+		`Oct 15 10:00:33 ip-192-168-1-1 kubelet[2951]: I1015 10:00:33.118406    2951 kubelet.go:2430] "SyncLoop (PLEG): event for pod" pod="kube-system/aws-node-x7k2p" event={"ID":"0f1e","Type":"ContainerStarted","Data":"4b1f"}`,
+	}},
+	"kube_apiserver_throttled": {description: "The kubelet's requests to the API server were throttled by its client-side rate limiter.", examples: []string{
+		"Oct 15 10:00:32 ip-192-168-1-1 kubelet[2951]: I1015 10:00:32.150061    2951 request.go:697] Waited for 1.047s due to client-side throttling, not priority and fairness, request: GET:https://example.gr7.us-west-2.eks.amazonaws.com/api/v1/namespaces/kube-system/configmaps",
+	}},
+	"kubelet_serving_certificate_issued": {description: "The kubelet logged the expiration of its kubernetes.io/kubelet-serving certificate, until then the API server can not reach the kubelet.", examples: []string{
+		"Oct 15 10:00:36 ip-192-168-1-1 kubelet[2951]: I1015 10:00:36.402118    2951 certificate_manager.go:356] kubernetes.io/kubelet-serving: Certificate expiration is 2027-10-15 09:55:00 +0000 UTC, rotation deadline is 2027-07-20 18:43:01 +0000 UTC",
+	}},
+	"kubelet_serving_certificate_written": {description: "The modification time of the kubelet serving certificate, read through the kubelet PKI directory."},
+	"static_pod_manifests_written":        {description: "The static pod manifests were written, read from the modification time of the manifest directory."},
+	"cni_first_not_ready":                 {description: "The kubelet first reported that the container runtime network is not ready.", examples: cniNotReadyExamples},
+	"cni_last_not_ready":                  {description: "The kubelet last reported that the container runtime network is not ready, shortly before the CNI is ready.", examples: cniNotReadyExamples},
+	"vpc_cni_init_start": {description: "containerd created the aws-vpc-cni-init container of the VPC CNI.", examples: []string{
+		`Oct 15 10:00:33 ip-192-168-1-1 containerd[2870]: time="2026-10-15T10:00:33.104Z" level=info msg="CreateContainer within sandbox \"8f3c\" for &ContainerMetadata{Name:aws-vpc-cni-init,Attempt:0,} returns container id \"1a2b\""`,
+	}},
+	"aws_node_start": {description: "containerd created the aws-node container of the VPC CNI.", examples: []string{
+		`Oct 15 10:00:34 ip-192-168-1-1 containerd[2870]: time="2026-10-15T10:00:34.612Z" level=info msg="CreateContainer within sandbox \"8f3c\" for &ContainerMetadata{Name:aws-node,Attempt:0,} returns container id \"5d2e\""`,
+	}},
+	"vpc_cni_plugin_initialized": {description: "The VPC CNI copied its plugin binary and config, the node network becomes ready.", examples: []string{
+		`{"level":"info","ts":"2026-10-15T10:00:35.120Z","caller":"entrypoint.sh","msg":"Successfully copied CNI plugin binary and config file."}`,
+	}},
+	"kube_proxy_start": {description: "containerd created the kube-proxy container.", examples: []string{
+		`Oct 15 10:00:34 ip-192-168-1-1 containerd[2870]: time="2026-10-15T10:00:34.811Z" level=info msg="CreateContainer within sandbox \"6e7f\" for &ContainerMetadata{Name:kube-proxy,Attempt:0,} returns container id \"9c8d\""`,
+	}},
+	"node_ready": {description: "The node became Ready, from the Ready condition in the K8s API or the NodeReady event the kubelet logs.", examples: []string{
+		`Oct 15 10:00:38 ip-192-168-1-1 kubelet[2951]: I1015 10:00:38.271846    2951 kubelet_node_status.go:493] "Recording event message for node" node="ip-192-168-1-1.us-west-2.compute.internal" event="NodeReady"`,
+	}},
+	"node_network_available":       {description: "The NetworkUnavailable condition of the node became false."},
+	"node_memory_pressure_settled": {description: "The MemoryPressure condition of the node became false."},
+	"node_disk_pressure_settled":   {description: "The DiskPressure condition of the node became false."},
+	"cloud_provider_taint_removed": {description: "The cloud controller manager removed the node.cloudprovider.kubernetes.io/uninitialized taint."},
+	"ccm_provider_id_set":          {description: "The cloud controller manager set the providerID of the node."},
+	"ccm_addresses_populated":      {description: "The cloud controller manager populated the addresses of the node."},
+	"pod_created":                  {description: "The measured pod was created, from the K8s API or the kubelet API."},
+	"pod_ready": {description: "The containers of the measured pod started.", examples: []string{
+		`Oct 15 10:00:45 ip-192-168-1-1 kubelet[2951]: I1015 10:00:45.123456    2951 kubelet.go:2141] "SyncLoop (PLEG): event for pod" pod="default/inflate-6d8b7c9f4-abcde" event=&{ID:0f1e Type:ContainerStarted Data:9a8b}`,
+	}},
+	"sandbox_created":             {description: "The container runtime created the sandbox of each measured pod, read from the CRI socket."},
+	"image_pull_started_estimate": {description: "Estimated start of the pull of each image of the measured pods, the first blob of the image written to the containerd content store after the pod sandbox was created."},
+	"image_pulled_estimate":       {description: "Estimated end of the pull of each image of the measured pods, the last blob of the image written to the containerd content store. Images without blobs written after the pod sandbox was created are left out."},
+	"first_container_started":     {description: "The first container of each measured pod started, read from the CRI socket."},
+	"pod_ready_condition":         {description: "The Ready condition of the measured pod became true, read from the kubelet API."},
+	"first_workload_pod_running":  {description: "The first pod on the node that is not a DaemonSet pod is running."},
+	"container_readiness_probe_succeeded": {description: "The first successful readiness probe of each container of the measured pods, which needs kubelet verbosity 3.", examples: []string{
+		`Oct 15 10:00:47 ip-192-168-1-1 kubelet[2951]: I1015 10:00:47.301122    2951 prober.go:107] "Probe succeeded" probeType="Readiness" pod="default/inflate-6d8b7c9f4-abcde" podUID="0f1e" containerName="inflate"`,
+	}},
+	"pod_readiness_probe_ready": {description: "Each measured pod was first marked ready by its readiness probes.", examples: []string{
+		`Oct 15 10:00:47 ip-192-168-1-1 kubelet[2951]: I1015 10:00:47.302013    2951 kubelet.go:2474] "SyncLoop (probe)" probe="readiness" status="ready" pod="default/inflate-6d8b7c9f4-abcde"`,
+	}},
+	"secret_mounted": {description: "The kubelet mounted a secret volume of the measured pods.", examples: []string{
+		`Oct 15 10:00:44 ip-192-168-1-1 kubelet[2951]: I1015 10:00:44.210332    2951 operation_generator.go:721] "MountVolume.SetUp succeeded for volume \"app-secret\" (UniqueName: \"kubernetes.io/secret/0f1e-app-secret\") pod \"inflate-6d8b7c9f4-abcde\" (UID: \"0f1e\") " pod="default/inflate-6d8b7c9f4-abcde"`,
+	}},
+	"configmap_mounted": {description: "The kubelet mounted a configmap volume of the measured pods.", examples: []string{
+		`Oct 15 10:00:44 ip-192-168-1-1 kubelet[2951]: I1015 10:00:44.211870    2951 operation_generator.go:721] "MountVolume.SetUp succeeded for volume \"app-config\" (UniqueName: \"kubernetes.io/configmap/0f1e-app-config\") pod \"inflate-6d8b7c9f4-abcde\" (UID: \"0f1e\") " pod="default/inflate-6d8b7c9f4-abcde"`,
+	}},
+	"projected_token_mounted": {description: "The kubelet mounted the projected service account token volume of the measured pods.", examples: []string{
+		`Oct 15 10:00:44 ip-192-168-1-1 kubelet[2951]: I1015 10:00:44.212411    2951 operation_generator.go:721] "MountVolume.SetUp succeeded for volume \"kube-api-access-x2v9c\" (UniqueName: \"kubernetes.io/projected/0f1e-kube-api-access-x2v9c\") pod \"inflate-6d8b7c9f4-abcde\" (UID: \"0f1e\") " pod="default/inflate-6d8b7c9f4-abcde"`,
+	}},
+	"secret_cache_populated": {description: "The kubelet filled its secret cache for the pod namespace, which needs kubelet verbosity 2.", examples: []string{
+		`Oct 15 10:00:44 ip-192-168-1-1 kubelet[2951]: I1015 10:00:44.101234    2951 reflector.go:359] Caches populated for *v1.Secret from object-"default"/"app-secret"`,
+	}},
+	"configmap_cache_populated": {description: "The kubelet filled its configmap cache for the pod namespace, which needs kubelet verbosity 2.", examples: []string{
+		`Oct 15 10:00:44 ip-192-168-1-1 kubelet[2951]: I1015 10:00:44.102871    2951 reflector.go:359] Caches populated for *v1.ConfigMap from object-"default"/"app-config"`,
+	}},
+	"volume_attached": {description: "The attach/detach controller attached a volume of the measured pods, from the SuccessfulAttachVolume pod event."},
+	"volume_attach_wait_start": {description: "The kubelet started waiting for a CSI or EBS volume of the measured pods to be attached.", examples: []string{
+		`Oct 15 10:00:44 ip-192-168-1-1 kubelet[2951]: I1015 10:00:44.301002    2951 reconciler_common.go:245] "operationExecutor.VerifyControllerAttachedVolume started for volume \"pvc-1234\" (UniqueName: \"kubernetes.io/csi/ebs.csi.aws.com^vol-0a1b2c3d\") pod \"db-0\" (UID: \"7c6d\") " pod="default/db-0"`,
+	}},
+	"volume_attach_confirmed": {description: "The kubelet confirmed that a CSI or EBS volume of the measured pods is attached.", examples: []string{
+		`Oct 15 10:00:52 ip-192-168-1-1 kubelet[2951]: I1015 10:00:52.118235    2951 operation_generator.go:580] "MountVolume.WaitForAttach succeeded for volume \"pvc-1234\" (UniqueName: \"kubernetes.io/csi/ebs.csi.aws.com^vol-0a1b2c3d\") pod \"db-0\" (UID: \"7c6d\") DevicePath \"\"" pod="default/db-0"`,
+	}},
+	"volume_staged": {description: "The kubelet staged the device of a CSI or EBS volume of the measured pods.", examples: []string{
+		`Oct 15 10:00:53 ip-192-168-1-1 kubelet[2951]: I1015 10:00:53.402817    2951 operation_generator.go:630] "MountVolume.MountDevice succeeded for volume \"pvc-1234\" (UniqueName: \"kubernetes.io/csi/ebs.csi.aws.com^vol-0a1b2c3d\") pod \"db-0\" (UID: \"7c6d\") device mount path \"/var/lib/kubelet/plugins/kubernetes.io/csi/ebs.csi.aws.com/1a2b/globalmount\"" pod="default/db-0"`,
+	}},
+	"volume_mounted": {description: "The kubelet mounted a CSI or EBS volume into the measured pods.", examples: []string{
+		`Oct 15 10:00:53 ip-192-168-1-1 kubelet[2951]: I1015 10:00:53.512236    2951 operation_generator.go:721] "MountVolume.SetUp succeeded for volume \"pvc-1234\" (UniqueName: \"kubernetes.io/csi/ebs.csi.aws.com^vol-0a1b2c3d\") pod \"db-0\" (UID: \"7c6d\") " pod="default/db-0"`,
+	}},
+	"drain_start":           {description: "The node was cordoned for the in-place upgrade, from the NodeNotSchedulable node event."},
+	"workloads_rescheduled": {description: "The workload pods are running on the node again after the in-place upgrade."},
+	"kube_node_installation_start": {description: "GKE started downloading and installing the K8s binaries and configurations.", examples: []string{
+		"Oct 15 10:00:08 gke-pool-1-abcd systemd[1]: Starting kube-node-installation.service - Download and install k8s binaries and configurations...",
+	}},
+	"kube_node_installation_finish": {description: "GKE finished installing the K8s binaries and configurations.", examples: []string{
+		"Oct 15 10:00:12 gke-pool-1-abcd systemd[1]: Finished kube-node-installation.service - Download and install k8s binaries and configurations.",
+	}},
+	"kube_node_configuration_start": {description: "GKE started configuring the K8s node.", examples: []string{
+		"Oct 15 10:00:12 gke-pool-1-abcd systemd[1]: Starting kube-node-configuration.service - Configure kubernetes node...",
+	}},
+	"kube_node_configuration_finish": {description: "GKE finished configuring the K8s node.", examples: []string{
+		"Oct 15 10:00:15 gke-pool-1-abcd systemd[1]: Finished kube-node-configuration.service - Configure kubernetes node.",
+	}},
+	"konlet_start": {description: "The konlet container startup of Container-Optimized OS started.", examples: []string{
+		"Oct 15 10:00:09 gke-pool-1-abcd systemd[1]: Starting konlet-startup.service - Containers on GCE Setup...",
+	}},
+	"konlet_container_launched": {description: "konlet launched the user container.", examples: []string{
+		"Oct 15 10:00:18 gke-pool-1-abcd konlet-startup[1021]: 2026/10/15 10:00:18 Launching user container 'gcr.io/project/app:1.0'",
+	}},
+	"cse_start": {description: "The AKS Custom Script Extension started provisioning the node.", examples: []string{
+		"Oct 15 10:00:14 aks-nodepool1-12345678-vmss000000 cloud-init[1011]: + /opt/azure/containers/provision_start.sh",
+	}},
+	"cse_finish": {description: "The AKS Custom Script Extension finished provisioning the node.", examples: []string{
+		"Oct 15 10:00:52 aks-nodepool1-12345678-vmss000000 cloud-init[1011]: Custom Script Extension finished with exit code 0",
+	}},
+	"ignition_start": {description: "Ignition started provisioning the RHCOS node in the initramfs.", examples: []string{
+		"Oct 15 10:00:04 localhost ignition[812]: Ignition 2.18.0",
+	}},
+	"ignition_finish": {description: "Ignition finished provisioning the RHCOS node.", examples: []string{
+		"Oct 15 10:00:21 localhost ignition[812]: Ignition finished successfully",
+	}},
+	"mcd_firstboot_start": {description: "The machine-config-daemon firstboot unit started applying the machine config.", examples: []string{
+		"Oct 15 10:00:40 ip-10-0-1-1 systemd[1]: Starting machine-config-daemon-firstboot.service - Machine Config Daemon Firstboot...",
+	}},
+	"mcd_firstboot_finish": {description: "The machine-config-daemon firstboot unit finished, the node reboots into the desired config if needed.", examples: []string{
+		"Oct 15 10:01:30 ip-10-0-1-1 systemd[1]: Finished machine-config-daemon-firstboot.service - Machine Config Daemon Firstboot.",
+	}},
+	"mcd_first_sync": {description: "The machine-config-daemon validated that the node is in its desired config.", examples: []string{
+		"Oct 15 10:02:11 ip-10-0-1-1 machine-config-daemon[2011]: I1015 10:02:11.118227    2011 daemon.go:1507] In desired config rendered-worker-5f1c",
+	}},
+	"sysprep_specialize_start": {description: "Windows setup started the specialize unattend pass.", examples: []string{
+		"2026-10-15 10:00:40, Info                  [windeploy.exe] Running 'specialize' pass",
+	}},
+	"sysprep_specialize_finish": {description: "Windows setup finished the specialize unattend pass.", examples: []string{
+		"2026-10-15 10:01:05, Info                  [windeploy.exe] Exiting 'specialize' pass",
+	}},
+	"sysprep_oobe_start": {description: "Windows setup started the oobeSystem unattend pass.", examples: []string{
+		"2026-10-15 10:01:20, Info                  [oobeldr.exe] Running 'oobeSystem' pass",
+	}},
+	"sysprep_oobe_finish": {description: "Windows setup finished the oobeSystem unattend pass.", examples: []string{
+		"2026-10-15 10:01:28, Info                  [oobeldr.exe] Exiting 'oobeSystem' pass",
+	}},
+	"ec2launch_boot_stage": {description: "EC2Launch v2 started its boot stage.", examples: []string{
+		"2026-10-15 10:01:02 Info: Stage: boot...",
+	}},
+	"ec2launch_post_ready_stage": {description: "EC2Launch v2 started its postReady stage.", examples: []string{
+		"2026-10-15 10:01:30 Info: Stage: postReady...",
+	}},
+	"ec2launch_windows_ready": {description: "EC2Launch v2 reported that Windows is ready to use.", examples: []string{
+		"2026-10-15 10:01:31 Info: Windows is Ready to use",
+	}},
+}
+
+// The example lines that are shared by event metrics
+var (
+	containerdStartExamples = []string{
+		"Oct 15 10:00:22 ip-192-168-1-1 systemd[1]: Starting containerd container runtime...",
+	}
+	containerdStartedExamples = []string{
+		"Oct 15 10:00:23 ip-192-168-1-1 systemd[1]: Started containerd container runtime.",
+	}
+	kubeletStartExamples = []string{
+		"Oct 15 10:00:29 ip-192-168-1-1 systemd[1]: Starting Kubernetes Kubelet...",
+		"Oct 15 10:00:29 ip-192-168-1-1 systemd[1]: Starting kubelet.service - Kubernetes Kubelet...",
+	}
+	kubeletStartedExamples = []string{
+		"Oct 15 10:00:30 ip-192-168-1-1 systemd[1]: Started kubelet.service - Kubernetes Kubelet.",
+	}
+	cniNotReadyExamples = []string{
+		`Oct 15 10:00:31 ip-192-168-1-1 kubelet[2951]: E1015 10:00:31.005123    2951 kubelet.go:2855] "Container runtime network not ready" networkReady="NetworkReady=false reason:NetworkPluginNotReady message:Network plugin returns error: cni plugin not initialized"`,
+	}
+)
@@ -0,0 +1,260 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"go.uber.org/zap"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// OSReleasePath is the os-release file the OS release of the node is read from, usually the host's /etc/os-release mounted into the container
+var OSReleasePath = "/etc/os-release"
+
+// EventGroup is a set of custom events that is only registered on nodes matching its selector, so one events file can serve a mixed fleet
+type EventGroup struct {
+	Name   string         `json:"name"`
+	When   NodeSelector   `json:"when"`
+	Events []*EventConfig `json:"events"`
+}
+
+// NodeSelector selects nodes by regexes of their attributes, all set regexes must match
+type NodeSelector struct {
+	// InstanceType is matched against the instance type, i.e. ^(p|g)[0-9] for GPU families
+	InstanceType string `json:"instanceType,omitempty"`
+	// AMI is matched against the AMI name and ID, the name needs the EC2 client
+	AMI string `json:"ami,omitempty"`
+	// OSRelease is matched against the PRETTY_NAME of the os-release, i.e. Amazon Linux 2023 or Bottlerocket OS 1.15.0
+	OSRelease string `json:"osRelease,omitempty"`
+	// Architecture is matched against the architecture, i.e. x86_64 or arm64
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// EventConfig is a custom regex event of an EventGroup registered to a RegexSource
+type EventConfig struct {
+	Name          string            `json:"name"`
+	Metric        string            `json:"metric"`
+	Src           string            `json:"src"`
+	Regex         string            `json:"regex"`
+	MatchSelector string            `json:"matchSelector,omitempty"`
+	Terminal      bool              `json:"terminal,omitempty"`
+	Track         string            `json:"track,omitempty"`
+	OnlyIf        string            `json:"onlyIf,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Severity      string            `json:"severity,omitempty"`
+	Owner         string            `json:"owner,omitempty"`
+	Aliases       []string          `json:"aliases,omitempty"`
+	// Comment is a text/template over the named capture groups of Regex, i.e. "pulled {{.image}} in {{.duration}}",
+	// the matched line is the comment if it is empty
+	Comment string `json:"comment,omitempty"`
+	// Description and Example, a line the Regex matches, document the event in the generated event documentation
+	Description string `json:"description,omitempty"`
+	Example     string `json:"example,omitempty"`
+}
+
+// NodeAttributes are the node attributes event groups are selected by
+type NodeAttributes struct {
+	InstanceType string
+	AMIID        string
+	AMIName      string
+	OSRelease    string
+	Architecture string
+}
+
+// LoadEventGroups loads a JSON list of event groups
+func LoadEventGroups(path string) ([]*EventGroup, error) {
+	groupsBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read events file %s: %w", path, err)
+	}
+	var groups []*EventGroup
+	if err := json.Unmarshal(groupsBytes, &groups); err != nil {
+		return nil, fmt.Errorf("unable to parse events file %s: %w", path, err)
+	}
+	return groups, nil
+}
+
+// RegisterEventGroups evaluates the selectors of the event groups against the node attributes once and registers the events of the matching groups
+func (m *Measurer) RegisterEventGroups(ctx context.Context, groups []*EventGroup) (*Measurer, error) {
+	var attributes NodeAttributes
+	if !m.eventCatalog {
+		attributes = m.nodeAttributes(ctx)
+	}
+	for _, group := range groups {
+		matches, err := group.When.Matches(attributes)
+		if err != nil {
+			return m, fmt.Errorf("invalid selector of event group \"%s\": %w", group.Name, err)
+		}
+		// the event catalog documents the events of all groups
+		if !matches && !m.eventCatalog {
+			zap.S().Infof("Skipping event group \"%s\" since the node does not match its selector", group.Name)
+			continue
+		}
+		for _, config := range group.Events {
+			event, err := m.event(config)
+			if err != nil {
+				return m, fmt.Errorf("invalid event \"%s\" of event group \"%s\": %w", config.Name, group.Name, err)
+			}
+			if _, err := m.RegisterEvents(event); err != nil {
+				return m, err
+			}
+		}
+	}
+	return m, nil
+}
+
+// Matches returns true if all set regexes of the selector match the node attributes
+func (s NodeSelector) Matches(attributes NodeAttributes) (bool, error) {
+	for _, selector := range []struct {
+		regex  string
+		values []string
+	}{
+		{s.InstanceType, []string{attributes.InstanceType}},
+		{s.AMI, []string{attributes.AMIName, attributes.AMIID}},
+		{s.OSRelease, []string{attributes.OSRelease}},
+		{s.Architecture, []string{attributes.Architecture}},
+	} {
+		if selector.regex == "" {
+			continue
+		}
+		re, err := regexp.Compile(selector.regex)
+		if err != nil {
+			return false, err
+		}
+		matched := false
+		for _, value := range selector.values {
+			matched = matched || (value != "" && re.MatchString(value))
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// event builds the regex event of an event config, the source must be a registered RegexSource
+func (m *Measurer) event(config *EventConfig) (*sources.Event, error) {
+	src, ok := m.GetSource(config.Src)
+	if !ok {
+		return nil, fmt.Errorf("source \"%s\" is not registered", config.Src)
+	}
+	regexSrc, ok := src.(sources.RegexSource)
+	if !ok {
+		return nil, fmt.Errorf("source \"%s\" can not be searched with a regex", config.Src)
+	}
+	re, err := sources.Regexes.Compile(config.Regex)
+	if err != nil {
+		return nil, err
+	}
+	commentFn := sources.CommentMatchedLine()
+	if config.Comment != "" {
+		tmpl, err := commentTemplate(re, config.Comment)
+		if err != nil {
+			return nil, err
+		}
+		commentFn = sources.CommentTemplate(re, tmpl)
+	}
+	matchSelector := config.MatchSelector
+	if matchSelector == "" {
+		matchSelector = sources.EventMatchSelectorFirst
+	}
+	return &sources.Event{
+		Name:          config.Name,
+		Metric:        config.Metric,
+		MatchSelector: matchSelector,
+		Terminal:      config.Terminal,
+		SrcName:       config.Src,
+		FindFn:        regexSrc.FindByRegex(re),
+		Regex:         re.String(),
+		CommentFn:     commentFn,
+		Track:         config.Track,
+		OnlyIf:        config.OnlyIf,
+		Labels:        config.Labels,
+		Severity:      config.Severity,
+		Owner:         config.Owner,
+		Aliases:       config.Aliases,
+		Description:   config.Description,
+		Example:       config.Example,
+	}, nil
+}
+
+// commentTemplate parses a comment template and checks that it only refers to named capture groups of the regex
+func commentTemplate(re *regexp.Regexp, comment string) (*template.Template, error) {
+	tmpl, err := template.New("comment").Option("missingkey=error").Parse(comment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, sources.CommentTemplateData(re, "")); err != nil {
+		return nil, fmt.Errorf("invalid comment template, only the named capture groups of the regex and line can be used: %w", err)
+	}
+	return tmpl, nil
+}
+
+// nodeAttributes collects the node attributes from the instance metadata, EC2 and the os-release, unknown attributes are empty
+func (m *Measurer) nodeAttributes(ctx context.Context) NodeAttributes {
+	var attributes NodeAttributes
+	if metadata, err := m.getMetadata(ctx); err == nil {
+		attributes.InstanceType = metadata.InstanceType
+		attributes.AMIID = metadata.AMIID
+		attributes.Architecture = metadata.Architecture
+	} else {
+		zap.S().Warnf("Unable to retrieve the instance metadata for event groups: %s", err)
+	}
+	if m.ec2Client != nil && strings.HasPrefix(attributes.AMIID, "ami-") {
+		if out, err := m.ec2Client.DescribeImages(ctx, &ec2.DescribeImagesInput{ImageIds: []string{attributes.AMIID}}); err != nil {
+			zap.S().Warnf("Unable to describe AMI %s for event groups: %s", attributes.AMIID, err)
+		} else if len(out.Images) > 0 {
+			attributes.AMIName = aws.ToString(out.Images[0].Name)
+		}
+	}
+	osRelease, err := readOSRelease(OSReleasePath)
+	if err != nil {
+		zap.S().Warnf("Unable to read the os-release for event groups: %s", err)
+	}
+	attributes.OSRelease = osRelease
+	return attributes
+}
+
+// readOSRelease returns the PRETTY_NAME of an os-release file, or the ID and VERSION_ID if it does not have one
+func readOSRelease(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			fields[key] = strings.Trim(value, `"'`)
+		}
+	}
+	if fields["PRETTY_NAME"] != "" {
+		return fields["PRETTY_NAME"], nil
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s", fields["ID"], fields["VERSION_ID"])), scanner.Err()
+}
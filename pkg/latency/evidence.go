@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// Evidence is the artifact of the matched log lines of a measurement's events
+type Evidence struct {
+	Path string `json:"path"`
+	// SHA256 is the checksum of the artifact file, which the integrity signature of the measurement covers
+	SHA256 string `json:"sha256"`
+	Lines  int    `json:"lines"`
+}
+
+// EvidenceLine is a matched log line of an event, one JSON object per line of the artifact
+type EvidenceLine struct {
+	Event     string    `json:"event"`
+	Metric    string    `json:"metric"`
+	Source    string    `json:"source"`
+	Timestamp time.Time `json:"timestamp"`
+	Line      string    `json:"line"`
+}
+
+// WriteEvidence writes the matched log lines of all events as gzipped JSON lines to <dir>/<node name>-<boot id>.evidence.jsonl.gz,
+// so the evidence of a regression is attached to the result. The lines of API sources are the API responses, events that were not found are left out.
+func (m *Measurement) WriteEvidence(dir string, nodeName string, bootID string) error {
+	if m.BootID != "" {
+		bootID = m.BootID
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.evidence.jsonl.gz", lo.Ternary(nodeName == "", "node", nodeName), lo.Ternary(bootID == "", "boot", bootID)))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("unable to create evidence directory %s: %w", dir, err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create evidence file %s: %w", path, err)
+	}
+	defer file.Close()
+	checksum := sha256.New()
+	gzipWriter := gzip.NewWriter(io.MultiWriter(file, checksum))
+	encoder := json.NewEncoder(gzipWriter)
+	lines := 0
+	for _, timing := range m.Timings {
+		if timing.Line == "" {
+			continue
+		}
+		if err := encoder.Encode(EvidenceLine{
+			Event:     timing.Event.Name,
+			Metric:    timing.Event.Metric,
+			Source:    timing.Event.SrcName,
+			Timestamp: timing.Timestamp,
+			Line:      timing.Line,
+		}); err != nil {
+			return fmt.Errorf("unable to write evidence file %s: %w", path, err)
+		}
+		lines++
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("unable to write evidence file %s: %w", path, err)
+	}
+	m.Evidence = &Evidence{Path: path, SHA256: hex.EncodeToString(checksum.Sum(nil)), Lines: lines}
+	return nil
+}
@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Explanation is how an event was searched in one source and which of its matches were selected
+type Explanation struct {
+	Event         string `json:"event"`
+	Source        string `json:"source"`
+	MatchSelector string `json:"matchSelector"`
+	// Location is the resolved source location, usually the log file, and ScannedBytes the bytes searched if the source reports them
+	Location     string       `json:"location,omitempty"`
+	ScannedBytes int          `json:"scannedBytes,omitempty"`
+	Candidates   []*Candidate `json:"candidates"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// Candidate is a match of an event's find func and the decision on it
+type Candidate struct {
+	Line      string    `json:"line"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	Selected  bool      `json:"selected"`
+	// Reason is why the candidate was rejected, or the problem of a selected candidate whose timestamp could not be parsed
+	Reason string `json:"reason,omitempty"`
+}
+
+// Explain searches the sources of the registered events with the name and explains all their matches and the selector decision
+func (m *Measurer) Explain(eventName string) ([]*Explanation, error) {
+	events := lo.Filter(m.events, func(e *sources.Event, _ int) bool { return strings.EqualFold(e.Name, eventName) })
+	if len(events) == 0 {
+		return nil, fmt.Errorf("event \"%s\" is not registered", eventName)
+	}
+	m.prepareSources()
+	var explanations []*Explanation
+	for _, event := range events {
+		explanations = append(explanations, explain(event))
+	}
+	return explanations, nil
+}
+
+// explain finds all matches of the event and applies the event's match selector to them like the source's Find does
+func explain(event *sources.Event) *Explanation {
+	explanation := &Explanation{
+		Event:         event.Name,
+		Source:        event.Src.String(),
+		MatchSelector: event.MatchSelector,
+	}
+	event.Src.ClearCache()
+	all := *event
+	all.MatchSelector = sources.EventMatchSelectorAll
+	results, err := event.Src.Find(&all)
+	if inspectedSrc, ok := event.Src.(sources.InspectedSource); ok {
+		explanation.Location, explanation.ScannedBytes = inspectedSrc.LastSearch()
+	}
+	if err != nil {
+		explanation.Error = err.Error()
+		return explanation
+	}
+	selected := selectedIndexes(len(results), event.MatchSelector)
+	for i, result := range results {
+		candidate := &Candidate{
+			Line:      result.Line,
+			Timestamp: result.Timestamp,
+			Comment:   result.Comment,
+			Selected:  selected[i],
+		}
+		switch {
+		case result.Err != nil:
+			candidate.Reason = fmt.Sprintf("unable to parse timestamp: %s", result.Err)
+		case !candidate.Selected:
+			candidate.Reason = fmt.Sprintf("not selected by the %s match selector", event.MatchSelector)
+		}
+		explanation.Candidates = append(explanation.Candidates, candidate)
+	}
+	return explanation
+}
+
+// selectedIndexes returns the indexes of n sorted results that sources.SelectMatches selects
+func selectedIndexes(n int, matchSelector string) map[int]bool {
+	switch {
+	case n == 0:
+		return nil
+	case matchSelector == sources.EventMatchSelectorFirst:
+		return map[int]bool{0: true}
+	case matchSelector == sources.EventMatchSelectorLast:
+		return map[int]bool{n - 1: true}
+	}
+	return lo.SliceToMap(lo.Range(n), func(i int) (int, bool) { return i, true })
+}
+
+// Print writes the explanation in a human readable form
+func (e *Explanation) Print(w io.Writer) {
+	fmt.Fprintf(w, "Event \"%s\" in %s (match selector: %s)\n", e.Event, e.Source, e.MatchSelector)
+	if e.Location != "" {
+		fmt.Fprintf(w, "  Searched %s (%d bytes)\n", e.Location, e.ScannedBytes)
+	}
+	if e.Error != "" {
+		fmt.Fprintf(w, "  No candidates: %s\n", e.Error)
+		return
+	}
+	fmt.Fprintf(w, "  %d candidate(s):\n", len(e.Candidates))
+	for _, c := range e.Candidates {
+		decision := "selected"
+		if !c.Selected || c.Reason != "" {
+			decision = "rejected"
+		}
+		fmt.Fprintf(w, "    [%s] %s\n", decision, c.Line)
+		if !c.Timestamp.IsZero() {
+			fmt.Fprintf(w, "        timestamp: %s\n", c.Timestamp.UTC().Format(time.RFC3339Nano))
+		}
+		if c.Reason != "" {
+			fmt.Fprintf(w, "        reason: %s\n", c.Reason)
+		}
+	}
+	if selected, ok := lo.Find(e.Candidates, func(c *Candidate) bool { return c.Selected && c.Reason == "" }); ok {
+		fmt.Fprintf(w, "  Decision: the %s match selector selected %s\n", e.MatchSelector, selected.Timestamp.UTC().Format(time.RFC3339Nano))
+	} else {
+		fmt.Fprintln(w, "  Decision: no timing, the event does not have a candidate with a parsable timestamp")
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"go.uber.org/zap"
+)
+
+// fastLaunchEnabled checks whether EC2 Fast Launch is enabled for the Windows AMI, so the instance was launched from a pre-provisioned
+// snapshot that already ran the sysprep specialize pass. The result is cached, it is nil if it is unknown.
+func (m *Measurer) fastLaunchEnabled(ctx context.Context, amiID string) *bool {
+	if m.fastLaunch != nil || m.ec2Client == nil {
+		return m.fastLaunch
+	}
+	out, err := m.ec2Client.DescribeFastLaunchImages(ctx, &ec2.DescribeFastLaunchImagesInput{ImageIds: []string{amiID}})
+	if err != nil {
+		zap.S().Warnf("Unable to describe the Fast Launch configuration of %s: %s", amiID, err)
+		return nil
+	}
+	enabled := len(out.FastLaunchImages) > 0 && out.FastLaunchImages[0].State == types.FastLaunchStateCodeEnabled
+	m.fastLaunch = &enabled
+	return m.fastLaunch
+}
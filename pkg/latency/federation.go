@@ -0,0 +1,65 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+)
+
+// AggregatorClusterHeader is the header naming the cluster a result is pushed from, the aggregator authenticates it with the cluster's token
+const AggregatorClusterHeader = "X-Cluster-Name"
+
+// WithClusterName labels measurements with the name of the cluster the node belongs to
+func (m *Measurer) WithClusterName(clusterName string) *Measurer {
+	m.clusterName = clusterName
+	return m
+}
+
+// PushToAggregator pushes the measurement to the results ingest of an aggregator, i.e. "node-latency-for-k8s analyze serve", as the cluster
+func (m *Measurement) PushToAggregator(ctx context.Context, url string, token string, cluster string) error {
+	if cluster == "" {
+		return fmt.Errorf("the cluster name is required to push to the aggregator")
+	}
+	measurementBytes, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if ok, err := dryRun("aggregator", url, json.RawMessage(measurementBytes)); ok {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(measurementBytes))
+	if err != nil {
+		return fmt.Errorf("unable to create aggregator request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(AggregatorClusterHeader, cluster)
+	resp, err := (&http.Client{Timeout: 10 * time.Second, Transport: proxy.Transport()}).Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to push to the aggregator: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("aggregator returned status %s", resp.Status)
+	}
+	return nil
+}
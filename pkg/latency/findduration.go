@@ -0,0 +1,103 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Find duration metric names
+const (
+	FindDurationMetric = "event_find_duration_seconds"
+	FindCallsMetric    = "event_find_calls_total"
+)
+
+// FindDuration is how long the Find of an event took, i.e. the log file scan or the API call, over all measurement passes
+type FindDuration struct {
+	Event  string        `json:"event"`
+	Source string        `json:"source"`
+	Calls  int           `json:"calls"`
+	Total  time.Duration `json:"total"`
+	Last   time.Duration `json:"last"`
+	Max    time.Duration `json:"max"`
+}
+
+// findTimed calls the Find of the event and records how long it took
+func (m *Measurer) findTimed(event *sources.Event) ([]sources.FindResult, error) {
+	start := time.Now()
+	results, err := event.Src.Find(event)
+	elapsed := time.Since(start)
+	if m.findDurations == nil {
+		m.findDurations = map[string]*FindDuration{}
+	}
+	duration, ok := m.findDurations[event.Name]
+	if !ok {
+		duration = &FindDuration{Event: event.Name, Source: event.Src.Name()}
+		m.findDurations[event.Name] = duration
+	}
+	duration.Calls++
+	duration.Total += elapsed
+	duration.Last = elapsed
+	duration.Max = lo.Max([]time.Duration{duration.Max, elapsed})
+	return results, err
+}
+
+// FindDurations returns the find durations of the searched events, slowest first
+func (m *Measurer) FindDurations() []*FindDuration {
+	durations := lo.Map(lo.Values(m.findDurations), func(d *FindDuration, _ int) *FindDuration {
+		copied := *d
+		return &copied
+	})
+	sort.Slice(durations, func(i, j int) bool {
+		if durations[i].Total != durations[j].Total {
+			return durations[i].Total > durations[j].Total
+		}
+		return durations[i].Event < durations[j].Event
+	})
+	return durations
+}
+
+// registerFindDurationMetrics registers the total find duration and number of finds of each event
+func (m *Measurement) registerFindDurationMetrics(register prometheus.Registerer) {
+	if len(m.FindDurations) == 0 {
+		return
+	}
+	durations := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: FindDurationMetric,
+		Help: "Total time the Find of the event took over all measurement passes, i.e. scanning a log file or calling an API",
+	}, []string{"event", "source"})
+	calls := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: FindCallsMetric,
+		Help: "Number of times the event was searched over all measurement passes",
+	}, []string{"event", "source"})
+	for _, collector := range []prometheus.Collector{durations, calls} {
+		if err := register.Register(collector); err != nil {
+			zap.S().Errorf("error registering find duration metrics: %v", err)
+			return
+		}
+	}
+	for _, d := range m.FindDurations {
+		labels := prometheus.Labels{"event": d.Event, "source": d.Source}
+		durations.With(labels).Set(d.Total.Seconds())
+		calls.With(labels).Set(float64(d.Calls))
+	}
+}
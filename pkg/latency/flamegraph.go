@@ -0,0 +1,63 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// CollapsedStacks returns the bootstrap as collapsed stacks (root;phase;event milliseconds) consumable by flamegraph.pl, inferno and speedscope.
+// Each event is weighted with the time from the previous event to the event, the root frame is the node name or "bootstrap".
+func (m *Measurement) CollapsedStacks(nodeName string) []string {
+	root := lo.Ternary(nodeName != "", nodeName, "bootstrap")
+	var stacks []string
+	var previous sources.Timing
+	for _, t := range lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil }) {
+		weight := (t.T - previous.T).Milliseconds()
+		previous = *t
+		if weight <= 0 {
+			continue
+		}
+		frames := []string{root}
+		if phase := t.Event.Labels["phase"]; phase != "" {
+			frames = append(frames, phase)
+		}
+		frames = append(frames, t.Event.Name)
+		stacks = append(stacks, fmt.Sprintf("%s %d", strings.Join(lo.Map(frames, func(frame string, _ int) string {
+			// semicolons separate the frames, spaces are allowed since the weight is separated by the last space
+			return strings.ReplaceAll(frame, ";", ":")
+		}), ";"), weight))
+	}
+	return stacks
+}
+
+// WriteFlamegraph writes the collapsed stacks of the measurement to a file
+func (m *Measurement) WriteFlamegraph(path string, nodeName string) error {
+	stacks := m.CollapsedStacks(nodeName)
+	if len(stacks) == 0 {
+		return fmt.Errorf("the measurement does not have timings")
+	}
+	//nolint:gosec
+	if err := os.WriteFile(path, []byte(strings.Join(stacks, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("unable to write flamegraph file %s: %w", path, err)
+	}
+	return nil
+}
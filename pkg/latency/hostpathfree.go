@@ -0,0 +1,61 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/dockerd"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/ec2launch"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/sysprep"
+)
+
+// hostPathSourceNames are the sources that read host paths, which are not registered without host paths
+var hostPathSourceNames = []string{messages.Name, awsnode.Name, journal.Name, dockerd.Name, sysprep.Name, ec2launch.Name}
+
+// kubeletHostPathMetrics are the kubelet source events that read host paths instead of the kubelet API
+var kubeletHostPathMetrics = []string{"static_pod_manifests_written", "kubelet_serving_certificate_written"}
+
+// WithHostPathFree leaves out the sources and events that read host paths, for clusters whose policies forbid hostPath volumes.
+// The K8s API, kubelet API, journal gateway, EC2 and IMDS sources are still registered, and the default log events are read from the
+// journal gateway if it is configured. Without the journal gateway, only the reduced event set of the API sources is measured.
+func (m *Measurer) WithHostPathFree() *Measurer {
+	m.hostPathFree = true
+	return m
+}
+
+// hostPathFreeEvents are the reduced default events of the API sources, which are only registered if their source is
+func (m *Measurer) hostPathFreeEvents() []*sources.Event {
+	events := m.podCreatedEvent()
+	events = append(events, m.ec2Events()...)
+	if k8sSrc, ok := m.GetSource(k8ssrc.Name); ok {
+		events = append(events, &sources.Event{
+			Name:          "Node Ready",
+			Metric:        "node_ready",
+			SrcName:       k8ssrc.Name,
+			Terminal:      true,
+			Track:         TrackNode,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        k8sSrc.(*k8ssrc.Source).FindNodeCondition(corev1.NodeReady, corev1.ConditionTrue),
+		})
+	}
+	events = append(events, m.nodeConditionEvents()...)
+	return append(events, m.kubeletEvents()...)
+}
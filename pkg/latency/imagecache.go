@@ -0,0 +1,98 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultContainerdContentDir is the containerd content store
+const DefaultContainerdContentDir = "/var/lib/containerd/io.containerd.content.v1.content"
+
+// maxManifestSize limits the blobs that are read to check if they are an image manifest, manifests are a few KiB
+const maxManifestSize = 64 * 1024
+
+// ImageCache is the content that was in the containerd content store before the node booted, i.e. images pre-pulled into the AMI
+type ImageCache struct {
+	// Warm is set if any image was cached before boot
+	Warm bool `json:"warm"`
+	// Images is the number of cached image manifests, one per image and platform
+	Images int `json:"images"`
+	// Bytes is the size of all cached blobs
+	Bytes int64 `json:"bytes"`
+}
+
+// WithImageCache is a builder func that adds the detection of images that were cached in the containerd content store before boot
+func (m *Measurer) WithImageCache(contentDir string, bootTime time.Time) *Measurer {
+	m.imageCacheDir = contentDir
+	m.bootTime = bootTime
+	return m
+}
+
+// scanImageCache counts the blobs of the content store that were written before boot and the image manifests among them.
+// The result is cached since the content from before boot does not change.
+func (m *Measurer) scanImageCache() (*ImageCache, error) {
+	if m.imageCache != nil {
+		return m.imageCache, nil
+	}
+	cache := &ImageCache{}
+	blobsDir := filepath.Join(m.imageCacheDir, "blobs")
+	err := filepath.WalkDir(blobsDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(m.bootTime) {
+			return nil
+		}
+		cache.Bytes += info.Size()
+		if info.Size() <= maxManifestSize && isImageManifest(path) {
+			cache.Images++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to scan the containerd content store: %w", err)
+	}
+	cache.Warm = cache.Images > 0
+	m.imageCache = cache
+	return cache, nil
+}
+
+// isImageManifest checks if a blob is an OCI or Docker image manifest, which are the only blobs with a config and layers
+func isImageManifest(path string) bool {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var manifest struct {
+		Config *json.RawMessage  `json:"config"`
+		Layers []json.RawMessage `json:"layers"`
+	}
+	return json.Unmarshal(blob, &manifest) == nil && manifest.Config != nil && len(manifest.Layers) > 0
+}
+
+// String is a human readable summary of the image cache
+func (c *ImageCache) String() string {
+	if !c.Warm {
+		return "cold, no images were cached before boot"
+	}
+	return fmt.Sprintf("warm, %d images (%.1f MiB) were cached before boot", c.Images, float64(c.Bytes)/(1024*1024))
+}
@@ -0,0 +1,178 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	kubeletsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/kubelet"
+)
+
+// Image pull classifications
+const (
+	// ImagePullCacheHit is an image of a measured pod that was already present on the node, i.e. pre-pulled or baked into the AMI
+	ImagePullCacheHit = "cache-hit"
+	// ImagePullNetwork is an image that containerd pulled over the network
+	ImagePullNetwork = "network-pull"
+)
+
+var (
+	// containerdPullImage matches the containerd CRI PullImage start and end records, i.e. msg="PullImage \"pause:3.2\" returns image reference \"sha256:...\""
+	containerdPullImage = regexp.MustCompile(`.*PullImage \\"([^"\\]+)\\"( returns image reference)?.*`)
+)
+
+// ImagePull is the classification of an image pull
+type ImagePull struct {
+	Image          string        `json:"image"`
+	Classification string        `json:"classification"`
+	Start          time.Time     `json:"start,omitempty"`
+	Duration       time.Duration `json:"seconds,omitempty"`
+}
+
+// ImagePullReport classifies the image pulls of the measured pods as cache hits or network pulls
+type ImagePullReport struct {
+	Pulls []*ImagePull `json:"pulls"`
+	// NetworkPullTime is the total time spent pulling images over the network which pre-pulling could save
+	NetworkPullTime time.Duration `json:"networkPullSeconds"`
+	// EstimatedSavedTime estimates the time the cache hits saved with the average network pull time
+	EstimatedSavedTime time.Duration `json:"estimatedSavedSeconds"`
+}
+
+// WithImagePullReport adds the image pull report to measurements
+func (m *Measurer) WithImagePullReport() *Measurer {
+	m.imagePullReport = true
+	return m
+}
+
+// imagePulls builds the image pull report from the containerd records in the log source and the images of the measured pods.
+// All pulled images are reported if the pods are not known, i.e. without the K8s and kubelet sources.
+func (m *Measurer) imagePulls(ctx context.Context) (*ImagePullReport, error) {
+	src, ok := m.GetSource(m.logSourceName())
+	if !ok {
+		return nil, fmt.Errorf("log source \"%s\" is not registered", m.logSourceName())
+	}
+	regexSrc, ok := src.(sources.RegexSource)
+	if !ok {
+		return nil, fmt.Errorf("log source \"%s\" is not searchable", src.Name())
+	}
+	results, err := src.Find(&sources.Event{
+		Name:          "Image Pulls",
+		MatchSelector: sources.EventMatchSelectorAll,
+		FindFn:        regexSrc.FindByRegex(containerdPullImage),
+		Regex:         containerdPullImage.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	networkPulls := map[string]*ImagePull{}
+	for _, result := range results {
+		match := containerdPullImage.FindStringSubmatch(result.Line)
+		if match == nil {
+			continue
+		}
+		// the containerd logfmt timestamp is more precise than a syslog timestamp
+		ts, err := sources.ParseLogfmtTimestamp(result.Line)
+		if err != nil {
+			ts = result.Timestamp
+		}
+		pull, ok := networkPulls[match[1]]
+		if !ok {
+			pull = &ImagePull{Image: match[1], Classification: ImagePullNetwork, Start: ts}
+			networkPulls[match[1]] = pull
+		}
+		if match[2] != "" && pull.Duration == 0 {
+			pull.Duration = ts.Sub(pull.Start)
+		}
+	}
+	images, err := m.podImages(ctx)
+	if err != nil {
+		images = lo.Keys(networkPulls)
+	}
+	report := &ImagePullReport{}
+	for _, image := range lo.Uniq(images) {
+		pull, ok := networkPulls[image]
+		if !ok {
+			pull = &ImagePull{Image: image, Classification: ImagePullCacheHit}
+		}
+		report.Pulls = append(report.Pulls, pull)
+		report.NetworkPullTime += pull.Duration
+	}
+	sort.Slice(report.Pulls, func(i, j int) bool { return report.Pulls[i].Image < report.Pulls[j].Image })
+	network := lo.CountBy(report.Pulls, func(p *ImagePull) bool { return p.Classification == ImagePullNetwork })
+	cacheHits := len(report.Pulls) - network
+	if network > 0 {
+		report.EstimatedSavedTime = report.NetworkPullTime / time.Duration(network) * time.Duration(cacheHits)
+	}
+	return report, nil
+}
+
+// podImages lists the container images of the measured pods from the K8s API, or the kubelet if there is no API server
+func (m *Measurer) podImages(ctx context.Context) ([]string, error) {
+	var pods []corev1.Pod
+	switch {
+	case m.k8sClientset != nil && m.nodeName != "":
+		podList, err := m.k8sClientset.CoreV1().Pods(m.podNamespace).List(ctx, v1.ListOptions{FieldSelector: fmt.Sprintf("spec.nodeName=%s", m.nodeName)})
+		if err != nil {
+			return nil, err
+		}
+		pods = podList.Items
+	default:
+		kubeletSrc, ok := m.GetSource(kubeletsrc.Name)
+		if !ok {
+			return nil, fmt.Errorf("the measured pods are not known without the K8s or kubelet source")
+		}
+		var err error
+		if pods, err = kubeletSrc.(*kubeletsrc.Source).Pods(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var images []string
+	for _, pod := range pods {
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			images = append(images, container.Image)
+		}
+	}
+	return images, nil
+}
+
+// Chart prints the image pull report as a markdown table
+func (r *ImagePullReport) Chart() {
+	fmt.Println("\nImage Pulls:")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Image", "Pull", "Duration"})
+	for _, pull := range r.Pulls {
+		duration := ""
+		if pull.Classification == ImagePullNetwork {
+			duration = fmt.Sprintf("%.1fs", pull.Duration.Seconds())
+		}
+		table.Append([]string{pull.Image, pull.Classification, duration})
+	}
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	table.Render()
+	fmt.Printf("Network pulls took %.1fs which pre-pulling could save, cache hits saved an estimated %.1fs\n",
+		r.NetworkPullTime.Seconds(), r.EstimatedSavedTime.Seconds())
+}
@@ -0,0 +1,300 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"go.uber.org/zap"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// Integrity makes a measurement reproducible and, when signed, tamper-evident
+type Integrity struct {
+	ToolVersion string `json:"toolVersion"`
+	// ConfigHash is the sha256 checksum of the configuration the measurement was taken with
+	ConfigHash string `json:"configHash,omitempty"`
+	// SourceChecksums are the sha256 checksums and byte ranges of the log contents the events were searched in, by source name
+	SourceChecksums map[string]*sources.ScanChecksum `json:"sourceChecksums,omitempty"`
+	Signature       *Signature                       `json:"signature,omitempty"`
+}
+
+// Signature is the signature of the sha256 digest of the canonical measurement JSON, see CanonicalDigest
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"keyID"`
+	// Value is the base64 encoded signature
+	Value string `json:"value"`
+}
+
+// Signer signs the digest of a measurement
+type Signer interface {
+	// Sign signs a sha256 digest
+	Sign(ctx context.Context, digest []byte) (*Signature, error)
+}
+
+// Integrity returns the integrity metadata of measurements taken by the Measurer, the tool version and config hash are supplied by the caller
+func (m *Measurer) Integrity(toolVersion string, configHash string) *Integrity {
+	integrity := &Integrity{
+		ToolVersion:     toolVersion,
+		ConfigHash:      configHash,
+		SourceChecksums: map[string]*sources.ScanChecksum{},
+	}
+	for name, src := range m.sources {
+		checksummed, ok := src.(sources.ChecksummedSource)
+		if !ok {
+			continue
+		}
+		checksum, err := checksummed.Checksum()
+		if err != nil {
+			zap.S().Warnf("Unable to checksum source %s: %s", name, err)
+			continue
+		}
+		integrity.SourceChecksums[name] = checksum
+	}
+	return integrity
+}
+
+// Digest is the sha256 digest of the canonical measurement JSON, which is what Sign signs
+func (m *Measurement) Digest() ([]byte, error) {
+	measurementJSON, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal the measurement: %w", err)
+	}
+	return CanonicalDigest(measurementJSON)
+}
+
+// CanonicalDigest is the sha256 digest of the canonical form of a measurement JSON document, which is the document without
+// integrity.signature, with the keys of every object sorted, without whitespace between tokens, with numbers as they are written
+// and strings as encoding/json writes them without escaping <, > and &. It only depends on the JSON document, so a result is
+// verified from the file it was written to, regardless of the indentation or key order the file was written with.
+func CanonicalDigest(measurementJSON []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(measurementJSON))
+	decoder.UseNumber()
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("unable to decode the measurement: %w", err)
+	}
+	if integrity, ok := document["integrity"].(map[string]interface{}); ok {
+		delete(integrity, "signature")
+	}
+	var canonical bytes.Buffer
+	encoder := json.NewEncoder(&canonical)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return nil, fmt.Errorf("unable to encode the canonical measurement: %w", err)
+	}
+	digest := sha256.Sum256(bytes.TrimSuffix(canonical.Bytes(), []byte("\n")))
+	return digest[:], nil
+}
+
+// VerifyMeasurement verifies the signature of a measurement JSON document with the public key of the key it was signed with
+func VerifyMeasurement(measurementJSON []byte, publicKey crypto.PublicKey) error {
+	var signed struct {
+		Integrity *struct {
+			Signature *Signature `json:"signature"`
+		} `json:"integrity"`
+	}
+	if err := json.Unmarshal(measurementJSON, &signed); err != nil {
+		return fmt.Errorf("unable to decode the measurement: %w", err)
+	}
+	if signed.Integrity == nil || signed.Integrity.Signature == nil {
+		return fmt.Errorf("the measurement is not signed")
+	}
+	signature := signed.Integrity.Signature
+	value, err := base64.StdEncoding.DecodeString(signature.Value)
+	if err != nil {
+		return fmt.Errorf("unable to decode the signature: %w", err)
+	}
+	digest, err := CanonicalDigest(measurementJSON)
+	if err != nil {
+		return err
+	}
+	valid := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if signature.Algorithm != "ECDSA_SHA_256" {
+			return fmt.Errorf("signature algorithm %s does not match the ECDSA key", signature.Algorithm)
+		}
+		valid = ecdsa.VerifyASN1(key, digest, value)
+	case *rsa.PublicKey:
+		switch signature.Algorithm {
+		case "RSASSA_PSS_SHA_256":
+			valid = rsa.VerifyPSS(key, crypto.SHA256, digest, value, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		case "RSASSA_PKCS1_V1_5_SHA_256":
+			valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, value) == nil
+		default:
+			return fmt.Errorf("signature algorithm %s does not match the RSA key", signature.Algorithm)
+		}
+	case ed25519.PublicKey:
+		if signature.Algorithm != "ED25519" {
+			return fmt.Errorf("signature algorithm %s does not match the Ed25519 key", signature.Algorithm)
+		}
+		valid = ed25519.Verify(key, digest, value)
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	if !valid {
+		return fmt.Errorf("the %s signature of key %s is not valid for the measurement", signature.Algorithm, signature.KeyID)
+	}
+	return nil
+}
+
+// LoadPublicKey loads a PEM encoded PKIX public key, i.e. from openssl pkey -pubout, or the public key of
+// a private key that NewFileSigner loads
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("public key %s is not PEM encoded", path)
+	}
+	if block.Type != "PUBLIC KEY" {
+		signer, err := NewFileSigner(path)
+		if err != nil {
+			return nil, err
+		}
+		return signer.key.Public(), nil
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse public key %s: %w", path, err)
+	}
+	return publicKey, nil
+}
+
+// Sign signs the measurement with the signer, the measurement must have integrity metadata
+func (m *Measurement) Sign(ctx context.Context, signer Signer) error {
+	if m.Integrity == nil {
+		return fmt.Errorf("the measurement does not have integrity metadata")
+	}
+	digest, err := m.Digest()
+	if err != nil {
+		return err
+	}
+	signature, err := signer.Sign(ctx, digest)
+	if err != nil {
+		return err
+	}
+	m.Integrity.Signature = signature
+	return nil
+}
+
+// FileSigner signs with a local PEM encoded ECDSA, Ed25519 or RSA private key
+type FileSigner struct {
+	key   crypto.Signer
+	keyID string
+}
+
+// NewFileSigner loads a PEM encoded PKCS#8, EC or PKCS#1 private key, the key id is the sha256 fingerprint of the public key
+func NewFileSigner(path string) (*FileSigner, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read signing key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse signing key %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not a signing key", path)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal the public key of %s: %w", path, err)
+	}
+	fingerprint := sha256.Sum256(publicKey)
+	return &FileSigner{key: signer, keyID: fmt.Sprintf("sha256:%s", hex.EncodeToString(fingerprint[:]))}, nil
+}
+
+// Sign signs the digest with ECDSA, RSASSA-PSS or, for Ed25519 keys, Ed25519 over the digest
+func (f *FileSigner) Sign(_ context.Context, digest []byte) (*Signature, error) {
+	var algorithm string
+	var opts crypto.SignerOpts
+	switch f.key.(type) {
+	case *ecdsa.PrivateKey:
+		algorithm, opts = "ECDSA_SHA_256", crypto.SHA256
+	case *rsa.PrivateKey:
+		algorithm, opts = "RSASSA_PSS_SHA_256", &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	case ed25519.PrivateKey:
+		algorithm, opts = "ED25519", crypto.Hash(0)
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", f.key)
+	}
+	signature, err := f.key.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign the measurement: %w", err)
+	}
+	return &Signature{Algorithm: algorithm, KeyID: f.keyID, Value: base64.StdEncoding.EncodeToString(signature)}, nil
+}
+
+// KMSSigner signs with an asymmetric AWS KMS key
+type KMSSigner struct {
+	client    *kms.Client
+	keyID     string
+	algorithm string
+}
+
+// NewKMSSigner creates a KMSSigner for the key id, ARN or alias and a signing algorithm supported by the key, i.e. ECDSA_SHA_256
+func NewKMSSigner(client *kms.Client, keyID string, algorithm string) *KMSSigner {
+	return &KMSSigner{client: client, keyID: keyID, algorithm: algorithm}
+}
+
+// Sign signs the digest with KMS, the key id of the signature is the key ARN
+func (k *KMSSigner) Sign(ctx context.Context, digest []byte) (*Signature, error) {
+	out, err := k.client.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(k.keyID),
+		Message:          digest,
+		MessageType:      kmstypes.MessageTypeDigest,
+		SigningAlgorithm: kmstypes.SigningAlgorithmSpec(k.algorithm),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign the measurement with KMS key %s: %w", k.keyID, err)
+	}
+	return &Signature{Algorithm: string(out.SigningAlgorithm), KeyID: aws.ToString(out.KeyId), Value: base64.StdEncoding.EncodeToString(out.Signature)}, nil
+}
@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+func TestSignVerify(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name          string
+		key           crypto.Signer
+		wantAlgorithm string
+	}{
+		{name: "ecdsa", key: ecdsaKey, wantAlgorithm: "ECDSA_SHA_256"},
+		{name: "rsa-pss", key: rsaKey, wantAlgorithm: "RSASSA_PSS_SHA_256"},
+		{name: "ed25519", key: ed25519Key, wantAlgorithm: "ED25519"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			privateKeyPath := writeKey(t, dir, "private.pem", "PRIVATE KEY", lo.Must(x509.MarshalPKCS8PrivateKey(tc.key)))
+			publicKeyPath := writeKey(t, dir, "public.pem", "PUBLIC KEY", lo.Must(x509.MarshalPKIXPublicKey(tc.key.Public())))
+			signer, err := NewFileSigner(privateKeyPath)
+			if err != nil {
+				t.Fatalf("NewFileSigner() error = %v", err)
+			}
+			measurement := &Measurement{
+				Metadata: &Metadata{InstanceID: "i-0123456789abcdef0"},
+				Timings:  []*sources.Timing{},
+				Integrity: &Integrity{
+					ToolVersion: "v1.2.3",
+					ConfigHash:  "abc",
+					SourceChecksums: map[string]*sources.ScanChecksum{
+						"messages": {Path: "/var/log/messages", SHA256: "def", Start: 10, End: 200},
+					},
+				},
+			}
+			if err := measurement.Sign(context.Background(), signer); err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if got := measurement.Integrity.Signature.Algorithm; got != tc.wantAlgorithm {
+				t.Errorf("Sign() algorithm = %s, want %s", got, tc.wantAlgorithm)
+			}
+			// results are usually written indented, the signature does not depend on the formatting
+			measurementJSON, err := json.MarshalIndent(measurement, "", "    ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			publicKey, err := LoadPublicKey(publicKeyPath)
+			if err != nil {
+				t.Fatalf("LoadPublicKey() error = %v", err)
+			}
+			if err := VerifyMeasurement(measurementJSON, publicKey); err != nil {
+				t.Errorf("VerifyMeasurement() error = %v, want nil", err)
+			}
+			// the public key of the private key file verifies too
+			privatePublicKey, err := LoadPublicKey(privateKeyPath)
+			if err != nil {
+				t.Fatalf("LoadPublicKey() of the private key error = %v", err)
+			}
+			if err := VerifyMeasurement(measurementJSON, privatePublicKey); err != nil {
+				t.Errorf("VerifyMeasurement() with the private key file error = %v, want nil", err)
+			}
+			tampered := []byte(strings.Replace(string(measurementJSON), `"end": 200`, `"end": 201`, 1))
+			if string(tampered) == string(measurementJSON) {
+				t.Fatal("the measurement JSON was not tampered with")
+			}
+			if err := VerifyMeasurement(tampered, publicKey); err == nil {
+				t.Error("VerifyMeasurement() of a tampered measurement error = nil, want an error")
+			}
+			if err := VerifyMeasurement(measurementJSON, otherKey.Public()); err == nil {
+				t.Error("VerifyMeasurement() with another key error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestCanonicalDigest(t *testing.T) {
+	want, err := CanonicalDigest([]byte(`{"a":1,"b":{"c":"<x>","d":1.50},"integrity":{"toolVersion":"v1"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name     string
+		document string
+		same     bool
+	}{
+		{name: "whitespace and key order are ignored", document: "{\n  \"b\": {\"d\": 1.50, \"c\": \"<x>\"},\n  \"integrity\": {\"toolVersion\": \"v1\"},\n  \"a\": 1\n}", same: true},
+		{name: "the signature is ignored", document: `{"a":1,"b":{"c":"<x>","d":1.50},"integrity":{"toolVersion":"v1","signature":{"value":"x"}}}`, same: true},
+		{name: "escaped characters are the same string", document: `{"a":1,"b":{"c":"\u003cx\u003e","d":1.50},"integrity":{"toolVersion":"v1"}}`, same: true},
+		{name: "numbers are kept as written", document: `{"a":1,"b":{"c":"<x>","d":1.5},"integrity":{"toolVersion":"v1"}}`, same: false},
+		{name: "values are covered", document: `{"a":2,"b":{"c":"<x>","d":1.50},"integrity":{"toolVersion":"v1"}}`, same: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CanonicalDigest([]byte(tc.document))
+			if err != nil {
+				t.Fatalf("CanonicalDigest() error = %v", err)
+			}
+			if same := string(got) == string(want); same != tc.same {
+				t.Errorf("CanonicalDigest() same = %t, want %t", same, tc.same)
+			}
+		})
+	}
+}
+
+func writeKey(t *testing.T, dir string, name string, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -21,15 +21,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math/rand"
 	"os"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -37,14 +40,27 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	"go.uber.org/zap"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	asgsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/asg"
 	"github.com/awslabs/node-latency-for-k8s/pkg/sources/awsnode"
+	azuresrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/azure"
+	chaossrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/chaos"
+	cloudinitsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/cloudinit"
+	crisrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/cri"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/dockerd"
 	ec2src "github.com/awslabs/node-latency-for-k8s/pkg/sources/ec2"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/ec2launch"
+	egresssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/egress"
+	gcesrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/gce"
 	imdssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/imds"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/journal"
 	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+	kubeletsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/kubelet"
 	"github.com/awslabs/node-latency-for-k8s/pkg/sources/messages"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/sysprep"
 )
 
 // Measurer holds registered sources and events to use for timing runs
@@ -54,17 +70,148 @@ type Measurer struct {
 	metadata     *Metadata
 	imdsClient   *imds.Client
 	ec2Client    *ec2.Client
+	asgClient    *autoscaling.Client
 	k8sClientset *kubernetes.Clientset
 	podNamespace string
 	nodeName     string
+	clusterName  string
+	windowStart  time.Time
+	windowEnd    time.Time
+	// measureEnd is the timeout or deadline of the running MeasureUntil, which ends the automatic search window
+	measureEnd time.Time
+	// logSource is the name of the source the default log events are registered to
+	logSource         string
+	journalGatewayURL string
+	journalRoot       string
+	journalNamespace  string
+	dockerdLogPath    string
+	// profile selects the default events, ProfileEKS if empty
+	profile string
+	// mode selects what the default events measure, ModeLaunch if empty
+	mode  string
+	gce   *gcesrc.Source
+	azure *azuresrc.Source
+	// kubeletEndpoint enables the local kubelet source for pod milestones when there is no API server
+	kubeletEndpoint string
+	// criEndpoint enables the CRI socket source, the image pulls are read from the containerd content store in criContentDir
+	criEndpoint   string
+	criContentDir string
+	// egressProbeURL enables the egress source which probes the URL until the first external HTTPS connection succeeds
+	egressProbeURL    string
+	staticManifestDir string
+	// deadline finalizes MeasureUntil regardless of missing events, zero if there is no deadline
+	deadline time.Time
+	// eventOwners maps the component label of the default events to their owner
+	eventOwners map[string]string
+	// dedup keeps one timing of events with the same metric found in multiple sources, sourcePriority breaks precision ties
+	dedup          bool
+	sourcePriority []string
+	// sourceLocations are the locations of timestamps without a zone by source name
+	sourceLocations map[string]*time.Location
+	// imagePullReport adds the classification of image pulls to measurements
+	imagePullReport bool
+	// admissionReport adds the admission latency estimate of the measured pods to measurements
+	admissionReport bool
+	// admissionRequestedAnnotation is the pod annotation holding the time the client requested the pod
+	admissionRequestedAnnotation string
+	// priceTable and spotPricing annotate measurements with the cost of the bootstrap window
+	priceTable  map[string]float64
+	spotPricing bool
+	spotPrices  map[string]float64
+	// scoreWeights are the weights and targets of the event metrics in the bootstrap score
+	scoreWeights []ScoreWeight
+	// baseline is the golden measurement that measurements are compared to with the default tolerance or the tolerance of the metric
+	baseline           *Baseline
+	baselineTolerance  Tolerance
+	baselineTolerances map[string]Tolerance
+	// imdsTagDimensions maps the instance tags read from IMDS to dimension names
+	imdsTagDimensions map[string]string
+	// registryHosts are the containerd registry mirrors and upstreams of the mirror events
+	registryHosts *RegistryHosts
+	// imageCacheDir is the containerd content store that is scanned for content written before bootTime
+	imageCacheDir string
+	// bootTime is the host boot time, which also starts the automatic search window
+	bootTime   time.Time
+	imageCache *ImageCache
+	// fastLaunch caches whether EC2 Fast Launch is enabled for the AMI of a Windows node
+	fastLaunch *bool
+	// spotSignals adds the spot interruption notice and rebalance recommendation events
+	spotSignals bool
+	// traceContext parents the emitted bootstrap trace, it is read from traceContextAnnotation if not set
+	traceContext           *TraceContext
+	traceContextAnnotation string
+	// stale marks measurements of a node that booted long before the measurer was configured, i.e. a DaemonSet rollout onto an existing fleet
+	stale bool
+	// startupGate keeps a startup taint on the node until its milestones are met
+	startupGate *StartupGate
+	// hostPathFree leaves out the sources and events that read host paths
+	hostPathFree bool
+	// chaos replaces the default sources and events with the fake events of a chaos scenario
+	chaos *chaossrc.Source
+	// eventCatalog registers the API sources without clients to document the default events, which can not be measured
+	eventCatalog bool
+	// namespaceFilter restricts the namespaces whose pods are measured across namespaces
+	namespaceFilter k8ssrc.NamespaceFilter
+	// launchTemplate and nodeGroupName are the cached launch template and node group of the instance
+	launchTemplate    *LaunchTemplate
+	nodeGroupName     string
+	nodeGroupResolved bool
+	// nodeClaimBaseline measures the creation of the node's Karpenter NodeClaim, which precedes the other events and becomes the baseline
+	nodeClaimBaseline bool
+	// findDurations is how long the Find of each event took over all measurement passes
+	findDurations map[string]*FindDuration
 }
 
 // Measurement is a specific timing produced from a Measurer run
 type Measurement struct {
 	Metadata *Metadata         `json:"metadata"`
 	Timings  []*sources.Timing `json:"timings"`
+	Tracks   []*TrackStatus    `json:"tracks,omitempty"`
+	// Stale is set when the node booted longer than the stale threshold before the measurement started
+	Stale bool `json:"stale,omitempty"`
+	// BootID is the boot that was measured by MeasureBoots
+	BootID string `json:"bootID,omitempty"`
+	// ImagePulls classifies the image pulls of the measured pods if the image pull report is enabled
+	ImagePulls *ImagePullReport `json:"imagePulls,omitempty"`
+	// Admission estimates the control plane admission cost of the measured pods if the admission report is enabled
+	Admission *AdmissionReport `json:"admission,omitempty"`
+	// Cost is the dollar cost of the bootstrap window if pricing is configured
+	Cost *Cost `json:"cost,omitempty"`
+	// Score is the composite bootstrap score if score weights are configured
+	Score *BootstrapScore `json:"score,omitempty"`
+	// Baseline is the comparison to the golden baseline if one is configured
+	Baseline *BaselineComparison `json:"baseline,omitempty"`
+	// ImageCache is the content that was cached in the containerd content store before boot if image cache detection is enabled
+	ImageCache *ImageCache `json:"imageCache,omitempty"`
+	// TraceContext is the incoming trace context the bootstrap trace is parented under
+	TraceContext *TraceContext `json:"traceContext,omitempty"`
+	// Skipped are the events that were not searched because their OnlyIf event did not match
+	Skipped []string `json:"skipped,omitempty"`
+	// Integrity is the tool version, config hash, source checksums and signature if integrity metadata is enabled
+	Integrity *Integrity `json:"integrity,omitempty"`
+	// AccessPaths is how each source read its logs, i.e. direct, helper, journalctl, or journal-gateway
+	AccessPaths map[string]string `json:"accessPaths,omitempty"`
+	// FindDurations is how long the Find of each event took, slowest first
+	FindDurations []*FindDuration `json:"findDurations,omitempty"`
+	// RegexCosts is the match cost of each regex searched in the log and journal sources, most expensive first
+	RegexCosts []*sources.RegexCost `json:"regexCosts,omitempty"`
+	// Evidence is the artifact of the matched log lines if the evidence export is enabled
+	Evidence *Evidence `json:"evidence,omitempty"`
 }
 
+// TrackStatus is the completion status of a measurement track
+type TrackStatus struct {
+	Track  string `json:"track"`
+	Status string `json:"status"`
+}
+
+// Track status consts for a TrackStatus' Status
+const (
+	TrackStatusComplete = "complete"
+	TrackStatusPending  = "pending"
+	TrackStatusTimedOut = "timed-out"
+)
+
 // Metadata provides data about the node where measurements are executed
 type Metadata struct {
 	Region           string `json:"region"`
@@ -75,6 +222,16 @@ type Metadata struct {
 	AvailabilityZone string `json:"availabilityZone"`
 	PrivateIP        string `json:"privateIP"`
 	AMIID            string `json:"amiID"`
+	// Cluster is the name of the cluster the node belongs to if it was configured
+	Cluster string `json:"cluster,omitempty"`
+	// Tags are the instance tags read from IMDS by their dimension name
+	Tags map[string]string `json:"tags,omitempty"`
+	// FastLaunch is whether EC2 Fast Launch is enabled for the AMI of a Windows node
+	FastLaunch *bool `json:"fastLaunch,omitempty"`
+	// LaunchTemplate is the launch template and version the instance was launched from, if it is known
+	LaunchTemplate *LaunchTemplate `json:"launchTemplate,omitempty"`
+	// NodeGroup is the managed node group of the node, if it is known
+	NodeGroup string `json:"nodeGroup,omitempty"`
 }
 
 // ChartOptions allows configuration of the markdown chart
@@ -82,17 +239,32 @@ type ChartOptions struct {
 	HiddenColumns []string
 }
 
+// Measurement tracks of the default events
+const (
+	// TrackNode is closed when the node is Ready
+	TrackNode = "node"
+	// TrackPod is closed when the first pod in the pod namespace is Ready
+	TrackPod = "pod"
+	// TrackCompleteMetric is the metric of the per-track completion status, labeled with the track
+	TrackCompleteMetric = "track_complete"
+)
+
 // Chart column label consts
 const (
 	ChartColumnEvent     = "Event"
 	ChartColumnTimestamp = "Timestamp"
 	ChartColumnT         = "T"
 	ChartColumnComment   = "Comment"
+	ChartColumnSinceBoot = "Since Boot"
+	ChartColumnOwner     = "Owner"
 )
 
 // Default Event regular expressions
 var (
 	vmInit                = regexp.MustCompile(`.*kernel: Linux version.*`)
+	arm64CPUBoot          = regexp.MustCompile(`.*kernel: Booting Linux on physical CPU.*`)
+	arm64GICInitialized   = regexp.MustCompile(`.*kernel: GICv[0-9]+: [0-9]+ SPIs implemented.*`)
+	arm64SMPReady         = regexp.MustCompile(`.*kernel: smp: Brought up .* CPUs.*`)
 	networkStart          = regexp.MustCompile(`.*Reached target Network \(Pre\).*`)
 	networkReady          = regexp.MustCompile(`.*Reached target Network\..*`)
 	cloudInitInitialStart = regexp.MustCompile(`.*cloud-init: Cloud-init v.* running 'init'.*`)
@@ -104,12 +276,21 @@ var (
 	kubeletStart          = regexp.MustCompile(`.*Starting Kubernetes Kubelet.*`)
 	kubeletInitialized    = regexp.MustCompile(`.*Started kubelet.*`)
 	kubeletRegistered     = regexp.MustCompile(`.*Successfully registered node.*`)
+	kubeletServingCert    = regexp.MustCompile(`.*(kubelet-serving.*Certificate expiration is|Certificate expiration is.*kubelet-serving).*`)
 	kubeProxyStart        = regexp.MustCompile(`.*CreateContainer within sandbox .*Name:kube-proxy.* returns container id.*`)
 	vpcCNIInitStart       = regexp.MustCompile(`.*CreateContainer within sandbox .*Name:aws-vpc-cni-init.* returns container id.*`)
 	awsNodeStart          = regexp.MustCompile(`.*CreateContainer within sandbox .*Name:aws-node.* returns container id.*`)
 	vpcCNIInitialized     = regexp.MustCompile(`.*Successfully copied CNI plugin binary and config file.*`)
 	nodeReady             = regexp.MustCompile(`.*event="NodeReady".*`)
+	kubeletVolumeManager  = regexp.MustCompile(`.*"Starting Kubelet Volume Manager".*`)
+	kubeletFirstPodSync   = regexp.MustCompile(`.*"SyncLoop ADD" source="api".*`)
+	kubeletPLEGEvent      = regexp.MustCompile(`.*"SyncLoop \(PLEG\): event for pod".*`)
+	cniNotReady           = regexp.MustCompile(`.*"Container runtime network not ready".*`)
 	throttled             = regexp.MustCompile(`.*Waited for .* due to client-side throttling, not priority and fairness, request: .*`)
+	dockerdStart          = regexp.MustCompile(`.*dockerd.*msg="Starting up".*`)
+	dockerdInitialized    = regexp.MustCompile(`.*dockerd.*msg="API listen on .*`)
+	dockerdContainerd     = regexp.MustCompile(`.*msg="containerd successfully booted in.*`)
+	dockerdFirstContainer = regexp.MustCompile(`.*msg="starting signal loop".*namespace=moby.*`)
 	podReadyStr           = `.*%s/.* Type:ContainerStarted.*`
 )
 
@@ -132,12 +313,31 @@ func (m *Measurer) WithEC2Client(ec2Client *ec2.Client) *Measurer {
 	return m
 }
 
+// WithAutoScalingClient is a builder func that adds an EC2 Auto Scaling client to a Measurer
+func (m *Measurer) WithAutoScalingClient(asgClient *autoscaling.Client) *Measurer {
+	m.asgClient = asgClient
+	return m
+}
+
 // WithK8sClientset is a builder func that adds a k8s clientset to a Measurer
 func (m *Measurer) WithK8sClientset(clientset *kubernetes.Clientset) *Measurer {
 	m.k8sClientset = clientset
 	return m
 }
 
+// WithNamespaceFilter restricts the namespaces whose pods are measured across namespaces, i.e. the workload pods and the sampled pod startups.
+// The pod namespace must be measured by the filter.
+func (m *Measurer) WithNamespaceFilter(filter k8ssrc.NamespaceFilter) (*Measurer, error) {
+	if err := filter.Validate(); err != nil {
+		return m, err
+	}
+	if m.podNamespace != "" && !filter.Measured(m.podNamespace) {
+		return m, fmt.Errorf("the pod namespace %s is not measured by the namespace filter", m.podNamespace)
+	}
+	m.namespaceFilter = filter
+	return m, nil
+}
+
 // WithPodNamespace sets the pod namespace that will be queried to measure pod creation to running time
 func (m *Measurer) WithPodNamespace(podNamespace string) *Measurer {
 	m.podNamespace = podNamespace
@@ -150,6 +350,152 @@ func (m *Measurer) WithNodeName(nodeName string) *Measurer {
 	return m
 }
 
+// NodeName is the name of the measured node, it is discovered via IMDS when the default sources are registered if it was not set
+func (m *Measurer) NodeName() string {
+	return m.nodeName
+}
+
+// WithSearchWindow overrides the automatic search window of sources that support it (time-sorted logs) to search only between start and end
+// A zero time keeps the automatic bound of that side of the window
+func (m *Measurer) WithSearchWindow(start time.Time, end time.Time) *Measurer {
+	m.windowStart = start
+	m.windowEnd = end
+	return m
+}
+
+// WithBootTime is a builder func that sets the host boot time, which starts the automatic search window of MeasureUntil
+func (m *Measurer) WithBootTime(bootTime time.Time) *Measurer {
+	m.bootTime = bootTime
+	return m
+}
+
+// searchWindowSlack widens the automatic search window so lines of clocks that are slightly off are still searched
+const searchWindowSlack = 5 * time.Minute
+
+// searchWindow returns the search window of the time-sorted logs. During MeasureUntil, it starts at the boot time and ends at the
+// timeout or deadline, so the lines of earlier boots and of the time after the measurement are not searched. WithSearchWindow
+// overrides either bound, and a zero time leaves the bound unbounded.
+func (m *Measurer) searchWindow() (time.Time, time.Time) {
+	start, end := m.windowStart, m.windowEnd
+	if m.measureEnd.IsZero() {
+		return start, end
+	}
+	if start.IsZero() && !m.bootTime.IsZero() {
+		start = m.bootTime.Add(-searchWindowSlack)
+	}
+	if end.IsZero() {
+		end = m.measureEnd.Add(searchWindowSlack)
+	}
+	return start, end
+}
+
+// WithDeduplication keeps only the most precise timing of events with the same metric that are registered to multiple sources, i.e. the journal and /var/log/messages
+// Timings with the same precision are picked by the order of the source names in priority, sources not in priority come last
+func (m *Measurer) WithDeduplication(priority []string) *Measurer {
+	m.dedup = true
+	m.sourcePriority = priority
+	return m
+}
+
+// WithSourceLocations sets the location of timestamps without a zone per source name, sources.DefaultLocation is used for other sources
+func (m *Measurer) WithSourceLocations(locations map[string]*time.Location) *Measurer {
+	m.sourceLocations = locations
+	return m
+}
+
+// WithStaleThreshold marks measurements as stale if the node booted more than threshold ago
+func (m *Measurer) WithStaleThreshold(bootTime time.Time, threshold time.Duration) *Measurer {
+	m.stale = time.Since(bootTime) > threshold
+	return m
+}
+
+// WithLogSource sets the source the default log events are registered to, i.e. messages.Name, journal.Name, or journal.GatewayName
+func (m *Measurer) WithLogSource(srcName string) *Measurer {
+	m.logSource = srcName
+	return m
+}
+
+// WithJournalGateway registers the systemd-journal-gatewayd source at url as a default source
+func (m *Measurer) WithJournalGateway(url string) *Measurer {
+	m.journalGatewayURL = url
+	return m
+}
+
+// WithJournal registers the local journal source, read with journalctl, as a default source
+// root is where the host filesystem is mounted and namespace is the journal namespace, empty for the default namespace
+func (m *Measurer) WithJournal(root string, namespace string) *Measurer {
+	m.journalRoot = root
+	m.journalNamespace = namespace
+	return m
+}
+
+// WithDockerd registers the dockerd source reading the Docker daemon logs at path and its default events
+func (m *Measurer) WithDockerd(path string) *Measurer {
+	m.dockerdLogPath = path
+	return m
+}
+
+// WithProfile selects the node profile of the default events, i.e. ProfileEKS, ProfileGKECOS, ProfileAKS, or ProfileOpenShift
+func (m *Measurer) WithProfile(profile string) *Measurer {
+	m.profile = profile
+	return m
+}
+
+// WithMode selects the measurement mode of the default events, i.e. ModeLaunch or ModeUpgrade
+func (m *Measurer) WithMode(mode string) *Measurer {
+	m.mode = mode
+	return m
+}
+
+// WithGCE is a builder func that adds a GCE metadata server source to a Measurer which is used for the node metadata when IMDS is not available
+func (m *Measurer) WithGCE(gce *gcesrc.Source) *Measurer {
+	m.gce = gce
+	return m
+}
+
+// WithAzure is a builder func that adds an Azure IMDS source to a Measurer which is used for the node metadata when EC2 IMDS is not available
+func (m *Measurer) WithAzure(azure *azuresrc.Source) *Measurer {
+	m.azure = azure
+	return m
+}
+
+// WithKubelet registers the local kubelet source which reads pods from the kubelet at endpoint and static pod manifests from manifestDir
+// It provides the pod milestones when the kubelet runs standalone (static pods only) without an API server
+func (m *Measurer) WithKubelet(endpoint string, manifestDir string) *Measurer {
+	m.kubeletEndpoint = endpoint
+	m.staticManifestDir = manifestDir
+	return m
+}
+
+// WithDeadline sets an overall deadline after which MeasureUntil finalizes the measurement regardless of missing events,
+// unlike the timeout it does not restart when the measurement is restarted
+func (m *Measurer) WithDeadline(deadline time.Time) *Measurer {
+	m.deadline = deadline
+	return m
+}
+
+// WithEventOwners sets the owner of the default events by their component label, i.e. cni=networking-team
+func (m *Measurer) WithEventOwners(owners map[string]string) *Measurer {
+	m.eventOwners = owners
+	return m
+}
+
+// WithNodeClaimBaseline measures the creation of the Karpenter NodeClaim that owns the node as the NodeClaim Created event.
+// Since Karpenter creates the NodeClaim before it launches the instance, the event is the first one and all timings are relative to it,
+// so the provisioner decision to pod ready latency can be compared across Karpenter versions.
+func (m *Measurer) WithNodeClaimBaseline() *Measurer {
+	m.nodeClaimBaseline = true
+	return m
+}
+
+// logSourceName returns the name of the source the default log events are registered to
+func (m *Measurer) logSourceName() string {
+	if m.logSource != "" {
+		return m.logSource
+	}
+	return messages.Name
+}
+
 // MustWithDefaultConfig registers the default sources and events to the Measurer and panics if any errors occur
 func (m *Measurer) MustWithDefaultConfig() *Measurer {
 	return lo.Must(m.RegisterDefaultSources().RegisterDefaultEvents())
@@ -169,10 +515,16 @@ func (m *Measurer) RegisterEvents(events ...*sources.Event) (*Measurer, error) {
 	for _, e := range events {
 		src, ok := m.GetSource(e.SrcName)
 		if !ok {
+			// without host paths, the events of the host path sources are left out
+			if m.hostPathFree && lo.Contains(hostPathSourceNames, e.SrcName) {
+				zap.S().Debugf("Skipping event \"%s\" since source \"%s\" reads host paths", e.Name, e.SrcName)
+				continue
+			}
 			errs = multierr.Append(errs, fmt.Errorf("unable to register event \"%s\" because source \"%s\" is not registered", e.Name, e.Src))
 			continue
 		}
 		e.Src = src
+		describeEvent(e)
 		m.events = append(m.events, e)
 	}
 	return m, errs
@@ -187,75 +539,190 @@ func (m *Measurer) GetSource(name string) (sources.Source, bool) {
 // Measure executes a single timing run with the registered sources and events
 func (m *Measurer) Measure(ctx context.Context) *Measurement {
 	var timings []*sources.Timing
-	for _, event := range m.events {
-		results, err := event.Src.Find(event)
+	m.prepareSources()
+	matched := map[string]bool{}
+	var skipped []string
+	for _, event := range m.conditionOrder() {
+		if event.OnlyIf != "" && !matched[event.OnlyIf] {
+			skipped = append(skipped, event.Name)
+			continue
+		}
+		results, err := m.findTimed(event)
 		if len(results) == 0 {
 			results = []sources.FindResult{}
 		}
 		for _, result := range results {
+			if err == nil && result.Err == nil {
+				matched[event.Name] = true
+			}
 			timings = append(timings, &sources.Timing{
 				Event:     event,
-				Timestamp: result.Timestamp,
-				Comment:   result.Comment,
+				Timestamp: result.Timestamp.UTC(),
+				Comment:   withOffset(result.Comment, result.Timestamp),
 				Error:     multierr.Append(err, result.Err),
+				Truncated: result.Truncated,
+				SinceBoot: result.SinceBoot,
+				Line:      result.Line,
 			})
 		}
 	}
+	if m.dedup {
+		timings = m.dedupTimings(timings)
+	}
 	// Sort timings so they are in chronological order
 	sort.Slice(timings, func(i, j int) bool {
 		return timings[i].Timestamp.UnixMicro() < timings[j].Timestamp.UnixMicro()
 	})
 
-	// Find the last terminal event index to filter out everything past
-	if _, lastTerminalIndex, ok := lo.FindLastIndexOf(timings, func(t *sources.Timing) bool {
-		return t.Event.Terminal
-	}); ok {
-		timings = timings[:lastTerminalIndex+1]
-	}
-	firstSuccessfulTiming := timings[0]
-	// Find first successful timing
-	for _, t := range timings {
-		if t.Error == nil {
-			firstSuccessfulTiming = t
-			break
+	// Find the last terminal event index of each track to filter out everything past it
+	lastTerminalIndex := map[string]int{}
+	for i, t := range timings {
+		if t.Event.Terminal {
+			lastTerminalIndex[t.Event.Track] = i
 		}
 	}
-	// Add normalized time delta
-	for _, t := range timings {
-		t.T = t.Timestamp.Sub(firstSuccessfulTiming.Timestamp)
+	if len(lastTerminalIndex) > 0 {
+		timings = lo.Filter(timings[:lo.Max(lo.Values(lastTerminalIndex))+1], func(t *sources.Timing, i int) bool {
+			trackEnd, ok := lastTerminalIndex[t.Event.Track]
+			return t.Event.Track == "" || !ok || i <= trackEnd
+		})
+	}
+	// No timings are found when none of the registered sources are available yet
+	if len(timings) > 0 {
+		firstSuccessfulTiming := timings[0]
+		// Find first successful timing
+		for _, t := range timings {
+			if t.Error == nil {
+				firstSuccessfulTiming = t
+				break
+			}
+		}
+		// Add normalized time delta
+		for _, t := range timings {
+			t.T = t.Timestamp.Sub(firstSuccessfulTiming.Timestamp)
+		}
 	}
 	// ignore metadata errors
 	metadata, _ := m.getMetadata(ctx)
-	return &Measurement{
-		Metadata: metadata,
-		Timings:  timings,
+	if m.clusterName != "" {
+		metadata = lo.Ternary(metadata == nil, &Metadata{}, metadata)
+		metadata.Cluster = m.clusterName
+	}
+	measurement := &Measurement{
+		Metadata:      metadata,
+		Timings:       timings,
+		Stale:         m.stale,
+		TraceContext:  m.resolveTraceContext(ctx),
+		Skipped:       skipped,
+		AccessPaths:   m.accessPaths(),
+		FindDurations: m.FindDurations(),
+		RegexCosts:    sources.Regexes.Costs(),
+	}
+	if m.priceTable != nil || m.spotPricing {
+		cost, err := m.cost(ctx, metadata, timings)
+		if err != nil {
+			zap.S().Warnf("Unable to annotate the bootstrap cost: %s", err)
+		}
+		measurement.Cost = cost
+	}
+	if len(m.scoreWeights) > 0 {
+		measurement.Score = bootstrapScore(m.scoreWeights, timings)
+	}
+	if m.baseline != nil {
+		measurement.Baseline = m.compareBaseline(timings)
+	}
+	if m.imageCacheDir != "" {
+		imageCache, err := m.scanImageCache()
+		if err != nil {
+			zap.S().Warnf("Unable to detect the image cache: %s", err)
+		}
+		measurement.ImageCache = imageCache
+	}
+	if m.imagePullReport {
+		imagePulls, err := m.imagePulls(ctx)
+		if err != nil {
+			zap.S().Warnf("Unable to report image pulls: %s", err)
+		}
+		measurement.ImagePulls = imagePulls
+	}
+	if m.admissionReport {
+		admission, err := m.admission(ctx)
+		if err != nil {
+			zap.S().Warnf("Unable to report admission: %s", err)
+		}
+		measurement.Admission = admission
+	}
+	for _, track := range m.Tracks() {
+		status := TrackStatusPending
+		if m.trackComplete(track, measurement) {
+			status = TrackStatusComplete
+		}
+		measurement.Tracks = append(measurement.Tracks, &TrackStatus{Track: track, Status: status})
+	}
+	return measurement
+}
+
+// prepareSources applies the search window and source locations to the registered sources before they are searched
+func (m *Measurer) prepareSources() {
+	windowStart, windowEnd := m.searchWindow()
+	for _, src := range m.sources {
+		if windowedSrc, ok := src.(sources.WindowedSource); ok {
+			windowedSrc.SetSearchWindow(windowStart, windowEnd)
+		}
+		if locatedSrc, ok := src.(sources.LocatedSource); ok {
+			locatedSrc.SetLocation(m.sourceLocations[locatedSrc.Name()])
+		}
 	}
 }
 
-// MeasureUntil executes timing runs with the registered sources and events until all terminal events have timings or the timeout is reached
+// MeasureUntil executes timing runs with the registered sources and events until all terminal events have timings or the timeout or deadline is reached
 func (m *Measurer) MeasureUntil(ctx context.Context, timeout time.Duration, retryDelay time.Duration) (*Measurement, error) {
 	startTime := time.Now().UTC()
 	var measurement *Measurement
-	terminalEvents := lo.CountBy(m.events, func(e *sources.Event) bool { return e.Terminal })
+	var terminalEvents int
+	completedTracks := map[string]bool{}
 	done := false
-	for !done && time.Since(startTime) < timeout {
+	endTime := startTime.Add(timeout)
+	if !m.deadline.IsZero() && m.deadline.Before(endTime) {
+		endTime = m.deadline
+	}
+	m.measureEnd = endTime
+	defer func() { m.measureEnd = time.Time{} }()
+	for !done && time.Now().Before(endTime) {
 		done = false
 		measurement = m.Measure(ctx)
 		for _, m := range measurement.Timings {
 			if m.Error != nil {
-				log.Printf("Unable to retrieve timing for Event \"%s\": %v\n", m.Event.Name, m.Error)
+				zap.S().Warnf("Unable to retrieve timing for Event \"%s\"%s: %v", m.Event.Name, eventAnnotations(m.Event), m.Error)
+			}
+			if m.Truncated {
+				zap.S().Warnf("Search for Event \"%s\" was truncated by the scan limits", m.Event.Name)
+			}
+		}
+		for _, track := range measurement.Tracks {
+			if track.Status == TrackStatusComplete && !completedTracks[track.Track] {
+				completedTracks[track.Track] = true
+				zap.S().Infof("Measurement track \"%s\" is complete", track.Track)
 			}
 		}
+		// skipped events are not waited for
+		events := m.searchedEvents(measurement)
+		terminalEvents = lo.CountBy(events, func(e *sources.Event) bool { return e.Terminal })
 		measuredEvents := lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Error == nil })
 		measuredTerminalEvents := lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Terminal && t.Error == nil })
 		// check if there are any terminal events, if so, check if they have completed successfully
 		if terminalEvents > 0 && terminalEvents == measuredTerminalEvents {
 			done = true
 			// if all events are not terminal, then try to time all events without errors until the timeout is reached.
-		} else if terminalEvents == 0 && measuredEvents >= len(m.events) {
+		} else if terminalEvents == 0 && measuredEvents >= len(events) {
 			done = true
 		}
 
+		// the measurement is not done while the startup gate keeps the node tainted, and it takes another pass to measure the taint removal
+		if m.startupGate != nil && !m.startupGate.open {
+			m.openStartupGate(ctx, measurement)
+			done = false
+		}
 		if done {
 			return measurement, nil
 		}
@@ -264,25 +731,201 @@ func (m *Measurer) MeasureUntil(ctx context.Context, timeout time.Duration, retr
 		}
 		time.Sleep(retryDelay)
 	}
+	// The deadline may have passed before the first timing run
+	if measurement == nil {
+		measurement = m.Measure(ctx)
+		terminalEvents = lo.CountBy(m.searchedEvents(measurement), func(e *sources.Event) bool { return e.Terminal })
+	}
+	if m.startupGate != nil && !m.startupGate.open {
+		m.timeoutStartupGate(ctx)
+	}
+	// Tracks that are not complete by the timeout or deadline are finalized as timed out
+	for _, track := range measurement.Tracks {
+		if track.Status == TrackStatusPending {
+			track.Status = TrackStatusTimedOut
+		}
+	}
 	if terminalEvents > 0 {
-		unmeasuredTerminalEvents := lo.Filter(m.events, func(e *sources.Event, _ int) bool {
+		unmeasuredTerminalEvents := lo.Filter(m.searchedEvents(measurement), func(e *sources.Event, _ int) bool {
 			return e.Terminal && lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Name == e.Name }) == 0
 		})
-		unmeasuredTerminalEventNames := lo.Map(unmeasuredTerminalEvents, func(e *sources.Event, _ int) string { return e.Name })
+		unmeasuredTerminalEventNames := lo.Map(unmeasuredTerminalEvents, func(e *sources.Event, _ int) string { return e.Name + eventAnnotations(e) })
 		return measurement, fmt.Errorf("unable to measure terminal events: %v", unmeasuredTerminalEventNames)
 	}
-	unmeasuredEvents := lo.Filter(m.events, func(e *sources.Event, _ int) bool {
+	unmeasuredEvents := lo.Filter(m.searchedEvents(measurement), func(e *sources.Event, _ int) bool {
 		return lo.CountBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Name == e.Name }) == 0
 	})
 	unmeasuredEventNames := lo.Map(unmeasuredEvents, func(e *sources.Event, _ int) string { return e.Name })
 	return measurement, fmt.Errorf("unable to measure events %v within timeout window", unmeasuredEventNames)
 }
 
+// conditionOrder returns the registered events ordered so that an event is searched after the event it is conditioned on with OnlyIf
+func (m *Measurer) conditionOrder() []*sources.Event {
+	depth := func(e *sources.Event) int {
+		d := 0
+		for names := map[string]bool{e.Name: true}; e.OnlyIf != "" && !names[e.OnlyIf]; d++ {
+			names[e.OnlyIf] = true
+			parent, ok := lo.Find(m.events, func(p *sources.Event) bool { return p.Name == e.OnlyIf })
+			if !ok {
+				break
+			}
+			e = parent
+		}
+		return d
+	}
+	events := append([]*sources.Event{}, m.events...)
+	sort.SliceStable(events, func(i, j int) bool { return depth(events[i]) < depth(events[j]) })
+	return events
+}
+
+// searchedEvents returns the registered events that were not skipped in the measurement
+func (m *Measurer) searchedEvents(measurement *Measurement) []*sources.Event {
+	return lo.Filter(m.events, func(e *sources.Event, _ int) bool { return !lo.Contains(measurement.Skipped, e.Name) })
+}
+
+// Tracks returns the measurement tracks of the registered terminal events, each is closed by its own terminal events
+func (m *Measurer) Tracks() []string {
+	return lo.Uniq(lo.FilterMap(m.events, func(e *sources.Event, _ int) (string, bool) { return e.Track, e.Terminal }))
+}
+
+// dedupTimings keeps the most precise successful timing of non-terminal events with the same metric found in multiple sources
+// The timestamps of the dropped alternates are recorded in the comment of the kept timing
+func (m *Measurer) dedupTimings(timings []*sources.Timing) []*sources.Timing {
+	dropped := map[*sources.Timing]bool{}
+	for _, group := range lo.GroupBy(timings, func(t *sources.Timing) string { return t.Event.Metric }) {
+		successful := lo.Filter(group, func(t *sources.Timing, _ int) bool { return t.Error == nil && !t.Event.Terminal })
+		srcNames := lo.Uniq(lo.Map(successful, func(t *sources.Timing, _ int) string { return t.Event.SrcName }))
+		// only one timing per source can be matched up, events with the "all" match selector are left as is
+		if len(srcNames) < 2 || len(srcNames) != len(successful) {
+			continue
+		}
+		sort.SliceStable(successful, func(i, j int) bool {
+			pi, pj := timestampPrecision(successful[i].Timestamp), timestampPrecision(successful[j].Timestamp)
+			if pi != pj {
+				return pi < pj
+			}
+			return m.sourceRank(successful[i].Event.SrcName) < m.sourceRank(successful[j].Event.SrcName)
+		})
+		kept := successful[0]
+		var alternates []string
+		for _, t := range successful[1:] {
+			dropped[t] = true
+			alternates = append(alternates, fmt.Sprintf("%s at %s", t.Event.SrcName, t.Timestamp.Format(time.RFC3339Nano)))
+		}
+		kept.Comment = strings.TrimSpace(fmt.Sprintf("%s (also %s)", kept.Comment, strings.Join(alternates, ", ")))
+	}
+	return lo.Filter(timings, func(t *sources.Timing, _ int) bool { return !dropped[t] })
+}
+
+// withOffset preserves the original offset of a timestamp that is not in UTC in the comment since timings are normalized to UTC
+func withOffset(comment string, ts time.Time) string {
+	if _, offset := ts.Zone(); offset == 0 {
+		return comment
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s (logged as %s)", comment, ts.Format(time.RFC3339Nano)))
+}
+
+// sourceRank is the index of the source in the source priority, sources without a priority rank last
+func (m *Measurer) sourceRank(srcName string) int {
+	if i := lo.IndexOf(m.sourcePriority, srcName); i >= 0 {
+		return i
+	}
+	return len(m.sourcePriority)
+}
+
+// timestampPrecision is the smallest unit of the timestamp that is set, i.e. a syslog timestamp has a precision of a second
+func timestampPrecision(ts time.Time) time.Duration {
+	for _, unit := range []time.Duration{time.Nanosecond, time.Microsecond, time.Millisecond} {
+		if ts.Nanosecond()%int(unit*1000) != 0 {
+			return unit
+		}
+	}
+	return time.Second
+}
+
+// MeasureBoots executes a single timing run per boot in the history of the log source, i.e. the journal of a node that rebooted during provisioning
+// Events of other sources are not restricted to a boot
+func (m *Measurer) MeasureBoots(ctx context.Context) ([]*Measurement, error) {
+	src, ok := m.GetSource(m.logSourceName())
+	if !ok {
+		return nil, fmt.Errorf("log source \"%s\" is not registered", m.logSourceName())
+	}
+	multiBootSrc, ok := src.(sources.MultiBootSource)
+	if !ok {
+		return nil, fmt.Errorf("log source \"%s\" does not hold multiple boots", src.Name())
+	}
+	boots, err := multiBootSrc.Boots()
+	if err != nil {
+		return nil, err
+	}
+	defer multiBootSrc.SetBoot("")
+	var measurements []*Measurement
+	for _, boot := range boots {
+		multiBootSrc.SetBoot(boot)
+		measurement := m.Measure(ctx)
+		measurement.BootID = boot
+		measurements = append(measurements, measurement)
+	}
+	return measurements, nil
+}
+
+// bootstrapTime is the time from the first to the last successful timing, the window in which the node is not doing useful work yet
+func bootstrapTime(timings []*sources.Timing) time.Duration {
+	var bootstrap time.Duration
+	for _, t := range timings {
+		if t.Error == nil && t.T > bootstrap {
+			bootstrap = t.T
+		}
+	}
+	return bootstrap
+}
+
+// trackComplete checks if all terminal events of a track have a successful timing in the measurement
+func (m *Measurer) trackComplete(track string, measurement *Measurement) bool {
+	return lo.EveryBy(m.searchedEvents(measurement), func(e *sources.Event) bool {
+		return !e.Terminal || e.Track != track || lo.ContainsBy(measurement.Timings, func(t *sources.Timing) bool {
+			return t.Event.Name == e.Name && t.Error == nil
+		})
+	})
+}
+
 // getMetadata populates the metadata for a Measurement
 func (m *Measurer) getMetadata(ctx context.Context) (*Metadata, error) {
 	if m.metadata != nil {
 		return m.metadata, nil
 	}
+	if m.imdsClient == nil && m.gce != nil {
+		md, err := m.gce.GetInstanceMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &Metadata{
+			Region:           md.Region,
+			InstanceType:     md.MachineType,
+			InstanceID:       md.ID,
+			AccountID:        md.ProjectID,
+			AvailabilityZone: md.Zone,
+			AMIID:            md.Image,
+			PrivateIP:        md.PrivateIP,
+			Architecture:     hostArchitecture(),
+		}, nil
+	}
+	if m.imdsClient == nil && m.azure != nil {
+		md, err := m.azure.GetInstanceMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &Metadata{
+			Region:           md.Location,
+			InstanceType:     md.VMSize,
+			InstanceID:       md.VMID,
+			AccountID:        md.SubscriptionID,
+			AvailabilityZone: md.Zone,
+			AMIID:            md.Image,
+			PrivateIP:        md.PrivateIP,
+			Architecture:     hostArchitecture(),
+		}, nil
+	}
 	if m.imdsClient == nil {
 		return nil, errors.New("imds client is nil")
 	}
@@ -290,7 +933,7 @@ func (m *Measurer) getMetadata(ctx context.Context) (*Metadata, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve instance-identity document: %w", err)
 	}
-	return &Metadata{
+	metadata := &Metadata{
 		Region:           idDoc.Region,
 		InstanceType:     idDoc.InstanceType,
 		InstanceID:       idDoc.InstanceID,
@@ -299,7 +942,15 @@ func (m *Measurer) getMetadata(ctx context.Context) (*Metadata, error) {
 		AvailabilityZone: idDoc.AvailabilityZone,
 		AMIID:            idDoc.ImageID,
 		PrivateIP:        idDoc.PrivateIP,
-	}, nil
+	}
+	if len(m.imdsTagDimensions) > 0 {
+		metadata.Tags = m.instanceTags(ctx)
+	}
+	if m.profile == ProfileWindows {
+		metadata.FastLaunch = m.fastLaunchEnabled(ctx, metadata.AMIID)
+	}
+	metadata.LaunchTemplate, metadata.NodeGroup = m.nodeGroup(ctx, metadata.InstanceID)
+	return metadata, nil
 }
 
 // Chart generates a markdown chart view of a Measurement
@@ -309,20 +960,38 @@ func (m *Measurement) Chart(opts ChartOptions) {
 			m.Metadata.InstanceID, m.Metadata.PrivateIP, m.Metadata.InstanceType, m.Metadata.Architecture,
 			m.Metadata.AvailabilityZone, m.Metadata.AMIID)
 	}
+	if m.BootID != "" {
+		fmt.Printf("#### Boot %s\n", m.BootID)
+	}
 	table := tablewriter.NewWriter(os.Stdout)
-	headers := []string{ChartColumnEvent, ChartColumnTimestamp, ChartColumnT, ChartColumnComment}
-	table.SetHeader(filterColumns(opts.HiddenColumns, headers, headers))
+	headers := []string{ChartColumnEvent, ChartColumnTimestamp, ChartColumnT, ChartColumnSinceBoot, ChartColumnOwner, ChartColumnComment}
+	hiddenColumns := append([]string{}, opts.HiddenColumns...)
+	// Only show the time since boot when a source recorded it
+	if !lo.ContainsBy(m.Timings, func(t *sources.Timing) bool { return t.SinceBoot != nil }) {
+		hiddenColumns = append(hiddenColumns, ChartColumnSinceBoot)
+	}
+	// Only show the owner when an event has one
+	if !lo.ContainsBy(m.Timings, func(t *sources.Timing) bool { return t.Event.Owner != "" }) {
+		hiddenColumns = append(hiddenColumns, ChartColumnOwner)
+	}
+	table.SetHeader(filterColumns(hiddenColumns, headers, headers))
 
 	var data [][]string
 	for _, t := range m.Timings {
 		if t.Error != nil {
-			log.Printf("Error with event \"%s\" timing: %v\n", t.Event.Name, t.Error)
+			zap.S().Errorf("Error with event \"%s\"%s timing: %v", t.Event.Name, eventAnnotations(t.Event), t.Error)
 			continue
 		}
-		data = append(data, filterColumns(opts.HiddenColumns, headers, []string{
+		sinceBoot := ""
+		if t.SinceBoot != nil {
+			sinceBoot = fmt.Sprintf("%.1fs", t.SinceBoot.Seconds())
+		}
+		data = append(data, filterColumns(hiddenColumns, headers, []string{
 			t.Event.Name,
 			t.Timestamp.Format("2006-01-02T15:04:05Z"),
 			fmt.Sprintf("%.0fs", t.T.Seconds()),
+			sinceBoot,
+			t.Event.Owner,
 			t.Comment,
 		}))
 	}
@@ -331,6 +1000,47 @@ func (m *Measurement) Chart(opts ChartOptions) {
 	table.SetCenterSeparator("|")
 	table.AppendBulk(data)
 	table.Render()
+	if len(m.Tracks) > 0 {
+		fmt.Printf("\nTracks: %s\n", strings.Join(lo.Map(m.Tracks, func(t *TrackStatus, _ int) string {
+			return fmt.Sprintf("%s (%s)", t.Track, t.Status)
+		}), ", "))
+	}
+	if len(m.Skipped) > 0 {
+		fmt.Printf("\nSkipped: %s\n", strings.Join(m.Skipped, ", "))
+	}
+	if len(m.AccessPaths) > 0 {
+		names := lo.Keys(m.AccessPaths)
+		sort.Strings(names)
+		fmt.Printf("\nAccess Paths: %s\n", strings.Join(lo.Map(names, func(name string, _ int) string {
+			return fmt.Sprintf("%s (%s)", name, m.AccessPaths[name])
+		}), ", "))
+	}
+	if len(m.FindDurations) > 0 {
+		fmt.Printf("\nSlowest Finds: %s\n", strings.Join(lo.Map(m.FindDurations[:lo.Min([]int{3, len(m.FindDurations)})], func(d *FindDuration, _ int) string {
+			return fmt.Sprintf("%s (%s, %d calls)", d.Event, d.Total.Round(time.Millisecond), d.Calls)
+		}), ", "))
+	}
+	if m.Cost != nil {
+		fmt.Printf("\nCost: %s\n", m.Cost)
+	}
+	if m.Score != nil {
+		fmt.Printf("\nBootstrap Score: %s\n", m.Score)
+	}
+	if m.ImageCache != nil {
+		fmt.Printf("\nImage Cache: %s\n", m.ImageCache)
+	}
+	if m.ImagePulls != nil {
+		m.ImagePulls.Chart()
+	}
+	if m.Baseline != nil {
+		m.Baseline.Chart()
+	}
+	if m.Admission != nil {
+		m.Admission.Chart()
+	}
+	if m.Stale {
+		fmt.Println("\nStale: the node booted before the stale threshold, the timings may not reflect a fresh node launch")
+	}
 }
 
 // filterColumns will filter out specified columns via case insensitive string matching
@@ -361,74 +1071,272 @@ func (m *Measurement) RegisterMetrics(register prometheus.Registerer, experiment
 	labels := lo.Keys(dimensions)
 
 	metricCollectors := map[string]*prometheus.GaugeVec{}
+	metricLabels := map[string][]string{}
+	for _, timing := range m.Timings {
+		metricLabels[timing.Event.Metric] = lo.Union(metricLabels[timing.Event.Metric], lo.Keys(eventLabels(timing.Event)))
+	}
 	for _, timing := range lo.UniqBy(m.Timings, func(t *sources.Timing) string { return t.Event.Metric }) {
 		collector := prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Name: timing.Event.Metric,
-		}, labels)
+		}, lo.Union(labels, metricLabels[timing.Event.Metric]))
 		if err := register.Register(collector); err != nil {
-			log.Printf("error registering metric %s: %v", timing.Event.Metric, err)
+			zap.S().Errorf("error registering metric %s: %v", timing.Event.Metric, err)
 		}
 		metricCollectors[timing.Event.Metric] = collector
 	}
 	for _, timing := range m.Timings {
 		collector, ok := metricCollectors[timing.Event.Metric]
 		if !ok {
-			log.Printf("error emitting metric for %s", timing.Event.Metric)
+			zap.S().Errorf("error emitting metric for %s", timing.Event.Metric)
 			continue
 		}
-		collector.With(dimensions).Set(timing.T.Seconds())
+		// every label of the metric must have a value, even if this event does not set it
+		values := lo.SliceToMap(metricLabels[timing.Event.Metric], func(label string) (string, string) { return label, "" })
+		collector.With(lo.Assign(values, eventDimensions(dimensions, timing.Event))).Set(timing.T.Seconds())
+	}
+	m.registerFindDurationMetrics(register)
+	if m.Score != nil {
+		scoreCollector := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        BootstrapScoreMetric,
+			Help:        "Composite bootstrap score from 0 to 100 of the weighted event metrics, 100 if all are within their targets",
+			ConstLabels: dimensions,
+		})
+		if err := register.Register(scoreCollector); err != nil {
+			zap.S().Errorf("error registering metric %s: %v", BootstrapScoreMetric, err)
+		}
+		scoreCollector.Set(m.Score.Score)
+	}
+	if len(m.Tracks) == 0 {
+		return
+	}
+	trackCollector := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: TrackCompleteMetric,
+		Help: "1 if all terminal events of the measurement track were measured, 0 if the track timed out or is pending",
+	}, append(labels, "track"))
+	if err := register.Register(trackCollector); err != nil {
+		zap.S().Errorf("error registering metric %s: %v", TrackCompleteMetric, err)
+	}
+	for _, track := range m.Tracks {
+		trackCollector.With(lo.Assign(dimensions, map[string]string{"track": track.Track})).Set(track.completeValue())
 	}
 }
 
-// EmitCloudWatchMetrics posts metric data to CloudWatch based on a Measurement
-func (m *Measurement) EmitCloudWatchMetrics(ctx context.Context, cw *cloudwatch.Client, experimentDimension string) error {
-	var errs error
+// completeValue is the value of the track complete metric
+func (t *TrackStatus) completeValue() float64 {
+	if t.Status == TrackStatusComplete {
+		return 1
+	}
+	return 0
+}
+
+// CloudWatchOptions configures the batching and pacing of the CloudWatch emission so many nodes launched together stay within the PutMetricData quota
+type CloudWatchOptions struct {
+	// BatchSize is the number of metric data per PutMetricData request, at most CloudWatchMaxBatchSize
+	BatchSize int
+	// Jitter is the upper bound of a random delay before the first request, spreading the requests of a simultaneous scale-up
+	Jitter time.Duration
+}
+
+// CloudWatchMaxBatchSize is the most metric data a PutMetricData request accepts
+const CloudWatchMaxBatchSize = 1000
+
+// NewCloudWatchClient creates a CloudWatch client with the adaptive retry mode, which rate limits the client when PutMetricData is throttled
+// and retries throttled batches up to maxAttempts instead of dropping them
+func NewCloudWatchClient(cfg aws.Config, maxAttempts int) *cloudwatch.Client {
+	return cloudwatch.NewFromConfig(cfg, func(o *cloudwatch.Options) {
+		o.Retryer = retry.NewAdaptiveMode(func(ao *retry.AdaptiveModeOptions) {
+			ao.StandardOptions = append(ao.StandardOptions, func(so *retry.StandardOptions) {
+				so.MaxAttempts = maxAttempts
+			})
+		})
+	})
+}
+
+// EmitCloudWatchMetrics posts metric data to CloudWatch based on a Measurement, in batches after a jittered delay
+func (m *Measurement) EmitCloudWatchMetrics(ctx context.Context, cw *cloudwatch.Client, experimentDimension string, opts CloudWatchOptions) error {
 	dimensions := m.metricDimensions(experimentDimension)
+	var metricData []types.MetricDatum
 	for _, timing := range m.Timings {
-		if _, err := cw.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
-			Namespace: aws.String("KubernetesNodeLatency"),
-			MetricData: []types.MetricDatum{
-				{
-					MetricName: aws.String(timing.Event.Metric),
-					Value:      aws.Float64(timing.T.Seconds()),
-					Unit:       types.StandardUnitSeconds,
-					Dimensions: lo.MapToSlice(dimensions, func(k, v string) types.Dimension {
-						return types.Dimension{
-							Name:  aws.String(k),
-							Value: aws.String(v),
-						}
-					}),
-				},
-			},
-		}); err != nil {
+		metricData = append(metricData, types.MetricDatum{
+			MetricName: aws.String(timing.Event.Metric),
+			Value:      aws.Float64(timing.T.Seconds()),
+			Unit:       types.StandardUnitSeconds,
+			Dimensions: cloudWatchDimensions(eventDimensions(dimensions, timing.Event)),
+		})
+	}
+	for _, track := range m.Tracks {
+		metricData = append(metricData, types.MetricDatum{
+			MetricName: aws.String(TrackCompleteMetric),
+			Value:      aws.Float64(track.completeValue()),
+			Unit:       types.StandardUnitCount,
+			Dimensions: cloudWatchDimensions(lo.Assign(dimensions, map[string]string{"track": track.Track})),
+		})
+	}
+	if m.Score != nil {
+		metricData = append(metricData, types.MetricDatum{
+			MetricName: aws.String(BootstrapScoreMetric),
+			Value:      aws.Float64(m.Score.Score),
+			Unit:       types.StandardUnitNone,
+			Dimensions: cloudWatchDimensions(dimensions),
+		})
+	}
+	batchSize := lo.Clamp(opts.BatchSize, 1, CloudWatchMaxBatchSize)
+	if opts.BatchSize == 0 {
+		batchSize = CloudWatchMaxBatchSize
+	}
+	var inputs []*cloudwatch.PutMetricDataInput
+	for _, batch := range lo.Chunk(metricData, batchSize) {
+		inputs = append(inputs, &cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String("KubernetesNodeLatency"),
+			MetricData: batch,
+		})
+	}
+	if ok, err := dryRun("cloudwatch", "PutMetricData", inputs); ok {
+		return err
+	}
+	if opts.Jitter > 0 {
+		jitter := time.Duration(rand.New(rand.NewSource(time.Now().UnixNano())).Int63n(int64(opts.Jitter)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jitter):
+		}
+	}
+	var errs error
+	for _, input := range inputs {
+		if _, err := cw.PutMetricData(ctx, input); err != nil {
 			errs = multierr.Append(errs, err)
 		}
 	}
 	return errs
 }
 
+// cloudWatchDimensions converts metric dimensions to CloudWatch dimensions
+func cloudWatchDimensions(dimensions map[string]string) []types.Dimension {
+	return lo.MapToSlice(dimensions, func(k, v string) types.Dimension {
+		return types.Dimension{
+			Name:  aws.String(k),
+			Value: aws.String(v),
+		}
+	})
+}
+
+// eventDimensions adds the event's labels to the metric dimensions, the default dimensions take precedence over labels with the same key
+func eventDimensions(dimensions map[string]string, event *sources.Event) map[string]string {
+	return lo.Assign(eventLabels(event), dimensions)
+}
+
+// eventLabels are the event's labels including the severity and owner when they are set
+func eventLabels(event *sources.Event) map[string]string {
+	labels := lo.Assign(event.Labels)
+	if event.Severity != "" {
+		labels["severity"] = event.Severity
+	}
+	if event.Owner != "" {
+		labels["owner"] = event.Owner
+	}
+	return labels
+}
+
+// eventAnnotations formats the severity and owner of an event for logs and errors, empty if neither is set
+func eventAnnotations(event *sources.Event) string {
+	var annotations []string
+	if event.Severity != "" {
+		annotations = append(annotations, fmt.Sprintf("severity=%s", event.Severity))
+	}
+	if event.Owner != "" {
+		annotations = append(annotations, fmt.Sprintf("owner=%s", event.Owner))
+	}
+	if len(annotations) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [%s]", strings.Join(annotations, " "))
+}
+
 // metricDimensions is a helper to construct default metric dimensions for both cloudwatch and prometheus
 func (m *Measurement) metricDimensions(experimentDimension string) map[string]string {
 	dimensions := map[string]string{
 		"experiment": experimentDimension,
 	}
 	if m.Metadata != nil {
-		dimensions = lo.Assign(dimensions, map[string]string{
+		// the instance tags can not override the default dimensions
+		dimensions = lo.Assign(dimensions, m.Metadata.Tags, map[string]string{
 			"instanceType":     m.Metadata.InstanceType,
 			"amiID":            m.Metadata.AMIID,
 			"region":           m.Metadata.Region,
 			"availabilityZone": m.Metadata.AvailabilityZone,
+			"architecture":     m.Metadata.Architecture,
 		})
 	}
+	if m.ImageCache != nil {
+		dimensions["warmImageCache"] = strconv.FormatBool(m.ImageCache.Warm)
+	}
+	if m.Metadata != nil && m.Metadata.FastLaunch != nil {
+		dimensions["fastLaunch"] = strconv.FormatBool(*m.Metadata.FastLaunch)
+	}
+	if m.Metadata != nil && m.Metadata.NodeGroup != "" {
+		dimensions["nodeGroup"] = m.Metadata.NodeGroup
+	}
+	if m.Metadata != nil && m.Metadata.LaunchTemplate != nil {
+		dimensions["launchTemplateVersion"] = m.Metadata.LaunchTemplate.Version
+	}
+	if m.Stale {
+		dimensions["stale"] = "true"
+	}
 	return dimensions
 }
 
 // RegisterDefaultSources registers the default sources to the Measurer
 func (m *Measurer) RegisterDefaultSources() *Measurer {
-	m.RegisterSources([]sources.Source{
-		messages.New(messages.DefaultPath),
-		awsnode.New(awsnode.DefaultPath),
-	}...)
+	if m.chaos != nil {
+		return m.RegisterSources(m.chaos)
+	}
+	if m.eventCatalog {
+		m.registerCatalogSources()
+	}
+	if !m.hostPathFree {
+		m.RegisterSources([]sources.Source{
+			messages.New(messages.DefaultPath),
+			awsnode.New(awsnode.DefaultPath),
+		}...)
+		if m.journalRoot != "" {
+			m.RegisterSources(journal.NewLocal(m.journalRoot, m.journalNamespace))
+		}
+		if m.dockerdLogPath != "" {
+			m.RegisterSources(dockerd.New(m.dockerdLogPath))
+		}
+		if m.profile == ProfileWindows {
+			m.RegisterSources(sysprep.New(sysprep.DefaultPath), ec2launch.New(ec2launch.DefaultPath))
+		}
+		if m.profile == ProfileEKS {
+			m.RegisterSources(cloudinitsrc.New(cloudinitsrc.DefaultPath))
+		}
+	}
+	if m.journalGatewayURL != "" {
+		m.RegisterSources(journal.NewGateway(m.journalGatewayURL))
+	}
+	if m.egressProbeURL != "" {
+		m.RegisterSources(egresssrc.New(m.egressProbeURL))
+	}
+	if m.kubeletEndpoint != "" {
+		m.RegisterSources(kubeletsrc.New(m.kubeletEndpoint, m.staticManifestDir, m.podNamespace))
+	}
+	// the CRI socket is a host path, the source is left out if the socket is missing so nodes without it still measure the log events
+	if m.criEndpoint != "" && !m.hostPathFree {
+		criSrc := crisrc.New(m.criEndpoint, m.criContentDir, m.podNamespace)
+		criSrc.SetNamespaceFilter(m.namespaceFilter)
+		if err := criSrc.Available(); err != nil {
+			zap.S().Infof("Not measuring the CRI events: %s", err)
+		} else {
+			m.RegisterSources(criSrc)
+		}
+	}
+	if m.gce != nil {
+		m.RegisterSources(m.gce)
+	}
+	if m.azure != nil {
+		m.RegisterSources(m.azure)
+	}
 	if m.imdsClient != nil {
 		m.RegisterSources(imdssrc.New(m.imdsClient))
 	}
@@ -437,160 +1345,239 @@ func (m *Measurer) RegisterDefaultSources() *Measurer {
 		if m.imdsClient != nil {
 			md, err := m.getMetadata(context.TODO())
 			if err != nil {
-				log.Printf("unable to retrieve instance-id to register the ec2 event source: %s", err)
+				zap.S().Warnf("unable to retrieve instance-id to register the ec2 event source: %s", err)
 			} else {
 				instanceID = md.InstanceID
 			}
 		}
 		m.RegisterSources(ec2src.New(m.ec2Client, instanceID, m.nodeName))
 	}
+	// the Auto Scaling group of the instance is looked up by its instance-id which is only available from IMDS
+	if m.asgClient != nil && m.imdsClient != nil {
+		md, err := m.getMetadata(context.TODO())
+		if err != nil {
+			zap.S().Warnf("unable to retrieve instance-id to register the auto scaling event source: %s", err)
+		} else {
+			m.RegisterSources(asgsrc.New(m.asgClient, md.InstanceID))
+		}
+	}
 	if m.k8sClientset != nil && m.podNamespace != "" {
 		if m.nodeName == "" && m.imdsClient != nil {
 			out, err := m.imdsClient.GetMetadata(context.TODO(), &imds.GetMetadataInput{Path: "/hostname"})
 			if err != nil {
-				log.Printf("unable to register K8s source because node name is required and is unable to be retrieved via EC2 IMDS: %v\n", err)
+				zap.S().Warnf("unable to register K8s source because node name is required and is unable to be retrieved via EC2 IMDS: %v", err)
 			}
 			dnsName, err := io.ReadAll(out.Content)
 			if err != nil {
-				log.Printf("unable to register K8s source because node name is required and is unable to be read via EC2 IMDS: %v\n", err)
+				zap.S().Warnf("unable to register K8s source because node name is required and is unable to be read via EC2 IMDS: %v", err)
 			}
 			m.nodeName = string(dnsName)
 		}
 		if m.nodeName != "" {
-			m.RegisterSources(k8ssrc.New(m.k8sClientset, m.nodeName, m.podNamespace))
+			k8sSrc := k8ssrc.New(m.k8sClientset, m.nodeName, m.podNamespace)
+			k8sSrc.SetNamespaceFilter(m.namespaceFilter)
+			m.RegisterSources(k8sSrc)
 		}
 	}
+	m.fallbackLogSource()
 	return m
 }
 
 // RegisterDefaultEvents registers all default events shipped
 func (m *Measurer) RegisterDefaultEvents() (*Measurer, error) {
-	return m.RegisterEvents([]*sources.Event{
-		{
-			Name:          "Pod Created",
-			Metric:        "pod_created",
-			SrcName:       k8ssrc.Name,
-			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(k8ssrc.Name)).(*k8ssrc.Source).FindPodCreationTime(),
-		},
-		{
-			Name:          "Fleet Requested",
-			Metric:        "fleet_requested",
-			SrcName:       ec2src.Name,
-			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(ec2src.Name)).(*ec2src.Source).FindFleetStart(),
-		},
-		{
-			Name:          "Instance Pending",
-			Metric:        "instance_pending",
-			SrcName:       imdssrc.Name,
-			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(imdssrc.Name)).(*imdssrc.Source).FindByPath(imdssrc.PendingTime),
-		},
+	if m.chaos != nil {
+		return m.RegisterEvents(m.withDefaultLabels(m.chaos.Events())...)
+	}
+	// without host paths and a journal gateway, only the reduced event set of the API sources is measured
+	if _, ok := m.GetSource(m.logSourceName()); m.hostPathFree && (!ok || m.profile == ProfileWindows) {
+		return m.RegisterEvents(m.withDefaultLabels(m.hostPathFreeEvents())...)
+	}
+	if m.mode == ModeUpgrade {
+		return m.RegisterEvents(m.withDefaultLabels(m.upgradeEvents())...)
+	}
+	switch m.profile {
+	case ProfileGKECOS:
+		return m.RegisterEvents(m.withDefaultLabels(m.gkeCOSEvents())...)
+	case ProfileAKS:
+		return m.RegisterEvents(m.withDefaultLabels(m.aksEvents())...)
+	case ProfileOpenShift:
+		return m.RegisterEvents(m.withDefaultLabels(m.openShiftEvents())...)
+	case ProfileWindows:
+		return m.RegisterEvents(m.withDefaultLabels(m.windowsEvents())...)
+	}
+	logSrc := m.logSourceName()
+	logFindByRegex := lo.Must(m.GetSource(logSrc)).(sources.RegexSource).FindByRegex
+	// API events are only registered if their source is, so the log events can be measured without an API server or outside of EC2
+	events := m.podCreatedEvent()
+	events = append(events, m.ec2Events()...)
+	events = append(events, []*sources.Event{
 		{
 			Name:          "VM Initialized",
 			Metric:        "vm_initialized",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(vmInit),
+			FindFn:        logFindByRegex(vmInit),
+			Regex:         vmInit.String(),
 		},
 		{
 			Name:          "Network Start",
 			Metric:        "network_start",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(networkStart),
+			FindFn:        logFindByRegex(networkStart),
+			Regex:         networkStart.String(),
 		},
 		{
 			Name:          "Network Ready",
 			Metric:        "network_ready",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(networkReady),
+			FindFn:        logFindByRegex(networkReady),
+			Regex:         networkReady.String(),
 		},
 		{
 			Name:          "Cloud-Init Initial Start",
 			Metric:        "cloudinit_initial_start",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(cloudInitInitialStart),
+			FindFn:        logFindByRegex(cloudInitInitialStart),
+			Regex:         cloudInitInitialStart.String(),
 		},
 		{
 			Name:          "Cloud-Init Config Start",
 			Metric:        "cloudinit_config_start",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(cloudInitConfigStart),
+			FindFn:        logFindByRegex(cloudInitConfigStart),
+			Regex:         cloudInitConfigStart.String(),
 		},
 		{
 			Name:          "Cloud-Init Final Start",
 			Metric:        "cloudinit_final_start",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(cloudInitFinalStart),
+			FindFn:        logFindByRegex(cloudInitFinalStart),
+			Regex:         cloudInitFinalStart.String(),
 		},
 		{
 			Name:          "Cloud-Init Final Finish",
 			Metric:        "cloudinit_final_finish",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(cloudInitFinalFinish),
+			FindFn:        logFindByRegex(cloudInitFinalFinish),
+			Regex:         cloudInitFinalFinish.String(),
 		},
 		{
 			Name:          "Containerd Start",
-			Metric:        "conatinerd_start",
-			SrcName:       messages.Name,
+			Metric:        "containerd_start",
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(containerdStart),
+			FindFn:        logFindByRegex(containerdStart),
+			Regex:         containerdStart.String(),
 		},
 		{
 			Name:          "Containerd Initialized",
-			Metric:        "conatinerd_initialized",
-			SrcName:       messages.Name,
+			Metric:        "containerd_initialized",
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(containerdInitialized),
+			FindFn:        logFindByRegex(containerdInitialized),
+			Regex:         containerdInitialized.String(),
 		},
 		{
 			Name:          "Kubelet Start",
 			Metric:        "kubelet_start",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(kubeletStart),
+			FindFn:        logFindByRegex(kubeletStart),
+			Regex:         kubeletStart.String(),
 		},
 		{
 			Name:          "Kubelet Initialized",
 			Metric:        "kubelet_initialized",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(kubeletInitialized),
+			FindFn:        logFindByRegex(kubeletInitialized),
+			Regex:         kubeletInitialized.String(),
 		},
 		{
 			Name:          "Kubelet Registered",
 			Metric:        "kubelet_registered",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        logFindByRegex(kubeletRegistered),
+			Regex:         kubeletRegistered.String(),
+		},
+		{
+			Name:          "Kubelet Serving Certificate Issued",
+			Metric:        "kubelet_serving_certificate_issued",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        logFindByRegex(kubeletServingCert),
+			Regex:         kubeletServingCert.String(),
+		},
+		{
+			Name:          "Kubelet Volume Manager Started",
+			Metric:        "kubelet_volume_manager_started",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        logFindByRegex(kubeletVolumeManager),
+			Regex:         kubeletVolumeManager.String(),
+		},
+		{
+			Name:          "Kubelet First Pod Sync",
+			Metric:        "kubelet_first_pod_sync",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        logFindByRegex(kubeletFirstPodSync),
+			Regex:         kubeletFirstPodSync.String(),
+		},
+		{
+			Name:          "Kubelet PLEG First Event",
+			Metric:        "kubelet_pleg_first_event",
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(kubeletRegistered),
+			FindFn:        logFindByRegex(kubeletPLEGEvent),
+			Regex:         kubeletPLEGEvent.String(),
+		},
+		{
+			Name:          "CNI First Not Ready",
+			Metric:        "cni_first_not_ready",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        logFindByRegex(cniNotReady),
+			Regex:         cniNotReady.String(),
+		},
+		{
+			Name:          "CNI Last Not Ready",
+			Metric:        "cni_last_not_ready",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorLast,
+			FindFn:        logFindByRegex(cniNotReady),
+			Regex:         cniNotReady.String(),
 		},
 		{
 			Name:          "Kube-Proxy Start",
 			Metric:        "kube_proxy_start",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(kubeProxyStart),
+			FindFn:        logFindByRegex(kubeProxyStart),
+			Regex:         kubeProxyStart.String(),
 		},
 		{
 			Name:          "VPC CNI Init Start",
 			Metric:        "vpc_cni_init_start",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(vpcCNIInitStart),
+			FindFn:        logFindByRegex(vpcCNIInitStart),
+			Regex:         vpcCNIInitStart.String(),
 		},
 		{
 			Name:          "AWS Node Start",
 			Metric:        "aws_node_start",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(awsNodeStart),
+			FindFn:        logFindByRegex(awsNodeStart),
+			Regex:         awsNodeStart.String(),
 		},
 		{
 			Name:          "VPC CNI Plugin Initialized",
@@ -598,30 +1585,121 @@ func (m *Measurer) RegisterDefaultEvents() (*Measurer, error) {
 			SrcName:       awsnode.Name,
 			MatchSelector: sources.EventMatchSelectorFirst,
 			FindFn:        lo.Must(m.GetSource(awsnode.Name)).(*awsnode.Source).FindByRegex(vpcCNIInitialized),
+			Regex:         vpcCNIInitialized.String(),
 		},
 		{
 			Name:          "Kube-APIServer Throttled",
 			Metric:        "kube_apiserver_throttled",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			MatchSelector: sources.EventMatchSelectorAll,
 			CommentFn:     sources.CommentMatchedLine(),
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(throttled),
+			FindFn:        logFindByRegex(throttled),
+			Regex:         throttled.String(),
 		},
 		{
 			Name:          "Node Ready",
 			Metric:        "node_ready",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			Terminal:      true,
+			Track:         TrackNode,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(nodeReady),
+			FindFn:        logFindByRegex(nodeReady),
+			Regex:         nodeReady.String(),
 		},
 		{
 			Name:          "Pod Ready",
 			Metric:        "pod_ready",
-			SrcName:       messages.Name,
+			SrcName:       logSrc,
 			Terminal:      true,
+			Track:         TrackPod,
 			MatchSelector: sources.EventMatchSelectorFirst,
-			FindFn:        lo.Must(m.GetSource(messages.Name)).(*messages.Source).FindByRegex(regexp.MustCompile(fmt.Sprintf(podReadyStr, m.podNamespace))),
+			FindFn:        logFindByRegex(regexp.MustCompile(fmt.Sprintf(podReadyStr, m.podNamespace))),
+			Regex:         fmt.Sprintf(podReadyStr, m.podNamespace),
 		},
 	}...)
+	events = append(events, m.nodeConditionEvents()...)
+	events = append(events, m.kubeletEvents()...)
+	events = append(events, m.criEvents()...)
+	events = append(events, m.reachabilityEvents()...)
+	events = append(events, m.registryMirrorEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.volumeEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.podObjectEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.readinessProbeEvents(logSrc, logFindByRegex)...)
+	if hostArchitecture() == ArchitectureARM64 {
+		events = append(events, arm64Events(logSrc, logFindByRegex)...)
+	}
+	// dockerd events are only registered on nodes running the Docker daemon so containerd nodes do not report them as missing
+	if dockerdSrc, ok := m.GetSource(dockerd.Name); ok {
+		dockerdFindByRegex := dockerdSrc.(*dockerd.Source).FindByRegex
+		events = append(events, []*sources.Event{
+			{
+				Name:          "Dockerd Start",
+				Metric:        "dockerd_start",
+				SrcName:       dockerd.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        dockerdFindByRegex(dockerdStart),
+				Regex:         dockerdStart.String(),
+			},
+			{
+				Name:          "Dockerd Containerd Ready",
+				Metric:        "dockerd_containerd_ready",
+				SrcName:       dockerd.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        dockerdFindByRegex(dockerdContainerd),
+				Regex:         dockerdContainerd.String(),
+			},
+			{
+				Name:          "Dockerd Initialized",
+				Metric:        "dockerd_initialized",
+				SrcName:       dockerd.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        dockerdFindByRegex(dockerdInitialized),
+				Regex:         dockerdInitialized.String(),
+			},
+			{
+				Name:          "Dockerd First Container Start",
+				Metric:        "dockerd_first_container_start",
+				SrcName:       dockerd.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        dockerdFindByRegex(dockerdFirstContainer),
+				Regex:         dockerdFirstContainer.String(),
+			},
+		}...)
+	}
+	return m.RegisterEvents(m.withDefaultLabels(events)...)
+}
+
+// ec2Events are the EC2 API, IMDS and Auto Scaling events of the instance launch, which are only registered if their source is
+func (m *Measurer) ec2Events() []*sources.Event {
+	var events []*sources.Event
+	if ec2Src, ok := m.GetSource(ec2src.Name); ok {
+		events = append(events, &sources.Event{
+			Name:          "Fleet Requested",
+			Metric:        "fleet_requested",
+			SrcName:       ec2src.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        ec2Src.(*ec2src.Source).FindFleetStart(),
+		})
+	}
+	if imdsSrc, ok := m.GetSource(imdssrc.Name); ok {
+		events = append(events, &sources.Event{
+			Name:          "Instance Pending",
+			Metric:        "instance_pending",
+			SrcName:       imdssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        imdsSrc.(*imdssrc.Source).FindByPath(imdssrc.PendingTime),
+		})
+	}
+	if asgSrc, ok := m.GetSource(asgsrc.Name); ok {
+		events = append(events, &sources.Event{
+			Name:          "ASG Launch Completed",
+			Metric:        "asg_launch_completed",
+			SrcName:       asgsrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     asgsrc.CommentStatus(),
+			FindFn:        asgSrc.(*asgsrc.Source).FindLaunchActivity(),
+		})
+	}
+	events = append(events, m.spotSignalEvents()...)
+	return events
 }
@@ -0,0 +1,143 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+func TestTimestampPrecision(t *testing.T) {
+	base := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name string
+		ts   time.Time
+		want time.Duration
+	}{
+		{name: "seconds", ts: base, want: time.Second},
+		{name: "milliseconds", ts: base.Add(123 * time.Millisecond), want: time.Millisecond},
+		{name: "trailing zero milliseconds", ts: base.Add(100 * time.Millisecond), want: time.Millisecond},
+		{name: "microseconds", ts: base.Add(123456 * time.Microsecond), want: time.Microsecond},
+		{name: "nanoseconds", ts: base.Add(123456789), want: time.Nanosecond},
+		{name: "a single nanosecond", ts: base.Add(1), want: time.Nanosecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := timestampPrecision(tc.ts); got != tc.want {
+				t.Errorf("timestampPrecision() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDedupTimings(t *testing.T) {
+	base := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	timing := func(metric string, srcName string, ts time.Time, terminal bool, failed bool) *sources.Timing {
+		result := &sources.Timing{Event: &sources.Event{Metric: metric, SrcName: srcName, Terminal: terminal}, Timestamp: ts}
+		if failed {
+			result.Error = errors.New("no match")
+		}
+		return result
+	}
+	// key identifies a timing by its source and timestamp
+	key := func(timing *sources.Timing) string {
+		return timing.Event.SrcName + "@" + timing.Timestamp.Format(time.RFC3339Nano)
+	}
+	for _, tc := range []struct {
+		name        string
+		priority    []string
+		timings     []*sources.Timing
+		want        []string
+		wantComment string
+	}{
+		{
+			name: "the most precise timing is kept",
+			timings: []*sources.Timing{
+				timing("kubelet_start", "messages", base, false, false),
+				timing("kubelet_start", "journal", base.Add(250*time.Millisecond), false, false),
+			},
+			want:        []string{"journal@2024-01-15T10:00:00.25Z"},
+			wantComment: "(also messages at 2024-01-15T10:00:00Z)",
+		},
+		{
+			name:     "the source priority breaks precision ties",
+			priority: []string{"messages", "journal"},
+			timings: []*sources.Timing{
+				timing("kubelet_start", "journal", base.Add(time.Second), false, false),
+				timing("kubelet_start", "messages", base, false, false),
+			},
+			want:        []string{"messages@2024-01-15T10:00:00Z"},
+			wantComment: "(also journal at 2024-01-15T10:00:01Z)",
+		},
+		{
+			name: "sources without a priority keep their order",
+			timings: []*sources.Timing{
+				timing("kubelet_start", "journal", base.Add(time.Second), false, false),
+				timing("kubelet_start", "messages", base, false, false),
+			},
+			want: []string{"journal@2024-01-15T10:00:01Z"},
+		},
+		{
+			name: "failed timings are kept and not counted",
+			timings: []*sources.Timing{
+				timing("kubelet_start", "messages", base, false, false),
+				timing("kubelet_start", "journal", base, false, true),
+			},
+			want: []string{"messages@2024-01-15T10:00:00Z", "journal@2024-01-15T10:00:00Z"},
+		},
+		{
+			name: "terminal events are not deduplicated",
+			timings: []*sources.Timing{
+				timing("node_ready", "messages", base, true, false),
+				timing("node_ready", "journal", base.Add(time.Millisecond), true, false),
+			},
+			want: []string{"messages@2024-01-15T10:00:00Z", "journal@2024-01-15T10:00:00.001Z"},
+		},
+		{
+			name: "events with multiple matches of a source are left as is",
+			timings: []*sources.Timing{
+				timing("container_start", "messages", base, false, false),
+				timing("container_start", "messages", base.Add(time.Second), false, false),
+				timing("container_start", "journal", base.Add(time.Millisecond), false, false),
+			},
+			want: []string{"messages@2024-01-15T10:00:00Z", "messages@2024-01-15T10:00:01Z", "journal@2024-01-15T10:00:00.001Z"},
+		},
+		{
+			name: "metrics are deduplicated separately",
+			timings: []*sources.Timing{
+				timing("kubelet_start", "messages", base, false, false),
+				timing("containerd_start", "messages", base.Add(time.Second), false, false),
+				timing("kubelet_start", "journal", base.Add(time.Millisecond), false, false),
+			},
+			want: []string{"messages@2024-01-15T10:00:01Z", "journal@2024-01-15T10:00:00.001Z"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := New().WithDeduplication(tc.priority)
+			got := m.dedupTimings(tc.timings)
+			if keys := lo.Map(got, func(timing *sources.Timing, _ int) string { return key(timing) }); strings.Join(keys, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("dedupTimings() = %v, want %v", keys, tc.want)
+			}
+			if tc.wantComment != "" && got[0].Comment != tc.wantComment {
+				t.Errorf("dedupTimings() comment = %q, want %q", got[0].Comment, tc.wantComment)
+			}
+		})
+	}
+}
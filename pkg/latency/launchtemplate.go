@@ -0,0 +1,72 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Tags EC2 and EKS set on the instances launched from a launch template, i.e. of a managed node group, and the node group label of EKS
+const (
+	LaunchTemplateIDTag      = "aws:ec2launchtemplate:id"
+	LaunchTemplateVersionTag = "aws:ec2launchtemplate:version"
+	NodeGroupTag             = "eks:nodegroup-name"
+	NodeGroupLabel           = "eks.amazonaws.com/nodegroup"
+)
+
+// LaunchTemplate is the launch template and version the instance was launched from
+type LaunchTemplate struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// nodeGroup returns the launch template of the instance and its node group, from the instance tags or the node group label of the node.
+// The result is cached, the launch template is nil and the node group is empty if they are unknown.
+func (m *Measurer) nodeGroup(ctx context.Context, instanceID string) (*LaunchTemplate, string) {
+	if m.nodeGroupResolved {
+		return m.launchTemplate, m.nodeGroupName
+	}
+	m.nodeGroupResolved = true
+	if m.ec2Client != nil && instanceID != "" {
+		out, err := m.ec2Client.DescribeTags(ctx, &ec2.DescribeTagsInput{Filters: []types.Filter{
+			{Name: aws.String("resource-id"), Values: []string{instanceID}},
+			{Name: aws.String("key"), Values: []string{LaunchTemplateIDTag, LaunchTemplateVersionTag, NodeGroupTag}},
+		}})
+		if err != nil {
+			zap.S().Warnf("Unable to describe the launch template tags of %s: %s", instanceID, err)
+		} else {
+			tags := map[string]string{}
+			for _, tag := range out.Tags {
+				tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			if tags[LaunchTemplateIDTag] != "" {
+				m.launchTemplate = &LaunchTemplate{ID: tags[LaunchTemplateIDTag], Version: tags[LaunchTemplateVersionTag]}
+			}
+			m.nodeGroupName = tags[NodeGroupTag]
+		}
+	}
+	if m.nodeGroupName == "" && m.k8sClientset != nil && m.nodeName != "" {
+		if node, err := m.k8sClientset.CoreV1().Nodes().Get(ctx, m.nodeName, metav1.GetOptions{}); err == nil {
+			m.nodeGroupName = node.Labels[NodeGroupLabel]
+		}
+	}
+	return m.launchTemplate, m.nodeGroupName
+}
@@ -0,0 +1,32 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+
+	asgsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/asg"
+)
+
+// CompleteLifecycleHook completes the launching lifecycle hook of the node's Auto Scaling group with the result (CONTINUE or ABANDON),
+// so the instance only goes InService once its readiness was measured
+func (m *Measurer) CompleteLifecycleHook(ctx context.Context, hookName string, result string) error {
+	asgSrc, ok := m.GetSource(asgsrc.Name)
+	if !ok {
+		return fmt.Errorf("completing a lifecycle hook requires the EC2 Auto Scaling source")
+	}
+	return asgSrc.(*asgsrc.Source).CompleteLifecycleAction(ctx, hookName, result)
+}
@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// DefaultContainerdHostsDir is the containerd registry config_path of the EKS optimized AMIs
+const DefaultContainerdHostsDir = "/etc/containerd/certs.d"
+
+var (
+	hostsTOMLServer = regexp.MustCompile(`^\s*server\s*=\s*"([^"]+)"`)
+	hostsTOMLHost   = regexp.MustCompile(`^\s*\[host\."([^"]+)"\]`)
+)
+
+// RegistryHosts are the mirror and upstream registry hosts configured in the containerd hosts.toml files
+type RegistryHosts struct {
+	Mirrors   []string
+	Upstreams []string
+}
+
+// LoadRegistryHosts reads the <registry>/hosts.toml files of a containerd hosts directory. The [host."..."] tables are the mirrors,
+// the server and the registry of the directory are the upstreams.
+func LoadRegistryHosts(dir string) (*RegistryHosts, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "hosts.toml"))
+	if err != nil {
+		return nil, err
+	}
+	hosts := &RegistryHosts{}
+	for _, path := range paths {
+		if registry := filepath.Base(filepath.Dir(path)); registry != "_default" {
+			hosts.Upstreams = append(hosts.Upstreams, registry)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if match := hostsTOMLServer.FindStringSubmatch(scanner.Text()); match != nil {
+				hosts.Upstreams = append(hosts.Upstreams, hostOf(match[1]))
+			} else if match := hostsTOMLHost.FindStringSubmatch(scanner.Text()); match != nil {
+				hosts.Mirrors = append(hosts.Mirrors, hostOf(match[1]))
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", path, err)
+		}
+	}
+	hosts.Mirrors = lo.Uniq(hosts.Mirrors)
+	// a mirror that is also a server is not an upstream
+	hosts.Upstreams = lo.Without(lo.Uniq(hosts.Upstreams), hosts.Mirrors...)
+	sort.Strings(hosts.Mirrors)
+	sort.Strings(hosts.Upstreams)
+	return hosts, nil
+}
+
+// hostOf is the host:port of a registry URL as it is logged by the containerd resolver, the host itself if it is not a URL
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return strings.TrimSuffix(rawURL, "/")
+}
+
+// WithRegistryHosts is a builder func that adds the events of pulls served by the registry mirrors or upstreams
+func (m *Measurer) WithRegistryHosts(hosts *RegistryHosts) *Measurer {
+	m.registryHosts = hosts
+	return m
+}
+
+// registryMirrorEvents are the first image layer fetched from a mirror and from an upstream, and the fallbacks from a mirror to the
+// next host, which show whether a pull-through cache engaged during the bootstrap. They are matched in the containerd resolver
+// records which are only logged with the containerd debug log level.
+func (m *Measurer) registryMirrorEvents(logSrc string, findByRegex func(*regexp.Regexp) sources.FindFunc) []*sources.Event {
+	if m.registryHosts == nil {
+		return nil
+	}
+	var events []*sources.Event
+	if len(m.registryHosts.Mirrors) > 0 {
+		fallback := resolverRegex("trying next host", m.registryHosts.Mirrors)
+		events = append(events,
+			logEvent(logSrc, findByRegex, "Registry Mirror First Fetch", "containerd_mirror_first_fetch", resolverRegex("fetch response received", m.registryHosts.Mirrors)),
+			&sources.Event{
+				Name:          "Registry Mirror Fallback",
+				Metric:        "containerd_mirror_fallback",
+				SrcName:       logSrc,
+				MatchSelector: sources.EventMatchSelectorAll,
+				CommentFn:     sources.CommentMatchedLine(),
+				FindFn:        findByRegex(fallback),
+				Regex:         fallback.String(),
+			},
+		)
+	}
+	if len(m.registryHosts.Upstreams) > 0 {
+		events = append(events, logEvent(logSrc, findByRegex, "Registry Upstream First Fetch", "containerd_upstream_first_fetch", resolverRegex("fetch response received", m.registryHosts.Upstreams)))
+	}
+	return events
+}
+
+// resolverRegex matches a containerd resolver record with the msg for one of the hosts, i.e. msg="fetch response received" host=mirror:5000
+func resolverRegex(msg string, hosts []string) *regexp.Regexp {
+	quoted := lo.Map(hosts, func(host string, _ int) string { return regexp.QuoteMeta(host) })
+	return regexp.MustCompile(fmt.Sprintf(`.*msg="%s".*\bhost="?(%s)"?(\s|$).*`, regexp.QuoteMeta(msg), strings.Join(quoted, "|")))
+}
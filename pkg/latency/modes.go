@@ -0,0 +1,86 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+)
+
+// Measurement modes select what the default events measure
+const (
+	// ModeLaunch measures a node launch from the instance request until the node is Ready
+	ModeLaunch = "launch"
+	// ModeUpgrade measures an in-place node upgrade from the drain or the runtime restart until the node is Ready and the workloads are rescheduled
+	ModeUpgrade = "upgrade"
+)
+
+// Modes are the supported measurement modes
+var Modes = []string{ModeLaunch, ModeUpgrade}
+
+// upgradeEvents are the default events of ModeUpgrade. The last restarts are used since the log includes the previous starts of the node.
+// The drain is the baseline when it is found, otherwise the first restart is.
+func (m *Measurer) upgradeEvents() []*sources.Event {
+	logSrc := m.logSourceName()
+	findByRegex := lo.Must(m.GetSource(logSrc)).(sources.RegexSource).FindByRegex
+	var events []*sources.Event
+	k8sSrc, hasK8s := m.GetSource(k8ssrc.Name)
+	if hasK8s {
+		events = append(events, &sources.Event{
+			Name:          "Drain Start",
+			Metric:        "drain_start",
+			SrcName:       k8ssrc.Name,
+			MatchSelector: sources.EventMatchSelectorLast,
+			FindFn:        k8sSrc.(*k8ssrc.Source).FindNodeEvent("NodeNotSchedulable"),
+		})
+	}
+	events = append(events,
+		logEvent(logSrc, findByRegex, "Containerd Restart", "containerd_restart", containerdStart),
+		logEvent(logSrc, findByRegex, "Containerd Restarted", "containerd_restarted", containerdInitialized),
+		logEvent(logSrc, findByRegex, "Kubelet Restart", "kubelet_restart", kubeletStart),
+		logEvent(logSrc, findByRegex, "Kubelet Restarted", "kubelet_restarted", kubeletInitialized),
+		&sources.Event{
+			Name:          "Node Ready",
+			Metric:        "node_ready",
+			SrcName:       logSrc,
+			Terminal:      true,
+			Track:         TrackNode,
+			MatchSelector: sources.EventMatchSelectorLast,
+			FindFn:        findByRegex(nodeReady),
+			Regex:         nodeReady.String(),
+		},
+	)
+	if hasK8s {
+		events = append(events, &sources.Event{
+			Name:          "Workloads Rescheduled",
+			Metric:        "workloads_rescheduled",
+			SrcName:       k8ssrc.Name,
+			Terminal:      true,
+			Track:         TrackNode,
+			MatchSelector: sources.EventMatchSelectorLast,
+			CommentFn:     k8ssrc.CommentReason(),
+			FindFn:        k8sSrc.(*k8ssrc.Source).FindWorkloadPodsRunning(),
+		})
+	}
+	// the drain of a spot node is correlated with its interruption notice
+	events = append(events, m.spotSignalEvents()...)
+	// logEvent matches the first occurrence which is the launch of the node
+	for _, event := range events {
+		event.MatchSelector = sources.EventMatchSelectorLast
+	}
+	return events
+}
@@ -0,0 +1,119 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	// NodeAnnotationPrefix is the prefix of the annotations and label patched onto the node, i.e. node-latency.k8s.aws/pod-ready-seconds
+	NodeAnnotationPrefix = "node-latency.k8s.aws/"
+	// BootstrapBucketLabel is the label with the bucketed bootstrap time, i.e. node-latency.k8s.aws/bootstrap=lt-60s
+	BootstrapBucketLabel = NodeAnnotationPrefix + "bootstrap"
+	// BootstrapBuckets are the upper bounds of the bootstrap time buckets of the BootstrapBucketLabel
+	BootstrapBuckets = []time.Duration{30 * time.Second, 60 * time.Second, 120 * time.Second, 300 * time.Second}
+)
+
+// PatchNode annotates the node with the seconds of every measured event, i.e. node-latency.k8s.aws/pod-ready-seconds: "42.1",
+// and optionally labels it with the bucketed bootstrap time so schedulers, deprovisioners and humans see the boot cost on the object
+func (m *Measurement) PatchNode(ctx context.Context, clientset kubernetes.Interface, nodeName string, bucketLabel bool) error {
+	if nodeName == "" {
+		return fmt.Errorf("the node name is not known")
+	}
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	if len(timings) == 0 {
+		return fmt.Errorf("there are no timings")
+	}
+	annotations := map[string]string{}
+	for _, timing := range timings {
+		key := fmt.Sprintf("%s%s-seconds", NodeAnnotationPrefix, strings.ReplaceAll(timing.Event.Metric, "_", "-"))
+		if _, ok := annotations[key]; !ok {
+			annotations[key] = fmt.Sprintf("%.1f", timing.T.Seconds())
+		}
+	}
+	metadata := map[string]interface{}{"annotations": annotations}
+	if bucketLabel {
+		metadata["labels"] = map[string]string{BootstrapBucketLabel: bootstrapBucket(bootstrapTime(timings))}
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return err
+	}
+	if ok, err := dryRun("node-annotations", nodeName, json.RawMessage(patch)); ok {
+		return err
+	}
+	if _, err := clientset.CoreV1().Nodes().Patch(ctx, nodeName, k8stypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("unable to patch node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// bootstrapBucket is the label value of the bucket of the bootstrap time, i.e. lt-60s, or ge-300s above the last bucket
+func bootstrapBucket(bootstrap time.Duration) string {
+	for _, bound := range BootstrapBuckets {
+		if bootstrap < bound {
+			return fmt.Sprintf("lt-%.0fs", bound.Seconds())
+		}
+	}
+	return fmt.Sprintf("ge-%.0fs", BootstrapBuckets[len(BootstrapBuckets)-1].Seconds())
+}
+
+// PublishSLOCondition sets a node condition, i.e. BootstrapLatencyWithinSLO, to True if all measurement tracks completed and the bootstrap time is within the SLO,
+// so other controllers can key off it, i.e. to prefer replacing chronically slow nodes
+func (m *Measurement) PublishSLOCondition(ctx context.Context, clientset kubernetes.Interface, nodeName string, conditionType string, slo time.Duration) error {
+	if nodeName == "" {
+		return fmt.Errorf("the node name is not known")
+	}
+	bootstrap := bootstrapTime(m.Timings)
+	condition := corev1.NodeCondition{
+		Type:               corev1.NodeConditionType(conditionType),
+		Status:             corev1.ConditionTrue,
+		Reason:             "WithinSLO",
+		Message:            fmt.Sprintf("bootstrap took %.0fs, the SLO is %.0fs", bootstrap.Seconds(), slo.Seconds()),
+		LastHeartbeatTime:  metav1.Now(),
+		LastTransitionTime: metav1.Now(),
+	}
+	if incomplete, ok := lo.Find(m.Tracks, func(t *TrackStatus) bool { return t.Status != TrackStatusComplete }); ok {
+		condition.Status, condition.Reason = corev1.ConditionFalse, "Incomplete"
+		condition.Message = fmt.Sprintf("measurement track %s is %s after %.0fs, the SLO is %.0fs", incomplete.Track, incomplete.Status, bootstrap.Seconds(), slo.Seconds())
+	} else if bootstrap > slo {
+		condition.Status, condition.Reason = corev1.ConditionFalse, "ExceededSLO"
+	}
+	patch, err := json.Marshal(map[string]interface{}{"status": map[string]interface{}{"conditions": []corev1.NodeCondition{condition}}})
+	if err != nil {
+		return err
+	}
+	if ok, err := dryRun("slo-condition", nodeName, json.RawMessage(patch)); ok {
+		return err
+	}
+	// conditions are merged by type with a strategic merge patch
+	if _, err := clientset.CoreV1().Nodes().PatchStatus(ctx, nodeName, patch); err != nil {
+		return fmt.Errorf("unable to set the %s condition of node %s: %w", conditionType, nodeName, err)
+	}
+	return nil
+}
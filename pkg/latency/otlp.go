@@ -0,0 +1,139 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// OTLPHistogramMetric is the histogram of all event timings which allows aggregating the timings of many nodes in the collector pipeline
+const OTLPHistogramMetric = "node_latency_seconds"
+
+// OTLPHistogramBounds are the explicit bucket bounds in seconds of the OTLPHistogramMetric
+var OTLPHistogramBounds = []float64{1, 2, 5, 10, 15, 20, 30, 45, 60, 90, 120, 180, 300, 600}
+
+// NewOTLPMetricsClient connects to an OTLP/gRPC metrics endpoint, i.e. an OpenTelemetry Collector at localhost:4317
+func NewOTLPMetricsClient(endpoint string, insecureTransport bool) (collectormetrics.MetricsServiceClient, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if insecureTransport {
+		creds = insecure.NewCredentials()
+	}
+	// the client connects on the first export, so an unreachable endpoint is returned as the error of the export
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %s: %w", endpoint, err)
+	}
+	return collectormetrics.NewMetricsServiceClient(conn), nil
+}
+
+// EmitOTLPMetrics exports a gauge per event metric and the OTLPHistogramMetric of the event timings with the node as resource attributes
+func (m *Measurement) EmitOTLPMetrics(ctx context.Context, client collectormetrics.MetricsServiceClient, experimentDimension string, nodeName string) error {
+	now := uint64(time.Now().UnixNano())
+	dimensions := m.metricDimensions(experimentDimension)
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	var metrics []*metricspb.Metric
+	for _, timing := range timings {
+		metrics = append(metrics, &metricspb.Metric{
+			Name: timing.Event.Metric,
+			Unit: "s",
+			Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+				DataPoints: []*metricspb.NumberDataPoint{{
+					Attributes:   otlpAttributes(eventDimensions(dimensions, timing.Event)),
+					TimeUnixNano: now,
+					Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: timing.T.Seconds()},
+				}},
+			}},
+		})
+	}
+	histogram := &metricspb.Histogram{AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA}
+	for _, timing := range timings {
+		bucketCounts := make([]uint64, len(OTLPHistogramBounds)+1)
+		bucketCounts[sort.SearchFloat64s(OTLPHistogramBounds, timing.T.Seconds())]++
+		histogram.DataPoints = append(histogram.DataPoints, &metricspb.HistogramDataPoint{
+			Attributes:     otlpAttributes(lo.Assign(dimensions, map[string]string{"event": timing.Event.Metric})),
+			TimeUnixNano:   now,
+			Count:          1,
+			Sum:            lo.ToPtr(timing.T.Seconds()),
+			BucketCounts:   bucketCounts,
+			ExplicitBounds: OTLPHistogramBounds,
+		})
+	}
+	metrics = append(metrics, &metricspb.Metric{Name: OTLPHistogramMetric, Unit: "s", Data: &metricspb.Metric_Histogram{Histogram: histogram}})
+
+	request := &collectormetrics.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource:     &resourcepb.Resource{Attributes: otlpAttributes(m.resourceAttributes(nodeName))},
+			ScopeMetrics: []*metricspb.ScopeMetrics{{Scope: &commonpb.InstrumentationScope{Name: "node-latency-for-k8s"}, Metrics: metrics}},
+		}},
+	}
+	if DryRunDir != "" {
+		requestBytes, err := protojson.Marshal(request)
+		if err != nil {
+			return err
+		}
+		_, err = dryRun("otlp", "MetricsService/Export", json.RawMessage(requestBytes))
+		return err
+	}
+	if _, err := client.Export(ctx, request); err != nil {
+		return fmt.Errorf("unable to export OTLP metrics: %w", err)
+	}
+	return nil
+}
+
+// resourceAttributes are the OpenTelemetry semantic convention attributes of the measured node
+func (m *Measurement) resourceAttributes(nodeName string) map[string]string {
+	attributes := map[string]string{"service.name": "node-latency-for-k8s"}
+	if nodeName != "" {
+		attributes["k8s.node.name"] = nodeName
+	}
+	if m.Metadata != nil {
+		attributes = lo.Assign(attributes, lo.PickBy(map[string]string{
+			"host.id":                 m.Metadata.InstanceID,
+			"host.type":               m.Metadata.InstanceType,
+			"host.arch":               m.Metadata.Architecture,
+			"host.image.id":           m.Metadata.AMIID,
+			"cloud.account.id":        m.Metadata.AccountID,
+			"cloud.region":            m.Metadata.Region,
+			"cloud.availability_zone": m.Metadata.AvailabilityZone,
+		}, func(_ string, v string) bool { return v != "" }))
+	}
+	return attributes
+}
+
+// otlpAttributes converts string key/values to OTLP attributes sorted by key
+func otlpAttributes(kvs map[string]string) []*commonpb.KeyValue {
+	keys := lo.Keys(kvs)
+	sort.Strings(keys)
+	return lo.Map(keys, func(k string, _ int) *commonpb.KeyValue {
+		return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: kvs[k]}}}
+	})
+}
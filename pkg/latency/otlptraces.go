@@ -0,0 +1,150 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/samber/lo"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// NewOTLPTracesClient connects to an OTLP/gRPC traces endpoint, i.e. an OpenTelemetry Collector, Tempo or Jaeger at localhost:4317
+func NewOTLPTracesClient(endpoint string, insecureTransport bool) (collectortrace.TraceServiceClient, error) {
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if insecureTransport {
+		creds = insecure.NewCredentials()
+	}
+	// the client connects on the first export, so an unreachable endpoint is returned as the error of the export
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP endpoint %s: %w", endpoint, err)
+	}
+	return collectortrace.NewTraceServiceClient(conn), nil
+}
+
+// OTLPSpans represents the bootstrap as a node-bootstrap root span from the first event to the last terminal event, or the last event
+// if no terminal event was measured. Events are instants, so the span of an event covers the time since the previous event, which is
+// the time the bootstrap waited for it. The root span is parented under the incoming trace context if there is one.
+func (m *Measurement) OTLPSpans(nodeName string) ([]*tracepb.Span, error) {
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	if len(timings) == 0 {
+		return nil, fmt.Errorf("there are no timings")
+	}
+	start, end := timings[0].Timestamp, timings[len(timings)-1].Timestamp
+	if terminal := lo.Filter(timings, func(t *sources.Timing, _ int) bool { return t.Event.Terminal }); len(terminal) > 0 {
+		end = terminal[len(terminal)-1].Timestamp
+	}
+	traceID := randomBytes(16)
+	var parentID []byte
+	if m.TraceContext != nil {
+		var err error
+		if traceID, err = hex.DecodeString(m.TraceContext.TraceID); err != nil || len(traceID) != 16 {
+			return nil, fmt.Errorf("invalid trace ID \"%s\" in the trace context", m.TraceContext.TraceID)
+		}
+		if parentID, err = hex.DecodeString(m.TraceContext.ParentID); err != nil || len(parentID) != 8 {
+			return nil, fmt.Errorf("invalid parent ID \"%s\" in the trace context", m.TraceContext.ParentID)
+		}
+	}
+	rootAttributes := map[string]string{}
+	if nodeName != "" {
+		rootAttributes["k8s.node.name"] = nodeName
+	}
+	if m.Metadata != nil && m.Metadata.InstanceType != "" {
+		rootAttributes["host.type"] = m.Metadata.InstanceType
+	}
+	root := &tracepb.Span{
+		TraceId:           traceID,
+		SpanId:            randomBytes(8),
+		ParentSpanId:      parentID,
+		Name:              "node-bootstrap",
+		Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+		StartTimeUnixNano: uint64(start.UnixNano()),
+		EndTimeUnixNano:   uint64(end.UnixNano()),
+		Attributes:        otlpAttributes(rootAttributes),
+		Status:            &tracepb.Status{Code: tracepb.Status_STATUS_CODE_OK},
+	}
+	spans := []*tracepb.Span{root}
+	previous := start
+	for _, timing := range timings {
+		attributes := lo.Assign(rootAttributes, map[string]string{
+			"event.metric": timing.Event.Metric,
+			"event.source": timing.Event.SrcName,
+		})
+		if timing.Comment != "" {
+			attributes["event.comment"] = timing.Comment
+		}
+		spans = append(spans, &tracepb.Span{
+			TraceId:           traceID,
+			SpanId:            randomBytes(8),
+			ParentSpanId:      root.SpanId,
+			Name:              timing.Event.Name,
+			Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+			StartTimeUnixNano: uint64(previous.UnixNano()),
+			EndTimeUnixNano:   uint64(timing.Timestamp.UnixNano()),
+			Attributes:        otlpAttributes(attributes),
+			Status:            &tracepb.Status{Code: tracepb.Status_STATUS_CODE_OK},
+		})
+		previous = timing.Timestamp
+	}
+	return spans, nil
+}
+
+// EmitOTLPTraces exports the bootstrap trace with the node as resource attributes
+func (m *Measurement) EmitOTLPTraces(ctx context.Context, client collectortrace.TraceServiceClient, nodeName string) error {
+	spans, err := m.OTLPSpans(nodeName)
+	if err != nil {
+		return err
+	}
+	request := &collectortrace.ExportTraceServiceRequest{
+		ResourceSpans: []*tracepb.ResourceSpans{{
+			Resource:   &resourcepb.Resource{Attributes: otlpAttributes(m.resourceAttributes(nodeName))},
+			ScopeSpans: []*tracepb.ScopeSpans{{Scope: &commonpb.InstrumentationScope{Name: "node-latency-for-k8s"}, Spans: spans}},
+		}},
+	}
+	if DryRunDir != "" {
+		requestBytes, err := protojson.Marshal(request)
+		if err != nil {
+			return err
+		}
+		_, err = dryRun("otlp-traces", "TraceService/Export", json.RawMessage(requestBytes))
+		return err
+	}
+	if _, err := client.Export(ctx, request); err != nil {
+		return fmt.Errorf("unable to export OTLP traces: %w", err)
+	}
+	return nil
+}
+
+// randomBytes is a random trace or span ID of n bytes
+func randomBytes(n int) []byte {
+	id := make([]byte, n)
+	_, _ = rand.Read(id)
+	return id
+}
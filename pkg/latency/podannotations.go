@@ -0,0 +1,151 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+)
+
+// PodBreakdown is where the startup time of a pod on the measured node went, each phase is only set if it is known
+type PodBreakdown struct {
+	// Admission is the admission estimate of the admission report
+	Admission *time.Duration
+	// Scheduling is the time from creation until the pod was bound to the node
+	Scheduling *time.Duration
+	// NodeReadyWait is the time the bound pod waited for the node to become ready
+	NodeReadyWait *time.Duration
+	// ImagePull is the network pull time of the pod's images of the image pull report
+	ImagePull *time.Duration
+	// Initialization is the time from binding until the init containers completed
+	Initialization *time.Duration
+	// ContainersReady is the time from initialization until all containers were ready
+	ContainersReady *time.Duration
+	// Startup is the time from creation until the pod was ready
+	Startup *time.Duration
+}
+
+// PatchPods annotates the pods in the namespace on the node with the breakdown of their startup time,
+// i.e. node-latency.k8s.aws/scheduling-seconds: "1.0", so application teams see where their pod's startup time went on the node.
+// It returns the number of annotated pods, pods that fail to be annotated do not stop the others.
+func (m *Measurement) PatchPods(ctx context.Context, clientset kubernetes.Interface, nodeName string, namespace string) (int, error) {
+	if nodeName == "" {
+		return 0, fmt.Errorf("the node name is not known")
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: fmt.Sprintf("spec.nodeName=%s", nodeName)})
+	if err != nil {
+		return 0, err
+	}
+	patches := map[string]json.RawMessage{}
+	for _, pod := range pods.Items {
+		// mirror pods of static manifests can not be changed through the API
+		if _, ok := pod.Annotations[k8ssrc.MirrorPodAnnotation]; ok {
+			continue
+		}
+		patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": m.PodBreakdown(pod).Annotations()}})
+		if err != nil {
+			return 0, err
+		}
+		patches[pod.Name] = patch
+	}
+	if ok, err := dryRun("pod-annotations", namespace, patches); ok {
+		return len(patches), err
+	}
+	var errs error
+	patched := 0
+	for name, patch := range patches {
+		if _, err := clientset.CoreV1().Pods(namespace).Patch(ctx, name, k8stypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("unable to patch pod %s/%s: %w", namespace, name, err))
+			continue
+		}
+		patched++
+	}
+	return patched, errs
+}
+
+// PodBreakdown breaks the startup time of the pod down into its phases from the pod conditions and the measurement
+func (m *Measurement) PodBreakdown(pod corev1.Pod) PodBreakdown {
+	var breakdown PodBreakdown
+	created := pod.CreationTimestamp.Time
+	conditions := map[corev1.PodConditionType]time.Time{}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue {
+			conditions[condition.Type] = condition.LastTransitionTime.Time
+		}
+	}
+	between := func(start time.Time, end time.Time) *time.Duration {
+		if start.IsZero() || end.IsZero() {
+			return nil
+		}
+		return lo.ToPtr(lo.Max([]time.Duration{end.Sub(start), 0}))
+	}
+	scheduled := conditions[corev1.PodScheduled]
+	breakdown.Scheduling = between(created, scheduled)
+	breakdown.Initialization = between(scheduled, conditions[corev1.PodInitialized])
+	breakdown.ContainersReady = between(conditions[corev1.PodInitialized], conditions[corev1.ContainersReady])
+	breakdown.Startup = between(created, conditions[corev1.PodReady])
+	if nodeReady, ok := lo.Find(m.Timings, func(t *sources.Timing) bool { return t.Event.Metric == "node_ready" && t.Error == nil }); ok && !scheduled.IsZero() {
+		breakdown.NodeReadyWait = lo.ToPtr(lo.Max([]time.Duration{nodeReady.Timestamp.Sub(scheduled), 0}))
+	}
+	if m.Admission != nil {
+		if admission, ok := lo.Find(m.Admission.Pods, func(p *PodAdmission) bool {
+			return p.Pod == fmt.Sprintf("%s/%s", pod.Namespace, pod.Name) && p.Source != ""
+		}); ok {
+			breakdown.Admission = lo.ToPtr(admission.Admission)
+		}
+	}
+	if m.ImagePulls != nil {
+		images := lo.Map(append(pod.Spec.InitContainers, pod.Spec.Containers...), func(c corev1.Container, _ int) string { return c.Image })
+		var imagePull time.Duration
+		for _, pull := range m.ImagePulls.Pulls {
+			if lo.Contains(images, pull.Image) {
+				imagePull += pull.Duration
+			}
+		}
+		breakdown.ImagePull = &imagePull
+	}
+	return breakdown
+}
+
+// Annotations are the pod annotations of the known phases, i.e. node-latency.k8s.aws/image-pull-seconds: "12.3"
+func (b PodBreakdown) Annotations() map[string]string {
+	annotations := map[string]string{}
+	for name, phase := range map[string]*time.Duration{
+		"admission":        b.Admission,
+		"scheduling":       b.Scheduling,
+		"node-ready-wait":  b.NodeReadyWait,
+		"image-pull":       b.ImagePull,
+		"initialization":   b.Initialization,
+		"containers-ready": b.ContainersReady,
+		"startup":          b.Startup,
+	} {
+		if phase != nil {
+			annotations[fmt.Sprintf("%s%s-seconds", NodeAnnotationPrefix, name)] = fmt.Sprintf("%.1f", phase.Seconds())
+		}
+	}
+	return annotations
+}
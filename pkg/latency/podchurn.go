@@ -0,0 +1,140 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PodStartupMetric is the histogram of the time from scheduled to ready of the sampled pods that land on the node in steady state
+const PodStartupMetric = "pod_startup_latency_seconds"
+
+// SamplePodStartups measures the time from scheduled to ready of a sample of the pods created on the node after it is called until the context is done.
+// Pods are sampled by their UID so a pod is either always or never sampled, sampleRate is the fraction of pods that are sampled (0, 1].
+// This allows NLK to export a continuous pod startup latency SLI after the node launch is measured.
+func (m *Measurer) SamplePodStartups(ctx context.Context, register prometheus.Registerer, sampleRate float64, interval time.Duration) error {
+	sampler, err := m.newPodChurnSampler(sampleRate)
+	if err != nil {
+		return err
+	}
+	histogram := newPodStartupHistogram()
+	if err := register.Register(histogram); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		startups, err := sampler.sample(ctx)
+		if err != nil {
+			zap.S().Warnf("Unable to list pods for pod startup sampling: %s", err)
+		}
+		for _, startup := range startups {
+			histogram.Observe(startup.Seconds())
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// newPodStartupHistogram is the histogram of the startups of the sampled pods
+func newPodStartupHistogram() prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    PodStartupMetric,
+		Help:    "Time from scheduled to ready of the sampled pods that started on the node",
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 12),
+	})
+}
+
+// podChurnSampler samples the pods created on the node after the sampler, each pod is observed once it is ready
+type podChurnSampler struct {
+	m          *Measurer
+	sampleRate float64
+	startTime  v1.Time
+	observed   map[types.UID]bool
+}
+
+// newPodChurnSampler creates a sampler of the pods created on the node from now on
+func (m *Measurer) newPodChurnSampler(sampleRate float64) (*podChurnSampler, error) {
+	if m.k8sClientset == nil || m.nodeName == "" {
+		return nil, fmt.Errorf("pod startup sampling requires the K8s clientset and node name")
+	}
+	return &podChurnSampler{m: m, sampleRate: sampleRate, startTime: v1.Now(), observed: map[types.UID]bool{}}, nil
+}
+
+// sample returns the startups of the sampled pods that became ready since the previous sample
+func (s *podChurnSampler) sample(ctx context.Context) ([]time.Duration, error) {
+	pods, err := s.m.k8sClientset.CoreV1().Pods("").List(ctx, v1.ListOptions{FieldSelector: fmt.Sprintf("spec.nodeName=%s", s.m.nodeName)})
+	if err != nil {
+		return nil, err
+	}
+	var startups []time.Duration
+	for _, pod := range pods.Items {
+		// the synthetic probe pods have their own metric
+		if _, ok := pod.Labels[SyntheticProbeLabel]; ok {
+			continue
+		}
+		if !s.m.namespaceFilter.Measured(pod.Namespace) {
+			continue
+		}
+		if s.observed[pod.UID] || pod.CreationTimestamp.Before(&s.startTime) || !sampled(pod.UID, s.sampleRate) {
+			continue
+		}
+		if startup, ok := podStartupLatency(pod); ok {
+			s.observed[pod.UID] = true
+			startups = append(startups, startup)
+		}
+	}
+	return startups, nil
+}
+
+// sampled deterministically selects the fraction sampleRate of pods by their UID
+func sampled(uid types.UID, sampleRate float64) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(uid))
+	return float64(h.Sum32()) < sampleRate*math.MaxUint32
+}
+
+// podStartupLatency returns the time from the PodScheduled to the Ready condition of a pod that is ready
+func podStartupLatency(pod corev1.Pod) (time.Duration, bool) {
+	var scheduled, ready time.Time
+	for _, cond := range pod.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case corev1.PodScheduled:
+			scheduled = cond.LastTransitionTime.Time
+		case corev1.PodReady:
+			ready = cond.LastTransitionTime.Time
+		}
+	}
+	if scheduled.IsZero() || ready.IsZero() {
+		return 0, false
+	}
+	return ready.Sub(scheduled), true
+}
@@ -0,0 +1,97 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	// PodMonitorGVR is the Prometheus Operator PodMonitor resource
+	PodMonitorGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "podmonitors"}
+	// PodMonitorFieldManager is the server-side apply field manager of the PodMonitor, every pod of the DaemonSet applies the same object
+	PodMonitorFieldManager = "node-latency-for-k8s"
+	// ServiceAccountNamespacePath is the namespace of the pod the tool runs in
+	ServiceAccountNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	// generatedPodLabels are set per pod by the workload controllers and are dropped from the PodMonitor selector
+	generatedPodLabels = []string{"controller-revision-hash", "pod-template-generation", "pod-template-hash"}
+)
+
+// EnsurePodMonitor applies a Prometheus Operator PodMonitor that scrapes the metrics port of the pods of the workload the tool runs in, owned by that workload
+// so it is removed with it. Nothing is created if the PodMonitor CRD is not installed.
+func EnsurePodMonitor(ctx context.Context, config *rest.Config, clientset kubernetes.Interface, podName string, port int) (bool, error) {
+	resources, err := clientset.Discovery().ServerResourcesForGroupVersion(PodMonitorGVR.GroupVersion().String())
+	if err != nil || !lo.ContainsBy(resources.APIResources, func(r metav1.APIResource) bool { return r.Name == PodMonitorGVR.Resource }) {
+		return false, nil
+	}
+	namespace, err := os.ReadFile(ServiceAccountNamespacePath)
+	if err != nil {
+		return false, fmt.Errorf("unable to read the pod namespace: %w", err)
+	}
+	pod, err := clientset.CoreV1().Pods(strings.TrimSpace(string(namespace))).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("unable to get pod %s: %w", podName, err)
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return false, fmt.Errorf("pod %s is not owned by a workload", podName)
+	}
+	podMonitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": PodMonitorGVR.GroupVersion().String(),
+		"kind":       "PodMonitor",
+		"metadata": map[string]interface{}{
+			"name":      owner.Name,
+			"namespace": pod.Namespace,
+			"ownerReferences": []interface{}{map[string]interface{}{
+				"apiVersion": owner.APIVersion,
+				"kind":       owner.Kind,
+				"name":       owner.Name,
+				"uid":        string(owner.UID),
+			}},
+		},
+		"spec": map[string]interface{}{
+			"podMetricsEndpoints": []interface{}{map[string]interface{}{
+				"honorLabels": true,
+				"interval":    "15s",
+				"path":        "/metrics",
+				"targetPort":  int64(port),
+				"scheme":      "http",
+			}},
+			"selector": map[string]interface{}{
+				"matchLabels": lo.MapValues(lo.OmitByKeys(pod.Labels, generatedPodLabels), func(v string, _ string) interface{} { return v }),
+			},
+		},
+	}}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return false, err
+	}
+	if _, err := dynamicClient.Resource(PodMonitorGVR).Namespace(pod.Namespace).Apply(ctx, owner.Name, podMonitor,
+		metav1.ApplyOptions{FieldManager: PodMonitorFieldManager, Force: true}); err != nil {
+		return false, fmt.Errorf("unable to apply PodMonitor %s/%s: %w", pod.Namespace, owner.Name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,203 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+)
+
+// Permission is a K8s API permission the configured sources or emitters need
+type Permission struct {
+	Group       string
+	Resource    string
+	Subresource string
+	Verb        string
+	// Namespace is empty for cluster scoped resources and permissions in all namespaces
+	Namespace string
+}
+
+// String is the permission as "verb group/resource/subresource"
+func (p Permission) String() string {
+	return fmt.Sprintf("%s %s", p.Verb, strings.Join(lo.Compact([]string{p.Group, p.Resource, p.Subresource}), "/"))
+}
+
+// PreflightCheck is the result of checking a host path or API permission before measuring
+type PreflightCheck struct {
+	Check  string `json:"check"`
+	Source string `json:"source,omitempty"`
+	OK     bool   `json:"ok"`
+	// Problem and Remediation explain a failed check, the remediation is the manifest snippet that fixes it
+	Problem     string `json:"problem,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// PreflightReport are the checks of all configured host paths and API permissions
+type PreflightReport struct {
+	Checks []*PreflightCheck `json:"checks"`
+}
+
+// Failed are the failed checks of the report
+func (r *PreflightReport) Failed() []*PreflightCheck {
+	return lo.Filter(r.Checks, func(c *PreflightCheck, _ int) bool { return !c.OK })
+}
+
+// Preflight verifies that the host paths of the registered sources are mounted and readable and that the K8s API permissions of the
+// K8s source and of the extra permissions, i.e. of the configured emitters, are granted, so missing access is reported once at startup
+// with the exact fix instead of as failures of individual events later
+func (m *Measurer) Preflight(ctx context.Context, extra ...Permission) *PreflightReport {
+	report := &PreflightReport{}
+	srcNames := lo.Keys(m.sources)
+	sort.Strings(srcNames)
+	for _, srcName := range srcNames {
+		hostPathSrc, ok := m.sources[srcName].(sources.HostPathSource)
+		if !ok {
+			continue
+		}
+		for _, path := range hostPathSrc.HostPaths() {
+			check := checkHostPath(path)
+			check.Source = srcName
+			report.Checks = append(report.Checks, check)
+		}
+	}
+	if m.k8sClientset == nil {
+		return report
+	}
+	var permissions []Permission
+	if _, ok := m.GetSource(k8ssrc.Name); ok {
+		permissions = append(permissions,
+			Permission{Resource: "nodes", Verb: "get"},
+			Permission{Resource: "pods", Verb: "list", Namespace: m.podNamespace},
+			Permission{Resource: "events", Verb: "list", Namespace: m.podNamespace},
+		)
+	}
+	for _, permission := range lo.Uniq(append(permissions, extra...)) {
+		report.Checks = append(report.Checks, m.checkPermission(ctx, permission))
+	}
+	return report
+}
+
+// checkHostPath checks that a host path, or the first match of a glob, can be opened
+func checkHostPath(path string) *PreflightCheck {
+	check := &PreflightCheck{Check: fmt.Sprintf("read %s", path)}
+	resolved := path
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil || len(matches) == 0 {
+			check.Problem = fmt.Sprintf("nothing matches %s, the host directory is not mounted or the log is not written on this node", path)
+			check.Remediation = hostPathVolume(filepath.Dir(path))
+			return check
+		}
+		resolved = matches[0]
+	}
+	file, err := os.Open(resolved)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		check.Problem = fmt.Sprintf("%s does not exist, the host directory is not mounted or the log is not written on this node", resolved)
+		check.Remediation = hostPathVolume(filepath.Dir(resolved))
+	case errors.Is(err, fs.ErrPermission):
+		check.Problem = fmt.Sprintf("%s is not readable, the container user lacks access or the read is denied by SELinux or AppArmor", resolved)
+		check.Remediation = hostPathSecurityContext
+		if denial := sources.ExplainDenial(resolved); denial != nil && !denial.Member {
+			check.Problem = fmt.Sprintf("%s is not readable, %s", resolved, denial.Hint())
+			if denial.GroupReadable {
+				check.Remediation = nonRootSecurityContext(denial.GID)
+			}
+		}
+		if sources.DefaultHelperSocket != "" {
+			check.Problem += fmt.Sprintf(", reads will fall back to the helper at %s", sources.DefaultHelperSocket)
+		}
+	case err != nil:
+		check.Problem = err.Error()
+	default:
+		_ = file.Close()
+		check.OK = true
+	}
+	return check
+}
+
+// checkPermission checks a permission with a SelfSubjectAccessReview
+func (m *Measurer) checkPermission(ctx context.Context, permission Permission) *PreflightCheck {
+	check := &PreflightCheck{Check: permission.String(), Source: k8ssrc.Name}
+	review, err := m.k8sClientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &authorizationv1.ResourceAttributes{
+			Group:       permission.Group,
+			Resource:    permission.Resource,
+			Subresource: permission.Subresource,
+			Verb:        permission.Verb,
+			Namespace:   permission.Namespace,
+		}},
+	}, metav1.CreateOptions{})
+	switch {
+	case err != nil:
+		check.Problem = fmt.Sprintf("unable to review the permission: %s", err)
+	case !review.Status.Allowed:
+		check.Problem = fmt.Sprintf("the service account is not allowed to %s", permission)
+		check.Remediation = rbacRule(permission)
+	default:
+		check.OK = true
+	}
+	return check
+}
+
+// hostPathSecurityContext lets the container read root owned logs, and read them on SELinux enforcing hosts
+const hostPathSecurityContext = `securityContext:
+  runAsUser: 0
+  seLinuxOptions:
+    type: spc_t`
+
+// nonRootSecurityContext lets a non-root container read logs through the group that owns them
+func nonRootSecurityContext(gid uint32) string {
+	return fmt.Sprintf(`securityContext:
+  runAsNonRoot: true
+  supplementalGroups:
+  - %d`, gid)
+}
+
+// hostPathVolume is the DaemonSet volume and read-only mount of a host directory
+func hostPathVolume(dir string) string {
+	name := strings.Trim(strings.NewReplacer("/", "-", ".", "-", "_", "-").Replace(strings.ToLower(dir)), "-")
+	return fmt.Sprintf(`volumes:
+- name: %[1]s
+  hostPath:
+    path: %[2]s
+volumeMounts:
+- name: %[1]s
+  mountPath: %[2]s
+  readOnly: true`, name, dir)
+}
+
+// rbacRule is the ClusterRole rule granting a permission
+func rbacRule(permission Permission) string {
+	return fmt.Sprintf(`- apiGroups:
+  - "%s"
+  resources:
+  - %s
+  verbs:
+  - %s`, permission.Group, strings.TrimSuffix(strings.Join([]string{permission.Resource, permission.Subresource}, "/"), "/"), permission.Verb)
+}
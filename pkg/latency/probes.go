@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	// the kubelet prober records of the measured pods, i.e. "Probe succeeded" probeType="Readiness" pod="default/web-0" podUID=... containerName="app",
+	// logged at kubelet verbosity 3 and higher, and the pod readiness sync, i.e. "SyncLoop (probe)" probe="readiness" status="ready" pod="default/web-0"
+	readinessProbeSucceededStr = `.*"Probe succeeded" probeType="Readiness" pod="(%s/[^"]+)".* containerName="([^"]+)".*`
+	readinessProbeReadyStr     = `.*"SyncLoop \(probe\)" probe="readiness" status="ready" pod="(%s/[^"]+)".*`
+)
+
+// readinessProbeEvents are the first successful readiness probe of each container of the measured pods, and the first time each pod was
+// marked ready by its probes. The gap from Pod Ready (containers started) to these events is application initialization.
+func (m *Measurer) readinessProbeEvents(logSrc string, findByRegex func(*regexp.Regexp) sources.FindFunc) []*sources.Event {
+	probeSucceeded := regexp.MustCompile(fmt.Sprintf(readinessProbeSucceededStr, regexp.QuoteMeta(m.podNamespace)))
+	probeReady := regexp.MustCompile(fmt.Sprintf(readinessProbeReadyStr, regexp.QuoteMeta(m.podNamespace)))
+	return []*sources.Event{
+		{
+			Name:          "Container Readiness Probe Succeeded",
+			Metric:        "container_readiness_probe_succeeded",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorAll,
+			CommentFn:     commentSubmatches(probeSucceeded),
+			FindFn:        firstPerSubmatch(probeSucceeded, findByRegex(probeSucceeded)),
+			Regex:         probeSucceeded.String(),
+		},
+		{
+			Name:          "Pod Readiness Probe Ready",
+			Metric:        "pod_readiness_probe_ready",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorAll,
+			CommentFn:     commentSubmatches(probeReady),
+			FindFn:        firstPerSubmatch(probeReady, findByRegex(probeReady)),
+			Regex:         probeReady.String(),
+		},
+	}
+}
+
+// firstPerSubmatch keeps the first matched line for each distinct set of submatches of the regex, i.e. the first probe success per container
+// since probes keep succeeding every period
+func firstPerSubmatch(re *regexp.Regexp, findFn sources.FindFunc) sources.FindFunc {
+	return func(s sources.Source, log []byte) ([]string, error) {
+		lines, err := findFn(s, log)
+		if err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{}
+		var first []string
+		for _, line := range lines {
+			match := re.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			key := strings.Join(match[1:], "/")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			first = append(first, line)
+		}
+		return first, nil
+	}
+}
+
+// commentSubmatches comments a matched line with the submatches of the regex joined by a slash, i.e. the pod and container
+func commentSubmatches(re *regexp.Regexp) sources.CommentFunc {
+	return func(matchedLine string) string {
+		match := re.FindStringSubmatch(matchedLine)
+		if len(match) < 2 {
+			return ""
+		}
+		return strings.Join(match[1:], "/")
+	}
+}
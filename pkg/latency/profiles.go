@@ -0,0 +1,530 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/ec2launch"
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+	kubeletsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/kubelet"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources/sysprep"
+)
+
+// Node profiles select the default events for a node OS and bootstrap flavor
+const (
+	// ProfileEKS is the default profile for EKS optimized AMIs (AL2, bootstrap.sh)
+	ProfileEKS = "eks"
+	// ProfileGKECOS is for GKE nodes running Container-Optimized OS which log only to the journal
+	ProfileGKECOS = "gke-cos"
+	// ProfileAKS is for AKS Ubuntu and AzureLinux nodes which are bootstrapped by the Custom Script Extension (CSE)
+	ProfileAKS = "aks"
+	// ProfileOpenShift is for OpenShift RHCOS nodes which are provisioned by Ignition and the Machine Config Operator
+	ProfileOpenShift = "openshift"
+	// ProfileWindows is for EKS optimized Windows AMIs which run the sysprep unattend passes and EC2Launch v2 on first boot
+	ProfileWindows = "windows"
+)
+
+// Profiles are the supported node profiles
+var Profiles = []string{ProfileEKS, ProfileGKECOS, ProfileAKS, ProfileOpenShift, ProfileWindows}
+
+// JournalProfiles are the profiles of node OSes that do not write /var/log/messages so the default log events are read from the journal
+var JournalProfiles = []string{ProfileGKECOS, ProfileAKS, ProfileOpenShift}
+
+var (
+	kubeNodeInstallationStart  = regexp.MustCompile(`.*Starting (kube-node-installation\.service|Download and install k8s binaries and configurations).*`)
+	kubeNodeInstallationFinish = regexp.MustCompile(`.*(Finished|Started) (kube-node-installation\.service|Download and install k8s binaries and configurations).*`)
+	kubeNodeConfigurationStart = regexp.MustCompile(`.*Starting (kube-node-configuration\.service|Configure kubernetes node).*`)
+	kubeNodeConfigFinish       = regexp.MustCompile(`.*(Finished|Started) (kube-node-configuration\.service|Configure kubernetes node).*`)
+	konletStart                = regexp.MustCompile(`.*Starting (konlet-startup\.service|Containers on GCE Setup).*`)
+	konletContainerLaunched    = regexp.MustCompile(`.*konlet.*Launching user container.*`)
+	kubeletUnitStart           = regexp.MustCompile(`(?i).*Starting (kubelet\.service|Kubernetes kubelet).*`)
+	kubeletUnitInitialized     = regexp.MustCompile(`(?i).*Started (kubelet\.service|Kubernetes kubelet).*`)
+	cseStart                   = regexp.MustCompile(`.*(cse_cmd\.sh|provision_start\.sh|cse_main\.sh).*`)
+	cseFinish                  = regexp.MustCompile(`.*(provision\.complete|Custom Script Extension (succeeded|finished)|CSE finished).*`)
+	aksKubeletStart            = regexp.MustCompile(`.*Starting (kubelet\.service|Kubelet)\b.*`)
+	aksKubeletInitialized      = regexp.MustCompile(`.*Started (kubelet\.service|Kubelet)\b.*`)
+	ignitionStart              = regexp.MustCompile(`.*ignition\[[0-9]+\]: Ignition [0-9.]+.*`)
+	ignitionFinish             = regexp.MustCompile(`.*ignition\[[0-9]+\]: Ignition finished successfully.*`)
+	mcdFirstbootStart          = regexp.MustCompile(`.*Starting (machine-config-daemon-firstboot\.service|Machine Config Daemon Firstboot).*`)
+	mcdFirstSync               = regexp.MustCompile(`.*machine-config-daemon.*(In desired config|Completed update to|Validated on-disk state).*`)
+	mcdFirstbootFinish         = regexp.MustCompile(`.*(Finished|Started) (machine-config-daemon-firstboot\.service|Machine Config Daemon Firstboot).*`)
+	crioStart                  = regexp.MustCompile(`.*Starting (crio\.service|Container Runtime Interface for OCI \(CRI-O\)).*`)
+	crioInitialized            = regexp.MustCompile(`.*Started (crio\.service|Container Runtime Interface for OCI \(CRI-O\)).*`)
+	sysprepSpecializeStart     = regexp.MustCompile(`.*Running 'specialize' pass.*`)
+	sysprepSpecializeFinish    = regexp.MustCompile(`.*Exiting 'specialize' pass.*`)
+	sysprepOOBEStart           = regexp.MustCompile(`.*Running 'oobeSystem' pass.*`)
+	sysprepOOBEFinish          = regexp.MustCompile(`.*Exiting 'oobeSystem' pass.*`)
+	// the persistent volume reconciler records of a pod in a namespace, i.e. "MountVolume.SetUp succeeded for volume \"pvc-1\" (UniqueName: \"kubernetes.io/csi/ebs.csi.aws.com^vol-1\") ..." pod="default/db-0"
+	volumeAttachWaitStr      = `.*VerifyControllerAttachedVolume started for volume .*UniqueName: \\?"kubernetes\.io/(csi|aws-ebs)/.*pod="%s/.*`
+	volumeAttachConfirmedStr = `.*MountVolume\.WaitForAttach succeeded for volume .*UniqueName: \\?"kubernetes\.io/(csi|aws-ebs)/.*pod="%s/.*`
+	volumeStagedStr          = `.*MountVolume\.MountDevice succeeded for volume .*UniqueName: \\?"kubernetes\.io/(csi|aws-ebs)/.*pod="%s/.*`
+	volumeMountedStr         = `.*MountVolume\.SetUp succeeded for volume .*UniqueName: \\?"kubernetes\.io/(csi|aws-ebs)/.*pod="%s/.*`
+	// the kubelet secret/configmap manager cache fills, i.e. "Caches populated for *v1.Secret from object-\"default\"/\"db-credentials\"", and
+	// the API object volume mounts of a pod in a namespace
+	secretCachePopulatedStr    = `.*Caches populated for \*v1\.Secret from object-\\?"%s\\?"/.*`
+	configMapCachePopulatedStr = `.*Caches populated for \*v1\.ConfigMap from object-\\?"%s\\?"/.*`
+	secretMountedStr           = `.*MountVolume\.SetUp succeeded for volume .*UniqueName: \\?"kubernetes\.io/secret/.*pod="%s/.*`
+	configMapMountedStr        = `.*MountVolume\.SetUp succeeded for volume .*UniqueName: \\?"kubernetes\.io/configmap/.*pod="%s/.*`
+	projectedTokenMountedStr   = `.*MountVolume\.SetUp succeeded for volume .*UniqueName: \\?"kubernetes\.io/projected/.*pod="%s/.*`
+	ec2LaunchBootStage         = regexp.MustCompile(`.*[Ss]tage: boot.*`)
+	ec2LaunchPostReadyStage    = regexp.MustCompile(`.*[Ss]tage: postReady.*`)
+	windowsReady               = regexp.MustCompile(`.*Windows is Ready to use.*`)
+)
+
+// defaultComponentLabels maps metric prefixes of the default events to the component and phase labels attached to their metrics
+var defaultComponentLabels = []struct {
+	prefix    string
+	component string
+	phase     string
+}{
+	{"pod_created", "k8s", "provisioning"},
+	{"drain_", "k8s", "upgrade"},
+	{"fleet_", "ec2", "provisioning"},
+	{"instance_", "ec2", "provisioning"},
+	{"asg_", "ec2", "provisioning"},
+	{"spot_", "ec2", "interruption"},
+	{"vm_", "kernel", "boot"},
+	{"ignition_", "ignition", "boot"},
+	{"network_", "network", "boot"},
+	{"cloudinit_", "cloud-init", "bootstrap"},
+	{"kube_node_", "kube-node-installation", "bootstrap"},
+	{"cse_", "cse", "bootstrap"},
+	{"mcd_", "machine-config-daemon", "bootstrap"},
+	{"sysprep_", "sysprep", "boot"},
+	{"ec2launch_", "ec2launch", "bootstrap"},
+	{"static_pod_", "kubelet", "bootstrap"},
+	{"containerd_", "containerd", "runtime"},
+	{"crio_", "crio", "runtime"},
+	{"dockerd_", "dockerd", "runtime"},
+	{"konlet_", "konlet", "runtime"},
+	{"kubelet_", "kubelet", "kubelet"},
+	{"kube_apiserver_", "kube-apiserver", "kubelet"},
+	{"kube_proxy_", "kube-proxy", "network"},
+	{"vpc_cni_", "cni", "network"},
+	{"aws_node_", "cni", "network"},
+	{"cni_", "cni", "network"},
+	{"node_network_", "cni", "network"},
+	{"node_", "kubelet", "ready"},
+	{"volume_", "csi", "volume"},
+	{"secret_", "kubelet", "volume"},
+	{"configmap_", "kubelet", "volume"},
+	{"projected_", "kubelet", "volume"},
+	{"container_readiness_", "kubelet", "ready"},
+	{"pod_readiness_", "kubelet", "ready"},
+	{"ccm_", "cloud-controller-manager", "initialization"},
+	{"cloud_provider_", "cloud-controller-manager", "initialization"},
+	{"pod_", "kubelet", "ready"},
+	{"first_workload_", "workload", "ready"},
+	{"workloads_", "workload", "ready"},
+}
+
+// defaultEventAliases maps the metrics of renamed default events to their previous metric names.
+// A default event is renamed by changing its metric and adding its previous metric here, never by only changing the metric.
+var defaultEventAliases = map[string][]string{
+	"containerd_start":       {"conatinerd_start"},
+	"containerd_initialized": {"conatinerd_initialized"},
+}
+
+// withDefaultLabels sets the component and phase labels of default events that do not have labels and the owner of their component,
+// and the aliases of renamed default events
+func (m *Measurer) withDefaultLabels(events []*sources.Event) []*sources.Event {
+	for _, event := range events {
+		if aliases, ok := defaultEventAliases[event.Metric]; ok && event.Aliases == nil {
+			event.Aliases = aliases
+		}
+		if event.Labels == nil {
+			for _, l := range defaultComponentLabels {
+				if strings.HasPrefix(event.Metric, l.prefix) {
+					event.Labels = map[string]string{"component": l.component, "phase": l.phase}
+					break
+				}
+			}
+		}
+		if owner, ok := m.eventOwners[event.Labels["component"]]; ok && event.Owner == "" {
+			event.Owner = owner
+		}
+	}
+	return events
+}
+
+// logEvent is a helper to construct an event that matches the first line of the log source for the regex
+func logEvent(logSrc string, findByRegex func(*regexp.Regexp) sources.FindFunc, name string, metric string, re *regexp.Regexp) *sources.Event {
+	return &sources.Event{
+		Name:          name,
+		Metric:        metric,
+		SrcName:       logSrc,
+		MatchSelector: sources.EventMatchSelectorFirst,
+		FindFn:        findByRegex(re),
+		Regex:         re.String(),
+	}
+}
+
+// arm64Events are the kernel init events that are only logged by arm64 (Graviton) kernels, which report the boot CPU
+// before the kernel version and initialize the GIC interrupt controller rather than the x86 APIC
+func arm64Events(logSrc string, findByRegex func(*regexp.Regexp) sources.FindFunc) []*sources.Event {
+	return []*sources.Event{
+		logEvent(logSrc, findByRegex, "ARM64 CPU Boot", "vm_arm64_cpu_boot", arm64CPUBoot),
+		logEvent(logSrc, findByRegex, "ARM64 GIC Initialized", "vm_arm64_gic_initialized", arm64GICInitialized),
+		logEvent(logSrc, findByRegex, "ARM64 SMP Ready", "vm_arm64_smp_ready", arm64SMPReady),
+	}
+}
+
+// volumeEvents are the attach, stage (MountDevice) and mount (SetUp) events of the persistent volumes of the measured pods, from the
+// reconciler records of the kubelet and the attach/detach controller events. Stateful pod startup is often dominated by these rather than image pulls.
+func (m *Measurer) volumeEvents(logSrc string, findByRegex func(*regexp.Regexp) sources.FindFunc) []*sources.Event {
+	events := []*sources.Event{
+		m.podNamespaceEvent(logSrc, findByRegex, "Volume Attach Wait Start", "volume_attach_wait_start", volumeAttachWaitStr),
+		m.podNamespaceEvent(logSrc, findByRegex, "Volume Attach Confirmed", "volume_attach_confirmed", volumeAttachConfirmedStr),
+		m.podNamespaceEvent(logSrc, findByRegex, "Volume Staged", "volume_staged", volumeStagedStr),
+		m.podNamespaceEvent(logSrc, findByRegex, "Volume Mounted", "volume_mounted", volumeMountedStr),
+	}
+	if k8sSrc, ok := m.GetSource(k8ssrc.Name); ok {
+		events = append(events, &sources.Event{
+			Name:          "Volume Attached",
+			Metric:        "volume_attached",
+			SrcName:       k8ssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     k8ssrc.CommentReason(),
+			FindFn:        k8sSrc.(*k8ssrc.Source).FindPodEvent("SuccessfulAttachVolume"),
+		})
+	}
+	return events
+}
+
+// podObjectEvents are the kubelet secret and configmap cache fills and the secret, configmap and projected service account token mounts of the
+// measured pods. The cache records are logged at kubelet verbosity 2 and higher; slow API server responses surface here as pod start delays.
+func (m *Measurer) podObjectEvents(logSrc string, findByRegex func(*regexp.Regexp) sources.FindFunc) []*sources.Event {
+	return []*sources.Event{
+		m.podNamespaceEvent(logSrc, findByRegex, "Secret Cache Populated", "secret_cache_populated", secretCachePopulatedStr),
+		m.podNamespaceEvent(logSrc, findByRegex, "ConfigMap Cache Populated", "configmap_cache_populated", configMapCachePopulatedStr),
+		m.podNamespaceEvent(logSrc, findByRegex, "Secret Mounted", "secret_mounted", secretMountedStr),
+		m.podNamespaceEvent(logSrc, findByRegex, "ConfigMap Mounted", "configmap_mounted", configMapMountedStr),
+		m.podNamespaceEvent(logSrc, findByRegex, "Projected Token Mounted", "projected_token_mounted", projectedTokenMountedStr),
+	}
+}
+
+// podNamespaceEvent is a log event whose regex format is filled in with the namespace of the measured pods, commented with the matched line
+func (m *Measurer) podNamespaceEvent(logSrc string, findByRegex func(*regexp.Regexp) sources.FindFunc, name string, metric string, format string) *sources.Event {
+	event := logEvent(logSrc, findByRegex, name, metric, regexp.MustCompile(fmt.Sprintf(format, regexp.QuoteMeta(m.podNamespace))))
+	event.CommentFn = sources.CommentMatchedLine()
+	return event
+}
+
+// readinessEvents are the kubelet registration and sync loop, CNI status, API server throttling, and terminal node and pod ready events shared by all profiles
+func (m *Measurer) readinessEvents(logSrc string, findByRegex func(*regexp.Regexp) sources.FindFunc) []*sources.Event {
+	return []*sources.Event{
+		logEvent(logSrc, findByRegex, "Kubelet Registered", "kubelet_registered", kubeletRegistered),
+		logEvent(logSrc, findByRegex, "Kubelet Serving Certificate Issued", "kubelet_serving_certificate_issued", kubeletServingCert),
+		logEvent(logSrc, findByRegex, "Kubelet Volume Manager Started", "kubelet_volume_manager_started", kubeletVolumeManager),
+		logEvent(logSrc, findByRegex, "Kubelet First Pod Sync", "kubelet_first_pod_sync", kubeletFirstPodSync),
+		logEvent(logSrc, findByRegex, "Kubelet PLEG First Event", "kubelet_pleg_first_event", kubeletPLEGEvent),
+		logEvent(logSrc, findByRegex, "CNI First Not Ready", "cni_first_not_ready", cniNotReady),
+		{
+			Name:          "CNI Last Not Ready",
+			Metric:        "cni_last_not_ready",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorLast,
+			FindFn:        findByRegex(cniNotReady),
+			Regex:         cniNotReady.String(),
+		},
+		{
+			Name:          "Kube-APIServer Throttled",
+			Metric:        "kube_apiserver_throttled",
+			SrcName:       logSrc,
+			MatchSelector: sources.EventMatchSelectorAll,
+			CommentFn:     sources.CommentMatchedLine(),
+			FindFn:        findByRegex(throttled),
+			Regex:         throttled.String(),
+		},
+		{
+			Name:          "Node Ready",
+			Metric:        "node_ready",
+			SrcName:       logSrc,
+			Terminal:      true,
+			Track:         TrackNode,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        findByRegex(nodeReady),
+			Regex:         nodeReady.String(),
+		},
+		{
+			Name:          "Pod Ready",
+			Metric:        "pod_ready",
+			SrcName:       logSrc,
+			Terminal:      true,
+			Track:         TrackPod,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        findByRegex(regexp.MustCompile(fmt.Sprintf(podReadyStr, m.podNamespace))),
+			Regex:         fmt.Sprintf(podReadyStr, m.podNamespace),
+		},
+	}
+}
+
+// podCreatedEvent returns the Pod Created event from the K8s source if it is registered, otherwise from the local kubelet source
+func (m *Measurer) podCreatedEvent() []*sources.Event {
+	if k8sSrc, ok := m.GetSource(k8ssrc.Name); ok {
+		return []*sources.Event{
+			{
+				Name:          "Pod Created",
+				Metric:        "pod_created",
+				SrcName:       k8ssrc.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        k8sSrc.(*k8ssrc.Source).FindPodCreationTime(),
+			},
+		}
+	}
+	if kubeletSrc, ok := m.GetSource(kubeletsrc.Name); ok {
+		return []*sources.Event{
+			{
+				Name:          "Pod Created",
+				Metric:        "pod_created",
+				SrcName:       kubeletsrc.Name,
+				MatchSelector: sources.EventMatchSelectorFirst,
+				FindFn:        kubeletSrc.(*kubeletsrc.Source).FindPodCreationTime(),
+			},
+		}
+	}
+	return nil
+}
+
+// nodeConditionEvents returns the node condition, cloud controller manager initialization, and first workload pod events if the K8s source is registered
+func (m *Measurer) nodeConditionEvents() []*sources.Event {
+	k8sSrc, ok := m.GetSource(k8ssrc.Name)
+	if !ok {
+		return nil
+	}
+	nodeEvent := func(name string, metric string, findFn sources.FindFunc) *sources.Event {
+		return &sources.Event{
+			Name:          name,
+			Metric:        metric,
+			SrcName:       k8ssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        findFn,
+		}
+	}
+	src := k8sSrc.(*k8ssrc.Source)
+	events := []*sources.Event{
+		nodeEvent("Node Network Available", "node_network_available", src.FindNodeCondition(corev1.NodeNetworkUnavailable, corev1.ConditionFalse)),
+		nodeEvent("Node Memory Pressure Settled", "node_memory_pressure_settled", src.FindNodeCondition(corev1.NodeMemoryPressure, corev1.ConditionFalse)),
+		nodeEvent("Node Disk Pressure Settled", "node_disk_pressure_settled", src.FindNodeCondition(corev1.NodeDiskPressure, corev1.ConditionFalse)),
+		{
+			Name:          "CCM Provider ID Set",
+			Metric:        "ccm_provider_id_set",
+			SrcName:       k8ssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     k8ssrc.CommentReason(),
+			FindFn:        src.FindFieldManagerUpdate("spec", "providerID"),
+		},
+		{
+			Name:          "CCM Addresses Populated",
+			Metric:        "ccm_addresses_populated",
+			SrcName:       k8ssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     k8ssrc.CommentReason(),
+			FindFn:        src.FindFieldManagerUpdate("status", "addresses"),
+		},
+		nodeEvent("Cloud Provider Taint Removed", "cloud_provider_taint_removed", src.FindTaintRemoval(k8ssrc.UninitializedTaint)),
+		{
+			Name:          "First Workload Pod Running",
+			Metric:        "first_workload_pod_running",
+			SrcName:       k8ssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     k8ssrc.CommentReason(),
+			FindFn:        src.FindFirstWorkloadPodRunning(),
+		},
+	}
+	if m.nodeClaimBaseline {
+		events = append(events, &sources.Event{
+			Name:          "NodeClaim Created",
+			Metric:        "nodeclaim_created",
+			SrcName:       k8ssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     k8ssrc.CommentReason(),
+			FindFn:        src.FindNodeClaimCreation(),
+		})
+	}
+	if m.startupGate != nil {
+		events = append(events, nodeEvent("Startup Taint Removed", "startup_taint_removed", src.FindTaintRemoval(m.startupGate.Taint.Key)))
+	}
+	return events
+}
+
+// kubeletEvents returns the static pod manifest, serving certificate and pod ready condition events if the local kubelet source is registered
+func (m *Measurer) kubeletEvents() []*sources.Event {
+	kubeletSrc, ok := m.GetSource(kubeletsrc.Name)
+	if !ok {
+		return nil
+	}
+	events := []*sources.Event{
+		{
+			Name:          "Static Pod Manifests Written",
+			Metric:        "static_pod_manifests_written",
+			SrcName:       kubeletsrc.Name,
+			MatchSelector: sources.EventMatchSelectorLast,
+			CommentFn:     sources.CommentMatchedLine(),
+			FindFn:        kubeletSrc.(*kubeletsrc.Source).FindStaticPodManifests(),
+		},
+		{
+			Name:          "Kubelet Serving Certificate Written",
+			Metric:        "kubelet_serving_certificate_written",
+			SrcName:       kubeletsrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     sources.CommentMatchedLine(),
+			FindFn:        kubeletSrc.(*kubeletsrc.Source).FindServingCertificate(),
+		},
+		{
+			Name:          "Pod Ready Condition",
+			Metric:        "pod_ready_condition",
+			SrcName:       kubeletsrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        kubeletSrc.(*kubeletsrc.Source).FindPodReadyTime(),
+		},
+	}
+	// the static pod manifests and serving certificate are read from host paths
+	if m.hostPathFree {
+		events = lo.Reject(events, func(e *sources.Event, _ int) bool { return lo.Contains(kubeletHostPathMetrics, e.Metric) })
+	}
+	return events
+}
+
+// gkeCOSEvents are the default events of GKE Container-Optimized OS nodes which are bootstrapped by the
+// kube-node-installation and kube-node-configuration units, and optionally run konlet for container declarations
+func (m *Measurer) gkeCOSEvents() []*sources.Event {
+	logSrc := m.logSourceName()
+	logFindByRegex := lo.Must(m.GetSource(logSrc)).(sources.RegexSource).FindByRegex
+	events := m.podCreatedEvent()
+	events = append(events, []*sources.Event{
+		logEvent(logSrc, logFindByRegex, "VM Initialized", "vm_initialized", vmInit),
+		logEvent(logSrc, logFindByRegex, "Network Ready", "network_ready", networkReady),
+		logEvent(logSrc, logFindByRegex, "Kube Node Installation Start", "kube_node_installation_start", kubeNodeInstallationStart),
+		logEvent(logSrc, logFindByRegex, "Kube Node Installation Finish", "kube_node_installation_finish", kubeNodeInstallationFinish),
+		logEvent(logSrc, logFindByRegex, "Kube Node Configuration Start", "kube_node_configuration_start", kubeNodeConfigurationStart),
+		logEvent(logSrc, logFindByRegex, "Kube Node Configuration Finish", "kube_node_configuration_finish", kubeNodeConfigFinish),
+		logEvent(logSrc, logFindByRegex, "Konlet Start", "konlet_start", konletStart),
+		logEvent(logSrc, logFindByRegex, "Konlet Container Launched", "konlet_container_launched", konletContainerLaunched),
+		logEvent(logSrc, logFindByRegex, "Containerd Start", "containerd_start", containerdStart),
+		logEvent(logSrc, logFindByRegex, "Containerd Initialized", "containerd_initialized", containerdInitialized),
+		logEvent(logSrc, logFindByRegex, "Kubelet Start", "kubelet_start", kubeletUnitStart),
+		logEvent(logSrc, logFindByRegex, "Kubelet Initialized", "kubelet_initialized", kubeletUnitInitialized),
+	}...)
+	events = append(events, m.registryMirrorEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.readinessEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.volumeEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.podObjectEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.readinessProbeEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.nodeConditionEvents()...)
+	events = append(events, m.kubeletEvents()...)
+	events = append(events, m.criEvents()...)
+	return append(events, m.reachabilityEvents()...)
+}
+
+// aksEvents are the default events of AKS Ubuntu and AzureLinux nodes which run cloud-init followed by the
+// Custom Script Extension (cse_cmd.sh) that configures and starts containerd and the kubelet
+func (m *Measurer) aksEvents() []*sources.Event {
+	logSrc := m.logSourceName()
+	logFindByRegex := lo.Must(m.GetSource(logSrc)).(sources.RegexSource).FindByRegex
+	events := m.podCreatedEvent()
+	events = append(events, []*sources.Event{
+		logEvent(logSrc, logFindByRegex, "VM Initialized", "vm_initialized", vmInit),
+		logEvent(logSrc, logFindByRegex, "Network Ready", "network_ready", networkReady),
+		logEvent(logSrc, logFindByRegex, "Cloud-Init Initial Start", "cloudinit_initial_start", cloudInitInitialStart),
+		logEvent(logSrc, logFindByRegex, "Cloud-Init Final Finish", "cloudinit_final_finish", cloudInitFinalFinish),
+		logEvent(logSrc, logFindByRegex, "CSE Start", "cse_start", cseStart),
+		logEvent(logSrc, logFindByRegex, "CSE Finish", "cse_finish", cseFinish),
+		logEvent(logSrc, logFindByRegex, "Containerd Start", "containerd_start", containerdStart),
+		logEvent(logSrc, logFindByRegex, "Containerd Initialized", "containerd_initialized", containerdInitialized),
+		logEvent(logSrc, logFindByRegex, "Kubelet Start", "kubelet_start", aksKubeletStart),
+		logEvent(logSrc, logFindByRegex, "Kubelet Initialized", "kubelet_initialized", aksKubeletInitialized),
+	}...)
+	events = append(events, m.registryMirrorEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.readinessEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.volumeEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.podObjectEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.readinessProbeEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.nodeConditionEvents()...)
+	events = append(events, m.kubeletEvents()...)
+	events = append(events, m.criEvents()...)
+	return append(events, m.reachabilityEvents()...)
+}
+
+// openShiftEvents are the default events of OpenShift RHCOS nodes which are provisioned by Ignition in the initramfs,
+// then rebased and configured by the machine-config-daemon firstboot unit before CRI-O and the kubelet start
+func (m *Measurer) openShiftEvents() []*sources.Event {
+	logSrc := m.logSourceName()
+	logFindByRegex := lo.Must(m.GetSource(logSrc)).(sources.RegexSource).FindByRegex
+	events := m.podCreatedEvent()
+	events = append(events, []*sources.Event{
+		logEvent(logSrc, logFindByRegex, "VM Initialized", "vm_initialized", vmInit),
+		logEvent(logSrc, logFindByRegex, "Ignition Start", "ignition_start", ignitionStart),
+		logEvent(logSrc, logFindByRegex, "Ignition Finish", "ignition_finish", ignitionFinish),
+		logEvent(logSrc, logFindByRegex, "Network Ready", "network_ready", networkReady),
+		logEvent(logSrc, logFindByRegex, "MCD Firstboot Start", "mcd_firstboot_start", mcdFirstbootStart),
+		logEvent(logSrc, logFindByRegex, "MCD First Sync", "mcd_first_sync", mcdFirstSync),
+		logEvent(logSrc, logFindByRegex, "MCD Firstboot Finish", "mcd_firstboot_finish", mcdFirstbootFinish),
+		logEvent(logSrc, logFindByRegex, "CRI-O Start", "crio_start", crioStart),
+		logEvent(logSrc, logFindByRegex, "CRI-O Initialized", "crio_initialized", crioInitialized),
+		logEvent(logSrc, logFindByRegex, "Kubelet Start", "kubelet_start", kubeletUnitStart),
+		logEvent(logSrc, logFindByRegex, "Kubelet Initialized", "kubelet_initialized", kubeletUnitInitialized),
+	}...)
+	events = append(events, m.readinessEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.volumeEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.podObjectEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.readinessProbeEvents(logSrc, logFindByRegex)...)
+	events = append(events, m.nodeConditionEvents()...)
+	events = append(events, m.kubeletEvents()...)
+	events = append(events, m.criEvents()...)
+	return append(events, m.reachabilityEvents()...)
+}
+
+// windowsEvents are the default events of EKS optimized Windows AMIs. On first boot the specialize and oobeSystem unattend passes of
+// sysprep run before EC2Launch v2 runs its stages and reports that Windows is ready. Windows nodes have neither /var/log/messages nor
+// the journal, so the kubelet and node readiness is measured with the K8s and kubelet API events.
+func (m *Measurer) windowsEvents() []*sources.Event {
+	sysprepFindByRegex := lo.Must(m.GetSource(sysprep.Name)).(sources.RegexSource).FindByRegex
+	ec2LaunchFindByRegex := lo.Must(m.GetSource(ec2launch.Name)).(sources.RegexSource).FindByRegex
+	events := m.podCreatedEvent()
+	events = append(events, m.ec2Events()...)
+	events = append(events, []*sources.Event{
+		logEvent(sysprep.Name, sysprepFindByRegex, "Sysprep Specialize Start", "sysprep_specialize_start", sysprepSpecializeStart),
+		logEvent(sysprep.Name, sysprepFindByRegex, "Sysprep Specialize Finish", "sysprep_specialize_finish", sysprepSpecializeFinish),
+		logEvent(sysprep.Name, sysprepFindByRegex, "Sysprep OOBE Start", "sysprep_oobe_start", sysprepOOBEStart),
+		logEvent(sysprep.Name, sysprepFindByRegex, "Sysprep OOBE Finish", "sysprep_oobe_finish", sysprepOOBEFinish),
+		logEvent(ec2launch.Name, ec2LaunchFindByRegex, "EC2Launch Boot Stage", "ec2launch_boot_stage", ec2LaunchBootStage),
+		logEvent(ec2launch.Name, ec2LaunchFindByRegex, "EC2Launch PostReady Stage", "ec2launch_post_ready_stage", ec2LaunchPostReadyStage),
+		logEvent(ec2launch.Name, ec2LaunchFindByRegex, "Windows Ready", "ec2launch_windows_ready", windowsReady),
+	}...)
+	// the kubelet on Windows does not log to a file that is searched, so Node Ready is its condition in the K8s API
+	if k8sSrc, ok := m.GetSource(k8ssrc.Name); ok {
+		events = append(events, &sources.Event{
+			Name:          "Node Ready",
+			Metric:        "node_ready",
+			SrcName:       k8ssrc.Name,
+			Terminal:      true,
+			Track:         TrackNode,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        k8sSrc.(*k8ssrc.Source).FindNodeCondition(corev1.NodeReady, corev1.ConditionTrue),
+		})
+	}
+	events = append(events, m.nodeConditionEvents()...)
+	events = append(events, m.kubeletEvents()...)
+	events = append(events, m.criEvents()...)
+	return append(events, m.reachabilityEvents()...)
+}
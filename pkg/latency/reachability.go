@@ -0,0 +1,65 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"regexp"
+	"text/template"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	cloudinitsrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/cloudinit"
+	egresssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/egress"
+)
+
+var (
+	// imdsFirstFetch matches the successful metadata service reads of the cloud-init datasource, the failed attempts before it
+	// are logged as warnings, i.e. "Read from http://169.254.169.254/latest/api/token (200, 56b) after 1 attempts"
+	imdsFirstFetch        = regexp.MustCompile(`.*url_helper\.py\[DEBUG\]: Read from (?P<url>https?://(169\.254\.169\.254|\[fd00:ec2::254\])\S*) \(200, \S+\) after (?P<attempts>[0-9]+) attempts.*`)
+	imdsFirstFetchComment = template.Must(template.New("imds").Parse("{{.url}} after {{.attempts}} attempts"))
+)
+
+// WithEgressProbe is a builder func that adds the egress source which probes the external HTTPS URL until the first connection succeeds
+func (m *Measurer) WithEgressProbe(url string) *Measurer {
+	m.egressProbeURL = url
+	return m
+}
+
+// reachabilityEvents returns the first IMDS fetch event if the cloud-init source is registered and the first external HTTPS connection
+// event if the egress source is registered, so metadata service and egress delays show up explicitly before the network dependent events
+func (m *Measurer) reachabilityEvents() []*sources.Event {
+	var events []*sources.Event
+	if cloudInitSrc, ok := m.GetSource(cloudinitsrc.Name); ok {
+		events = append(events, &sources.Event{
+			Name:          "IMDS First Fetch",
+			Metric:        "imds_first_fetch",
+			SrcName:       cloudinitsrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     sources.CommentTemplate(imdsFirstFetch, imdsFirstFetchComment),
+			FindFn:        cloudInitSrc.(*cloudinitsrc.Source).FindByRegex(imdsFirstFetch),
+			Regex:         imdsFirstFetch.String(),
+		})
+	}
+	if egressSrc, ok := m.GetSource(egresssrc.Name); ok {
+		events = append(events, &sources.Event{
+			Name:          "External HTTPS First Connection",
+			Metric:        "egress_first_connection",
+			SrcName:       egresssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			CommentFn:     egresssrc.Comment,
+			FindFn:        egressSrc.(*egresssrc.Source).FindFirstConnection(),
+		})
+	}
+	return events
+}
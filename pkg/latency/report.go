@@ -0,0 +1,216 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// reportPhaseColors are the colors the phases of the report timeline are colored with in the order the phases first occur
+var reportPhaseColors = []string{"#17becf", "#ff7f0e", "#2ca02c", "#9467bd", "#1f77b4", "#d62728"}
+
+// BudgetResult is the evaluation of a latency budget of an event metric
+type BudgetResult struct {
+	Metric string        `json:"metric"`
+	Budget time.Duration `json:"budget"`
+	// Actual is the latest time of the metric's events since the first event
+	Actual time.Duration `json:"actual"`
+	Passed bool          `json:"passed"`
+	// Missing is set if the metric does not have a timing, which fails the budget
+	Missing bool `json:"missing,omitempty"`
+}
+
+// EvaluateBudgets checks the latest timing of each budgeted metric against its budget
+func (m *Measurement) EvaluateBudgets(budgets map[string]time.Duration) []*BudgetResult {
+	var results []*BudgetResult
+	for metric, budget := range budgets {
+		result := &BudgetResult{Metric: metric, Budget: budget}
+		timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil && t.Event.Metric == metric })
+		if len(timings) == 0 {
+			result.Missing = true
+		} else {
+			result.Actual = bootstrapTime(timings)
+			result.Passed = result.Actual <= budget
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Metric < results[j].Metric })
+	return results
+}
+
+// ReportOptions configures the HTML report
+type ReportOptions struct {
+	NodeName string
+	// Budgets are the latency budgets by event metric that are evaluated in the report
+	Budgets map[string]time.Duration
+}
+
+type reportBar struct {
+	Name    string
+	Phase   string
+	Color   string
+	Left    float64
+	Width   float64
+	T       float64
+	Elapsed float64
+}
+
+// WriteHTMLReport writes a self-contained HTML report of the measurement with the metadata, a timeline, the timings and the budget results
+func (m *Measurement) WriteHTMLReport(w io.Writer, opts ReportOptions) error {
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	total := bootstrapTime(timings)
+	phases := lo.Uniq(lo.FilterMap(timings, func(t *sources.Timing, _ int) (string, bool) {
+		return t.Event.Labels["phase"], t.Event.Labels["phase"] != ""
+	}))
+	var bars []reportBar
+	var previous time.Duration
+	for _, t := range timings {
+		bar := reportBar{Name: t.Event.Name, Phase: t.Event.Labels["phase"], Color: "#7f7f7f", T: t.T.Seconds(), Elapsed: (t.T - previous).Seconds()}
+		if i := lo.IndexOf(phases, bar.Phase); i >= 0 {
+			bar.Color = reportPhaseColors[i%len(reportPhaseColors)]
+		}
+		if total > 0 {
+			bar.Left = float64(previous) / float64(total) * 100
+			bar.Width = float64(t.T-previous) / float64(total) * 100
+		}
+		bars = append(bars, bar)
+		previous = t.T
+	}
+	budgets := m.EvaluateBudgets(opts.Budgets)
+	return reportTemplate.Execute(w, map[string]interface{}{
+		"Measurement":   m,
+		"NodeName":      opts.NodeName,
+		"Generated":     time.Now().UTC().Format(time.RFC3339),
+		"Total":         total.Seconds(),
+		"Bars":          bars,
+		"Timings":       m.Timings,
+		"Budgets":       budgets,
+		"BudgetsPassed": lo.CountBy(budgets, func(b *BudgetResult) bool { return b.Passed }),
+	})
+}
+
+// WriteHTMLReportFile writes the HTML report to a file
+func (m *Measurement) WriteHTMLReportFile(path string, opts ReportOptions) error {
+	var report bytes.Buffer
+	if err := m.WriteHTMLReport(&report, opts); err != nil {
+		return fmt.Errorf("unable to render the HTML report: %w", err)
+	}
+	//nolint:gosec
+	if err := os.WriteFile(path, report.Bytes(), 0644); err != nil {
+		return fmt.Errorf("unable to write HTML report %s: %w", path, err)
+	}
+	return nil
+}
+
+// UploadHTMLReport uploads the HTML report to an S3 URI prefix (s3://bucket/prefix) as <node name>-<unix time>.html and returns the object URI
+func (m *Measurement) UploadHTMLReport(ctx context.Context, client *s3.Client, s3URI string, opts ReportOptions) (string, error) {
+	uri, err := url.Parse(s3URI)
+	if err != nil || uri.Scheme != "s3" || uri.Host == "" {
+		return "", fmt.Errorf("\"%s\" is not an S3 URI (s3://bucket/prefix)", s3URI)
+	}
+	var report bytes.Buffer
+	if err := m.WriteHTMLReport(&report, opts); err != nil {
+		return "", fmt.Errorf("unable to render the HTML report: %w", err)
+	}
+	name := lo.Ternary(opts.NodeName != "", opts.NodeName, "node")
+	key := strings.TrimPrefix(fmt.Sprintf("%s/%s-%d.html", strings.TrimSuffix(uri.Path, "/"), name, time.Now().Unix()), "/")
+	if ok, err := dryRun("s3", fmt.Sprintf("s3://%s/%s", uri.Host, key), report.String()); ok {
+		return fmt.Sprintf("s3://%s/%s", uri.Host, key), err
+	}
+	if _, err := client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(uri.Host),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(report.Bytes()),
+		ContentType: aws.String("text/html; charset=utf-8"),
+	}); err != nil {
+		return "", fmt.Errorf("unable to upload the HTML report to s3://%s/%s: %w", uri.Host, key, err)
+	}
+	return fmt.Sprintf("s3://%s/%s", uri.Host, key), nil
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": func(d time.Duration) string { return fmt.Sprintf("%.1fs", d.Seconds()) },
+	"rfc3339": func(t time.Time) string { return t.UTC().Format(time.RFC3339Nano) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Node bootstrap latency{{ if .NodeName }} of {{ .NodeName }}{{ end }}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #d0d7de; padding: 4px 10px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+.timeline td.bar { width: 60vw; padding: 4px 0; }
+.timeline .fill { height: 14px; min-width: 2px; position: relative; }
+.pass { color: #1a7f37; font-weight: bold; }
+.fail { color: #cf222e; font-weight: bold; }
+.muted { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>Node bootstrap latency{{ if .NodeName }} of {{ .NodeName }}{{ end }}</h1>
+<p class="muted">Generated {{ .Generated }}, bootstrap took {{ printf "%.1f" .Total }}s</p>
+{{ with .Measurement.Metadata }}
+<h2>Metadata</h2>
+<table>
+<tr><th>Instance ID</th><td>{{ .InstanceID }}</td></tr>
+<tr><th>Instance Type</th><td>{{ .InstanceType }}</td></tr>
+<tr><th>Architecture</th><td>{{ .Architecture }}</td></tr>
+<tr><th>Availability Zone</th><td>{{ .AvailabilityZone }}</td></tr>
+<tr><th>AMI ID</th><td>{{ .AMIID }}</td></tr>
+<tr><th>Private IP</th><td>{{ .PrivateIP }}</td></tr>
+</table>
+{{ end }}
+{{ if .Measurement.Tracks }}
+<p>Tracks: {{ range $i, $t := .Measurement.Tracks }}{{ if $i }}, {{ end }}{{ $t.Track }} ({{ $t.Status }}){{ end }}</p>
+{{ end }}
+{{ with .Measurement.Cost }}<p>Cost: {{ .String }}</p>{{ end }}
+{{ if .Measurement.Stale }}<p class="fail">Stale: the node booted before the stale threshold, the timings may not reflect a fresh node launch</p>{{ end }}
+{{ if .Budgets }}
+<h2>Budgets ({{ .BudgetsPassed }}/{{ len .Budgets }} passed)</h2>
+<table>
+<tr><th>Metric</th><th>Budget</th><th>Actual</th><th>Result</th></tr>
+{{ range .Budgets }}<tr><td>{{ .Metric }}</td><td>{{ seconds .Budget }}</td><td>{{ if .Missing }}-{{ else }}{{ seconds .Actual }}{{ end }}</td><td>{{ if .Passed }}<span class="pass">PASS</span>{{ else if .Missing }}<span class="fail">FAIL (missing)</span>{{ else }}<span class="fail">FAIL</span>{{ end }}</td></tr>
+{{ end }}</table>
+{{ end }}
+<h2>Timeline</h2>
+<table class="timeline">
+<tr><th>Event</th><th>Phase</th><th>Time spent before the event</th><th>T</th></tr>
+{{ range .Bars }}<tr><td>{{ .Name }}</td><td>{{ .Phase }}</td><td class="bar"><div class="fill" style="left: {{ printf "%.2f" .Left }}%; width: {{ printf "%.2f" .Width }}%; background: {{ .Color }};" title="+{{ printf "%.1f" .Elapsed }}s"></div></td><td>{{ printf "%.1f" .T }}s</td></tr>
+{{ end }}</table>
+<h2>Timings</h2>
+<table>
+<tr><th>Event</th><th>Timestamp</th><th>T</th><th>Owner</th><th>Comment</th></tr>
+{{ range .Timings }}<tr><td>{{ .Event.Name }}</td>{{ if .Error }}<td colspan="4" class="fail">{{ .Error }}</td>{{ else }}<td>{{ rfc3339 .Timestamp }}</td><td>{{ seconds .T }}</td><td>{{ .Event.Owner }}</td><td>{{ .Comment }}</td>{{ end }}</tr>
+{{ end }}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,163 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is when the rounds of a soak track run, every Interval or at the minutes matching Cron, each delayed by a random Jitter.
+// The soak tracks match Cron in UTC.
+type Schedule struct {
+	Interval time.Duration
+	Cron     *Cron
+	// Jitter is the upper bound of the random delay of a round, which spreads the rounds of nodes that share a schedule
+	Jitter time.Duration
+}
+
+// Next is the start of the next round after now
+func (s Schedule) Next(now time.Time, random *rand.Rand) time.Time {
+	next := now.Add(s.Interval)
+	if s.Cron != nil {
+		next = s.Cron.Next(now)
+	}
+	if s.Jitter > 0 {
+		next = next.Add(time.Duration(random.Int63n(int64(s.Jitter))))
+	}
+	return next
+}
+
+// cronMaxSearch bounds the search for the next matching minute
+const cronMaxSearch = 5 * 366 * 24 * time.Hour
+
+// Cron is a standard 5 field cron expression of the minute, hour, day of month, month and day of week.
+// Each field is a *, or a comma separated list of values and a-b ranges, optionally with a /step.
+type Cron struct {
+	expression string
+	minutes    map[int]bool
+	hours      map[int]bool
+	days       map[int]bool
+	months     map[int]bool
+	weekdays   map[int]bool
+	// anyDay and anyWeekday are set if the field is *, otherwise a day matches if either field matches like in cron
+	anyDay     bool
+	anyWeekday bool
+}
+
+// ParseCron parses a 5 field cron expression, i.e. */15 * * * * or 0 9-17 * * 1-5
+func ParseCron(expression string) (*Cron, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression \"%s\" must have 5 fields: minute, hour, day of month, month and day of week", expression)
+	}
+	cron := &Cron{expression: expression, anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	var err error
+	for _, field := range []struct {
+		values   *map[int]bool
+		min, max int
+	}{
+		{&cron.minutes, 0, 59},
+		{&cron.hours, 0, 23},
+		{&cron.days, 1, 31},
+		{&cron.months, 1, 12},
+		{&cron.weekdays, 0, 7},
+	} {
+		if *field.values, err = parseCronField(fields[0], field.min, field.max); err != nil {
+			return nil, fmt.Errorf("invalid cron expression \"%s\": %w", expression, err)
+		}
+		fields = fields[1:]
+	}
+	// 7 is also Sunday
+	if cron.weekdays[7] {
+		cron.weekdays[0] = true
+	}
+	return cron, nil
+}
+
+// parseCronField parses the values of a cron field within [min, max]
+func parseCronField(field string, min int, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step \"%s\"", stepPart)
+			}
+		}
+		low, high := min, max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(lowPart); err != nil {
+				return nil, fmt.Errorf("invalid value \"%s\"", lowPart)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highPart); err != nil {
+					return nil, fmt.Errorf("invalid value \"%s\"", highPart)
+				}
+			} else if hasStep {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("\"%s\" is not within %d-%d", part, min, max)
+		}
+		for value := low; value <= high; value += step {
+			values[value] = true
+		}
+	}
+	return values, nil
+}
+
+// Next is the first minute after now that matches the expression, in the location of now.
+// An expression that never matches, i.e. 0 0 31 2 *, is next after cronMaxSearch.
+func (c *Cron) Next(now time.Time) time.Time {
+	end := now.Add(cronMaxSearch)
+	for next := now.Truncate(time.Minute).Add(time.Minute); next.Before(end); next = next.Add(time.Minute) {
+		if c.matches(next) {
+			return next
+		}
+	}
+	return end
+}
+
+// matches is true if the expression matches the minute of t
+func (c *Cron) matches(t time.Time) bool {
+	if !c.minutes[t.Minute()] || !c.hours[t.Hour()] || !c.months[int(t.Month())] {
+		return false
+	}
+	day, weekday := c.days[t.Day()], c.weekdays[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
+
+// String is the cron expression
+func (c *Cron) String() string {
+	return c.expression
+}
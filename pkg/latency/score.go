@@ -0,0 +1,95 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// BootstrapScoreMetric is the composite bootstrap score of a measurement if score weights are configured
+const BootstrapScoreMetric = "bootstrap_score"
+
+// ScoreWeight is the weight of an event metric in the bootstrap score and the target seconds it is scored against
+type ScoreWeight struct {
+	Metric string        `json:"metric"`
+	Weight float64       `json:"weight"`
+	Target time.Duration `json:"target"`
+}
+
+// BootstrapScore combines the event metrics of a measurement into a single normalized score from 0 to 100, so a fleet can be ranked
+// and alerted on with one metric. A metric within its target scores 1, a slower metric scores target / actual, and a missing metric
+// scores 0. The score is the weighted mean of the metric scores times 100.
+type BootstrapScore struct {
+	Score      float64           `json:"score"`
+	Components []*ScoreComponent `json:"components"`
+}
+
+// ScoreComponent is the score of an event metric in the bootstrap score
+type ScoreComponent struct {
+	ScoreWeight
+	// Actual is the latest time of the metric's events since the first event
+	Actual  time.Duration `json:"actual"`
+	Missing bool          `json:"missing,omitempty"`
+	Score   float64       `json:"score"`
+}
+
+// WithBootstrapScore adds the bootstrap score of the weighted event metrics to measurements
+func (m *Measurer) WithBootstrapScore(weights []ScoreWeight) *Measurer {
+	m.scoreWeights = weights
+	return m
+}
+
+// bootstrapScore scores the timings against the weights
+func bootstrapScore(weights []ScoreWeight, timings []*sources.Timing) *BootstrapScore {
+	score := &BootstrapScore{}
+	var weighted, total float64
+	for _, weight := range weights {
+		component := &ScoreComponent{ScoreWeight: weight}
+		metricTimings := lo.Filter(timings, func(t *sources.Timing, _ int) bool { return t.Error == nil && t.Event.Metric == weight.Metric })
+		if len(metricTimings) == 0 {
+			component.Missing = true
+		} else {
+			component.Actual = bootstrapTime(metricTimings)
+			component.Score = lo.Ternary(component.Actual <= weight.Target, 1, weight.Target.Seconds()/component.Actual.Seconds())
+		}
+		weighted += component.Score * weight.Weight
+		total += weight.Weight
+		score.Components = append(score.Components, component)
+	}
+	if total > 0 {
+		score.Score = weighted / total * 100
+	}
+	return score
+}
+
+// String is a human readable summary of the score and its lowest scoring metrics
+func (s *BootstrapScore) String() string {
+	low := lo.Filter(s.Components, func(c *ScoreComponent, _ int) bool { return c.Score < 1 })
+	if len(low) == 0 {
+		return fmt.Sprintf("%.1f/100, all metrics within their targets", s.Score)
+	}
+	return fmt.Sprintf("%.1f/100, over target: %s", s.Score, strings.Join(lo.Map(low, func(c *ScoreComponent, _ int) string {
+		if c.Missing {
+			return fmt.Sprintf("%s (missing)", c.Metric)
+		}
+		return fmt.Sprintf("%s (%.0fs > %.0fs)", c.Metric, c.Actual.Seconds(), c.Target.Seconds())
+	}), ", "))
+}
@@ -0,0 +1,297 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// Soak tracks are the non-boot measurements that are re-run in rounds after the boot measurement
+const (
+	// SoakTrackPodChurn samples the startups of the pods created on the node since the previous round
+	SoakTrackPodChurn = "pod-churn"
+	// SoakTrackSyntheticProbe runs a synthetic probe pod each round
+	SoakTrackSyntheticProbe = "synthetic-probe"
+)
+
+// SoakFailuresMetric counts the failures of the soak rounds emitted to CloudWatch by the track and phase they failed in
+const SoakFailuresMetric = "soak_failures"
+
+// SoakTracks are the supported soak tracks
+var SoakTracks = []string{SoakTrackPodChurn, SoakTrackSyntheticProbe}
+
+// Soak emitters a track emits its rounds to
+const (
+	SoakEmitterPrometheus = "prometheus"
+	SoakEmitterCloudWatch = "cloudwatch"
+	SoakEmitterLog        = "log"
+)
+
+// SoakEmitters are the supported soak emitters
+var SoakEmitters = []string{SoakEmitterPrometheus, SoakEmitterCloudWatch, SoakEmitterLog}
+
+// SoakObservation is a duration observed in a soak round, i.e. the startup of a sampled pod or a phase of a synthetic probe
+type SoakObservation struct {
+	Metric  string  `json:"metric"`
+	Phase   string  `json:"phase,omitempty"`
+	Seconds float64 `json:"seconds"`
+}
+
+// SoakRound is the result of a round of a soak track
+type SoakRound struct {
+	Track        string            `json:"track"`
+	Round        int               `json:"round"`
+	Time         time.Time         `json:"time"`
+	Observations []SoakObservation `json:"observations"`
+	// Failures counts the failures of the round by the phase they failed in
+	Failures map[string]int `json:"failures,omitempty"`
+}
+
+// SoakTrack is a non-boot measurement that is re-run in rounds on its schedule and emitted to its own emitters
+type SoakTrack struct {
+	Name     string
+	Schedule Schedule
+	Emitters []string
+	// round measures a round of the track
+	round func(ctx context.Context) *SoakRound
+}
+
+// SoakOptions are the emitter clients and dimensions shared by the soak tracks
+type SoakOptions struct {
+	// Registerer registers the metrics of the tracks with the prometheus emitter
+	Registerer prometheus.Registerer
+	// CloudWatch is the client of the tracks with the cloudwatch emitter
+	CloudWatch          *cloudwatch.Client
+	ExperimentDimension string
+}
+
+// PodChurnTrack samples the startups of the pods created on the node each round, sampleRate is the fraction of pods that are sampled (0, 1]
+func (m *Measurer) PodChurnTrack(sampleRate float64, schedule Schedule, emitters []string) (*SoakTrack, error) {
+	sampler, err := m.newPodChurnSampler(sampleRate)
+	if err != nil {
+		return nil, err
+	}
+	return &SoakTrack{Name: SoakTrackPodChurn, Schedule: schedule, Emitters: emitters, round: func(ctx context.Context) *SoakRound {
+		round := &SoakRound{Track: SoakTrackPodChurn, Time: time.Now()}
+		startups, err := sampler.sample(ctx)
+		if err != nil {
+			zap.S().Warnf("Unable to list pods for pod startup sampling: %s", err)
+			round.Failures = map[string]int{"list": 1}
+		}
+		for _, startup := range startups {
+			round.Observations = append(round.Observations, SoakObservation{Metric: PodStartupMetric, Seconds: startup.Seconds()})
+		}
+		return round
+	}}, nil
+}
+
+// SyntheticProbeTrack runs a synthetic probe each round, the schedule replaces the interval of the probe
+func (m *Measurer) SyntheticProbeTrack(probe SyntheticProbe, schedule Schedule, emitters []string) (*SoakTrack, error) {
+	if m.k8sClientset == nil || m.nodeName == "" {
+		return nil, fmt.Errorf("the synthetic probe requires the K8s clientset and node name")
+	}
+	return &SoakTrack{Name: SoakTrackSyntheticProbe, Schedule: schedule, Emitters: emitters, round: func(ctx context.Context) *SoakRound {
+		round := &SoakRound{Track: SoakTrackSyntheticProbe, Time: time.Now()}
+		phases, err := m.syntheticProbe(ctx, probe)
+		for _, phase := range []string{SyntheticPhaseScheduled, SyntheticPhaseReady, SyntheticPhaseDeleted} {
+			if duration, ok := phases[phase]; ok {
+				round.Observations = append(round.Observations, SoakObservation{Metric: SyntheticPodStartupMetric, Phase: phase, Seconds: duration.Seconds()})
+			}
+		}
+		if err != nil {
+			zap.S().Warnf("Synthetic probe failed to be %s: %s", err.phase, err.err)
+			round.Failures = map[string]int{err.phase: 1}
+		}
+		return round
+	}}, nil
+}
+
+// RunSoak runs the rounds of the soak tracks on their schedules until the context is done. Each track runs independently of the boot
+// measurement and the other tracks, so a slow round only delays the next round of its own track, and emits its rounds to its own emitters.
+// The measurement provides the dimensions of the emitted metrics.
+func (m *Measurer) RunSoak(ctx context.Context, measurement *Measurement, tracks []*SoakTrack, opts SoakOptions) error {
+	dimensions := measurement.metricDimensions(opts.ExperimentDimension)
+	var emitFns []func(ctx context.Context, round *SoakRound) error
+	for _, track := range tracks {
+		emit, err := track.emitter(dimensions, opts)
+		if err != nil {
+			return fmt.Errorf("unable to configure the emitters of soak track %s: %w", track.Name, err)
+		}
+		emitFns = append(emitFns, emit)
+	}
+	var wg sync.WaitGroup
+	for i, track := range tracks {
+		wg.Add(1)
+		go func(track *SoakTrack, emit func(ctx context.Context, round *SoakRound) error) {
+			defer wg.Done()
+			track.run(ctx, emit)
+		}(track, emitFns[i])
+	}
+	wg.Wait()
+	return nil
+}
+
+// run measures and emits the rounds of the track until the context is done
+func (t *SoakTrack) run(ctx context.Context, emit func(ctx context.Context, round *SoakRound) error) {
+	//nolint:gosec
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 1; ; i++ {
+		round := t.round(ctx)
+		round.Round = i
+		if err := emit(ctx, round); err != nil {
+			zap.S().Errorf("Error emitting round %d of soak track %s: %s", i, t.Name, err)
+		}
+		next := t.Schedule.Next(time.Now().UTC(), random)
+		zap.S().Debugf("Next round of soak track %s at %s", t.Name, next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// emitter returns a func that emits a round to all emitters of the track, the prometheus metrics of the track are registered once
+func (t *SoakTrack) emitter(dimensions map[string]string, opts SoakOptions) (func(ctx context.Context, round *SoakRound) error, error) {
+	var emitFns []func(ctx context.Context, round *SoakRound) error
+	for _, emitter := range t.Emitters {
+		switch emitter {
+		case SoakEmitterPrometheus:
+			if opts.Registerer == nil {
+				return nil, fmt.Errorf("the prometheus emitter requires --prometheus-metrics")
+			}
+			emit, err := t.prometheusEmitter(opts.Registerer)
+			if err != nil {
+				return nil, err
+			}
+			emitFns = append(emitFns, emit)
+		case SoakEmitterCloudWatch:
+			if opts.CloudWatch == nil {
+				return nil, fmt.Errorf("the cloudwatch emitter requires a CloudWatch client")
+			}
+			emitFns = append(emitFns, func(ctx context.Context, round *SoakRound) error {
+				return round.emitCloudWatch(ctx, opts.CloudWatch, dimensions)
+			})
+		case SoakEmitterLog:
+			emitFns = append(emitFns, func(_ context.Context, round *SoakRound) error {
+				roundBytes, err := json.Marshal(round)
+				if err != nil {
+					return err
+				}
+				zap.S().Infof("Soak round: %s", roundBytes)
+				return nil
+			})
+		default:
+			return nil, fmt.Errorf("unknown soak emitter \"%s\"", emitter)
+		}
+	}
+	return func(ctx context.Context, round *SoakRound) error {
+		var errs error
+		for _, emit := range emitFns {
+			errs = multierr.Append(errs, emit(ctx, round))
+		}
+		return errs
+	}, nil
+}
+
+// prometheusEmitter registers the metrics of the track, which are the same as without the soak schedule, and returns a func that observes a round
+func (t *SoakTrack) prometheusEmitter(register prometheus.Registerer) (func(ctx context.Context, round *SoakRound) error, error) {
+	switch t.Name {
+	case SoakTrackPodChurn:
+		histogram := newPodStartupHistogram()
+		if err := register.Register(histogram); err != nil {
+			return nil, err
+		}
+		return func(_ context.Context, round *SoakRound) error {
+			for _, observation := range round.Observations {
+				histogram.Observe(observation.Seconds)
+			}
+			return nil
+		}, nil
+	case SoakTrackSyntheticProbe:
+		histogram, failures := newSyntheticProbeCollectors()
+		for _, collector := range []prometheus.Collector{histogram, failures} {
+			if err := register.Register(collector); err != nil {
+				return nil, err
+			}
+		}
+		return func(_ context.Context, round *SoakRound) error {
+			for _, observation := range round.Observations {
+				histogram.WithLabelValues(observation.Phase).Observe(observation.Seconds)
+			}
+			for phase, count := range round.Failures {
+				failures.WithLabelValues(phase).Add(float64(count))
+			}
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown soak track \"%s\"", t.Name)
+}
+
+// emitCloudWatch posts the observations and failures of the round to CloudWatch with the phase as an additional dimension
+func (r *SoakRound) emitCloudWatch(ctx context.Context, cw *cloudwatch.Client, dimensions map[string]string) error {
+	dimensions = lo.Assign(dimensions, map[string]string{"track": r.Track})
+	var metricData []types.MetricDatum
+	for _, observation := range r.Observations {
+		metricData = append(metricData, types.MetricDatum{
+			MetricName: aws.String(observation.Metric),
+			Value:      aws.Float64(observation.Seconds),
+			Unit:       types.StandardUnitSeconds,
+			Timestamp:  aws.Time(r.Time),
+			Dimensions: cloudWatchDimensions(lo.Assign(dimensions, lo.OmitByValues(map[string]string{"phase": observation.Phase}, []string{""}))),
+		})
+	}
+	for phase, count := range r.Failures {
+		metricData = append(metricData, types.MetricDatum{
+			MetricName: aws.String(SoakFailuresMetric),
+			Value:      aws.Float64(float64(count)),
+			Unit:       types.StandardUnitCount,
+			Timestamp:  aws.Time(r.Time),
+			Dimensions: cloudWatchDimensions(lo.Assign(dimensions, map[string]string{"phase": phase})),
+		})
+	}
+	if len(metricData) == 0 {
+		return nil
+	}
+	var inputs []*cloudwatch.PutMetricDataInput
+	for _, batch := range lo.Chunk(metricData, CloudWatchMaxBatchSize) {
+		inputs = append(inputs, &cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String("KubernetesNodeLatency"),
+			MetricData: batch,
+		})
+	}
+	if ok, err := dryRun(fmt.Sprintf("soak-%s-cloudwatch", r.Track), "PutMetricData", inputs); ok {
+		return err
+	}
+	for _, input := range inputs {
+		if _, err := cw.PutMetricData(ctx, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
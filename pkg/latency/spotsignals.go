@@ -0,0 +1,110 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	imdssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/imds"
+)
+
+// Spot signal event metrics
+const (
+	SpotInterruptionMetric = "spot_interruption_notice"
+	SpotRebalanceMetric    = "spot_rebalance_recommendation"
+)
+
+// WithSpotSignals is a builder func that adds the spot interruption notice and rebalance recommendation events from IMDS
+func (m *Measurer) WithSpotSignals() *Measurer {
+	m.spotSignals = true
+	return m
+}
+
+// spotSignalEvents are the spot interruption notice and rebalance recommendation events if spot signals are enabled and IMDS is available.
+// They are not found until the signal is given, so they are measured with the launch or upgrade and polled afterwards by PollSpotSignals.
+func (m *Measurer) spotSignalEvents() []*sources.Event {
+	imdsSrc, ok := m.GetSource(imdssrc.Name)
+	if !m.spotSignals || !ok {
+		return nil
+	}
+	return []*sources.Event{
+		{
+			Name:          "Spot Interruption Notice",
+			Metric:        SpotInterruptionMetric,
+			SrcName:       imdssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        imdsSrc.(*imdssrc.Source).FindByPath(imdssrc.SpotInstanceAction),
+		},
+		{
+			Name:          "Rebalance Recommendation",
+			Metric:        SpotRebalanceMetric,
+			SrcName:       imdssrc.Name,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			FindFn:        imdsSrc.(*imdssrc.Source).FindByPath(imdssrc.RebalanceRecommendation),
+		},
+	}
+}
+
+// PollSpotSignals polls IMDS every interval for the spot signals that were not given by the time of the measurement, until they are
+// given or the context is done. Signals are registered as metrics of the measurement's dimensions in seconds since its first successful
+// timing, so the termination latency can be correlated with the signal time.
+func (m *Measurer) PollSpotSignals(ctx context.Context, register prometheus.Registerer, measurement *Measurement, experimentDimension string, interval time.Duration) error {
+	start, ok := lo.Find(measurement.Timings, func(t *sources.Timing) bool { return t.Error == nil })
+	if !ok {
+		return nil
+	}
+	startTime := start.Timestamp.Add(-start.T)
+	dimensions := measurement.metricDimensions(experimentDimension)
+	pending := lo.Filter(m.events, func(e *sources.Event, _ int) bool {
+		return (e.Metric == SpotInterruptionMetric || e.Metric == SpotRebalanceMetric) &&
+			!lo.ContainsBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Metric == e.Metric })
+	})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		var given []*sources.Event
+		for _, event := range pending {
+			results, err := event.Src.Find(event)
+			if err != nil {
+				zap.S().Warnf("Unable to poll Event \"%s\": %s", event.Name, err)
+				continue
+			}
+			if len(results) == 0 || results[0].Err != nil {
+				continue
+			}
+			labels := lo.Assign(dimensions, eventLabels(event))
+			gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: event.Metric}, lo.Keys(labels))
+			if err := register.Register(gauge); err != nil {
+				return err
+			}
+			gauge.With(labels).Set(results[0].Timestamp.Sub(startTime).Seconds())
+			zap.S().Infof("Observed Event \"%s\" at %s", event.Name, results[0].Timestamp.UTC().Format(time.RFC3339))
+			given = append(given, event)
+		}
+		pending = lo.Without(pending, given...)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// DefaultStartupTaint is the taint the startup gate keeps on the node until it is really ready
+const DefaultStartupTaint = "node-latency.k8s.aws/startup"
+
+// StartupGate keeps a startup taint on the node until its milestones are measured and its resources are allocatable,
+// so workloads only land on the node once it is really ready, i.e. once the image cache is warmed or the GPUs are allocatable
+type StartupGate struct {
+	// Taint is applied when the measurement starts, unless the node registered with it, and removed once the gate opens
+	Taint corev1.Taint
+	// Milestones are the event metrics that must be measured
+	Milestones []string
+	// Resources are the resources that must be allocatable, i.e. nvidia.com/gpu
+	Resources []corev1.ResourceName
+	// RemoveOnTimeout removes the taint when the milestones are not met by the timeout or deadline of the measurement
+	RemoveOnTimeout bool
+	// Opened is set if the gate already opened during this boot, i.e. before the measurer restarted, so the taint is not applied again
+	Opened bool
+	open   bool
+}
+
+// WithStartupGate keeps the startup taint of the gate on the node while MeasureUntil runs until the gate's milestones are met.
+// The K8s source measures the removal as the Startup Taint Removed event.
+func (m *Measurer) WithStartupGate(gate *StartupGate) *Measurer {
+	m.startupGate = gate
+	return m
+}
+
+// ApplyStartupTaint taints the node with the startup taint of the gate if the node is fresh and does not have it yet.
+// A node is not fresh if it booted before the stale threshold, if the gate already opened during this boot or if it is Ready without
+// the taint, since workloads may already run on it. The gate is then open and the taint is neither applied nor waited for.
+func (m *Measurer) ApplyStartupTaint(ctx context.Context) error {
+	if m.startupGate == nil {
+		return nil
+	}
+	reason, err := m.staleStartupGate(ctx)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		zap.S().Infof("Not applying the %s startup taint, %s", m.startupGate.Taint.Key, reason)
+		m.startupGate.open = true
+		return nil
+	}
+	return m.updateTaints(ctx, func(taints []corev1.Taint) ([]corev1.Taint, bool) {
+		if lo.ContainsBy(taints, func(t corev1.Taint) bool { return t.Key == m.startupGate.Taint.Key }) {
+			return taints, false
+		}
+		return append(taints, m.startupGate.Taint), true
+	})
+}
+
+// staleStartupGate returns why the startup taint must not be applied to the node, or an empty string if the node is fresh
+func (m *Measurer) staleStartupGate(ctx context.Context) (string, error) {
+	if m.stale {
+		return "the node booted before the stale threshold", nil
+	}
+	if m.startupGate.Opened {
+		return "the startup gate already opened during this boot", nil
+	}
+	if m.k8sClientset == nil || m.nodeName == "" {
+		return "", fmt.Errorf("the startup gate requires the K8s API and the node name")
+	}
+	node, err := m.k8sClientset.CoreV1().Nodes().Get(ctx, m.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	// a node that registered with the taint keeps it until the gate opens, even if it is already Ready
+	if lo.ContainsBy(node.Spec.Taints, func(t corev1.Taint) bool { return t.Key == m.startupGate.Taint.Key }) {
+		return "", nil
+	}
+	if lo.ContainsBy(node.Status.Conditions, func(c corev1.NodeCondition) bool {
+		return c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue
+	}) {
+		return "the node is already Ready", nil
+	}
+	return "", nil
+}
+
+// StartupGateOpened returns true if the startup gate removed the taint, or was open since the node was not fresh
+func (m *Measurer) StartupGateOpened() bool {
+	return m.startupGate != nil && m.startupGate.open
+}
+
+// openStartupGate removes the startup taint once the milestones are measured and the resources are allocatable
+func (m *Measurer) openStartupGate(ctx context.Context, measurement *Measurement) {
+	if unmet := m.startupGate.unmetMilestones(measurement); len(unmet) > 0 {
+		zap.S().Debugf("Startup gate is waiting for %s", strings.Join(unmet, ", "))
+		return
+	}
+	if len(m.startupGate.Resources) > 0 {
+		node, err := m.k8sClientset.CoreV1().Nodes().Get(ctx, m.nodeName, metav1.GetOptions{})
+		if err != nil {
+			zap.S().Warnf("Unable to get node %s for the startup gate: %s", m.nodeName, err)
+			return
+		}
+		for _, resource := range m.startupGate.Resources {
+			if quantity, ok := node.Status.Allocatable[resource]; !ok || quantity.IsZero() {
+				zap.S().Debugf("Startup gate is waiting for %s to be allocatable", resource)
+				return
+			}
+		}
+	}
+	if err := m.removeStartupTaint(ctx); err != nil {
+		zap.S().Errorf("Unable to remove the startup taint: %s", err)
+		return
+	}
+	zap.S().Infof("Startup gate milestones are met, removed the %s taint", m.startupGate.Taint.Key)
+	m.startupGate.open = true
+}
+
+// timeoutStartupGate removes the startup taint if the gate removes it on timeout, otherwise the node keeps it
+func (m *Measurer) timeoutStartupGate(ctx context.Context) {
+	if !m.startupGate.RemoveOnTimeout {
+		zap.S().Errorf("Startup gate milestones were not met, the node keeps the %s taint", m.startupGate.Taint.Key)
+		return
+	}
+	if err := m.removeStartupTaint(ctx); err != nil {
+		zap.S().Errorf("Unable to remove the startup taint: %s", err)
+		return
+	}
+	zap.S().Warnf("Startup gate milestones were not met, removed the %s taint on timeout", m.startupGate.Taint.Key)
+	m.startupGate.open = true
+}
+
+// unmetMilestones returns the milestone metrics that do not have a successful timing in the measurement
+func (g *StartupGate) unmetMilestones(measurement *Measurement) []string {
+	return lo.Filter(g.Milestones, func(metric string, _ int) bool {
+		return !lo.ContainsBy(measurement.Timings, func(t *sources.Timing) bool { return t.Event.Metric == metric && t.Error == nil })
+	})
+}
+
+// removeStartupTaint removes the startup taint of the gate from the node
+func (m *Measurer) removeStartupTaint(ctx context.Context) error {
+	return m.updateTaints(ctx, func(taints []corev1.Taint) ([]corev1.Taint, bool) {
+		remaining := lo.Reject(taints, func(t corev1.Taint, _ int) bool { return t.Key == m.startupGate.Taint.Key })
+		return remaining, len(remaining) != len(taints)
+	})
+}
+
+// updateTaints updates the taints of the node with the update func, which returns false if nothing changed.
+// The taints are patched with the resourceVersion they were read at, so the patch is retried on conflicts with other updates of the node.
+func (m *Measurer) updateTaints(ctx context.Context, update func([]corev1.Taint) ([]corev1.Taint, bool)) error {
+	if m.k8sClientset == nil || m.nodeName == "" {
+		return fmt.Errorf("the startup gate requires the K8s API and the node name")
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := m.k8sClientset.CoreV1().Nodes().Get(ctx, m.nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		taints, changed := update(node.Spec.Taints)
+		if !changed {
+			return nil
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"resourceVersion": node.ResourceVersion},
+			"spec":     map[string]interface{}{"taints": taints},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = m.k8sClientset.CoreV1().Nodes().Patch(ctx, m.nodeName, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
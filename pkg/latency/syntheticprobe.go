@@ -0,0 +1,196 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// SyntheticPodStartupMetric is the histogram of the phases of the synthetic probe pods by the phase label
+	SyntheticPodStartupMetric = "synthetic_pod_startup_seconds"
+	// SyntheticProbeFailuresMetric counts the synthetic probes that failed by the phase label they failed in
+	SyntheticProbeFailuresMetric = "synthetic_pod_probe_failures_total"
+	// SyntheticProbeLabel marks the synthetic probe pods, they are not sampled as workload pods
+	SyntheticProbeLabel = "node-latency.k8s.aws/synthetic-probe"
+	// DefaultSyntheticProbeImage is the image of the synthetic probe pods
+	DefaultSyntheticProbeImage = "registry.k8s.io/pause:3.9"
+)
+
+// Phases of a synthetic probe
+const (
+	SyntheticPhaseScheduled = "scheduled"
+	SyntheticPhaseReady     = "ready"
+	SyntheticPhaseDeleted   = "deleted"
+)
+
+// syntheticProbePollInterval is how often the probe pod is polled for its phase transitions, which bounds the precision of the phases
+const syntheticProbePollInterval = 250 * time.Millisecond
+
+// SyntheticProbe configures the probe pods that are periodically created on the node
+type SyntheticProbe struct {
+	Namespace string
+	Image     string
+	// Interval is the time between the start of two probes
+	Interval time.Duration
+	// Timeout fails a probe phase that takes longer
+	Timeout time.Duration
+}
+
+// RunSyntheticProbes periodically creates a tiny pod pinned to the node with a node selector until the context is done and observes the time
+// from creation to scheduled, from scheduled to ready, and from the delete request until the pod is gone.
+// This is an end-to-end pod start SLI of the node in steady state, distinct from the bootstrap measurement.
+func (m *Measurer) RunSyntheticProbes(ctx context.Context, register prometheus.Registerer, probe SyntheticProbe) error {
+	if m.k8sClientset == nil || m.nodeName == "" {
+		return fmt.Errorf("the synthetic probe requires the K8s clientset and node name")
+	}
+	histogram, failures := newSyntheticProbeCollectors()
+	for _, collector := range []prometheus.Collector{histogram, failures} {
+		if err := register.Register(collector); err != nil {
+			return err
+		}
+	}
+	ticker := time.NewTicker(probe.Interval)
+	defer ticker.Stop()
+	for {
+		phases, err := m.syntheticProbe(ctx, probe)
+		for phase, duration := range phases {
+			histogram.WithLabelValues(phase).Observe(duration.Seconds())
+		}
+		if err != nil {
+			failures.WithLabelValues(err.phase).Inc()
+			zap.S().Warnf("Synthetic probe failed to be %s: %s", err.phase, err.err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// newSyntheticProbeCollectors are the histogram of the phases and the failure counter of the synthetic probes
+func newSyntheticProbeCollectors() (*prometheus.HistogramVec, *prometheus.CounterVec) {
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    SyntheticPodStartupMetric,
+		Help:    "Time of the phases of the synthetic probe pods, from creation to scheduled, scheduled to ready, and the delete request until the pod is gone",
+		Buckets: prometheus.ExponentialBuckets(0.25, 2, 12),
+	}, []string{"phase"})
+	failures := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: SyntheticProbeFailuresMetric,
+		Help: "Synthetic probes that failed by the phase they failed in",
+	}, []string{"phase"})
+	return histogram, failures
+}
+
+// syntheticProbeError is a failed phase of a synthetic probe
+type syntheticProbeError struct {
+	phase string
+	err   error
+}
+
+// syntheticProbe creates, waits for, and deletes a probe pod and returns the durations of the phases it completed
+func (m *Measurer) syntheticProbe(ctx context.Context, probe SyntheticProbe) (map[string]time.Duration, *syntheticProbeError) {
+	phases := map[string]time.Duration{}
+	pods := m.k8sClientset.CoreV1().Pods(probe.Namespace)
+	node, err := m.k8sClientset.CoreV1().Nodes().Get(ctx, m.nodeName, v1.GetOptions{})
+	if err != nil {
+		return phases, &syntheticProbeError{SyntheticPhaseScheduled, err}
+	}
+	created := time.Now()
+	pod, err := pods.Create(ctx, syntheticProbePod(probe, node.Labels[corev1.LabelHostname]), v1.CreateOptions{})
+	if err != nil {
+		return phases, &syntheticProbeError{SyntheticPhaseScheduled, err}
+	}
+	var scheduled time.Time
+	if err := wait.PollImmediateWithContext(ctx, syntheticProbePollInterval, probe.Timeout, func(ctx context.Context) (bool, error) {
+		current, err := pods.Get(ctx, pod.Name, v1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		for _, cond := range current.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionTrue && scheduled.IsZero() {
+				scheduled = time.Now()
+				phases[SyntheticPhaseScheduled] = scheduled.Sub(created)
+			}
+		}
+		for _, cond := range current.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue && !scheduled.IsZero() {
+				phases[SyntheticPhaseReady] = time.Since(scheduled)
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		// the probe pod is deleted so that a failed probe does not linger on the node
+		if err := pods.Delete(ctx, pod.Name, v1.DeleteOptions{GracePeriodSeconds: lo.ToPtr[int64](0)}); err != nil && !k8serrors.IsNotFound(err) {
+			zap.S().Warnf("Unable to delete synthetic probe pod %s/%s: %s", pod.Namespace, pod.Name, err)
+		}
+		return phases, &syntheticProbeError{lo.Ternary(scheduled.IsZero(), SyntheticPhaseScheduled, SyntheticPhaseReady), err}
+	}
+	deleted := time.Now()
+	if err := pods.Delete(ctx, pod.Name, v1.DeleteOptions{GracePeriodSeconds: lo.ToPtr[int64](0)}); err != nil && !k8serrors.IsNotFound(err) {
+		return phases, &syntheticProbeError{SyntheticPhaseDeleted, err}
+	}
+	if err := wait.PollImmediateWithContext(ctx, syntheticProbePollInterval, probe.Timeout, func(ctx context.Context) (bool, error) {
+		_, err := pods.Get(ctx, pod.Name, v1.GetOptions{})
+		return k8serrors.IsNotFound(err), nil
+	}); err != nil {
+		return phases, &syntheticProbeError{SyntheticPhaseDeleted, err}
+	}
+	phases[SyntheticPhaseDeleted] = time.Since(deleted)
+	return phases, nil
+}
+
+// syntheticProbePod is a tiny pod that is pinned to the node by its hostname label, so it still goes through the scheduler
+func syntheticProbePod(probe SyntheticProbe, hostname string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: "node-latency-probe-",
+			Namespace:    probe.Namespace,
+			Labels:       map[string]string{SyntheticProbeLabel: "true"},
+		},
+		Spec: corev1.PodSpec{
+			NodeSelector:                  map[string]string{corev1.LabelHostname: hostname},
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			TerminationGracePeriodSeconds: lo.ToPtr[int64](0),
+			AutomountServiceAccountToken:  lo.ToPtr(false),
+			Containers: []corev1.Container{{
+				Name:  "probe",
+				Image: probe.Image,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1m"),
+						corev1.ResourceMemory: resource.MustParse("8Mi"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("8Mi"),
+					},
+				},
+			}},
+		},
+	}
+}
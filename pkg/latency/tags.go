@@ -0,0 +1,67 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"go.uber.org/zap"
+)
+
+// dimensionNameRegex is the format of prometheus label names, which is the strictest of the emitters
+var dimensionNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// WithIMDSTagDimensions is a builder func that adds the instance tags read from IMDS as dimensions of the measurement,
+// tagDimensions maps tag keys to dimension names, i.e. {"Team": "team"}
+func (m *Measurer) WithIMDSTagDimensions(tagDimensions map[string]string) (*Measurer, error) {
+	for tag, dimension := range tagDimensions {
+		if !dimensionNameRegex.MatchString(dimension) {
+			return m, fmt.Errorf("dimension \"%s\" of tag %s must match %s", dimension, tag, dimensionNameRegex)
+		}
+	}
+	m.imdsTagDimensions = tagDimensions
+	return m, nil
+}
+
+// instanceTags reads the tags of the instance from IMDS by their dimension name, tags that are not set are left out.
+// Instance tags are only available in IMDS if they are allowed in the instance metadata options.
+func (m *Measurer) instanceTags(ctx context.Context) map[string]string {
+	tags := map[string]string{}
+	for tag, dimension := range m.imdsTagDimensions {
+		out, err := m.imdsClient.GetMetadata(ctx, &imds.GetMetadataInput{Path: fmt.Sprintf("tags/instance/%s", tag)})
+		if err != nil {
+			var respErr *smithyhttp.ResponseError
+			if !errors.As(err, &respErr) || respErr.HTTPStatusCode() != http.StatusNotFound {
+				zap.S().Warnf("Unable to read instance tag %s from IMDS: %s", tag, err)
+			}
+			continue
+		}
+		value, err := io.ReadAll(out.Content)
+		out.Content.Close()
+		if err != nil {
+			zap.S().Warnf("Unable to read instance tag %s from IMDS: %s", tag, err)
+			continue
+		}
+		tags[dimension] = string(value)
+	}
+	return tags
+}
@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// DefaultTimelineWidth is the width of the timeline bars in characters
+const DefaultTimelineWidth = 60
+
+// phaseColors are the ANSI colors the phases of the timeline are colored with in the order the phases first occur
+var phaseColors = []string{"\033[36m", "\033[33m", "\033[32m", "\033[35m", "\033[34m", "\033[31m"}
+
+const colorReset = "\033[0m"
+
+// TimelineOptions configures the timeline rendering
+type TimelineOptions struct {
+	// Width is the width of the bars in characters, DefaultTimelineWidth is used if 0
+	Width int
+	// Color colors the bars by the phase label of their event, usually only if stdout is a terminal
+	Color bool
+}
+
+// Timeline prints an ASCII Gantt chart of the measurement to stdout, each event is a bar from the previous event to the event,
+// so the bar length is proportional to the time spent before the event
+func (m *Measurement) Timeline(opts TimelineOptions) {
+	width := lo.Ternary(opts.Width > 0, opts.Width, DefaultTimelineWidth)
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	if len(timings) == 0 {
+		fmt.Println("No timings")
+		return
+	}
+	total := lo.MaxBy(timings, func(a, b *sources.Timing) bool { return a.T > b.T }).T
+	nameWidth := len(lo.MaxBy(timings, func(a, b *sources.Timing) bool { return len(a.Event.Name) > len(b.Event.Name) }).Event.Name)
+	phases := lo.Uniq(lo.FilterMap(timings, func(t *sources.Timing, _ int) (string, bool) {
+		return t.Event.Labels["phase"], t.Event.Labels["phase"] != ""
+	}))
+	// column returns the bar column of an offset from the first event
+	column := func(t float64) int {
+		if total <= 0 {
+			return 0
+		}
+		return int(math.Round(t / total.Seconds() * float64(width)))
+	}
+	var previous float64
+	for _, t := range timings {
+		start, end := column(previous), column(t.T.Seconds())
+		// an event always has a visible marker, even if no time passed since the previous event
+		barLen := lo.Max([]int{end - start, 1})
+		start = lo.Min([]int{start, width - barLen})
+		bar := strings.Repeat("█", barLen)
+		phase := t.Event.Labels["phase"]
+		if i := lo.IndexOf(phases, phase); i >= 0 && opts.Color {
+			bar = phaseColors[i%len(phaseColors)] + bar + colorReset
+		} else if phase != "" {
+			phase = fmt.Sprintf(" [%s]", phase)
+		}
+		fmt.Printf("%-*s |%s%s%s| %6.1fs (+%.1fs)%s\n", nameWidth, t.Event.Name, strings.Repeat(" ", start), bar, strings.Repeat(" ", width-start-barLen),
+			t.T.Seconds(), t.T.Seconds()-previous, lo.Ternary(opts.Color, "", phase))
+		previous = t.T.Seconds()
+	}
+	fmt.Printf("%-*s |%s| %6.1fs\n", nameWidth, "", strings.Repeat("-", width), total.Seconds())
+	if opts.Color && len(phases) > 0 {
+		fmt.Printf("\nPhases: %s\n", strings.Join(lo.Map(phases, func(phase string, i int) string {
+			return phaseColors[i%len(phaseColors)] + "█" + colorReset + " " + phase
+		}), "  "))
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+)
+
+var (
+	traceparentRE = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+	xrayTraceRE   = regexp.MustCompile(`^1-[0-9a-f]{8}-[0-9a-f]{24}$`)
+	xrayParentRE  = regexp.MustCompile(`^[0-9a-f]{16}$`)
+)
+
+// TraceContext is an incoming trace context, i.e. of the provisioning trace of Karpenter, that the bootstrap trace is parented under
+type TraceContext struct {
+	// TraceID is the 32 hex char W3C trace ID
+	TraceID string `json:"traceID"`
+	// ParentID is the 16 hex char ID of the parent span
+	ParentID string `json:"parentID"`
+	Sampled  bool   `json:"sampled"`
+}
+
+// ParseTraceContext parses a W3C traceparent (00-<trace-id>-<parent-id>-01) or an X-Ray trace header (Root=1-...;Parent=...;Sampled=1)
+func ParseTraceContext(value string) (*TraceContext, error) {
+	value = strings.TrimSpace(value)
+	if match := traceparentRE.FindStringSubmatch(value); match != nil {
+		return &TraceContext{TraceID: match[1], ParentID: match[2], Sampled: match[3] == "01"}, nil
+	}
+	tc := &TraceContext{Sampled: true}
+	for _, field := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch key {
+		case "Root":
+			if !xrayTraceRE.MatchString(val) {
+				return nil, fmt.Errorf("invalid X-Ray trace ID \"%s\"", val)
+			}
+			tc.TraceID = strings.ReplaceAll(strings.TrimPrefix(val, "1-"), "-", "")
+		case "Parent":
+			if !xrayParentRE.MatchString(val) {
+				return nil, fmt.Errorf("invalid X-Ray parent ID \"%s\"", val)
+			}
+			tc.ParentID = val
+		case "Sampled":
+			tc.Sampled = val != "0"
+		}
+	}
+	if tc.TraceID == "" || tc.ParentID == "" {
+		return nil, fmt.Errorf("\"%s\" is not a traceparent or X-Ray trace header", value)
+	}
+	return tc, nil
+}
+
+// XRayTraceID is the trace ID in the X-Ray format, 1-<8 hex epoch>-<24 hex>
+func (tc *TraceContext) XRayTraceID() string {
+	return fmt.Sprintf("1-%s-%s", tc.TraceID[:8], tc.TraceID[8:])
+}
+
+// WithTraceContext parents the emitted bootstrap trace under an incoming trace context, i.e. from the TRACEPARENT env var
+func (m *Measurer) WithTraceContext(tc *TraceContext) *Measurer {
+	m.traceContext = tc
+	return m
+}
+
+// WithTraceContextAnnotation reads the incoming trace context from an annotation on the Node, or on its Karpenter NodeClaim, if there is no static trace context
+func (m *Measurer) WithTraceContextAnnotation(annotation string) *Measurer {
+	m.traceContextAnnotation = annotation
+	return m
+}
+
+// resolveTraceContext returns the static trace context or the trace context annotated on the node by its provisioner
+func (m *Measurer) resolveTraceContext(ctx context.Context) *TraceContext {
+	if m.traceContext != nil || m.traceContextAnnotation == "" {
+		return m.traceContext
+	}
+	k8sSrc, ok := m.GetSource(k8ssrc.Name)
+	if !ok {
+		return nil
+	}
+	value, err := k8sSrc.(*k8ssrc.Source).Annotation(ctx, m.traceContextAnnotation)
+	if err != nil {
+		return nil
+	}
+	tc, err := ParseTraceContext(value)
+	if err != nil {
+		zap.S().Warnf("Invalid trace context annotation %s: %s", m.traceContextAnnotation, err)
+		return nil
+	}
+	// the annotation does not change once the node is provisioned
+	m.traceContext = tc
+	return tc
+}
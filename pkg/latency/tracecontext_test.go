@@ -0,0 +1,112 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+func TestParseTraceContext(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		value   string
+		want    *TraceContext
+		wantErr bool
+	}{
+		{
+			name:  "traceparent",
+			value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			want:  &TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7", Sampled: true},
+		},
+		{
+			name:  "traceparent not sampled",
+			value: " 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00\n",
+			want:  &TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7"},
+		},
+		{
+			name:  "X-Ray trace header",
+			value: "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1",
+			want:  &TraceContext{TraceID: "5759e988bd862e3fe1be46a994272793", ParentID: "53995c3f42cd8ad8", Sampled: true},
+		},
+		{
+			name:  "X-Ray trace header not sampled",
+			value: "Root=1-5759e988-bd862e3fe1be46a994272793; Parent=53995c3f42cd8ad8; Sampled=0",
+			want:  &TraceContext{TraceID: "5759e988bd862e3fe1be46a994272793", ParentID: "53995c3f42cd8ad8"},
+		},
+		{name: "traceparent with a short parent ID", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902-01", wantErr: true},
+		{name: "X-Ray parent ID is not hex", value: "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8adz", wantErr: true},
+		{name: "X-Ray parent ID is too short", value: "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f", wantErr: true},
+		{name: "X-Ray parent ID is too long", value: "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8aa", wantErr: true},
+		{name: "X-Ray trace header without a parent", value: "Root=1-5759e988-bd862e3fe1be46a994272793", wantErr: true},
+		{name: "invalid X-Ray trace ID", value: "Root=1-5759e988-bd862e3f;Parent=53995c3f42cd8ad8", wantErr: true},
+		{name: "not a trace context", value: "garbage", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseTraceContext(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseTraceContext() error = %v, wantErr %t", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if *got != *tc.want {
+				t.Errorf("ParseTraceContext() = %+v, want %+v", *got, *tc.want)
+			}
+		})
+	}
+}
+
+func TestOTLPSpansTraceContext(t *testing.T) {
+	now := time.Now()
+	for _, tc := range []struct {
+		name         string
+		traceContext *TraceContext
+		wantParentID string
+		wantErr      bool
+	}{
+		{name: "no trace context"},
+		{
+			name:         "parented under the trace context",
+			traceContext: &TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "00f067aa0ba902b7"},
+			wantParentID: "00f067aa0ba902b7",
+		},
+		{name: "invalid trace ID", traceContext: &TraceContext{TraceID: "4bf92f35", ParentID: "00f067aa0ba902b7"}, wantErr: true},
+		{name: "invalid parent ID", traceContext: &TraceContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentID: "parent"}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			measurement := &Measurement{
+				Timings: []*sources.Timing{
+					{Event: &sources.Event{Name: "Instance Pending", Metric: "instance_pending"}, Timestamp: now},
+					{Event: &sources.Event{Name: "Node Ready", Metric: "node_ready", Terminal: true}, Timestamp: now.Add(time.Minute)},
+				},
+				TraceContext: tc.traceContext,
+			}
+			spans, err := measurement.OTLPSpans("node")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("OTLPSpans() error = %v, wantErr %t", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got := hex.EncodeToString(spans[0].ParentSpanId); got != tc.wantParentID {
+				t.Errorf("OTLPSpans() root parent span ID = %s, want %s", got, tc.wantParentID)
+			}
+		})
+	}
+}
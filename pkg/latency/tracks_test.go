@@ -0,0 +1,194 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// fakeSource finds the timestamps of the events it has an offset for and returns an error for all others
+type fakeSource struct {
+	start   time.Time
+	offsets map[string]time.Duration
+}
+
+func (s *fakeSource) Find(event *sources.Event) ([]sources.FindResult, error) {
+	offset, ok := s.offsets[event.Name]
+	if !ok {
+		return nil, fmt.Errorf("no match for event \"%s\"", event.Name)
+	}
+	return []sources.FindResult{{Timestamp: s.start.Add(offset)}}, nil
+}
+
+func (s *fakeSource) Name() string   { return "fake" }
+func (s *fakeSource) ClearCache()    {}
+func (s *fakeSource) String() string { return "fake" }
+
+// trackEvents are a shared event, an event and a terminal event per track
+func trackEvents() []*sources.Event {
+	return []*sources.Event{
+		{Name: "start", SrcName: "fake"},
+		{Name: "kubelet", SrcName: "fake", Track: TrackNode},
+		{Name: "node-ready", SrcName: "fake", Track: TrackNode, Terminal: true},
+		{Name: "image-pulled", SrcName: "fake", Track: TrackPod},
+		{Name: "pod-ready", SrcName: "fake", Track: TrackPod, Terminal: true},
+	}
+}
+
+func newTrackMeasurer(t *testing.T, offsets map[string]time.Duration) *Measurer {
+	t.Helper()
+	src := &fakeSource{start: time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC), offsets: offsets}
+	m, err := New().RegisterSources(src).RegisterEvents(trackEvents()...)
+	if err != nil {
+		t.Fatalf("RegisterEvents() error = %v", err)
+	}
+	return m
+}
+
+func successfulTimings(measurement *Measurement) []string {
+	return lo.FilterMap(measurement.Timings, func(t *sources.Timing, _ int) (string, bool) { return t.Event.Name, t.Error == nil })
+}
+
+func trackStatuses(measurement *Measurement) map[string]string {
+	return lo.SliceToMap(measurement.Tracks, func(t *TrackStatus) (string, string) { return t.Track, t.Status })
+}
+
+func TestMeasureTracks(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		offsets     map[string]time.Duration
+		wantTimings []string
+		wantTracks  map[string]string
+	}{
+		{
+			name: "all tracks complete",
+			offsets: map[string]time.Duration{
+				"start": 0, "kubelet": 2 * time.Second, "node-ready": 5 * time.Second,
+				"image-pulled": 7 * time.Second, "pod-ready": 9 * time.Second,
+			},
+			wantTimings: []string{"start", "kubelet", "node-ready", "image-pulled", "pod-ready"},
+			wantTracks:  map[string]string{TrackNode: TrackStatusComplete, TrackPod: TrackStatusComplete},
+		},
+		{
+			name: "events past the terminal event of their track are dropped",
+			offsets: map[string]time.Duration{
+				"start": 0, "node-ready": 5 * time.Second, "kubelet": 6 * time.Second,
+				"image-pulled": 7 * time.Second, "pod-ready": 9 * time.Second,
+			},
+			wantTimings: []string{"start", "node-ready", "image-pulled", "pod-ready"},
+			wantTracks:  map[string]string{TrackNode: TrackStatusComplete, TrackPod: TrackStatusComplete},
+		},
+		{
+			name: "shared events are kept past a terminal event",
+			offsets: map[string]time.Duration{
+				"kubelet": 2 * time.Second, "node-ready": 5 * time.Second, "start": 6 * time.Second,
+				"image-pulled": 7 * time.Second, "pod-ready": 9 * time.Second,
+			},
+			wantTimings: []string{"kubelet", "node-ready", "start", "image-pulled", "pod-ready"},
+			wantTracks:  map[string]string{TrackNode: TrackStatusComplete, TrackPod: TrackStatusComplete},
+		},
+		{
+			name: "a track without its terminal event is pending",
+			offsets: map[string]time.Duration{
+				"start": 0, "image-pulled": 2 * time.Second, "kubelet": 3 * time.Second, "node-ready": 5 * time.Second,
+			},
+			wantTimings: []string{"start", "image-pulled", "kubelet", "node-ready"},
+			wantTracks:  map[string]string{TrackNode: TrackStatusComplete, TrackPod: TrackStatusPending},
+		},
+		{
+			name:        "no terminal events",
+			offsets:     map[string]time.Duration{"start": 0, "kubelet": 2 * time.Second},
+			wantTimings: []string{"start", "kubelet"},
+			wantTracks:  map[string]string{TrackNode: TrackStatusPending, TrackPod: TrackStatusPending},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			measurement := newTrackMeasurer(t, tc.offsets).Measure(context.Background())
+			if got := successfulTimings(measurement); !reflect.DeepEqual(got, tc.wantTimings) {
+				t.Errorf("Measure() timings = %v, want %v", got, tc.wantTimings)
+			}
+			if got := trackStatuses(measurement); !reflect.DeepEqual(got, tc.wantTracks) {
+				t.Errorf("Measure() tracks = %v, want %v", got, tc.wantTracks)
+			}
+		})
+	}
+}
+
+func TestTracks(t *testing.T) {
+	m := newTrackMeasurer(t, nil)
+	if got, want := m.Tracks(), []string{TrackNode, TrackPod}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tracks() = %v, want %v", got, want)
+	}
+}
+
+func TestMeasureUntilDeadline(t *testing.T) {
+	allEvents := map[string]time.Duration{
+		"start": 0, "kubelet": 2 * time.Second, "node-ready": 5 * time.Second,
+		"image-pulled": 7 * time.Second, "pod-ready": 9 * time.Second,
+	}
+	withoutPodReady := lo.OmitByKeys(allEvents, []string{"pod-ready"})
+	for _, tc := range []struct {
+		name       string
+		offsets    map[string]time.Duration
+		deadline   time.Duration
+		wantErr    bool
+		wantTracks map[string]string
+	}{
+		{
+			name:       "all tracks complete before the deadline",
+			offsets:    allEvents,
+			deadline:   time.Hour,
+			wantTracks: map[string]string{TrackNode: TrackStatusComplete, TrackPod: TrackStatusComplete},
+		},
+		{
+			name:       "pending tracks time out at the deadline",
+			offsets:    withoutPodReady,
+			deadline:   50 * time.Millisecond,
+			wantErr:    true,
+			wantTracks: map[string]string{TrackNode: TrackStatusComplete, TrackPod: TrackStatusTimedOut},
+		},
+		{
+			name:       "a deadline that passed before the first timing run",
+			offsets:    withoutPodReady,
+			deadline:   -time.Minute,
+			wantErr:    true,
+			wantTracks: map[string]string{TrackNode: TrackStatusComplete, TrackPod: TrackStatusTimedOut},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTrackMeasurer(t, tc.offsets).WithDeadline(time.Now().Add(tc.deadline))
+			start := time.Now()
+			// the timeout is far past the deadline, so only the deadline can end the measurement in time
+			measurement, err := m.MeasureUntil(context.Background(), time.Hour, 10*time.Millisecond)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("MeasureUntil() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("MeasureUntil() took %s, want it to end at the deadline", elapsed)
+			}
+			if got := trackStatuses(measurement); !reflect.DeepEqual(got, tc.wantTracks) {
+				t.Errorf("MeasureUntil() tracks = %v, want %v", got, tc.wantTracks)
+			}
+		})
+	}
+}
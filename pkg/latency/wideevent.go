@@ -0,0 +1,126 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// DefaultHoneycombAPIHost is the Honeycomb US API
+const DefaultHoneycombAPIHost = "https://api.honeycomb.io"
+
+// WideEvent flattens the measurement into one wide event with the node metadata and every measured event as fields,
+// i.e. {"instance_type": "m5.large", "node_ready": 42.1, ...}, for event stores that analyze high-cardinality fields better than pre-aggregated metrics
+func (m *Measurement) WideEvent(experimentDimension string, nodeName string) map[string]interface{} {
+	event := map[string]interface{}{
+		"experiment": experimentDimension,
+		"stale":      m.Stale,
+	}
+	if nodeName != "" {
+		event["node_name"] = nodeName
+	}
+	if m.Metadata != nil {
+		event = lo.Assign(event, lo.MapValues(m.Metadata.Tags, func(v string, _ string) interface{} { return v }))
+		event = lo.Assign(event, lo.MapValues(lo.PickBy(map[string]string{
+			"instance_id":       m.Metadata.InstanceID,
+			"instance_type":     m.Metadata.InstanceType,
+			"account_id":        m.Metadata.AccountID,
+			"architecture":      m.Metadata.Architecture,
+			"region":            m.Metadata.Region,
+			"availability_zone": m.Metadata.AvailabilityZone,
+			"ami_id":            m.Metadata.AMIID,
+			"node_group":        m.Metadata.NodeGroup,
+		}, func(_ string, v string) bool { return v != "" }), func(v string, _ string) interface{} { return v }))
+	}
+	if m.Metadata != nil && m.Metadata.LaunchTemplate != nil {
+		event["launch_template_id"] = m.Metadata.LaunchTemplate.ID
+		event["launch_template_version"] = m.Metadata.LaunchTemplate.Version
+	}
+	if m.BootID != "" {
+		event["boot_id"] = m.BootID
+	}
+	for _, timing := range lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil }) {
+		// the first match of events that match all occurrences is the field
+		if _, ok := event[timing.Event.Metric]; !ok {
+			event[timing.Event.Metric] = timing.T.Seconds()
+		}
+	}
+	for _, track := range m.Tracks {
+		event[fmt.Sprintf("track_%s", track.Track)] = track.Status
+	}
+	if m.Cost != nil {
+		event["bootstrap_cost"] = m.Cost.BootstrapCost
+	}
+	if m.ImageCache != nil {
+		event["warm_image_cache"] = m.ImageCache.Warm
+		event["image_cache_images"] = m.ImageCache.Images
+		event["image_cache_bytes"] = m.ImageCache.Bytes
+	}
+	if start, ok := m.start(); ok {
+		event["timestamp"] = start.Format(time.RFC3339Nano)
+	}
+	return event
+}
+
+// start is the timestamp of the first successful timing of the measurement
+func (m *Measurement) start() (time.Time, bool) {
+	first, ok := lo.Find(m.Timings, func(t *sources.Timing) bool { return t.Error == nil })
+	if !ok {
+		return time.Time{}, false
+	}
+	return first.Timestamp, true
+}
+
+// EmitHoneycombEvent sends the measurement as one wide event to a Honeycomb dataset
+func (m *Measurement) EmitHoneycombEvent(ctx context.Context, apiHost string, apiKey string, dataset string, experimentDimension string, nodeName string) error {
+	eventBytes, err := json.Marshal(m.WideEvent(experimentDimension, nodeName))
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/1/events/%s", strings.TrimSuffix(apiHost, "/"), url.PathEscape(dataset))
+	if ok, err := dryRun("honeycomb", endpoint, json.RawMessage(eventBytes)); ok {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(eventBytes))
+	if err != nil {
+		return fmt.Errorf("unable to create Honeycomb request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Honeycomb-Team", apiKey)
+	if start, ok := m.start(); ok {
+		req.Header.Set("X-Honeycomb-Event-Time", start.Format(time.RFC3339Nano))
+	}
+	resp, err := (&http.Client{Timeout: 10 * time.Second, Transport: proxy.Transport()}).Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send Honeycomb event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("honeycomb returned status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package latency
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// DefaultXRayDaemonAddress is the UDP address of the X-Ray daemon
+const DefaultXRayDaemonAddress = "127.0.0.1:2000"
+
+// xrayHeader precedes every segment document sent to the X-Ray daemon
+const xrayHeader = `{"format": "json", "version": 1}` + "\n"
+
+// XRaySegment is an X-Ray segment or subsegment document
+type XRaySegment struct {
+	Name        string                 `json:"name"`
+	ID          string                 `json:"id"`
+	TraceID     string                 `json:"trace_id,omitempty"`
+	ParentID    string                 `json:"parent_id,omitempty"`
+	StartTime   float64                `json:"start_time"`
+	EndTime     float64                `json:"end_time"`
+	Origin      string                 `json:"origin,omitempty"`
+	Annotations map[string]string      `json:"annotations,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Subsegments []*XRaySegment         `json:"subsegments,omitempty"`
+}
+
+// XRaySegment represents the bootstrap as a segment from the first to the last timing with a subsegment per phase label of the events.
+// The segment is parented under the incoming trace context if there is one.
+// The event timestamps of a phase are recorded in its subsegment metadata since events are instants.
+func (m *Measurement) XRaySegment(nodeName string) (*XRaySegment, error) {
+	timings := lo.Filter(m.Timings, func(t *sources.Timing, _ int) bool { return t.Error == nil })
+	if len(timings) == 0 {
+		return nil, fmt.Errorf("there are no timings")
+	}
+	start, end := timings[0].Timestamp, timings[len(timings)-1].Timestamp
+	segment := &XRaySegment{
+		Name:        "node-bootstrap",
+		ID:          xrayID(8),
+		TraceID:     fmt.Sprintf("1-%08x-%s", start.Unix(), xrayID(12)),
+		StartTime:   epochSeconds(start),
+		EndTime:     epochSeconds(end),
+		Origin:      "AWS::EC2::Instance",
+		Annotations: map[string]string{},
+	}
+	if m.TraceContext != nil {
+		segment.TraceID = m.TraceContext.XRayTraceID()
+		segment.ParentID = m.TraceContext.ParentID
+	}
+	if nodeName != "" {
+		segment.Annotations["node_name"] = nodeName
+	}
+	if m.Metadata != nil {
+		segment.Annotations = lo.Assign(segment.Annotations, lo.PickBy(map[string]string{
+			"instance_id":       m.Metadata.InstanceID,
+			"instance_type":     m.Metadata.InstanceType,
+			"ami_id":            m.Metadata.AMIID,
+			"availability_zone": m.Metadata.AvailabilityZone,
+		}, func(_ string, v string) bool { return v != "" }))
+	}
+	phases := lo.GroupBy(timings, func(t *sources.Timing) string {
+		return lo.Ternary(t.Event.Labels["phase"] != "", t.Event.Labels["phase"], "other")
+	})
+	for phase, phaseTimings := range phases {
+		segment.Subsegments = append(segment.Subsegments, &XRaySegment{
+			Name:      phase,
+			ID:        xrayID(8),
+			StartTime: epochSeconds(phaseTimings[0].Timestamp),
+			EndTime:   epochSeconds(phaseTimings[len(phaseTimings)-1].Timestamp),
+			Metadata: map[string]interface{}{"events": lo.SliceToMap(phaseTimings, func(t *sources.Timing) (string, float64) {
+				return t.Event.Name, epochSeconds(t.Timestamp)
+			})},
+		})
+	}
+	sort.Slice(segment.Subsegments, func(i, j int) bool { return segment.Subsegments[i].StartTime < segment.Subsegments[j].StartTime })
+	return segment, nil
+}
+
+// EmitXRaySegment sends the bootstrap segment to the X-Ray daemon at the UDP address
+func (m *Measurement) EmitXRaySegment(daemonAddress string, nodeName string) error {
+	segment, err := m.XRaySegment(nodeName)
+	if err != nil {
+		return err
+	}
+	if ok, err := dryRun("xray", daemonAddress, segment); ok {
+		return err
+	}
+	segmentBytes, err := json.Marshal(segment)
+	if err != nil {
+		return err
+	}
+	conn, err := net.Dial("udp", daemonAddress)
+	if err != nil {
+		return fmt.Errorf("unable to connect to the X-Ray daemon %s: %w", daemonAddress, err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(append([]byte(xrayHeader), segmentBytes...)); err != nil {
+		return fmt.Errorf("unable to send the X-Ray segment: %w", err)
+	}
+	return nil
+}
+
+// xrayID is a random hex ID of n bytes
+func xrayID(n int) string {
+	id := make([]byte, n)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// epochSeconds is the time in fractional seconds since the epoch as used by X-Ray
+func epochSeconds(t time.Time) float64 {
+	return float64(t.UnixMicro()) / float64(time.Second/time.Microsecond)
+}
@@ -0,0 +1,71 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logging configures the process-wide leveled, structured logger
+package logging
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// Formats are the supported log output formats
+	Formats = []string{"console", "json"}
+	// Levels are the supported log levels
+	Levels = []string{"debug", "info", "warn", "error"}
+
+	// base logs at debug level and is filtered by the configured level, except for debug sources
+	base         = zap.NewNop()
+	debugSources = map[string]bool{}
+)
+
+// Configure replaces the global zap logger with a logger writing to stderr in the format at the level,
+// and redirects the standard library log to it. Sources in debugSources log at debug level regardless of the level.
+func Configure(level string, format string, sourceNames []string) error {
+	var zapLevel zapcore.Level
+	if err := zapLevel.Set(level); err != nil || !lo.Contains(Levels, level) {
+		return fmt.Errorf("invalid log level \"%s\", must be one of %v", level, Levels)
+	}
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	var encoder zapcore.Encoder
+	switch format {
+	case "json":
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case "console":
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return fmt.Errorf("invalid log format \"%s\", must be one of %v", format, Formats)
+	}
+	base = zap.New(zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), zapcore.DebugLevel))
+	logger := base.WithOptions(zap.IncreaseLevel(zapLevel))
+	zap.ReplaceGlobals(logger)
+	zap.RedirectStdLog(logger)
+	debugSources = lo.SliceToMap(sourceNames, func(name string) (string, bool) { return name, true })
+	return nil
+}
+
+// ForSource returns the logger of a source, which logs at debug level if the source is a debug source
+func ForSource(name string) *zap.SugaredLogger {
+	if debugSources[name] {
+		return base.Named(name).Sugar()
+	}
+	return zap.S().Named(name)
+}
@@ -0,0 +1,113 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package proxy configures the process-wide HTTP(S) proxy of the network sources and emitters
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/samber/lo"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// MetadataHosts are the instance metadata services and the EKS Pod Identity and ECS credential agents which are link-local and never
+// reached through a proxy
+var MetadataHosts = []string{"169.254.169.254", "fd00:ec2::254", "metadata.google.internal", "169.254.170.23", "fd00:ec2::23", "169.254.170.2"}
+
+var (
+	config    = httpproxy.FromEnvironment()
+	proxyFunc = config.ProxyFunc()
+)
+
+// Configure sets the proxy of all HTTP clients. The proxies and the hosts that bypass them default to the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY env vars, the MetadataHosts and the bypass hosts, i.e. a custom IMDS endpoint, are always bypassed. The resulting config is
+// written back to the env vars so clients that read them, like gRPC, use the same proxy. It needs to be called before the clients are created.
+func Configure(httpProxy string, httpsProxy string, noProxy string, bypass ...string) error {
+	cfg := httpproxy.FromEnvironment()
+	if httpProxy != "" {
+		cfg.HTTPProxy = httpProxy
+	}
+	if httpsProxy != "" {
+		cfg.HTTPSProxy = httpsProxy
+	}
+	if noProxy != "" {
+		cfg.NoProxy = noProxy
+	}
+	for _, proxy := range []string{cfg.HTTPProxy, cfg.HTTPSProxy} {
+		if proxy == "" {
+			continue
+		}
+		if u, err := url.Parse(proxy); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy \"%s\", must be a URL like http://proxy:3128", proxy)
+		}
+	}
+	hosts := lo.FilterMap(strings.Split(cfg.NoProxy, ","), func(host string, _ int) (string, bool) {
+		return strings.TrimSpace(host), strings.TrimSpace(host) != ""
+	})
+	hosts = lo.Uniq(append(hosts, append(MetadataHosts, lo.Compact(bypass)...)...))
+	cfg.NoProxy = strings.Join(hosts, ",")
+	for key, value := range map[string]string{"HTTP_PROXY": cfg.HTTPProxy, "HTTPS_PROXY": cfg.HTTPSProxy, "NO_PROXY": cfg.NoProxy} {
+		if value == "" {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("unable to set %s: %w", key, err)
+		}
+	}
+	config = cfg
+	proxyFunc = cfg.ProxyFunc()
+	return nil
+}
+
+// Func returns the proxy of a request, nil if it is not proxied. It is used as the Proxy of an http.Transport.
+func Func(req *http.Request) (*url.URL, error) {
+	return proxyFunc(req.URL)
+}
+
+// Transport is a clone of the default transport that uses the configured proxy
+func Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = Func
+	return transport
+}
+
+// Enabled is true if an HTTP or HTTPS proxy is configured
+func Enabled() bool {
+	return config.HTTPProxy != "" || config.HTTPSProxy != ""
+}
+
+// String is a human readable description of the configured proxy, the credentials of the proxy URLs are redacted
+func String() string {
+	if !Enabled() {
+		return "no proxy"
+	}
+	return fmt.Sprintf("HTTP proxy %s, HTTPS proxy %s, bypassed for %s", redact(config.HTTPProxy), redact(config.HTTPSProxy), config.NoProxy)
+}
+
+// redact removes the password of a proxy URL
+func redact(proxy string) string {
+	if proxy == "" {
+		return "<none>"
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return proxy
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,114 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// clearProxyEnv unsets the proxy env vars for the test and restores the configured proxy after it
+func clearProxyEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy", "REQUEST_METHOD"} {
+		t.Setenv(key, "")
+	}
+	savedConfig, savedProxyFunc := config, proxyFunc
+	t.Cleanup(func() { config, proxyFunc = savedConfig, savedProxyFunc })
+}
+
+func TestConfigureNoProxy(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		env     string
+		noProxy string
+		bypass  []string
+		want    []string
+	}{
+		{
+			name: "metadata hosts are always bypassed",
+			want: MetadataHosts,
+		},
+		{
+			name: "env var hosts are kept and trimmed",
+			env:  " .internal , 10.0.0.0/8,,",
+			want: append([]string{".internal", "10.0.0.0/8"}, MetadataHosts...),
+		},
+		{
+			name:    "the flag replaces the env var",
+			env:     ".internal",
+			noProxy: ".example.com",
+			want:    append([]string{".example.com"}, MetadataHosts...),
+		},
+		{
+			name:   "bypass hosts are added once",
+			env:    "169.254.169.254",
+			bypass: []string{"imds.local", "", "imds.local"},
+			want:   append(append([]string{}, MetadataHosts...), "imds.local"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clearProxyEnv(t)
+			t.Setenv("NO_PROXY", tc.env)
+			if err := Configure("http://proxy:3128", "", tc.noProxy, tc.bypass...); err != nil {
+				t.Fatalf("Configure() error = %v", err)
+			}
+			if got := strings.Split(config.NoProxy, ","); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Configure() NoProxy = %v, want %v", got, tc.want)
+			}
+			if got := os.Getenv("NO_PROXY"); got != config.NoProxy {
+				t.Errorf("Configure() NO_PROXY env var = %q, want %q", got, config.NoProxy)
+			}
+		})
+	}
+}
+
+func TestConfigureFunc(t *testing.T) {
+	clearProxyEnv(t)
+	if err := Configure("http://proxy:3128", "", ".internal"); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	for _, tc := range []struct {
+		url  string
+		want string
+	}{
+		{url: "http://example.com/", want: "http://proxy:3128"},
+		{url: "http://169.254.169.254/latest/meta-data/", want: ""},
+		{url: "http://api.internal/", want: ""},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+		got, err := Func(req)
+		if err != nil {
+			t.Fatalf("Func(%s) error = %v", tc.url, err)
+		}
+		gotURL := ""
+		if got != nil {
+			gotURL = got.String()
+		}
+		if gotURL != tc.want {
+			t.Errorf("Func(%s) = %q, want %q", tc.url, gotURL, tc.want)
+		}
+	}
+}
+
+func TestConfigureInvalidProxy(t *testing.T) {
+	clearProxyEnv(t)
+	if err := Configure("proxy:3128", "", ""); err == nil {
+		t.Errorf("Configure() error = nil, want an error for a proxy without a scheme")
+	}
+}
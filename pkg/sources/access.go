@@ -0,0 +1,96 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/samber/lo"
+)
+
+// AccessDenial explains why the process can not read a path and how a non-root process is granted access
+type AccessDenial struct {
+	// Path is the path that denies access, which is a parent directory of the read path if the directory can not be searched
+	Path string
+	UID  int
+	// GID is the group of Path, which is allowed to read it if GroupReadable
+	GID           uint32
+	GroupReadable bool
+	// Member is set if the process already is root or in the group, so the read is denied by SELinux, AppArmor or an ACL
+	Member bool
+}
+
+// ExplainDenial returns why the path can not be read, nil if it can be read, does not exist, or its group is not known
+func ExplainDenial(path string) *AccessDenial {
+	path = filepath.Clean(path)
+	denied := path
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrPermission) {
+		// the deepest directory that can be stat'ed is the one that can not be searched
+		for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+			if _, err := os.Stat(dir); err == nil || dir == filepath.Dir(dir) {
+				denied = dir
+				break
+			}
+		}
+	} else if err != nil {
+		return nil
+	} else if file, err := os.Open(path); err == nil {
+		_ = file.Close()
+		return nil
+	}
+	info, err := os.Stat(denied)
+	if err != nil {
+		return nil
+	}
+	gid, groupReadable, ok := fileGroup(info)
+	if !ok {
+		return nil
+	}
+	groups, _ := os.Getgroups()
+	return &AccessDenial{
+		Path:          denied,
+		UID:           os.Geteuid(),
+		GID:           gid,
+		GroupReadable: groupReadable,
+		Member:        os.Geteuid() == 0 || uint32(os.Getegid()) == gid || lo.Contains(groups, int(gid)),
+	}
+}
+
+// Hint is how the process is granted access to the path
+func (d *AccessDenial) Hint() string {
+	switch {
+	case d.Member:
+		return fmt.Sprintf("reading %s is denied by SELinux, AppArmor or an ACL, read it through the helper socket", d.Path)
+	case d.GroupReadable:
+		return fmt.Sprintf("uid %d can not read %s, add its group %d to the supplementalGroups of the pod", d.UID, d.Path, d.GID)
+	default:
+		return fmt.Sprintf("%s is only readable by its owner, run as the owner or read it through the helper socket", d.Path)
+	}
+}
+
+// permissionError adds the hint of the access denial of the path to a permission error
+func permissionError(path string, err error) error {
+	if !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	if denial := ExplainDenial(path); denial != nil {
+		return fmt.Errorf("%w, %s", err, denial.Hint())
+	}
+	return err
+}
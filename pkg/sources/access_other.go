@@ -0,0 +1,26 @@
+//go:build !linux && !darwin
+
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"os"
+)
+
+// fileGroup is unavailable on this platform so no group is suggested for denied reads
+func fileGroup(_ os.FileInfo) (gid uint32, groupReadable bool, ok bool) {
+	return 0, false, false
+}
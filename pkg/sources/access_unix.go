@@ -0,0 +1,36 @@
+//go:build linux || darwin
+
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileGroup returns the group of the file and if the group is allowed to read it, ok is false if the group is not known
+func fileGroup(info os.FileInfo) (gid uint32, groupReadable bool, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false, false
+	}
+	// reading a directory also needs the search permission
+	mask := os.FileMode(0o040)
+	if info.IsDir() {
+		mask = 0o050
+	}
+	return stat.Gid, info.Mode().Perm()&mask == mask, true
+}
@@ -0,0 +1,181 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package asg is a latency timing source for EC2 Auto Scaling group activities and lifecycle hooks
+package asg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "EC2 Auto Scaling"
+	// LifecycleActionContinue and LifecycleActionAbandon are the results a launching lifecycle hook is completed with
+	LifecycleActionContinue = "CONTINUE"
+	LifecycleActionAbandon  = "ABANDON"
+	// maxActivityPages limits the scaling activities that are searched for the launch of the instance, most recent first
+	maxActivityPages = 5
+)
+
+// Source is the EC2 Auto Scaling API source
+type Source struct {
+	client     *autoscaling.Client
+	instanceID string
+	groupName  string
+}
+
+// New instantiates a new instance of the EC2 Auto Scaling source for an instance
+func New(client *autoscaling.Client, instanceID string) *Source {
+	return &Source{
+		client:     client,
+		instanceID: instanceID,
+	}
+}
+
+// ClearCache is a noop for the EC2 Auto Scaling Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return Name
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// FindLaunchActivity retrieves the launch activity of the instance once it finished, which is when all launching lifecycle hooks
+// were completed with CONTINUE (Successful) or one was completed with ABANDON or timed out (Cancelled or Failed)
+func (s *Source) FindLaunchActivity() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		ctx := context.Background()
+		groupName, err := s.getGroupName(ctx)
+		if err != nil {
+			return nil, err
+		}
+		paginator := autoscaling.NewDescribeScalingActivitiesPaginator(s.client, &autoscaling.DescribeScalingActivitiesInput{
+			AutoScalingGroupName: aws.String(groupName),
+		})
+		for page := 0; page < maxActivityPages && paginator.HasMorePages(); page++ {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, activity := range out.Activities {
+				if !strings.HasPrefix(aws.ToString(activity.Description), "Launching") || !strings.Contains(aws.ToString(activity.Description), s.instanceID) {
+					continue
+				}
+				// the activity is in progress until the lifecycle hooks are completed
+				if activity.EndTime == nil {
+					return nil, nil
+				}
+				activityBytes, err := json.Marshal(activity)
+				return []string{string(activityBytes)}, err
+			}
+		}
+		return nil, fmt.Errorf("no launch activity found for %s in %s", s.instanceID, groupName)
+	}
+}
+
+// CommentStatus is a helper func that returns a CommentFunc with the status of a scaling activity
+func CommentStatus() sources.CommentFunc {
+	return func(activityJSON string) string {
+		var activity types.Activity
+		if err := json.Unmarshal([]byte(activityJSON), &activity); err != nil {
+			return ""
+		}
+		return string(activity.StatusCode)
+	}
+}
+
+// CompleteLifecycleAction completes the launching lifecycle hook of the instance with the result, i.e. LifecycleActionContinue
+func (s *Source) CompleteLifecycleAction(ctx context.Context, hookName string, result string) error {
+	groupName, err := s.getGroupName(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.CompleteLifecycleAction(ctx, &autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  aws.String(groupName),
+		LifecycleHookName:     aws.String(hookName),
+		LifecycleActionResult: aws.String(result),
+		InstanceId:            aws.String(s.instanceID),
+	}); err != nil {
+		return fmt.Errorf("unable to complete lifecycle hook %s of %s: %w", hookName, s.instanceID, err)
+	}
+	return nil
+}
+
+// getGroupName retrieves the name of the Auto Scaling group of the instance from cached values or DescribeAutoScalingInstances
+func (s *Source) getGroupName(ctx context.Context) (string, error) {
+	if s.groupName != "" {
+		return s.groupName, nil
+	}
+	if s.instanceID == "" {
+		return "", fmt.Errorf("unable to get instance ID")
+	}
+	out, err := s.client.DescribeAutoScalingInstances(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []string{s.instanceID},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(out.AutoScalingInstances) != 1 {
+		return "", fmt.Errorf("%s is not in an Auto Scaling group", s.instanceID)
+	}
+	s.groupName = aws.ToString(out.AutoScalingInstances[0].AutoScalingGroupName)
+	return s.groupName, nil
+}
+
+// ParseTimeFor parses the end time of a scaling activity
+func (s *Source) ParseTimeFor(event []byte) (time.Time, error) {
+	var activity types.Activity
+	if err := json.Unmarshal(event, &activity); err == nil && activity.EndTime != nil {
+		return *activity.EndTime, nil
+	}
+	return time.Time{}, fmt.Errorf("unable to parse event")
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	activities, err := event.FindFn(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, activity := range activities {
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(activity)
+		}
+		eventTime, err := s.ParseTimeFor([]byte(activity))
+		results = append(results, sources.FindResult{
+			Line:      activity,
+			Timestamp: eventTime,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return results, nil
+}
@@ -18,6 +18,7 @@ package awsnode
 import (
 	"regexp"
 	"sort"
+	"time"
 
 	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
 )
@@ -38,10 +39,12 @@ type Source struct {
 func New(path string) *Source {
 	return &Source{
 		logReader: &sources.LogReader{
+			Name:            Name,
 			Path:            path,
 			Glob:            true,
 			TimestampRegex:  TimestampFormat,
 			TimestampLayout: TimestampLayout,
+			Sorted:          true,
 		},
 	}
 }
@@ -51,11 +54,36 @@ func (a Source) ClearCache() {
 	a.logReader.ClearCache()
 }
 
+// Checksum returns the sha256 checksum and byte range of the searched contents of the log file
+func (a Source) Checksum() (*sources.ScanChecksum, error) {
+	return a.logReader.Checksum()
+}
+
+// LastSearch returns the log file and the number of bytes searched by the last Find
+func (a Source) LastSearch() (string, int) {
+	return a.logReader.LastSearch()
+}
+
+// SetSearchWindow restricts the log search to lines between start and end
+func (a Source) SetSearchWindow(start time.Time, end time.Time) {
+	a.logReader.SetSearchWindow(start, end)
+}
+
 // String is a human readable string of the source, usually the log file path
 func (a Source) String() string {
 	return a.logReader.Path
 }
 
+// HostPaths is the log file the source reads
+func (a Source) HostPaths() []string {
+	return []string{a.logReader.Path}
+}
+
+// AccessPath is how the log file was last read, directly or through the helper socket
+func (a Source) AccessPath() string {
+	return a.logReader.AccessPath()
+}
+
 // Name is the log source name
 func (a Source) Name() string {
 	return Name
@@ -90,6 +118,7 @@ func (a Source) Find(event *sources.Event) ([]sources.FindResult, error) {
 			Timestamp: ts,
 			Err:       err,
 			Comment:   comment,
+			Truncated: a.logReader.Truncated(),
 		})
 	}
 	sort.Slice(results, func(i, j int) bool {
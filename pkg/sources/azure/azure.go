@@ -0,0 +1,190 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package azure is a latency timing source for the Azure Instance Metadata Service (IMDS) used by AKS nodes
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "Azure IMDS"
+	// DefaultEndpoint is the Azure Instance Metadata Service
+	DefaultEndpoint = "http://169.254.169.254"
+	// APIVersion is the IMDS api-version that is requested
+	APIVersion = "2021-02-01"
+)
+
+// Source is the Azure Instance Metadata Service (IMDS) http source
+type Source struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// InstanceMetadata is the subset of the Azure instance metadata that describes the node
+type InstanceMetadata struct {
+	VMID           string
+	SubscriptionID string
+	VMSize         string
+	Location       string
+	Zone           string
+	Image          string
+	PrivateIP      string
+}
+
+// New instantiates a new instance of the Azure IMDS source
+func New(endpoint string) *Source {
+	return &Source{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: proxy.Transport()},
+	}
+}
+
+// ClearCache is a noop for the Azure IMDS Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return Name
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// FindByPath is a helper func that returns a FindFunc to query IMDS for a path holding an RFC3339 timestamp, i.e. a VM tag, that can be used in an Event
+func (s Source) FindByPath(path string) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		result, err := s.GetMetadata(context.TODO(), path)
+		return []string{result}, err
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	timestamps, err := event.FindFn(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, tsStr := range timestamps {
+		ts, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(tsStr))
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(tsStr)
+		}
+		results = append(results, sources.FindResult{
+			Line:      tsStr,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return results, nil
+}
+
+// GetMetadata queries Azure IMDS for a path relative to /metadata/instance as text, i.e. "compute/vmId"
+func (s Source) GetMetadata(ctx context.Context, metadataPath string) (string, error) {
+	return s.get(ctx, fmt.Sprintf("%s?api-version=%s&format=text", path.Join("/metadata/instance", metadataPath), APIVersion))
+}
+
+// GetInstanceMetadata retrieves the instance metadata that describes the node
+func (s Source) GetInstanceMetadata(ctx context.Context) (*InstanceMetadata, error) {
+	instanceJSON, err := s.get(ctx, fmt.Sprintf("/metadata/instance?api-version=%s", APIVersion))
+	if err != nil {
+		return nil, err
+	}
+	var instance struct {
+		Compute struct {
+			VMID           string `json:"vmId"`
+			SubscriptionID string `json:"subscriptionId"`
+			VMSize         string `json:"vmSize"`
+			Location       string `json:"location"`
+			Zone           string `json:"zone"`
+			StorageProfile struct {
+				ImageReference struct {
+					ID        string `json:"id"`
+					Publisher string `json:"publisher"`
+					Offer     string `json:"offer"`
+					SKU       string `json:"sku"`
+					Version   string `json:"version"`
+				} `json:"imageReference"`
+			} `json:"storageProfile"`
+		} `json:"compute"`
+		Network struct {
+			Interface []struct {
+				IPv4 struct {
+					IPAddress []struct {
+						PrivateIPAddress string `json:"privateIpAddress"`
+					} `json:"ipAddress"`
+				} `json:"ipv4"`
+			} `json:"interface"`
+		} `json:"network"`
+	}
+	if err := json.Unmarshal([]byte(instanceJSON), &instance); err != nil {
+		return nil, fmt.Errorf("unable to parse Azure instance metadata: %w", err)
+	}
+	// AKS node images are shared gallery images referenced by id, marketplace images by publisher:offer:sku:version
+	imageRef := instance.Compute.StorageProfile.ImageReference
+	image := path.Base(imageRef.ID)
+	if imageRef.ID == "" {
+		image = strings.Join([]string{imageRef.Publisher, imageRef.Offer, imageRef.SKU, imageRef.Version}, ":")
+	}
+	md := &InstanceMetadata{
+		VMID:           instance.Compute.VMID,
+		SubscriptionID: instance.Compute.SubscriptionID,
+		VMSize:         instance.Compute.VMSize,
+		Location:       instance.Compute.Location,
+		Zone:           instance.Compute.Zone,
+		Image:          image,
+	}
+	if len(instance.Network.Interface) > 0 && len(instance.Network.Interface[0].IPv4.IPAddress) > 0 {
+		md.PrivateIP = instance.Network.Interface[0].IPv4.IPAddress[0].PrivateIPAddress
+	}
+	return md, nil
+}
+
+// get queries Azure IMDS for a path and query
+func (s Source) get(ctx context.Context, pathAndQuery string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint+pathAndQuery, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to create Azure IMDS request: %w", err)
+	}
+	req.Header.Set("Metadata", "true")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to query Azure IMDS %s: %w", pathAndQuery, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata for \"%s\" is not available from Azure IMDS: %s", pathAndQuery, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read Azure IMDS %s: %w", pathAndQuery, err)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,155 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Read cache defaults
+const (
+	DefaultReadCacheTTL      = time.Minute
+	DefaultReadCacheMaxBytes = 128 << 20
+)
+
+// DefaultMmapMinBytes is the file size at or above which LogReaders that do not specify MmapMinBytes memory map rotated uncompressed files
+// Memory mapping is disabled when it is 0
+var DefaultMmapMinBytes int64
+
+// DefaultReadCache is the process-wide read cache used by LogReaders that do not specify their own
+var DefaultReadCache = NewReadCache(DefaultReadCacheTTL, DefaultReadCacheMaxBytes)
+
+// FileStamp identifies a version of a file, cached contents are only reused while the file has the same stamp
+type FileStamp struct {
+	ID      uint64
+	Size    int64
+	ModTime time.Time
+}
+
+// Stamp returns the FileStamp of a file
+func Stamp(info os.FileInfo) FileStamp {
+	return FileStamp{ID: fileID(info), Size: info.Size(), ModTime: info.ModTime()}
+}
+
+// ReadCache is a file content cache keyed by resolved path that is shared across sources.
+// Entries expire after the TTL and the least recently used entries are evicted when the total size exceeds MaxBytes.
+// Each entry records the FileStamp it was read at, so readers can tell whether the file changed since.
+type ReadCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	maxBytes int
+	size     int
+	entries  map[string]*readCacheEntry
+}
+
+type readCacheEntry struct {
+	data       []byte
+	stamp      FileStamp
+	created    time.Time
+	lastAccess time.Time
+}
+
+// NewReadCache creates a new ReadCache, a ttl of 0 disables expiry and a maxBytes of 0 disables caching
+func NewReadCache(ttl time.Duration, maxBytes int) *ReadCache {
+	return &ReadCache{
+		ttl:      ttl,
+		maxBytes: maxBytes,
+		entries:  map[string]*readCacheEntry{},
+	}
+}
+
+// Configure updates the TTL and size limit of the cache and evicts any entries that no longer fit
+func (c *ReadCache) Configure(ttl time.Duration, maxBytes int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+	c.maxBytes = maxBytes
+	c.evict()
+}
+
+// Get returns the cached contents for path and the stamp of the file they were read at if present and not expired
+func (c *ReadCache) Get(path string) ([]byte, FileStamp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[path]
+	if !ok {
+		return nil, FileStamp{}, false
+	}
+	if c.ttl > 0 && time.Since(entry.created) > c.ttl {
+		c.remove(path)
+		return nil, FileStamp{}, false
+	}
+	entry.lastAccess = time.Now()
+	return entry.data, entry.stamp, true
+}
+
+// Put caches the contents for path read at the stamp of the file and returns false if they were not cached
+// because they are larger than the cache size limit
+func (c *ReadCache) Put(path string, data []byte, stamp FileStamp) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(path)
+	if len(data) > c.maxBytes {
+		return false
+	}
+	now := time.Now()
+	c.entries[path] = &readCacheEntry{data: data, stamp: stamp, created: now, lastAccess: now}
+	c.size += len(data)
+	c.evict()
+	return true
+}
+
+// Delete removes the cached contents for path
+func (c *ReadCache) Delete(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(path)
+}
+
+// Size returns the total bytes currently cached
+func (c *ReadCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// remove deletes an entry, the lock must be held
+func (c *ReadCache) remove(path string) {
+	if entry, ok := c.entries[path]; ok {
+		c.size -= len(entry.data)
+		delete(c.entries, path)
+	}
+}
+
+// evict removes expired entries and then the least recently used entries until the cache fits within maxBytes, the lock must be held
+func (c *ReadCache) evict() {
+	for path, entry := range c.entries {
+		if c.ttl > 0 && time.Since(entry.created) > c.ttl {
+			c.remove(path)
+		}
+	}
+	for c.size > c.maxBytes && len(c.entries) > 0 {
+		var lruPath string
+		var lru *readCacheEntry
+		for path, entry := range c.entries {
+			if lru == nil || entry.lastAccess.Before(lru.lastAccess) {
+				lruPath, lru = path, entry
+			}
+		}
+		c.remove(lruPath)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCache(t *testing.T) {
+	// op is a step applied to the cache, entries are backdated instead of sleeping so the LRU order and expiry are deterministic
+	type op struct {
+		put       string
+		get       string
+		backdate  string
+		by        time.Duration
+		configure bool
+		ttl       time.Duration
+		maxBytes  int
+	}
+	for _, tc := range []struct {
+		name      string
+		ttl       time.Duration
+		maxBytes  int
+		ops       []op
+		wantPaths []string
+		wantSize  int
+	}{
+		{
+			name:      "entries within the size limit are kept",
+			ttl:       time.Minute,
+			maxBytes:  10,
+			ops:       []op{{put: "a"}, {put: "bb"}, {put: "ccc"}},
+			wantPaths: []string{"a", "bb", "ccc"},
+			wantSize:  6,
+		},
+		{
+			name:      "entries larger than the limit are not cached",
+			ttl:       time.Minute,
+			maxBytes:  3,
+			ops:       []op{{put: "a"}, {put: "bbbb"}},
+			wantPaths: []string{"a"},
+			wantSize:  1,
+		},
+		{
+			name:      "least recently used entries are evicted",
+			ttl:       time.Minute,
+			maxBytes:  6,
+			ops:       []op{{put: "aa"}, {backdate: "aa", by: 2 * time.Second}, {put: "bb"}, {backdate: "bb", by: time.Second}, {put: "ccc"}},
+			wantPaths: []string{"bb", "ccc"},
+			wantSize:  5,
+		},
+		{
+			name:      "reads refresh the LRU order",
+			ttl:       time.Minute,
+			maxBytes:  6,
+			ops:       []op{{put: "aa"}, {backdate: "aa", by: 2 * time.Second}, {put: "bb"}, {backdate: "bb", by: time.Second}, {get: "aa"}, {put: "ccc"}},
+			wantPaths: []string{"aa", "ccc"},
+			wantSize:  5,
+		},
+		{
+			name:      "expired entries are not returned",
+			ttl:       time.Minute,
+			maxBytes:  10,
+			ops:       []op{{put: "aa"}, {put: "bb"}, {backdate: "aa", by: 2 * time.Minute}, {get: "aa"}},
+			wantPaths: []string{"bb"},
+			wantSize:  2,
+		},
+		{
+			name:      "a ttl of 0 disables expiry",
+			maxBytes:  10,
+			ops:       []op{{put: "aa"}, {backdate: "aa", by: 24 * time.Hour}, {get: "aa"}},
+			wantPaths: []string{"aa"},
+			wantSize:  2,
+		},
+		{
+			name:      "a max size of 0 disables caching",
+			ttl:       time.Minute,
+			ops:       []op{{put: "a"}},
+			wantPaths: []string{},
+		},
+		{
+			name:      "putting a path again replaces its entry",
+			ttl:       time.Minute,
+			maxBytes:  10,
+			ops:       []op{{put: "path"}, {put: "path"}},
+			wantPaths: []string{"path"},
+			wantSize:  4,
+		},
+		{
+			name:      "configure evicts entries that no longer fit",
+			ttl:       time.Minute,
+			maxBytes:  10,
+			ops:       []op{{put: "aa"}, {backdate: "aa", by: time.Second}, {put: "bbb"}, {configure: true, ttl: time.Minute, maxBytes: 4}},
+			wantPaths: []string{"bbb"},
+			wantSize:  3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cache := NewReadCache(tc.ttl, tc.maxBytes)
+			for _, o := range tc.ops {
+				switch {
+				case o.put != "":
+					// the contents are the path so the size of an entry is the length of its path
+					cache.Put(o.put, []byte(o.put), FileStamp{Size: int64(len(o.put))})
+				case o.get != "":
+					if data, stamp, ok := cache.Get(o.get); ok && (string(data) != o.get || stamp.Size != int64(len(o.get))) {
+						t.Fatalf("Get(%s) = %q, %v", o.get, data, stamp)
+					}
+				case o.backdate != "":
+					entry := cache.entries[o.backdate]
+					entry.created = entry.created.Add(-o.by)
+					entry.lastAccess = entry.lastAccess.Add(-o.by)
+				case o.configure:
+					cache.Configure(o.ttl, o.maxBytes)
+				}
+			}
+			var paths []string
+			for _, path := range tc.wantPaths {
+				if _, _, ok := cache.Get(path); !ok {
+					t.Errorf("Get(%s) is not cached", path)
+				}
+			}
+			for path := range cache.entries {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			if strings.Join(paths, ",") != strings.Join(tc.wantPaths, ",") {
+				t.Errorf("cached paths = %v, want %v", paths, tc.wantPaths)
+			}
+			if got := cache.Size(); got != tc.wantSize {
+				t.Errorf("Size() = %d, want %d", got, tc.wantSize)
+			}
+		})
+	}
+}
+
+func TestReadCacheDelete(t *testing.T) {
+	cache := NewReadCache(time.Minute, 10)
+	stamp := FileStamp{ID: 1, Size: 3, ModTime: time.Date(2022, time.November, 28, 0, 0, 0, 0, time.UTC)}
+	cache.Put("log", []byte("log"), stamp)
+	if _, got, ok := cache.Get("log"); !ok || !reflect.DeepEqual(got, stamp) {
+		t.Fatalf("Get() = %v, %v, want %v", got, ok, stamp)
+	}
+	cache.Delete("log")
+	if _, _, ok := cache.Get("log"); ok || cache.Size() != 0 {
+		t.Errorf("Get() after Delete() is cached, size %d", cache.Size())
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package chaos is a synthetic latency timing source that injects configured fake events, delays and failures,
+// so the emitters, budgets, aggregation and alerting can be tested end-to-end in CI without a real node bootstrap
+package chaos
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "Chaos"
+)
+
+// Config is a chaos scenario, a JSON file of the fake events to inject
+type Config struct {
+	// Seed makes the jitter and failures of a scenario reproducible, a random seed is used if it is 0
+	Seed   int64          `json:"seed,omitempty"`
+	Events []*EventConfig `json:"events"`
+}
+
+// EventConfig is a fake event of a chaos scenario
+type EventConfig struct {
+	Name     string `json:"name"`
+	Metric   string `json:"metric"`
+	Terminal bool   `json:"terminal,omitempty"`
+	Track    string `json:"track,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+	// OffsetSeconds is the timestamp of the event after the start of the scenario, plus a random jitter of up to JitterSeconds
+	OffsetSeconds float64 `json:"offsetSeconds"`
+	JitterSeconds float64 `json:"jitterSeconds,omitempty"`
+	// DelaySeconds is the wall time after the start of the scenario until the event is found, which exercises the retries and the timeout
+	DelaySeconds float64 `json:"delaySeconds,omitempty"`
+	// FailureRate is the probability that a Find of the event fails with a transient error
+	FailureRate float64 `json:"failureRate,omitempty"`
+	// Missing events are never found
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Source is the chaos source of a scenario
+type Source struct {
+	config *Config
+	// start is when the scenario started, the base of the event offsets and delays
+	start time.Time
+	// jitters are drawn once per event so the timestamp of an event is stable across measurement passes
+	jitters map[string]time.Duration
+	mu      sync.Mutex
+	random  *rand.Rand
+}
+
+// LoadConfig loads and validates a chaos scenario
+func LoadConfig(path string) (*Config, error) {
+	configBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read chaos scenario %s: %w", path, err)
+	}
+	var config Config
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse chaos scenario %s: %w", path, err)
+	}
+	names := map[string]bool{}
+	for _, event := range config.Events {
+		if event.Name == "" || event.Metric == "" {
+			return nil, fmt.Errorf("every event of chaos scenario %s needs a name and metric", path)
+		}
+		if names[event.Name] {
+			return nil, fmt.Errorf("event \"%s\" of chaos scenario %s is not unique", event.Name, path)
+		}
+		names[event.Name] = true
+		if event.OffsetSeconds < 0 || event.JitterSeconds < 0 || event.DelaySeconds < 0 || event.FailureRate < 0 || event.FailureRate > 1 {
+			return nil, fmt.Errorf("event \"%s\" of chaos scenario %s has a negative duration or a failure rate outside of [0, 1]", event.Name, path)
+		}
+	}
+	return &config, nil
+}
+
+// New instantiates a new instance of the chaos source, the scenario starts now
+func New(config *Config) *Source {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	//nolint:gosec
+	random := rand.New(rand.NewSource(seed))
+	jitters := map[string]time.Duration{}
+	for _, event := range config.Events {
+		jitters[event.Name] = time.Duration(random.Float64() * event.JitterSeconds * float64(time.Second))
+	}
+	return &Source{config: config, start: time.Now(), jitters: jitters, random: random}
+}
+
+// ClearCache is a noop for the chaos source since its events are generated
+func (s *Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s *Source) String() string {
+	return fmt.Sprintf("%s (%d events)", Name, len(s.config.Events))
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// Events are the fake events of the scenario
+func (s *Source) Events() []*sources.Event {
+	var events []*sources.Event
+	for _, config := range s.config.Events {
+		config := config
+		events = append(events, &sources.Event{
+			Name:          config.Name,
+			Metric:        config.Metric,
+			MatchSelector: sources.EventMatchSelectorFirst,
+			Terminal:      config.Terminal,
+			Track:         config.Track,
+			SrcName:       Name,
+			FindFn:        s.findEvent(config),
+			CommentFn:     func(string) string { return config.Comment },
+		})
+	}
+	return events
+}
+
+// findEvent returns a FindFunc of the fake event, which fails with the configured failure rate and is not found before its delay
+func (s *Source) findEvent(config *EventConfig) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		s.mu.Lock()
+		failed := s.random.Float64() < config.FailureRate
+		s.mu.Unlock()
+		if failed {
+			return nil, fmt.Errorf("injected failure of event \"%s\"", config.Name)
+		}
+		if config.Missing || time.Since(s.start) < time.Duration(config.DelaySeconds*float64(time.Second)) {
+			return nil, nil
+		}
+		timestamp := s.start.Add(time.Duration(config.OffsetSeconds*float64(time.Second)) + s.jitters[config.Name])
+		return []string{fmt.Sprintf("%s chaos: injected %s", timestamp.UTC().Format(time.RFC3339Nano), config.Name)}, nil
+	}
+}
+
+// ParseTimeFor parses the RFC3339 timestamp that prefixes a fake event line
+func (s *Source) ParseTimeFor(line []byte) (time.Time, error) {
+	timestamp, _, _ := strings.Cut(string(line), " ")
+	return time.Parse(time.RFC3339Nano, timestamp)
+}
+
+// Find will use the Event's FindFunc and CommentFunc to generate the fake event and return the result
+func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	lines, err := event.FindFn(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("unable to find event \"%s\" in the chaos scenario", event.Name)
+	}
+	var results []sources.FindResult
+	for _, line := range lines {
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		eventTime, err := s.ParseTimeFor([]byte(line))
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: eventTime,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
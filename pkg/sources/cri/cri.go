@@ -0,0 +1,396 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cri is a latency timing source for the container runtime's CRI socket which times pod sandboxes, containers and
+// image pulls per pod without depending on the runtime's log format
+package cri
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+	k8ssrc "github.com/awslabs/node-latency-for-k8s/pkg/sources/k8s"
+)
+
+var (
+	Name = "CRI"
+	// DefaultEndpoint is the containerd CRI socket
+	DefaultEndpoint = "unix:///run/containerd/containerd.sock"
+	// podNamespaceLabel is the label the kubelet sets on pod sandboxes with the pod namespace
+	podNamespaceLabel = "io.kubernetes.pod.namespace"
+	// requestTimeout limits each search of the CRI socket
+	requestTimeout = 10 * time.Second
+)
+
+// Source is the CRI socket source
+type Source struct {
+	endpoint     string
+	contentDir   string
+	podNamespace string
+	namespaces   k8ssrc.NamespaceFilter
+	mu           sync.Mutex
+	conn         *grpc.ClientConn
+}
+
+// New instantiates a new instance of the CRI source. The image pull timings are read from the blobs of the images in the
+// containerd content store (contentDir) since the CRI does not record when images were pulled.
+func New(endpoint string, contentDir string, podNamespace string) *Source {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = fmt.Sprintf("unix://%s", endpoint)
+	}
+	return &Source{
+		endpoint:     endpoint,
+		contentDir:   contentDir,
+		podNamespace: podNamespace,
+	}
+}
+
+// SetNamespaceFilter restricts the namespaces of the pod sandboxes that are measured across namespaces
+func (s *Source) SetNamespaceFilter(filter k8ssrc.NamespaceFilter) {
+	s.namespaces = filter
+}
+
+// ClearCache is a noop for the CRI Source since it is a socket source, not a log file
+func (s *Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s *Source) String() string {
+	return fmt.Sprintf("%s (%s)", Name, s.endpoint)
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// Available returns an error if the CRI socket does not exist, i.e. it is not mounted or the node runs another runtime
+func (s *Source) Available() error {
+	if !strings.HasPrefix(s.endpoint, "unix://") {
+		return nil
+	}
+	if _, err := os.Stat(strings.TrimPrefix(s.endpoint, "unix://")); err != nil {
+		return fmt.Errorf("the CRI socket is not available: %w", err)
+	}
+	return nil
+}
+
+// HostPaths are the CRI socket and the containerd content store, which need to be mounted
+func (s *Source) HostPaths() []string {
+	return []string{strings.TrimPrefix(s.endpoint, "unix://"), s.contentDir}
+}
+
+// FindSandboxCreated retrieves the creation time of the pod sandboxes in the pod namespace, one per pod
+func (s *Source) FindSandboxCreated() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+		sandboxes, err := s.sandboxes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		for _, sandbox := range sandboxes {
+			lines = append(lines, line(time.Unix(0, sandbox.CreatedAt), "sandbox of pod %s created", podName(sandbox)))
+		}
+		return lines, nil
+	}
+}
+
+// FindFirstContainerStarted retrieves the time the first container of each pod in the pod namespace started
+func (s *Source) FindFirstContainerStarted() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+		sandboxes, err := s.sandboxes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := s.connection()
+		if err != nil {
+			return nil, err
+		}
+		client := runtimeapi.NewRuntimeServiceClient(conn)
+		var lines []string
+		for _, sandbox := range sandboxes {
+			containers, err := client.ListContainers(ctx, &runtimeapi.ListContainersRequest{Filter: &runtimeapi.ContainerFilter{PodSandboxId: sandbox.Id}})
+			if err != nil {
+				return nil, fmt.Errorf("unable to list the containers of pod %s: %w", podName(sandbox), err)
+			}
+			var first *runtimeapi.ContainerStatus
+			for _, container := range containers.Containers {
+				status, err := client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: container.Id})
+				if err != nil || status.Status == nil || status.Status.StartedAt == 0 {
+					continue
+				}
+				if first == nil || status.Status.StartedAt < first.StartedAt {
+					first = status.Status
+				}
+			}
+			if first != nil {
+				lines = append(lines, line(time.Unix(0, first.StartedAt), "container %s of pod %s started", first.Metadata.GetName(), podName(sandbox)))
+			}
+		}
+		return lines, nil
+	}
+}
+
+// FindImagePullStarted retrieves the estimated time the pulls of the images of the pods in the pod namespace started, one per image
+func (s *Source) FindImagePullStarted() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		pulls, err := s.imagePulls()
+		if err != nil {
+			return nil, err
+		}
+		return lo.Map(pulls, func(pull imagePull, _ int) string {
+			return line(pull.start, "pull of image %s for pod %s started (estimated from the content store)", pull.image, pull.pod)
+		}), nil
+	}
+}
+
+// FindImagePulled retrieves the estimated time the pulls of the images of the pods in the pod namespace finished, one per image
+func (s *Source) FindImagePulled() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		pulls, err := s.imagePulls()
+		if err != nil {
+			return nil, err
+		}
+		return lo.Map(pulls, func(pull imagePull, _ int) string {
+			return line(pull.finish, "image %s pulled for pod %s in ~%s (estimated from the content store)", pull.image, pull.pod, pull.finish.Sub(pull.start))
+		}), nil
+	}
+}
+
+// imagePull is the pull of an image by the first pod that uses it
+type imagePull struct {
+	image  string
+	pod    string
+	start  time.Time
+	finish time.Time
+}
+
+// imagePulls estimates the pulls of the images of the pods in the pod namespace from the modification times of their blobs in the
+// content store, since neither the CRI nor the content store record the pull. The index or manifest is written first and the last
+// written layer finishes the pull. Only the blobs written after the sandbox of the first pod using the image was created count, so
+// layers shared with other images or cached in the AMI do not stretch the pull, and images without any such blob were not pulled.
+func (s *Source) imagePulls() ([]imagePull, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	sandboxes, err := s.sandboxes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(sandboxes, func(i, j int) bool { return sandboxes[i].CreatedAt < sandboxes[j].CreatedAt })
+	conn, err := s.connection()
+	if err != nil {
+		return nil, err
+	}
+	runtimeClient := runtimeapi.NewRuntimeServiceClient(conn)
+	imageClient := runtimeapi.NewImageServiceClient(conn)
+	seen := map[string]bool{}
+	var pulls []imagePull
+	for _, sandbox := range sandboxes {
+		containers, err := runtimeClient.ListContainers(ctx, &runtimeapi.ListContainersRequest{Filter: &runtimeapi.ContainerFilter{PodSandboxId: sandbox.Id}})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the containers of pod %s: %w", podName(sandbox), err)
+		}
+		for _, container := range containers.Containers {
+			if seen[container.ImageRef] {
+				continue
+			}
+			seen[container.ImageRef] = true
+			status, err := imageClient.ImageStatus(ctx, &runtimeapi.ImageStatusRequest{Image: &runtimeapi.ImageSpec{Image: container.ImageRef}})
+			if err != nil || status.Image == nil {
+				continue
+			}
+			start, finish, ok := s.blobTimes(status.Image.RepoDigests, time.Unix(0, sandbox.CreatedAt))
+			if !ok {
+				continue
+			}
+			image := container.Image.GetImage()
+			if len(status.Image.RepoTags) > 0 {
+				image = status.Image.RepoTags[0]
+			}
+			pulls = append(pulls, imagePull{image: image, pod: podName(sandbox), start: start, finish: finish})
+		}
+	}
+	return pulls, nil
+}
+
+// blobTimes returns the earliest and latest modification time of the blobs of the repo digests of an image, which are an
+// index or a manifest, that were written after since. Layers that containerd discarded after unpacking them
+// (discard_unpacked_layers) are not included.
+func (s *Source) blobTimes(repoDigests []string, since time.Time) (time.Time, time.Time, bool) {
+	var start, finish time.Time
+	visit := func(digest string) []string {
+		algorithm, hex, ok := strings.Cut(digest, ":")
+		if !ok {
+			return nil
+		}
+		path := filepath.Join(s.contentDir, "blobs", algorithm, hex)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().Before(since) {
+			return children(path)
+		}
+		if start.IsZero() || info.ModTime().Before(start) {
+			start = info.ModTime()
+		}
+		if info.ModTime().After(finish) {
+			finish = info.ModTime()
+		}
+		return children(path)
+	}
+	queue := lo.FilterMap(repoDigests, func(repoDigest string, _ int) (string, bool) {
+		_, digest, ok := strings.Cut(repoDigest, "@")
+		return digest, ok
+	})
+	for len(queue) > 0 {
+		digest := queue[0]
+		queue = append(queue[1:], visit(digest)...)
+	}
+	return start, finish, !start.IsZero()
+}
+
+// maxManifestSize limits the blobs that are read as an index or manifest, they are a few KiB
+const maxManifestSize = 64 * 1024
+
+// children returns the digests an index or manifest blob refers to, the platform manifests of an index or the config and
+// layers of a manifest
+func children(path string) []string {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > maxManifestSize {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	type descriptor struct {
+		Digest string `json:"digest"`
+	}
+	var manifest struct {
+		Manifests []descriptor `json:"manifests"`
+		Config    *descriptor  `json:"config"`
+		Layers    []descriptor `json:"layers"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+	descriptors := append(manifest.Manifests, manifest.Layers...)
+	if manifest.Config != nil {
+		descriptors = append(descriptors, *manifest.Config)
+	}
+	return lo.Map(descriptors, func(d descriptor, _ int) string { return d.Digest })
+}
+
+// sandboxes lists the ready pod sandboxes in the pod namespace, or in all pod namespaces measured by the namespace filter if it is not set
+func (s *Source) sandboxes(ctx context.Context) ([]*runtimeapi.PodSandbox, error) {
+	filter := &runtimeapi.PodSandboxFilter{State: &runtimeapi.PodSandboxStateValue{State: runtimeapi.PodSandboxState_SANDBOX_READY}}
+	if s.podNamespace != "" {
+		filter.LabelSelector = map[string]string{podNamespaceLabel: s.podNamespace}
+	}
+	conn, err := s.connection()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := runtimeapi.NewRuntimeServiceClient(conn).ListPodSandbox(ctx, &runtimeapi.ListPodSandboxRequest{Filter: filter})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list pod sandboxes of %s: %w", s.String(), err)
+	}
+	sandboxes := lo.Filter(resp.Items, func(sandbox *runtimeapi.PodSandbox, _ int) bool {
+		return sandbox.Metadata != nil && s.namespaces.Measured(sandbox.Metadata.Namespace)
+	})
+	if len(sandboxes) == 0 {
+		return nil, fmt.Errorf("no measured pod sandboxes in namespace \"%s\" on %s", s.podNamespace, s.String())
+	}
+	return sandboxes, nil
+}
+
+// connection returns the connection to the CRI socket, which is created on first use since the runtime may not be started yet.
+// The client connects lazily, so an unreachable socket is returned as the error of the requests.
+func (s *Source) connection() (*grpc.ClientConn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := grpc.NewClient(s.endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CRI endpoint %s: %w", s.endpoint, err)
+		}
+		s.conn = conn
+	}
+	return s.conn, nil
+}
+
+// podName is the namespaced name of the pod of a sandbox
+func podName(sandbox *runtimeapi.PodSandbox) string {
+	return fmt.Sprintf("%s/%s", sandbox.Metadata.GetNamespace(), sandbox.Metadata.GetName())
+}
+
+// line formats a CRI source result prefixed with the RFC3339 timestamp
+func line(ts time.Time, format string, args ...interface{}) string {
+	return fmt.Sprintf("%s %s", ts.UTC().Format(time.RFC3339Nano), fmt.Sprintf(format, args...))
+}
+
+// ParseTimestamp parses the timestamp prefix of a CRI source result
+func ParseTimestamp(line string) (time.Time, error) {
+	rawTS, _, _ := strings.Cut(line, " ")
+	return time.Parse(time.RFC3339Nano, rawTS)
+}
+
+// Comment is a CommentFunc that comments the result without its timestamp, i.e. the pod and image
+func Comment(line string) string {
+	_, comment, _ := strings.Cut(line, " ")
+	return comment
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the results based on the Event's matcher
+func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	lines, err := event.FindFn(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, l := range lines {
+		ts, err := ParseTimestamp(l)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(l)
+		}
+		results = append(results, sources.FindResult{
+			Line:      l,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -0,0 +1,132 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ec2launch is a latency timing source for the EC2Launch v2 agent log of Windows instances
+package ec2launch
+
+import (
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name            = "EC2Launch"
+	DefaultPath     = `C:\ProgramData\Amazon\EC2Launch\log\agent.log`
+	TimestampFormat = regexp.MustCompile(`[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}:[0-9]{2}`)
+	TimestampLayout = "2006-01-02 15:04:05"
+)
+
+// Source is the EC2Launch v2 agent log source
+type Source struct {
+	logReader *sources.LogReader
+}
+
+// New instantiates a new instance of the EC2Launch source
+func New(path string) *Source {
+	return &Source{
+		logReader: &sources.LogReader{
+			Name:            Name,
+			Path:            path,
+			TimestampRegex:  TimestampFormat,
+			TimestampLayout: TimestampLayout,
+			Sorted:          true,
+		},
+	}
+}
+
+// ClearCache will clear the log reader cache
+func (s Source) ClearCache() {
+	s.logReader.ClearCache()
+}
+
+// Checksum returns the sha256 checksum and byte range of the searched contents of the log file
+func (s Source) Checksum() (*sources.ScanChecksum, error) {
+	return s.logReader.Checksum()
+}
+
+// LastSearch returns the log file and the number of bytes searched by the last Find
+func (s Source) LastSearch() (string, int) {
+	return s.logReader.LastSearch()
+}
+
+// SetSearchWindow restricts the log search to lines between start and end
+func (s Source) SetSearchWindow(start time.Time, end time.Time) {
+	s.logReader.SetSearchWindow(start, end)
+}
+
+// SetLocation sets the location of the agent log timestamps which are in the local time of the instance
+func (s Source) SetLocation(loc *time.Location) {
+	s.logReader.SetLocation(loc)
+}
+
+// String is a human readable string of the source, usually the log file path
+func (s Source) String() string {
+	return s.logReader.Path
+}
+
+// HostPaths is the log file the source reads
+func (s Source) HostPaths() []string {
+	return []string{s.logReader.Path}
+}
+
+// AccessPath is how the log file was last read, directly or through the helper socket
+func (s Source) AccessPath() string {
+	return s.logReader.AccessPath()
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in a log source that can be used in an Event
+func (s Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(_ sources.Source, log []byte) ([]string, error) {
+		return s.logReader.Find(re)
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the log source and return the results based on the Event's matcher
+func (s Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	logBytes, err := s.logReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	matchedLines, err := event.FindFn(s, logBytes)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := s.logReader.ParseTimestamp(line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+			Truncated: s.logReader.Truncated(),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -0,0 +1,132 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package egress is a latency timing source that actively probes an external HTTPS endpoint until the first connection
+// succeeds, which shows NAT gateway, proxy and firewall delays of the node's egress
+package egress
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "Egress"
+	// ExampleURL is an external HTTPS endpoint that every EC2 node with egress is able to reach
+	ExampleURL = "https://sts.amazonaws.com"
+)
+
+// Source is the egress probe source
+type Source struct {
+	url        string
+	httpClient *http.Client
+	mu         sync.Mutex
+	attempts   int
+	first      string
+}
+
+// New instantiates a new instance of the egress source, the probe goes through the configured proxy
+func New(url string) *Source {
+	return &Source{
+		url: url,
+		httpClient: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: proxy.Transport(),
+			// the first response proves egress, redirects are not followed
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// ClearCache is a noop for the egress Source since the first connection does not change
+func (s *Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s *Source) String() string {
+	return fmt.Sprintf("%s (%s)", Name, s.url)
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return Name
+}
+
+// FindFirstConnection probes the URL until a request succeeds, any HTTP response counts since it can only be received once the TLS
+// handshake completed. Each search is one attempt, so the time of the first connection is as precise as the event retry delay.
+func (s *Source) FindFirstConnection() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.first != "" {
+			return []string{s.first}, nil
+		}
+		s.attempts++
+		req, err := http.NewRequest(http.MethodHead, s.url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create egress probe request: %w", err)
+		}
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to %s after %d attempts: %w", s.url, s.attempts, err)
+		}
+		resp.Body.Close()
+		s.first = fmt.Sprintf("%s connected to %s (%s) after %d attempts", time.Now().UTC().Format(time.RFC3339Nano), s.url, resp.Status, s.attempts)
+		return []string{s.first}, nil
+	}
+}
+
+// ParseTimestamp parses the timestamp prefix of an egress source result
+func ParseTimestamp(line string) (time.Time, error) {
+	rawTS, _, _ := strings.Cut(line, " ")
+	return time.Parse(time.RFC3339Nano, rawTS)
+}
+
+// Comment is a CommentFunc that comments the result without its timestamp
+func Comment(line string) string {
+	_, comment, _ := strings.Cut(line, " ")
+	return comment
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the results based on the Event's matcher
+func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	lines, err := event.FindFn(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, l := range lines {
+		ts, err := ParseTimestamp(l)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(l)
+		}
+		results = append(results, sources.FindResult{
+			Line:      l,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -0,0 +1,186 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gce is a latency timing source for the GCE metadata server used by GKE nodes
+package gce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "GCE Metadata"
+	// DefaultEndpoint is the GCE metadata server, also reachable at http://169.254.169.254
+	DefaultEndpoint = "http://metadata.google.internal"
+	metadataPrefix  = "/computeMetadata/v1"
+)
+
+// Source is the GCE metadata server http source
+type Source struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// InstanceMetadata is the subset of the GCE instance metadata that describes the node
+type InstanceMetadata struct {
+	ID          string
+	ProjectID   string
+	MachineType string
+	Zone        string
+	Region      string
+	Image       string
+	PrivateIP   string
+}
+
+// New instantiates a new instance of the GCE metadata source
+func New(endpoint string) *Source {
+	return &Source{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: proxy.Transport()},
+	}
+}
+
+// ClearCache is a noop for the GCE Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return Name
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// FindByPath is a helper func that returns a FindFunc to query the metadata server for a path holding an RFC3339 timestamp, i.e. an instance attribute, that can be used in an Event
+func (s Source) FindByPath(path string) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		result, err := s.GetMetadata(context.TODO(), path)
+		return []string{result}, err
+	}
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the result
+func (s Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	timestamps, err := event.FindFn(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, tsStr := range timestamps {
+		ts, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(tsStr))
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(tsStr)
+		}
+		results = append(results, sources.FindResult{
+			Line:      tsStr,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	return results, nil
+}
+
+// GetMetadata queries the GCE metadata server for a path relative to /computeMetadata/v1, i.e. "instance/id"
+func (s Source) GetMetadata(ctx context.Context, metadataPath string) (string, error) {
+	url := fmt.Sprintf("%s%s/%s", s.endpoint, metadataPrefix, strings.TrimPrefix(metadataPath, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to create GCE metadata request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to query GCE metadata %s: %w", metadataPath, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata for path \"%s\" is not available from the GCE metadata server: %s", metadataPath, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read GCE metadata %s: %w", metadataPath, err)
+	}
+	return string(body), nil
+}
+
+// GetInstanceMetadata retrieves the instance metadata that describes the node
+func (s Source) GetInstanceMetadata(ctx context.Context) (*InstanceMetadata, error) {
+	instanceJSON, err := s.GetMetadata(ctx, "instance/?recursive=true")
+	if err != nil {
+		return nil, err
+	}
+	var instance struct {
+		ID                json.Number `json:"id"`
+		MachineType       string      `json:"machineType"`
+		Zone              string      `json:"zone"`
+		Image             string      `json:"image"`
+		NetworkInterfaces []struct {
+			IP string `json:"ip"`
+		} `json:"networkInterfaces"`
+	}
+	if err := json.Unmarshal([]byte(instanceJSON), &instance); err != nil {
+		return nil, fmt.Errorf("unable to parse GCE instance metadata: %w", err)
+	}
+	projectID, err := s.GetMetadata(ctx, "project/numeric-project-id")
+	if err != nil {
+		return nil, err
+	}
+	// zone and machineType are resource names, i.e. projects/123/zones/us-central1-a
+	zone := path.Base(instance.Zone)
+	region := zone
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+	md := &InstanceMetadata{
+		ID:          instance.ID.String(),
+		ProjectID:   projectID,
+		MachineType: path.Base(instance.MachineType),
+		Zone:        zone,
+		Region:      region,
+		Image:       path.Base(instance.Image),
+	}
+	if len(instance.NetworkInterfaces) > 0 {
+		md.PrivateIP = instance.NetworkInterfaces[0].IP
+	}
+	return md, nil
+}
+
+// AccessToken retrieves an OAuth2 access token of the instance's default service account, or of the Kubernetes service account with GKE workload identity
+func (s Source) AccessToken(ctx context.Context) (string, error) {
+	tokenJSON, err := s.GetMetadata(ctx, "instance/service-accounts/default/token")
+	if err != nil {
+		return "", err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal([]byte(tokenJSON), &token); err != nil {
+		return "", fmt.Errorf("unable to parse GCE access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,219 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/logging"
+)
+
+const (
+	// AccessPathDirect is the access path of logs opened directly from the host path
+	AccessPathDirect = "direct"
+	// AccessPathHelper is the access path of logs read through the privileged helper socket
+	AccessPathHelper = "helper"
+	// HelperReadPath is the HTTP path of the helper read endpoint, the file is passed in the path query parameter
+	HelperReadPath = "/read"
+)
+
+// DefaultHelperMaxBytes is the largest file the helper serves when HelperHandler.MaxBytes is 0
+var DefaultHelperMaxBytes int64 = 1 << 30
+
+// DefaultHelperSocket is the unix socket of a privileged helper that LogReaders fall back to when opening a log
+// is denied, i.e. by SELinux or AppArmor policy. The fallback is disabled when it is empty.
+var DefaultHelperSocket string
+
+// AccessPathSource is a Source that reports how it reached its logs
+type AccessPathSource interface {
+	Source
+	// AccessPath is how the source read its logs, empty if it has not read anything yet
+	AccessPath() string
+}
+
+// AccessPath is how the log was last read, AccessPathDirect or AccessPathHelper, empty if it has not been read
+func (l *LogReader) AccessPath() string {
+	return l.accessPath
+}
+
+// open opens the log file, returning a nil file and no error if access was denied and the helper socket is configured
+func (l *LogReader) open(resolvedPath string) (*os.File, error) {
+	file, err := os.Open(resolvedPath)
+	if err == nil {
+		l.accessPath = AccessPathDirect
+		return file, nil
+	}
+	if errors.Is(err, fs.ErrPermission) && DefaultHelperSocket != "" {
+		if l.accessPath != AccessPathHelper {
+			logging.ForSource(l.Name).Infof("access to log file %s was denied, reading it through the helper at %s", resolvedPath, DefaultHelperSocket)
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unable to open log file %s: %w", resolvedPath, permissionError(resolvedPath, err))
+}
+
+// readFromHelper reads the whole log file through the helper socket and keeps it until ClearCache() is called
+func (l *LogReader) readFromHelper(resolvedPath string) ([]byte, error) {
+	fileBytes, err := ReadFromHelper(DefaultHelperSocket, resolvedPath)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(resolvedPath, ".gz") {
+		gzReader, err := gzip.NewReader(bytes.NewReader(fileBytes))
+		if err != nil {
+			return nil, fmt.Errorf("unable to create gzip reader for file %s: %w", resolvedPath, err)
+		}
+		defer gzReader.Close()
+		if fileBytes, err = io.ReadAll(gzReader); err != nil {
+			return nil, fmt.Errorf("unable to read file %s: %w", resolvedPath, err)
+		}
+	}
+	l.accessPath = AccessPathHelper
+	l.file = fileBytes
+	return fileBytes, nil
+}
+
+// ReadFromHelper reads a file through the helper listening on the unix socket
+func ReadFromHelper(socket string, path string) ([]byte, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	resp, err := client.Get("http://helper" + HelperReadPath + "?" + url.Values{"path": []string{path}}.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s through the helper at %s: %w", path, socket, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s through the helper at %s: %w", path, socket, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to read %s through the helper at %s: %s: %s", path, socket, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// peerUIDKey is the request context key of the UID of the process connected to the helper
+type peerUIDKey struct{}
+
+// HelperConnContext records the UID of the process connecting to the helper socket in the request context, so HelperHandler is able to check it.
+// It is meant to be the ConnContext of the helper's http.Server.
+func HelperConnContext(ctx context.Context, conn net.Conn) context.Context {
+	uid, err := peerUID(conn)
+	if err != nil {
+		logging.ForSource("helper").Warnf("unable to get the peer credentials of a connection: %v", err)
+		return ctx
+	}
+	return context.WithValue(ctx, peerUIDKey{}, uid)
+}
+
+// HelperHandler serves the allowed log files to unprivileged node-latency-for-k8s processes over a unix socket.
+// It is meant to run with a security context that is permitted to read host logs, i.e. privileged or unconfined.
+type HelperHandler struct {
+	// Allowed are the globs of the files that may be read, the directory of each glob is resolved before matching the resolved file
+	Allowed []string
+	// AllowedUIDs are the UIDs of the processes that may read through the helper, connections are only accepted through HelperConnContext
+	AllowedUIDs []uint32
+	// MaxBytes is the largest file that is served, DefaultHelperMaxBytes is used if 0
+	MaxBytes int64
+}
+
+func (h HelperHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != HelperReadPath || r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+	uid, ok := r.Context().Value(peerUIDKey{}).(uint32)
+	if !ok {
+		http.Error(w, "unable to identify the peer", http.StatusForbidden)
+		return
+	}
+	if !lo.Contains(h.AllowedUIDs, uid) {
+		http.Error(w, fmt.Sprintf("uid %d is not allowed", uid), http.StatusForbidden)
+		return
+	}
+	path := r.URL.Query().Get("path")
+	if !filepath.IsAbs(path) {
+		http.Error(w, "path must be absolute", http.StatusBadRequest)
+		return
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !h.allowed(resolved) {
+		http.Error(w, fmt.Sprintf("%s is not an allowed log file", resolved), http.StatusForbidden)
+		return
+	}
+	file, err := os.Open(resolved)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		http.Error(w, fmt.Sprintf("%s is not a regular file", resolved), http.StatusBadRequest)
+		return
+	}
+	maxBytes := h.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultHelperMaxBytes
+	}
+	if info.Size() > maxBytes {
+		http.Error(w, fmt.Sprintf("%s is larger than %d bytes", resolved, maxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	// the file may grow while it is sent, i.e. an active log
+	if _, err := io.Copy(w, io.LimitReader(file, maxBytes)); err != nil {
+		logging.ForSource("helper").Warnf("unable to send %s: %v", resolved, err)
+	}
+}
+
+// allowed returns true if the path matches one of the allowed globs
+func (h HelperHandler) allowed(path string) bool {
+	for _, glob := range h.Allowed {
+		dir, pattern := filepath.Split(filepath.Clean(glob))
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		if matched, err := filepath.Match(filepath.Join(dir, pattern), path); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHelperHandlerAllowed(t *testing.T) {
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "log")
+	if err := os.MkdirAll(filepath.Join(logDir, "pods", "kube-system_aws-node-abc", "aws-node"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// link is a symlink to the log directory, globs under it are resolved before matching
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(logDir, link); err != nil {
+		t.Fatal(err)
+	}
+	handler := HelperHandler{Allowed: []string{
+		filepath.Join(logDir, "messages*"),
+		filepath.Join(logDir, "pods", "kube-system_aws-node-*", "aws-node", "*.log"),
+		filepath.Join(link, "cloud-init.log"),
+	}}
+	for _, tc := range []struct {
+		name string
+		path string
+		want bool
+	}{
+		{name: "exact glob", path: filepath.Join(logDir, "messages"), want: true},
+		{name: "rotated file", path: filepath.Join(logDir, "messages-20240101.gz"), want: true},
+		{name: "glob in a directory", path: filepath.Join(logDir, "pods", "kube-system_aws-node-abc", "aws-node", "0.log"), want: true},
+		{name: "glob under a symlinked directory", path: filepath.Join(logDir, "cloud-init.log"), want: true},
+		{name: "other file in an allowed directory", path: filepath.Join(logDir, "secure"), want: false},
+		{name: "stars do not match subdirectories", path: filepath.Join(logDir, "messages.d", "messages"), want: false},
+		{name: "other pod", path: filepath.Join(logDir, "pods", "default_app-abc", "aws-node", "0.log"), want: false},
+		{name: "allowed directory itself", path: logDir, want: false},
+		{name: "file outside the allowed directories", path: "/etc/shadow", want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := handler.allowed(tc.path); got != tc.want {
+				t.Errorf("allowed(%s) = %v, want %v", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHelperHandlerServeHTTP(t *testing.T) {
+	dir := t.TempDir()
+	messages := filepath.Join(dir, "messages")
+	if err := os.WriteFile(messages, []byte("Nov 28 02:59:10 ip-10-0-0-1 systemd[1]: Started kubelet.\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secure"), []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	uid, rootUID := uint32(1000), uint32(0)
+	for _, tc := range []struct {
+		name       string
+		peerUID    *uint32
+		path       string
+		maxBytes   int64
+		wantStatus int
+	}{
+		{name: "allowed file", peerUID: &uid, path: messages, wantStatus: http.StatusOK},
+		{name: "unknown peer", path: messages, wantStatus: http.StatusForbidden},
+		{name: "peer uid not allowed", peerUID: &rootUID, path: messages, wantStatus: http.StatusForbidden},
+		{name: "file not allowed", peerUID: &uid, path: filepath.Join(dir, "secure"), wantStatus: http.StatusForbidden},
+		{name: "relative path", peerUID: &uid, path: "messages", wantStatus: http.StatusBadRequest},
+		{name: "missing file", peerUID: &uid, path: filepath.Join(dir, "messages-20240101"), wantStatus: http.StatusNotFound},
+		{name: "file larger than the limit", peerUID: &uid, path: messages, maxBytes: 10, wantStatus: http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := HelperHandler{Allowed: []string{filepath.Join(dir, "messages*")}, AllowedUIDs: []uint32{uid}, MaxBytes: tc.maxBytes}
+			req := httptest.NewRequest(http.MethodGet, HelperReadPath+"?"+url.Values{"path": []string{tc.path}}.Encode(), nil)
+			if tc.peerUID != nil {
+				req = req.WithContext(context.WithValue(req.Context(), peerUIDKey{}, *tc.peerUID))
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			if recorder.Code != tc.wantStatus {
+				t.Fatalf("ServeHTTP() status = %d, want %d: %s", recorder.Code, tc.wantStatus, strings.TrimSpace(recorder.Body.String()))
+			}
+			if tc.wantStatus == http.StatusOK && !strings.Contains(recorder.Body.String(), "Started kubelet") {
+				t.Errorf("ServeHTTP() body = %q", recorder.Body.String())
+			}
+		})
+	}
+}
@@ -61,6 +61,10 @@ func (i Source) Name() string {
 func (i Source) FindByPath(path string) sources.FindFunc {
 	return func(s sources.Source, log []byte) ([]string, error) {
 		result, err := i.GetMetadata(path)
+		// signals that were not given are not found rather than an error
+		if result == "" && err == nil {
+			return nil, nil
+		}
 		return []string{result}, err
 	}
 }
@@ -88,9 +92,16 @@ func (i Source) Find(event *sources.Event) ([]sources.FindResult, error) {
 	return results, nil
 }
 
-// GetMetadata queries EC2 IMDS
+// GetMetadata queries EC2 IMDS, signals are returned as their notice time or empty if they were not given
 func (i Source) GetMetadata(path string) (string, error) {
 	ctx := context.TODO()
+	if path == SpotInstanceAction || path == RebalanceRecommendation {
+		signal, err := i.GetSignal(ctx, path)
+		if err != nil || signal == nil {
+			return "", err
+		}
+		return strconv.FormatInt(signal.NoticeTime.UnixMicro(), 10), nil
+	}
 	identityDoc, err := i.imds.GetInstanceIdentityDocument(ctx, &imds.GetInstanceIdentityDocumentInput{})
 	if err != nil {
 		return "", fmt.Errorf("unable to retrieve instance-identity document: %w", err)
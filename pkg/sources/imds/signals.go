@@ -0,0 +1,77 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+var (
+	SpotInstanceAction      = "/meta-data/spot/instance-action"
+	RebalanceRecommendation = "/meta-data/events/recommendations/rebalance"
+	// SpotInterruptionWarning is the time between a spot interruption notice and the instance action
+	SpotInterruptionWarning = 2 * time.Minute
+)
+
+// Signal is a spot interruption notice or a rebalance recommendation
+type Signal struct {
+	Path string
+	// Action is the instance action of a spot interruption notice, i.e. terminate, stop or hibernate
+	Action     string
+	NoticeTime time.Time
+}
+
+// GetSignal queries EC2 IMDS for a spot interruption notice or rebalance recommendation, the signal is nil if there is none
+func (i Source) GetSignal(ctx context.Context, path string) (*Signal, error) {
+	out, err := i.imds.GetMetadata(ctx, &imds.GetMetadataInput{Path: strings.TrimPrefix(path, "/meta-data/")})
+	if err != nil {
+		var respErr *smithyhttp.ResponseError
+		if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to retrieve %s: %w", path, err)
+	}
+	defer out.Content.Close()
+	switch path {
+	case SpotInstanceAction:
+		var action struct {
+			Action string    `json:"action"`
+			Time   time.Time `json:"time"`
+		}
+		if err := json.NewDecoder(out.Content).Decode(&action); err != nil {
+			return nil, fmt.Errorf("unable to decode the spot instance action: %w", err)
+		}
+		// the instance action time is when the instance is interrupted, the notice is given two minutes before
+		return &Signal{Path: path, Action: action.Action, NoticeTime: action.Time.Add(-SpotInterruptionWarning)}, nil
+	case RebalanceRecommendation:
+		var recommendation struct {
+			NoticeTime time.Time `json:"noticeTime"`
+		}
+		if err := json.NewDecoder(out.Content).Decode(&recommendation); err != nil {
+			return nil, fmt.Errorf("unable to decode the rebalance recommendation: %w", err)
+		}
+		return &Signal{Path: path, NoticeTime: recommendation.NoticeTime}, nil
+	}
+	return nil, fmt.Errorf("path \"%s\" is not a signal", path)
+}
@@ -0,0 +1,360 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/logging"
+)
+
+// The journal file format, see https://systemd.io/JOURNAL_FILE_FORMAT/
+const (
+	fileSignature    = "LPKSHHRH"
+	fileHeaderMinLen = 208
+
+	incompatibleCompressedXZ   = 1 << 0
+	incompatibleCompressedLZ4  = 1 << 1
+	incompatibleKeyedHash      = 1 << 2
+	incompatibleCompressedZSTD = 1 << 3
+	incompatibleCompact        = 1 << 4
+	incompatibleSupported      = incompatibleCompressedXZ | incompatibleCompressedLZ4 | incompatibleKeyedHash | incompatibleCompressedZSTD | incompatibleCompact
+
+	objectHeaderLen = 16
+	objectData      = 1
+	objectEntry     = 3
+
+	objectCompressedXZ   = 1 << 0
+	objectCompressedLZ4  = 1 << 1
+	objectCompressedZSTD = 1 << 2
+
+	entryItemsOffset       = 64
+	dataPayloadOffset      = 64
+	compactDataPayloadOffs = 72
+	// maxObjectLen bounds the objects read from a journal file, so a corrupted size does not allocate unbounded memory
+	maxObjectLen = 64 * 1024 * 1024
+)
+
+var (
+	// ErrUnsupported is returned by the FileReader for journal files it is not able to parse, i.e. XZ compressed entries or a newer file format
+	ErrUnsupported = errors.New("unsupported journal file")
+
+	// bootIDPath is the boot ID of the running kernel, which is the current boot of the journal
+	bootIDPath = "/proc/sys/kernel/random/boot_id"
+)
+
+// FileReader reads journal entries natively from the journal files under Root, without executing journalctl.
+// Only the MESSAGE and SYSLOG_IDENTIFIER fields are decoded, other data objects are skipped after reading their field name.
+type FileReader struct {
+	Root      string
+	Namespace string
+	// AllBoots reads the entries of all boots instead of only the current boot
+	AllBoots bool
+	// BootWindow drops the entries logged later than this after their boot started, DefaultBootWindow is used if 0
+	BootWindow time.Duration
+	// Fallback reads the entries if the journal files can not be parsed natively, i.e. journalctl for XZ compressed journals
+	Fallback Reader
+	// fellBack is set if the last read used the Fallback
+	fellBack bool
+}
+
+// String is a human readable description of the journal files read, or of the Fallback if the last read used it
+func (f *FileReader) String() string {
+	if f.fellBack {
+		return f.Fallback.String()
+	}
+	dirs, err := Directories(f.Root, f.Namespace)
+	if err != nil {
+		return fmt.Sprintf("journal files in %s", f.Root)
+	}
+	return fmt.Sprintf("journal files in %s", strings.Join(dirs, ","))
+}
+
+// SetAllBoots sets if the entries of all boots are read instead of only the current boot
+func (f *FileReader) SetAllBoots(allBoots bool) {
+	f.AllBoots = allBoots
+	if fallback, ok := f.Fallback.(allBootsReader); ok {
+		fallback.SetAllBoots(allBoots)
+	}
+}
+
+// FellBack returns true if the last read used the Fallback
+func (f *FileReader) FellBack() bool {
+	return f.fellBack
+}
+
+// Entries parses the journal files and returns the entries in chronological order, the Fallback is used if the files can not be parsed
+func (f *FileReader) Entries(ctx context.Context) ([]Entry, error) {
+	entries, err := f.readFiles(ctx)
+	f.fellBack = err != nil && f.Fallback != nil && ctx.Err() == nil
+	if !f.fellBack {
+		return entries, err
+	}
+	logging.ForSource(Name).Debugf("unable to read the journal files natively, falling back to %s: %v", f.Fallback.String(), err)
+	return f.Fallback.Entries(ctx)
+}
+
+// readFiles parses the journal files natively
+func (f *FileReader) readFiles(ctx context.Context) ([]Entry, error) {
+	dirs, err := Directories(f.Root, f.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	files, err := Files(dirs)
+	if err != nil {
+		return nil, err
+	}
+	boot := ""
+	if !f.AllBoots {
+		rawBootID, err := os.ReadFile(bootIDPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the current boot ID: %w", err)
+		}
+		boot = strings.ReplaceAll(strings.TrimSpace(string(rawBootID)), "-", "")
+	}
+	var entries []Entry
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fileEntries, err := readJournalFile(file, boot, bootWindow(f.BootWindow))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read journal file %s: %w", file, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+	// entries of different files are interleaved, i.e. the system journal and the rotated user journals
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Realtime.Before(entries[j].Realtime) })
+	return entries, nil
+}
+
+// journalFile is an open journal file
+type journalFile struct {
+	r       io.ReaderAt
+	compact bool
+	// skipped and identifiers cache the data objects by offset since most of them are shared between entries, i.e. _HOSTNAME= or SYSLOG_IDENTIFIER=kubelet
+	skipped     map[uint64]bool
+	identifiers map[uint64]string
+	zstd        *zstd.Decoder
+}
+
+// readJournalFile reads the entries of a journal file, restricted to a boot ID if it is not empty.
+// Entries logged later than the window after their boot started are dropped.
+func readJournalFile(path string, boot string, window time.Duration) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseJournalFile(file, boot, window)
+}
+
+// parseJournalFile walks the objects of a journal file in the order they were appended and decodes the entry objects
+func parseJournalFile(r io.ReaderAt, boot string, window time.Duration) ([]Entry, error) {
+	header := make([]byte, fileHeaderMinLen)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("unable to read header: %w", err)
+	}
+	if string(header[:8]) != fileSignature {
+		return nil, fmt.Errorf("invalid signature %q", header[:8])
+	}
+	incompatible := binary.LittleEndian.Uint32(header[12:16])
+	if incompatible&^incompatibleSupported != 0 {
+		return nil, fmt.Errorf("%w: incompatible flags %#x", ErrUnsupported, incompatible)
+	}
+	// the boot ID of the header is the boot that last wrote to the file, a file last written by another boot does not hold entries of the current boot
+	if writerBoot := header[56:72]; boot != "" && hex.EncodeToString(writerBoot) != boot && !bytes.Equal(writerBoot, make([]byte, 16)) {
+		return nil, nil
+	}
+	jf := &journalFile{
+		r:           r,
+		compact:     incompatible&incompatibleCompact != 0,
+		skipped:     map[uint64]bool{},
+		identifiers: map[uint64]string{},
+	}
+	defer func() {
+		if jf.zstd != nil {
+			jf.zstd.Close()
+		}
+	}()
+	headerSize := binary.LittleEndian.Uint64(header[88:96])
+	tail := binary.LittleEndian.Uint64(header[136:144])
+	var entries []Entry
+	for offset := headerSize; offset != 0 && offset <= tail; {
+		objectType, size, err := jf.objectHeader(offset)
+		if err != nil {
+			return nil, err
+		}
+		if objectType == objectEntry {
+			entry, bootID, err := jf.entry(offset, size, boot)
+			if err != nil {
+				return nil, err
+			}
+			if boot == "" || bootID == boot {
+				if window > 0 && *entry.Monotonic > window {
+					// the entries of a boot are appended in order, so the rest of the boot is outside the window
+					if boot != "" {
+						return entries, nil
+					}
+				} else {
+					entries = append(entries, entry)
+				}
+			}
+		}
+		offset += (size + 7) &^ 7
+	}
+	return entries, nil
+}
+
+// objectHeader reads the type and size of the object at offset
+func (jf *journalFile) objectHeader(offset uint64) (byte, uint64, error) {
+	header := make([]byte, objectHeaderLen)
+	if _, err := jf.r.ReadAt(header, int64(offset)); err != nil {
+		return 0, 0, fmt.Errorf("unable to read object at %d: %w", offset, err)
+	}
+	size := binary.LittleEndian.Uint64(header[8:16])
+	if size < objectHeaderLen || size > maxObjectLen {
+		return 0, 0, fmt.Errorf("invalid object size %d at %d", size, offset)
+	}
+	return header[0], size, nil
+}
+
+// entry decodes the entry object at offset. The data objects are not read if the entry is not of the boot.
+func (jf *journalFile) entry(offset uint64, size uint64, boot string) (Entry, string, error) {
+	if size < entryItemsOffset {
+		return Entry{}, "", fmt.Errorf("invalid entry object size %d at %d", size, offset)
+	}
+	object := make([]byte, size)
+	if _, err := jf.r.ReadAt(object, int64(offset)); err != nil {
+		return Entry{}, "", fmt.Errorf("unable to read entry object at %d: %w", offset, err)
+	}
+	bootID := hex.EncodeToString(object[40:56])
+	entry := Entry{
+		BootID:    bootID,
+		Realtime:  time.UnixMicro(int64(binary.LittleEndian.Uint64(object[24:32]))),
+		Monotonic: lo.ToPtr(time.Duration(binary.LittleEndian.Uint64(object[32:40])) * time.Microsecond),
+	}
+	if boot != "" && bootID != boot {
+		return entry, bootID, nil
+	}
+	itemLen := 16
+	if jf.compact {
+		itemLen = 4
+	}
+	for item := object[entryItemsOffset:]; len(item) >= itemLen; item = item[itemLen:] {
+		var dataOffset uint64
+		if jf.compact {
+			dataOffset = uint64(binary.LittleEndian.Uint32(item))
+		} else {
+			dataOffset = binary.LittleEndian.Uint64(item)
+		}
+		if jf.skipped[dataOffset] {
+			continue
+		}
+		if identifier, ok := jf.identifiers[dataOffset]; ok {
+			entry.Identifier = identifier
+			continue
+		}
+		name, value, err := jf.data(dataOffset)
+		if err != nil {
+			return Entry{}, "", err
+		}
+		switch name {
+		case "MESSAGE":
+			entry.Message = value
+		case "SYSLOG_IDENTIFIER":
+			entry.Identifier = value
+			jf.identifiers[dataOffset] = value
+		default:
+			jf.skipped[dataOffset] = true
+		}
+	}
+	return entry, bootID, nil
+}
+
+// data reads the data object at offset and splits its payload into the field name and value
+func (jf *journalFile) data(offset uint64) (string, string, error) {
+	objectType, size, err := jf.objectHeader(offset)
+	if err != nil {
+		return "", "", err
+	}
+	payloadOffset := uint64(dataPayloadOffset)
+	if jf.compact {
+		payloadOffset = compactDataPayloadOffs
+	}
+	if objectType != objectData || size < payloadOffset {
+		return "", "", fmt.Errorf("invalid data object at %d", offset)
+	}
+	object := make([]byte, size)
+	if _, err := jf.r.ReadAt(object, int64(offset)); err != nil {
+		return "", "", fmt.Errorf("unable to read data object at %d: %w", offset, err)
+	}
+	payload, err := jf.decompress(object[1], object[payloadOffset:])
+	if err != nil {
+		return "", "", fmt.Errorf("unable to decompress data object at %d: %w", offset, err)
+	}
+	name, value, found := bytes.Cut(payload, []byte("="))
+	if !found {
+		return "", "", fmt.Errorf("invalid data object payload at %d", offset)
+	}
+	return string(name), string(value), nil
+}
+
+// decompress decompresses a data object payload according to the object flags
+func (jf *journalFile) decompress(flags byte, payload []byte) ([]byte, error) {
+	switch {
+	case flags&objectCompressedZSTD != 0:
+		if jf.zstd == nil {
+			decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			jf.zstd = decoder
+		}
+		return jf.zstd.DecodeAll(payload, nil)
+	case flags&objectCompressedLZ4 != 0:
+		// systemd prefixes the LZ4 block with the little endian uncompressed size
+		if len(payload) < 8 {
+			return nil, fmt.Errorf("invalid LZ4 payload")
+		}
+		size := binary.LittleEndian.Uint64(payload[:8])
+		if size > maxObjectLen {
+			return nil, fmt.Errorf("invalid LZ4 uncompressed size %d", size)
+		}
+		decompressed := make([]byte, size)
+		n, err := lz4.UncompressBlock(payload[8:], decompressed)
+		if err != nil {
+			return nil, err
+		}
+		return decompressed[:n], nil
+	case flags&objectCompressedXZ != 0:
+		return nil, fmt.Errorf("%w: XZ compressed data object", ErrUnsupported)
+	}
+	return payload, nil
+}
@@ -0,0 +1,201 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+const testHeaderSize = 272
+
+var (
+	bootA = strings.Repeat("a", 32)
+	bootB = strings.Repeat("b", 32)
+)
+
+// testJournal builds a journal file of DATA and ENTRY objects, the hash tables and entry arrays are left out since the reader walks the objects
+type testJournal struct {
+	buf     []byte
+	compact bool
+	tail    uint64
+}
+
+func newTestJournal(incompatible uint32, writerBoot string) *testJournal {
+	j := &testJournal{buf: make([]byte, testHeaderSize), compact: incompatible&incompatibleCompact != 0}
+	copy(j.buf, fileSignature)
+	binary.LittleEndian.PutUint32(j.buf[12:16], incompatible)
+	boot, _ := hex.DecodeString(writerBoot)
+	copy(j.buf[56:72], boot)
+	binary.LittleEndian.PutUint64(j.buf[88:96], testHeaderSize)
+	return j
+}
+
+// object appends an object and returns its offset
+func (j *testJournal) object(objectType byte, flags byte, body []byte) uint64 {
+	offset := uint64(len(j.buf))
+	header := make([]byte, objectHeaderLen)
+	header[0], header[1] = objectType, flags
+	binary.LittleEndian.PutUint64(header[8:16], uint64(objectHeaderLen+len(body)))
+	j.buf = append(j.buf, header...)
+	j.buf = append(j.buf, body...)
+	for len(j.buf)%8 != 0 {
+		j.buf = append(j.buf, 0)
+	}
+	j.tail = offset
+	return offset
+}
+
+func (j *testJournal) data(flags byte, payload []byte) uint64 {
+	fieldsLen := dataPayloadOffset - objectHeaderLen
+	if j.compact {
+		fieldsLen = compactDataPayloadOffs - objectHeaderLen
+	}
+	return j.object(objectData, flags, append(make([]byte, fieldsLen), payload...))
+}
+
+func (j *testJournal) entry(boot string, realtime time.Time, monotonic time.Duration, items ...uint64) {
+	body := make([]byte, entryItemsOffset-objectHeaderLen)
+	binary.LittleEndian.PutUint64(body[8:16], uint64(realtime.UnixMicro()))
+	binary.LittleEndian.PutUint64(body[16:24], uint64(monotonic.Microseconds()))
+	bootID, _ := hex.DecodeString(boot)
+	copy(body[24:40], bootID)
+	for _, item := range items {
+		if j.compact {
+			body = binary.LittleEndian.AppendUint32(body, uint32(item))
+		} else {
+			body = binary.LittleEndian.AppendUint64(body, item)
+			body = binary.LittleEndian.AppendUint64(body, 0)
+		}
+	}
+	j.object(objectEntry, 0, body)
+}
+
+func (j *testJournal) bytes() []byte {
+	binary.LittleEndian.PutUint64(j.buf[136:144], j.tail)
+	return j.buf
+}
+
+// bootJournal is a journal of the kubelet starting in boot B followed by boot A, one entry per second of each boot
+func bootJournal(incompatible uint32) []byte {
+	j := newTestJournal(incompatible, bootA)
+	identifier := j.data(0, []byte("SYSLOG_IDENTIFIER=kubelet"))
+	hostname := j.data(0, []byte("_HOSTNAME=ip-10-0-0-1"))
+	start := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	for _, boot := range []string{bootB, bootA} {
+		for i := 1; i <= 3; i++ {
+			message := j.data(0, []byte("MESSAGE="+boot[:1]+strings.Repeat("-", i)))
+			j.entry(boot, start.Add(time.Duration(i)*time.Second), time.Duration(i)*time.Second, identifier, message, hostname)
+		}
+		start = start.Add(time.Hour)
+	}
+	return j.bytes()
+}
+
+func TestParseJournalFile(t *testing.T) {
+	zstdEncoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zstdEncoder.Close()
+	lz4Block := func(payload []byte) []byte {
+		block := make([]byte, lz4.CompressBlockBound(len(payload)))
+		n, err := lz4.CompressBlock(payload, block, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(binary.LittleEndian.AppendUint64(nil, uint64(len(payload))), block[:n]...)
+	}
+	compressed := func(incompatible uint32, flags byte, payload []byte) []byte {
+		j := newTestJournal(incompatible, bootA)
+		j.entry(bootA, time.Unix(1, 0), time.Second, j.data(0, []byte("SYSLOG_IDENTIFIER=kubelet")), j.data(flags, payload))
+		return j.bytes()
+	}
+	message := []byte("MESSAGE=" + strings.Repeat("Started kubelet. ", 64))
+	for _, tc := range []struct {
+		name         string
+		file         []byte
+		boot         string
+		window       time.Duration
+		wantMessages []string
+		wantErr      error
+	}{
+		{name: "current boot", file: bootJournal(0), boot: bootA, wantMessages: []string{"a-", "a--", "a---"}},
+		{name: "compact", file: bootJournal(incompatibleCompact | incompatibleKeyedHash), boot: bootA, wantMessages: []string{"a-", "a--", "a---"}},
+		{name: "all boots", file: bootJournal(0), wantMessages: []string{"b-", "b--", "b---", "a-", "a--", "a---"}},
+		{name: "boot window stops at the first entry past the window", file: bootJournal(0), boot: bootA, window: 2 * time.Second, wantMessages: []string{"a-", "a--"}},
+		{name: "boot window of all boots", file: bootJournal(0), window: time.Second, wantMessages: []string{"b-", "a-"}},
+		{name: "file last written by another boot is skipped", file: bootJournal(0), boot: bootB},
+		{name: "zstd", file: compressed(incompatibleCompressedZSTD, objectCompressedZSTD, zstdEncoder.EncodeAll(message, nil)), boot: bootA, wantMessages: []string{string(message[8:])}},
+		{name: "lz4", file: compressed(incompatibleCompressedLZ4, objectCompressedLZ4, lz4Block(message)), boot: bootA, wantMessages: []string{string(message[8:])}},
+		{name: "xz is not supported", file: compressed(incompatibleCompressedXZ, objectCompressedXZ, message), boot: bootA, wantErr: ErrUnsupported},
+		{name: "unknown incompatible flags are not supported", file: newTestJournal(1<<10, bootA).bytes(), boot: bootA, wantErr: ErrUnsupported},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, err := parseJournalFile(bytes.NewReader(tc.file), tc.boot, tc.window)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("parseJournalFile() error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseJournalFile() error = %v", err)
+			}
+			var messages []string
+			for _, entry := range entries {
+				messages = append(messages, entry.Message)
+				if entry.Identifier != "kubelet" {
+					t.Errorf("entry identifier = %q, want kubelet", entry.Identifier)
+				}
+				if entry.Monotonic == nil {
+					t.Errorf("entry %q has no monotonic timestamp", entry.Message)
+				}
+			}
+			if strings.Join(messages, ",") != strings.Join(tc.wantMessages, ",") {
+				t.Errorf("parseJournalFile() messages = %v, want %v", messages, tc.wantMessages)
+			}
+		})
+	}
+}
+
+func TestParseJournalFileInvalid(t *testing.T) {
+	valid := bootJournal(0)
+	corrupt := append([]byte{}, valid...)
+	// the size of the first object is larger than any object that is read
+	binary.LittleEndian.PutUint64(corrupt[testHeaderSize+8:testHeaderSize+16], maxObjectLen+1)
+	for _, tc := range []struct {
+		name string
+		file []byte
+	}{
+		{name: "truncated header", file: valid[:100]},
+		{name: "invalid signature", file: append([]byte("NOTAJRNL"), valid[8:]...)},
+		{name: "invalid object size", file: corrupt},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseJournalFile(bytes.NewReader(tc.file), bootA, 0); err == nil {
+				t.Error("parseJournalFile() did not fail")
+			}
+		})
+	}
+}
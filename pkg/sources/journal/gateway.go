@@ -0,0 +1,87 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/proxy"
+)
+
+var (
+	GatewayName = "Journal Gateway"
+	// DefaultGatewayURL is the default listen address of systemd-journal-gatewayd
+	DefaultGatewayURL = "http://localhost:19531"
+)
+
+// GatewayReader reads journal entries over the systemd-journal-gatewayd HTTP export API
+// which allows measuring without mounting any host paths into the pod
+type GatewayReader struct {
+	URL        string
+	HTTPClient *http.Client
+	// AllBoots reads the entries of all boots instead of only the current boot
+	AllBoots bool
+	// BootWindow drops the entries logged later than this after their boot started, DefaultBootWindow is used if 0
+	BootWindow time.Duration
+}
+
+// NewGateway instantiates a new journal source that reads from systemd-journal-gatewayd at url
+func NewGateway(url string) *Source {
+	return New(GatewayName, &GatewayReader{
+		URL:        strings.TrimSuffix(url, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second, Transport: proxy.Transport()},
+	})
+}
+
+// String is a human readable description of the gateway
+func (g *GatewayReader) String() string {
+	return g.URL
+}
+
+// SetAllBoots sets if the entries of all boots are read instead of only the current boot
+func (g *GatewayReader) SetAllBoots(allBoots bool) {
+	g.AllBoots = allBoots
+}
+
+// Entries retrieves the entries of the current boot, or all boots, as JSON from the gateway's /entries endpoint
+func (g *GatewayReader) Entries(ctx context.Context) ([]Entry, error) {
+	url := fmt.Sprintf("%s/entries?boot", g.URL)
+	if g.AllBoots {
+		url = fmt.Sprintf("%s/entries", g.URL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create journal gateway request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := g.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query journal gateway %s: %w", g.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("journal gateway %s returned status %s", g.URL, resp.Status)
+	}
+	// closing the body after parsing stopped at the boot window ends the export
+	entries, _, err := parseJSONEntries(resp.Body, bootWindow(g.BootWindow), !g.AllBoots)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read journal gateway entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,420 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package journal is a latency timing source for the systemd journal
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/logging"
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "Journal"
+	// PersistentPath is where journald stores journals when Storage=persistent (or auto and the directory exists)
+	PersistentPath = "/var/log/journal"
+	// VolatilePath is where journald stores journals when Storage=volatile, or on minimal distros that never persist
+	VolatilePath = "/run/log/journal"
+
+	// DefaultBootWindow drops the entries logged later than this after their boot started while reading the journal,
+	// so the boot window of a multi-GB journal of a long-lived node can be measured on modest hardware. The window is unlimited when it is 0
+	DefaultBootWindow time.Duration
+
+	machineIDDirRE = regexp.MustCompile(`^[0-9a-f]{32}(\.[^/]+)?$`)
+	monotonicRE    = regexp.MustCompile(`^\S+ \[([0-9]+\.[0-9]+)\] `)
+)
+
+// Directories resolves the journal directories of a namespace under root, persistent directories first followed by volatile directories.
+// Journal namespaces (i.e. units with LogNamespace=) are stored in "<machine-id>.<namespace>" directories, an empty namespace resolves the default namespace.
+func Directories(root string, namespace string) ([]string, error) {
+	var dirs []string
+	for _, base := range []string{PersistentPath, VolatilePath} {
+		entries, err := os.ReadDir(filepath.Join(root, base))
+		if err != nil {
+			continue
+		}
+		var baseDirs []string
+		for _, entry := range entries {
+			if !entry.IsDir() || !machineIDDirRE.MatchString(entry.Name()) {
+				continue
+			}
+			_, entryNamespace, _ := strings.Cut(entry.Name(), ".")
+			if entryNamespace == namespace {
+				baseDirs = append(baseDirs, filepath.Join(root, base, entry.Name()))
+			}
+		}
+		sort.Strings(baseDirs)
+		dirs = append(dirs, baseDirs...)
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("unable to find journal directories for namespace \"%s\" in %s or %s", namespace,
+			filepath.Join(root, PersistentPath), filepath.Join(root, VolatilePath))
+	}
+	return dirs, nil
+}
+
+// Files returns the journal files within the journal directories, including files that were not cleanly closed (*.journal~)
+func Files(dirs []string) ([]string, error) {
+	var files []string
+	for _, dir := range dirs {
+		for _, pattern := range []string{"*.journal", "*.journal~"} {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("unable to find journal files in %v", dirs)
+	}
+	return files, nil
+}
+
+// Entry is a single journal entry
+type Entry struct {
+	Message    string
+	Identifier string
+	BootID     string
+	Realtime   time.Time
+	// Monotonic is the time since kernel boot which, unlike Realtime, is not stepped when NTP syncs mid-boot
+	Monotonic *time.Duration
+}
+
+// Line formats the entry similar to a syslog line, prefixed with the RFC3339 realtime timestamp and the dmesg style monotonic seconds if known,
+// so regexes written for /var/log/messages also match journal entries
+func (e Entry) Line() string {
+	if e.Monotonic == nil {
+		return fmt.Sprintf("%s %s: %s", e.Realtime.UTC().Format(time.RFC3339Nano), e.Identifier, e.Message)
+	}
+	return fmt.Sprintf("%s [%.6f] %s: %s", e.Realtime.UTC().Format(time.RFC3339Nano), e.Monotonic.Seconds(), e.Identifier, e.Message)
+}
+
+// Reader reads the entries of the current boot from a journal backend
+type Reader interface {
+	// Entries returns all journal entries of the current boot in chronological order
+	Entries(ctx context.Context) ([]Entry, error)
+	// String is a human readable description of the backend
+	String() string
+}
+
+// allBootsReader is a Reader that is able to read the entries of all boots in the journal instead of only the current boot
+type allBootsReader interface {
+	SetAllBoots(allBoots bool)
+}
+
+// Source is the systemd journal source
+type Source struct {
+	name    string
+	reader  Reader
+	entries []Entry
+	// lines are the entries of the boot rendered by Entry.Line(), so they are rendered once per read instead of once per regex search
+	lines []string
+	// boot restricts the entries to a boot ID, the reader's entries of the current boot are used if empty
+	boot string
+	// scannedBytes is the length of the entry lines searched by the last regex search
+	scannedBytes int
+}
+
+// New instantiates a new instance of the journal source backed by the reader
+func New(name string, reader Reader) *Source {
+	return &Source{
+		name:   name,
+		reader: reader,
+	}
+}
+
+// ClearCache clears the cached journal entries
+func (s *Source) ClearCache() {
+	s.entries = nil
+	s.lines = nil
+}
+
+// String is a human readable string of the source
+func (s *Source) String() string {
+	return s.reader.String()
+}
+
+// HostPaths are the journal directories that are read, or the persistent journal directory if none are found.
+// The journal gateway does not read host paths.
+func (s *Source) HostPaths() []string {
+	var root, namespace string
+	switch reader := s.reader.(type) {
+	case *FileReader:
+		root, namespace = reader.Root, reader.Namespace
+	case *JournalctlReader:
+		root, namespace = reader.Root, reader.Namespace
+	default:
+		return nil
+	}
+	dirs, err := Directories(root, namespace)
+	if err != nil {
+		return []string{filepath.Join(root, PersistentPath)}
+	}
+	return dirs
+}
+
+// AccessPath is how the journal is read, directly from the journal files, through journalctl or the journal gateway
+func (s *Source) AccessPath() string {
+	switch reader := s.reader.(type) {
+	case *GatewayReader:
+		return "journal-gateway"
+	case *FileReader:
+		if !reader.FellBack() {
+			return sources.AccessPathDirect
+		}
+	}
+	return "journalctl"
+}
+
+// Name is the name of the source
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Entries returns the cached journal entries or reads them from the journal backend
+func (s *Source) Entries() ([]Entry, error) {
+	if s.entries == nil {
+		entries, err := s.reader.Entries(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		s.entries = entries
+	}
+	if s.boot == "" {
+		return s.entries, nil
+	}
+	return lo.Filter(s.entries, func(e Entry, _ int) bool { return e.BootID == s.boot }), nil
+}
+
+// Boots reads the entries of all boots in the journal and returns the boot IDs in the order the boots started
+func (s *Source) Boots() ([]string, error) {
+	reader, ok := s.reader.(allBootsReader)
+	if !ok {
+		return nil, fmt.Errorf("%s is not able to read multiple boots", s.String())
+	}
+	if s.boot == "" {
+		reader.SetAllBoots(true)
+		s.entries = nil
+	}
+	s.lines = nil
+	boot := s.boot
+	s.boot = ""
+	defer func() { s.boot = boot }()
+	entries, err := s.Entries()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Realtime.Before(entries[j].Realtime) })
+	return lo.Uniq(lo.FilterMap(entries, func(e Entry, _ int) (string, bool) { return e.BootID, e.BootID != "" })), nil
+}
+
+// SetBoot restricts the entries to a boot ID returned by Boots, an empty boot ID reads only the current boot again
+func (s *Source) SetBoot(bootID string) {
+	if bootID == "" {
+		if reader, ok := s.reader.(allBootsReader); ok {
+			reader.SetAllBoots(false)
+		}
+		s.entries = nil
+	}
+	s.boot = bootID
+	s.lines = nil
+}
+
+// LastSearch returns the journal and the length of the entry lines searched by the last regex search
+func (s *Source) LastSearch() (string, int) {
+	return s.String(), s.scannedBytes
+}
+
+// FindByRegex is a helper func that returns a FindFunc to search for a regex in the journal entries that can be used in an Event
+func (s *Source) FindByRegex(re *regexp.Regexp) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		entryLines, err := s.entryLines()
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		s.scannedBytes = 0
+		start := time.Now()
+		for _, line := range entryLines {
+			s.scannedBytes += len(line)
+			if re.MatchString(line) {
+				lines = append(lines, line)
+			}
+		}
+		sources.Regexes.Observe(re, s.scannedBytes, time.Since(start))
+		logging.ForSource(Name).Debugw("searched journal", "source", s.String(), "boot", s.boot, "regex", re.String(), "entries", len(entryLines), "matches", len(lines))
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no matches in %s for regex \"%s\"", s.String(), re.String())
+		}
+		return lines, nil
+	}
+}
+
+// entryLines returns the entries rendered by Entry.Line(), which are cached until the entries are read again or the boot changes
+func (s *Source) entryLines() ([]string, error) {
+	if s.entries != nil && s.lines != nil {
+		return s.lines, nil
+	}
+	entries, err := s.Entries()
+	if err != nil {
+		return nil, err
+	}
+	s.lines = make([]string, 0, len(entries))
+	for _, entry := range entries {
+		s.lines = append(s.lines, entry.Line())
+	}
+	return s.lines, nil
+}
+
+// ParseTimestamp parses the realtime timestamp prefix of a line formatted by Entry.Line()
+func ParseTimestamp(line string) (time.Time, error) {
+	rawTS, _, _ := strings.Cut(line, " ")
+	return time.Parse(time.RFC3339Nano, rawTS)
+}
+
+// ParseMonotonic parses the monotonic time since boot of a line formatted by Entry.Line()
+func ParseMonotonic(line string) (time.Duration, error) {
+	match := monotonicRE.FindStringSubmatch(line)
+	if match == nil {
+		return 0, fmt.Errorf("unable to find monotonic timestamp on journal line: \"%s\"", line)
+	}
+	seconds, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Microsecond), nil
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the journal and return the results based on the Event's matcher
+func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	matchedLines, err := event.FindFn(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, line := range matchedLines {
+		ts, err := ParseTimestamp(line)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(line)
+		}
+		var sinceBoot *time.Duration
+		if monotonic, err := ParseMonotonic(line); err == nil {
+			sinceBoot = &monotonic
+		}
+		results = append(results, sources.FindResult{
+			Line:      line,
+			Timestamp: ts,
+			Err:       err,
+			Comment:   comment,
+			SinceBoot: sinceBoot,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
+
+// parseJSONEntries parses newline delimited journal export JSON objects into Entries.
+// Entries logged later than the boot window after their boot started are dropped while parsing, so they are never held in memory.
+// If the entries are of a single boot, they are chronological and parsing stops at the first entry past the window, which is reported by stopped.
+func parseJSONEntries(r io.Reader, window time.Duration, singleBoot bool) (entries []Entry, stopped bool, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(line, &fields); err != nil {
+			return nil, false, fmt.Errorf("unable to parse journal entry: %w", err)
+		}
+		entry, err := entryFromJSON(fields)
+		if err != nil {
+			return nil, false, err
+		}
+		// entries without a monotonic timestamp can not be placed in the boot window and are kept
+		if window > 0 && entry.Monotonic != nil && *entry.Monotonic > window {
+			if singleBoot {
+				return entries, true, nil
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, false, scanner.Err()
+}
+
+// bootWindow returns the boot window of a reader, DefaultBootWindow is used if it is 0
+func bootWindow(window time.Duration) time.Duration {
+	if window == 0 {
+		return DefaultBootWindow
+	}
+	return window
+}
+
+// entryFromJSON converts a journal export JSON object (as produced by journalctl -o json and systemd-journal-gatewayd) to an Entry
+func entryFromJSON(fields map[string]json.RawMessage) (Entry, error) {
+	realtimeMicros, err := strconv.ParseInt(jsonFieldString(fields["__REALTIME_TIMESTAMP"]), 10, 64)
+	if err != nil {
+		return Entry{}, fmt.Errorf("unable to parse journal entry __REALTIME_TIMESTAMP: %w", err)
+	}
+	// __MONOTONIC_TIMESTAMP is optional since it is not available for all entries, i.e. entries imported from other machines
+	var monotonic *time.Duration
+	if monotonicMicros, err := strconv.ParseInt(jsonFieldString(fields["__MONOTONIC_TIMESTAMP"]), 10, 64); err == nil {
+		monotonic = lo.ToPtr(time.Duration(monotonicMicros) * time.Microsecond)
+	}
+	return Entry{
+		Message:    jsonFieldString(fields["MESSAGE"]),
+		Identifier: jsonFieldString(fields["SYSLOG_IDENTIFIER"]),
+		BootID:     jsonFieldString(fields["_BOOT_ID"]),
+		Realtime:   time.UnixMicro(realtimeMicros),
+		Monotonic:  monotonic,
+	}, nil
+}
+
+// jsonFieldString decodes a journal JSON field which is a string, or an array of bytes if the value is not valid UTF-8
+func jsonFieldString(raw json.RawMessage) string {
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str
+	}
+	var byteValues []byte
+	var ints []int
+	if err := json.Unmarshal(raw, &ints); err == nil {
+		for _, i := range ints {
+			byteValues = append(byteValues, byte(i))
+		}
+	}
+	return string(byteValues)
+}
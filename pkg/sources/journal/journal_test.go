@@ -0,0 +1,121 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+// stubReader returns fixed entries and counts its reads
+type stubReader struct {
+	entries []Entry
+	err     error
+	reads   int
+}
+
+func (r *stubReader) Entries(_ context.Context) ([]Entry, error) {
+	r.reads++
+	return r.entries, r.err
+}
+
+func (r *stubReader) String() string {
+	return "stub"
+}
+
+func testEntries() []Entry {
+	start := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	return []Entry{
+		{Message: "Starting kubelet", Identifier: "systemd", BootID: bootB, Realtime: start, Monotonic: lo.ToPtr(time.Second)},
+		{Message: "Starting kubelet", Identifier: "systemd", BootID: bootA, Realtime: start.Add(time.Hour), Monotonic: lo.ToPtr(time.Second)},
+		{Message: "Started kubelet", Identifier: "systemd", BootID: bootA, Realtime: start.Add(time.Hour + time.Second), Monotonic: lo.ToPtr(2 * time.Second)},
+	}
+}
+
+func TestFileReaderFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		fallback       Reader
+		wantEntries    int
+		wantErr        bool
+		wantAccessPath string
+	}{
+		{name: "falls back when the journal files can not be read", fallback: &stubReader{entries: testEntries()}, wantEntries: 3, wantAccessPath: "journalctl"},
+		{name: "fallback error", fallback: &stubReader{err: errors.New("journalctl failed")}, wantErr: true, wantAccessPath: "journalctl"},
+		{name: "no fallback", wantErr: true, wantAccessPath: sources.AccessPathDirect},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// the root has no journal directories
+			reader := &FileReader{Root: t.TempDir(), Fallback: tc.fallback}
+			source := New(Name, reader)
+			entries, err := source.Entries()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Entries() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(entries) != tc.wantEntries {
+				t.Errorf("Entries() = %d entries, want %d", len(entries), tc.wantEntries)
+			}
+			if got := source.AccessPath(); got != tc.wantAccessPath {
+				t.Errorf("AccessPath() = %s, want %s", got, tc.wantAccessPath)
+			}
+			if tc.fallback != nil && source.String() != tc.fallback.String() {
+				t.Errorf("String() = %s, want the fallback %s", source.String(), tc.fallback.String())
+			}
+		})
+	}
+}
+
+func TestSourceFindByRegexLines(t *testing.T) {
+	reader := &stubReader{entries: testEntries()}
+	source := New(Name, reader)
+	find := func(re string) []string {
+		lines, _ := source.FindByRegex(regexp.MustCompile(re))(source, nil)
+		return lines
+	}
+	for _, tc := range []struct {
+		name      string
+		action    func()
+		re        string
+		wantLines int
+		wantReads int
+	}{
+		{name: "first search reads the journal", re: "Starting kubelet", wantLines: 2, wantReads: 1},
+		{name: "later searches reuse the rendered lines", re: "Started kubelet", wantLines: 1, wantReads: 1},
+		{name: "the boot restricts the lines", action: func() { source.SetBoot(bootB) }, re: "kubelet", wantLines: 1, wantReads: 1},
+		{name: "clearing the cache reads the journal again", action: func() { source.SetBoot(""); source.ClearCache() }, re: "kubelet", wantLines: 3, wantReads: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.action != nil {
+				tc.action()
+			}
+			if got := find(tc.re); len(got) != tc.wantLines {
+				t.Errorf("FindByRegex() = %v, want %d lines", got, tc.wantLines)
+			}
+			if reader.reads != tc.wantReads {
+				t.Errorf("reads = %d, want %d", reader.reads, tc.wantReads)
+			}
+			if _, scanned := source.LastSearch(); scanned == 0 {
+				t.Error("LastSearch() scanned no bytes")
+			}
+		})
+	}
+}
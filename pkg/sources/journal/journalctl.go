@@ -0,0 +1,147 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package journal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	// DefaultJournalctlPath is the journalctl binary looked up in $PATH
+	DefaultJournalctlPath = "journalctl"
+)
+
+// JournalctlReader reads journal entries by executing `journalctl -o json --boot` and parsing the MESSAGE and __REALTIME_TIMESTAMP fields.
+// The journal files are resolved under Root so that a host journal mounted into a container can be read.
+type JournalctlReader struct {
+	Path      string
+	Root      string
+	Namespace string
+	// AllBoots reads the entries of all boots instead of only the current boot
+	AllBoots bool
+	// BootWindow drops the entries logged later than this after their boot started, DefaultBootWindow is used if 0
+	BootWindow time.Duration
+}
+
+// NewLocal instantiates a new journal source that reads the journal files under root natively, falling back to journalctl for journal files it is not able to parse
+func NewLocal(root string, namespace string) *Source {
+	return New(Name, &FileReader{
+		Root:      root,
+		Namespace: namespace,
+		Fallback: &JournalctlReader{
+			Path:      DefaultJournalctlPath,
+			Root:      root,
+			Namespace: namespace,
+		},
+	})
+}
+
+// String is a human readable description of the journalctl command
+func (j *JournalctlReader) String() string {
+	return strings.Join(append([]string{j.Path}, j.args()...), " ")
+}
+
+// SetAllBoots sets if the entries of all boots are read instead of only the current boot
+func (j *JournalctlReader) SetAllBoots(allBoots bool) {
+	j.AllBoots = allBoots
+}
+
+// args builds the journalctl arguments, passing the resolved journal files explicitly if any are found under Root
+func (j *JournalctlReader) args() []string {
+	args := []string{"--output=json", "--no-pager", "--quiet"}
+	if !j.AllBoots {
+		args = append(args, "--boot")
+	}
+	if files, err := j.files(); err == nil {
+		for _, file := range files {
+			args = append(args, fmt.Sprintf("--file=%s", file))
+		}
+		return args
+	}
+	if j.Namespace != "" {
+		args = append(args, fmt.Sprintf("--namespace=%s", j.Namespace))
+	}
+	return args
+}
+
+// files returns the journal files under Root that can be read, skipping files that a non-root process is not allowed to read
+func (j *JournalctlReader) files() ([]string, error) {
+	dirs, err := Directories(j.Root, j.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	files, err := Files(dirs)
+	if err != nil {
+		return nil, err
+	}
+	readable := lo.Filter(files, func(file string, _ int) bool {
+		f, err := os.Open(file)
+		if err != nil {
+			return false
+		}
+		_ = f.Close()
+		return true
+	})
+	if len(readable) == 0 {
+		if denial := sources.ExplainDenial(files[0]); denial != nil {
+			return nil, fmt.Errorf("unable to read journal files in %v: %w, %s", dirs, fs.ErrPermission, denial.Hint())
+		}
+		return nil, fmt.Errorf("unable to read journal files in %v: %w", dirs, fs.ErrPermission)
+	}
+	return readable, nil
+}
+
+// Entries executes journalctl and parses the JSON output
+func (j *JournalctlReader) Entries(ctx context.Context) ([]Entry, error) {
+	// journalctl silently returns no entries for journal files it is not allowed to read
+	if _, err := j.files(); errors.Is(err, fs.ErrPermission) {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, j.Path, j.args()...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read journalctl output: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to execute journalctl: %w", err)
+	}
+	entries, stopped, parseErr := parseJSONEntries(stdout, bootWindow(j.BootWindow), !j.AllBoots)
+	if parseErr != nil || stopped {
+		// stop journalctl so it does not block writing output that will never be read
+		_ = cmd.Process.Kill()
+	}
+	if err := cmd.Wait(); err != nil && parseErr == nil && !stopped {
+		return nil, fmt.Errorf("journalctl failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if parseErr != nil {
+		return nil, fmt.Errorf("unable to parse journalctl output: %w", parseErr)
+	}
+	return entries, nil
+}
@@ -19,6 +19,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/samber/lo"
@@ -32,6 +35,18 @@ import (
 
 var (
 	Name = "K8s"
+	// UninitializedTaint is the taint kubelets with an external cloud provider register with until the cloud controller manager initializes the node
+	UninitializedTaint = "node.cloudprovider.kubernetes.io/uninitialized"
+	// TaintRemovedReason is the reason of the synthetic node condition that records when a taint was observed to be removed
+	TaintRemovedReason = "TaintRemoved"
+	// KubeletManager is the field manager of the kubelet, fields it owns were not set by a cloud controller manager
+	KubeletManager = "kubelet"
+	// MirrorPodAnnotation is set on the API mirror pods of static pods
+	MirrorPodAnnotation = "kubernetes.io/config.mirror"
+	// NodeClaimsPath is the API path of the Karpenter NodeClaims
+	NodeClaimsPath = "/apis/karpenter.sh/v1/nodeclaims"
+	// KarpenterGroup is the API group of the Karpenter NodeClaims, and of the Machines before Karpenter v0.32
+	KarpenterGroup = "karpenter.sh"
 )
 
 // Source is the K8s API http source
@@ -39,14 +54,52 @@ type Source struct {
 	clientset    *kubernetes.Clientset
 	nodeName     string
 	podNamespace string
+	// taintsSeen and taintsRemoved track taints across measurement passes since the node does not record when a taint is removed
+	taintsSeen    map[string]bool
+	taintsRemoved map[string]time.Time
+	// namespaces restricts the namespaces of the pods that are measured across namespaces, i.e. the workload pods
+	namespaces NamespaceFilter
+}
+
+// NamespaceFilter restricts the namespaces whose pods are measured on shared clusters, where measuring arbitrary tenant pods is a privacy and a noise problem.
+// The patterns are path.Match globs, i.e. team-*. A namespace is measured if it matches an allowed pattern, or no allowed patterns are set, and no denied pattern.
+type NamespaceFilter struct {
+	Allow []string
+	Deny  []string
+}
+
+// Validate checks that the patterns are valid globs
+func (f NamespaceFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Allow...), f.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern \"%s\": %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Measured returns true if the pods of the namespace are measured
+func (f NamespaceFilter) Measured(namespace string) bool {
+	matches := func(pattern string) bool {
+		matched, _ := path.Match(pattern, namespace)
+		return matched
+	}
+	return (len(f.Allow) == 0 || lo.SomeBy(f.Allow, matches)) && !lo.SomeBy(f.Deny, matches)
+}
+
+// SetNamespaceFilter restricts the namespaces of the pods that are measured across namespaces
+func (s *Source) SetNamespaceFilter(filter NamespaceFilter) {
+	s.namespaces = filter
 }
 
 // New instantiates a new instance of the K8s API source
 func New(clientset *kubernetes.Clientset, nodeName string, podNamespace string) *Source {
 	return &Source{
-		clientset:    clientset,
-		nodeName:     nodeName,
-		podNamespace: podNamespace,
+		clientset:     clientset,
+		nodeName:      nodeName,
+		podNamespace:  podNamespace,
+		taintsSeen:    map[string]bool{},
+		taintsRemoved: map[string]time.Time{},
 	}
 }
 
@@ -82,12 +135,340 @@ func (s *Source) FindPodCreationTime() sources.FindFunc {
 	}
 }
 
+// FindNodeCondition retrieves the last transition time of the node condition if it has the status, i.e. NetworkUnavailable=False
+func (s *Source) FindNodeCondition(conditionType corev1.NodeConditionType, status corev1.ConditionStatus) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		node, err := s.clientset.CoreV1().Nodes().Get(context.Background(), s.nodeName, v1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		condition, ok := lo.Find(node.Status.Conditions, func(c corev1.NodeCondition) bool { return c.Type == conditionType })
+		if !ok {
+			return nil, fmt.Errorf("node %s does not have the %s condition", s.nodeName, conditionType)
+		}
+		if condition.Status != status {
+			return nil, fmt.Errorf("node %s condition %s is %s", s.nodeName, conditionType, condition.Status)
+		}
+		conditionBytes, err := json.Marshal(condition)
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(conditionBytes)}, nil
+	}
+}
+
+// FindTaintRemoval retrieves the time the taint was observed to be removed from the node.
+// The node does not record taint removals, so the time is only as precise as the measurement retry delay and
+// it is not found if the taint was removed before the first measurement pass.
+func (s *Source) FindTaintRemoval(taintKey string) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		if _, ok := s.taintsRemoved[taintKey]; !ok {
+			node, err := s.clientset.CoreV1().Nodes().Get(context.Background(), s.nodeName, v1.GetOptions{})
+			if err != nil {
+				return nil, err
+			}
+			if lo.ContainsBy(node.Spec.Taints, func(t corev1.Taint) bool { return t.Key == taintKey }) {
+				s.taintsSeen[taintKey] = true
+				return nil, fmt.Errorf("node %s still has the %s taint", s.nodeName, taintKey)
+			}
+			if !s.taintsSeen[taintKey] {
+				return nil, fmt.Errorf("node %s did not have the %s taint when it was first observed", s.nodeName, taintKey)
+			}
+			s.taintsRemoved[taintKey] = time.Now()
+		}
+		conditionBytes, err := json.Marshal(corev1.NodeCondition{
+			Type:               corev1.NodeConditionType(taintKey),
+			Status:             corev1.ConditionFalse,
+			Reason:             TaintRemovedReason,
+			LastTransitionTime: v1.NewTime(s.taintsRemoved[taintKey]),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(conditionBytes)}, nil
+	}
+}
+
+// FindFieldManagerUpdate retrieves the time the node field at fieldPath (i.e. "spec", "providerID") was last updated by a field manager other than the kubelet.
+// The cloud controller manager sets the provider ID and removes the uninitialized taint in a single update when it initializes the node,
+// and populates the addresses with a separate update of the status subresource.
+func (s *Source) FindFieldManagerUpdate(fieldPath ...string) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		node, err := s.clientset.CoreV1().Nodes().Get(context.Background(), s.nodeName, v1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range node.ManagedFields {
+			if entry.Manager == KubeletManager || entry.Time == nil || entry.FieldsV1 == nil || !ownsField(entry.FieldsV1.Raw, fieldPath) {
+				continue
+			}
+			conditionBytes, err := json.Marshal(corev1.NodeCondition{
+				Type:               corev1.NodeConditionType(strings.Join(fieldPath, ".")),
+				Status:             corev1.ConditionTrue,
+				Reason:             entry.Manager,
+				LastTransitionTime: *entry.Time,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return []string{string(conditionBytes)}, nil
+		}
+		return nil, fmt.Errorf("node %s field %s is not managed by a cloud controller manager", s.nodeName, strings.Join(fieldPath, "."))
+	}
+}
+
+// ownsField checks if the managed fields (FieldsV1 format, i.e. {"f:spec":{"f:providerID":{}}}) contain the field path
+func ownsField(fieldsV1 []byte, fieldPath []string) bool {
+	fields := fieldsV1
+	for _, field := range fieldPath {
+		var set map[string]json.RawMessage
+		if err := json.Unmarshal(fields, &set); err != nil {
+			return false
+		}
+		next, ok := set[fmt.Sprintf("f:%s", field)]
+		if !ok {
+			return false
+		}
+		fields = next
+	}
+	return true
+}
+
+// FindFirstWorkloadPodRunning retrieves the time the first workload pod on the node, in any namespace, had all of its containers running.
+// DaemonSet and static (mirror) pods are not workloads since they run on every node regardless of whether the node is doing useful work.
+// The results of all running workload pods are returned so the event's match selector picks the first.
+func (s *Source) FindFirstWorkloadPodRunning() sources.FindFunc {
+	return s.FindWorkloadPodsRunning()
+}
+
+// FindWorkloadPodsRunning retrieves the times the workload pods on the node, in any namespace, had all of their containers running
+func (s *Source) FindWorkloadPodsRunning() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		pods, err := s.clientset.CoreV1().Pods("").List(context.Background(), v1.ListOptions{FieldSelector: fmt.Sprintf("spec.nodeName=%s", s.nodeName)})
+		if err != nil {
+			return nil, err
+		}
+		var running []string
+		for _, pod := range pods.Items {
+			if _, ok := pod.Annotations[MirrorPodAnnotation]; ok {
+				continue
+			}
+			if !s.namespaces.Measured(pod.Namespace) {
+				continue
+			}
+			if lo.ContainsBy(pod.OwnerReferences, func(o v1.OwnerReference) bool { return o.Kind == "DaemonSet" }) {
+				continue
+			}
+			runningAt, ok := podRunningTime(pod)
+			if !ok {
+				continue
+			}
+			conditionBytes, err := json.Marshal(corev1.NodeCondition{
+				Type:               corev1.NodeConditionType(corev1.PodRunning),
+				Status:             corev1.ConditionTrue,
+				Reason:             fmt.Sprintf("%s/%s", pod.Namespace, pod.Name),
+				LastTransitionTime: v1.NewTime(runningAt),
+			})
+			if err != nil {
+				return nil, err
+			}
+			running = append(running, string(conditionBytes))
+		}
+		if len(running) == 0 {
+			return nil, fmt.Errorf("no workload pods are running on node %s", s.nodeName)
+		}
+		return running, nil
+	}
+}
+
+// FindNodeEvent retrieves the times the K8s event with the reason (i.e. NodeNotSchedulable when the node is cordoned for a drain) was recorded for the node
+func (s *Source) FindNodeEvent(reason string) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		events, err := s.clientset.CoreV1().Events("").List(context.Background(), v1.ListOptions{
+			FieldSelector: fmt.Sprintf("involvedObject.kind=Node,involvedObject.name=%s,reason=%s", s.nodeName, reason),
+		})
+		if err != nil {
+			return nil, err
+		}
+		var recorded []string
+		for _, event := range events.Items {
+			eventTime := lo.Ternary(event.EventTime.IsZero(), event.LastTimestamp.Time, event.EventTime.Time)
+			if eventTime.IsZero() {
+				eventTime = event.FirstTimestamp.Time
+			}
+			conditionBytes, err := json.Marshal(corev1.NodeCondition{
+				Type:               corev1.NodeConditionType(reason),
+				Status:             corev1.ConditionTrue,
+				Reason:             reason,
+				Message:            event.Message,
+				LastTransitionTime: v1.NewTime(eventTime),
+			})
+			if err != nil {
+				return nil, err
+			}
+			recorded = append(recorded, string(conditionBytes))
+		}
+		if len(recorded) == 0 {
+			return nil, fmt.Errorf("no %s events were recorded for node %s", reason, s.nodeName)
+		}
+		return recorded, nil
+	}
+}
+
+// FindPodEvent retrieves the times the K8s event with the reason (i.e. SuccessfulAttachVolume) was recorded for the pods on the node in the pod namespace,
+// the reason of the results is the pod
+func (s *Source) FindPodEvent(reason string) sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		ctx := context.Background()
+		pods, err := s.clientset.CoreV1().Pods(s.podNamespace).List(ctx, v1.ListOptions{FieldSelector: fmt.Sprintf("spec.nodeName=%s", s.nodeName)})
+		if err != nil {
+			return nil, err
+		}
+		podNames := lo.SliceToMap(pods.Items, func(pod corev1.Pod) (string, bool) { return pod.Name, true })
+		events, err := s.clientset.CoreV1().Events(s.podNamespace).List(ctx, v1.ListOptions{
+			FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,reason=%s", reason),
+		})
+		if err != nil {
+			return nil, err
+		}
+		var recorded []string
+		for _, event := range events.Items {
+			if !podNames[event.InvolvedObject.Name] {
+				continue
+			}
+			eventTime := lo.Ternary(event.EventTime.IsZero(), event.LastTimestamp.Time, event.EventTime.Time)
+			if eventTime.IsZero() {
+				eventTime = event.FirstTimestamp.Time
+			}
+			conditionBytes, err := json.Marshal(corev1.NodeCondition{
+				Type:               corev1.NodeConditionType(reason),
+				Status:             corev1.ConditionTrue,
+				Reason:             fmt.Sprintf("%s/%s", event.InvolvedObject.Namespace, event.InvolvedObject.Name),
+				Message:            event.Message,
+				LastTransitionTime: v1.NewTime(eventTime),
+			})
+			if err != nil {
+				return nil, err
+			}
+			recorded = append(recorded, string(conditionBytes))
+		}
+		if len(recorded) == 0 {
+			return nil, fmt.Errorf("no %s events were recorded for pods in namespace %s on node %s", reason, s.podNamespace, s.nodeName)
+		}
+		return recorded, nil
+	}
+}
+
+// Annotation retrieves an annotation of the node, or of the Karpenter NodeClaim of the node if the node does not have it
+func (s *Source) Annotation(ctx context.Context, annotation string) (string, error) {
+	node, err := s.clientset.CoreV1().Nodes().Get(ctx, s.nodeName, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if value, ok := node.Annotations[annotation]; ok {
+		return value, nil
+	}
+	nodeClaimsBytes, err := s.clientset.Discovery().RESTClient().Get().AbsPath(NodeClaimsPath).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("node %s does not have the annotation %s and its NodeClaim is not available: %w", s.nodeName, annotation, err)
+	}
+	var nodeClaims struct {
+		Items []struct {
+			Metadata v1.ObjectMeta `json:"metadata"`
+			Status   struct {
+				NodeName string `json:"nodeName"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(nodeClaimsBytes, &nodeClaims); err != nil {
+		return "", fmt.Errorf("unable to parse NodeClaims: %w", err)
+	}
+	for _, nodeClaim := range nodeClaims.Items {
+		if value, ok := nodeClaim.Metadata.Annotations[annotation]; ok && nodeClaim.Status.NodeName == s.nodeName {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("node %s and its NodeClaim do not have the annotation %s", s.nodeName, annotation)
+}
+
+// FindNodeClaimCreation retrieves the creation time of the Karpenter NodeClaim, or Machine before Karpenter v0.32, that owns the node.
+// Karpenter creates the NodeClaim when it decides to launch the node, so it is the baseline of the provisioner decision to pod ready latency.
+func (s *Source) FindNodeClaimCreation() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		ctx := context.Background()
+		node, err := s.clientset.CoreV1().Nodes().Get(ctx, s.nodeName, v1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		owner, ok := lo.Find(node.OwnerReferences, func(o v1.OwnerReference) bool {
+			return strings.HasPrefix(o.APIVersion, KarpenterGroup+"/")
+		})
+		if !ok {
+			return nil, fmt.Errorf("node %s is not owned by a Karpenter NodeClaim or Machine", s.nodeName)
+		}
+		ownerBytes, err := s.clientset.Discovery().RESTClient().Get().
+			AbsPath(fmt.Sprintf("/apis/%s/%ss/%s", owner.APIVersion, strings.ToLower(owner.Kind), owner.Name)).DoRaw(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get %s %s of node %s: %w", owner.Kind, owner.Name, s.nodeName, err)
+		}
+		var ownerObject struct {
+			Metadata v1.ObjectMeta `json:"metadata"`
+		}
+		if err := json.Unmarshal(ownerBytes, &ownerObject); err != nil {
+			return nil, fmt.Errorf("unable to parse %s %s: %w", owner.Kind, owner.Name, err)
+		}
+		conditionBytes, err := json.Marshal(corev1.NodeCondition{
+			Type:               corev1.NodeConditionType(owner.Kind + "Created"),
+			Status:             corev1.ConditionTrue,
+			Reason:             fmt.Sprintf("%s/%s", owner.Kind, owner.Name),
+			LastTransitionTime: ownerObject.Metadata.CreationTimestamp,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(conditionBytes)}, nil
+	}
+}
+
+// podRunningTime returns the time the last container of a pod started running, if all of its containers are running
+func podRunningTime(pod corev1.Pod) (time.Time, bool) {
+	if pod.Status.Phase != corev1.PodRunning || len(pod.Status.ContainerStatuses) == 0 {
+		return time.Time{}, false
+	}
+	var runningAt time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running == nil {
+			return time.Time{}, false
+		}
+		if status.State.Running.StartedAt.After(runningAt) {
+			runningAt = status.State.Running.StartedAt.Time
+		}
+	}
+	return runningAt, true
+}
+
+// CommentReason is a helper func that returns a func that can be used as a CommentFunc in an Event
+// The func will use the reason of a node condition result as the comment, i.e. the pod or field manager
+func CommentReason() func(matchedLine string) string {
+	return func(matchedLine string) string {
+		var condition corev1.NodeCondition
+		if err := json.Unmarshal([]byte(matchedLine), &condition); err != nil {
+			return ""
+		}
+		return condition.Reason
+	}
+}
+
 // ParseTimeFor parses an event and returns the time
 func (s *Source) ParseTimeFor(event []byte) (time.Time, error) {
 	var pod *corev1.Pod
 	if err := json.Unmarshal(event, &pod); err == nil && !pod.CreationTimestamp.IsZero() {
 		return pod.CreationTimestamp.Time, nil
 	}
+	var condition *corev1.NodeCondition
+	if err := json.Unmarshal(event, &condition); err == nil && !condition.LastTransitionTime.IsZero() {
+		return condition.LastTransitionTime.Time, nil
+	}
 	return time.Time{}, fmt.Errorf("unable to parse event")
 }
 
@@ -111,5 +492,8 @@ func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
 			Err:       err,
 		})
 	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
 	return sources.SelectMatches(results, event.MatchSelector), nil
 }
@@ -0,0 +1,231 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kubelet is a latency timing source for the local kubelet API and static pod manifests
+// which allows measuring pod milestones when the kubelet runs standalone without an API server
+package kubelet
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
+)
+
+var (
+	Name = "Kubelet"
+	// DefaultEndpoint is the kubelet read-only port, an https endpoint like the authenticated port (https://localhost:10250) is read with the
+	// pod's service account token, which needs get on nodes/proxy
+	DefaultEndpoint = "http://localhost:10255"
+	// DefaultManifestDir is the kubelet staticPodPath on most distros
+	DefaultManifestDir = "/etc/kubernetes/manifests"
+	// ServingCertPath is the serving certificate the kubelet writes once its CSR is approved when serving certificate bootstrapping is enabled
+	ServingCertPath = "/var/lib/kubelet/pki/kubelet-server-current.pem"
+	// TokenPath is the service account token sent to the authenticated kubelet port
+	TokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+// Source is the local kubelet http source
+type Source struct {
+	endpoint     string
+	manifestDir  string
+	podNamespace string
+	httpClient   *http.Client
+}
+
+// New instantiates a new instance of the kubelet source
+func New(endpoint string, manifestDir string, podNamespace string) *Source {
+	return &Source{
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		manifestDir:  manifestDir,
+		podNamespace: podNamespace,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				// the kubelet serving certificate is self-signed unless serving certificate bootstrapping is enabled
+				//nolint:gosec
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// ClearCache is a noop for the kubelet Source since it is an http source, not a log file
+func (s Source) ClearCache() {}
+
+// String is a human readable string of the source
+func (s Source) String() string {
+	return fmt.Sprintf("%s (%s)", Name, s.endpoint)
+}
+
+// Name is the name of the source
+func (s Source) Name() string {
+	return Name
+}
+
+// FindPodCreationTime retrieves the creation time of the pods in the pod namespace from the kubelet /pods endpoint
+func (s *Source) FindPodCreationTime() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		pods, err := s.Pods(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		for _, pod := range pods {
+			if !pod.CreationTimestamp.IsZero() {
+				lines = append(lines, line(pod.CreationTimestamp.Time, "pod %s/%s created", pod.Namespace, pod.Name))
+			}
+		}
+		return lines, nil
+	}
+}
+
+// FindPodReadyTime retrieves the time the pods in the pod namespace became Ready from the kubelet /pods endpoint
+func (s *Source) FindPodReadyTime() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		pods, err := s.Pods(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		var lines []string
+		for _, pod := range pods {
+			for _, cond := range pod.Status.Conditions {
+				if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+					lines = append(lines, line(cond.LastTransitionTime.Time, "pod %s/%s ready", pod.Namespace, pod.Name))
+				}
+			}
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no ready pods in namespace \"%s\" on %s", s.podNamespace, s.String())
+		}
+		return lines, nil
+	}
+}
+
+// FindStaticPodManifests retrieves the modification time of the static pod manifests which are written by the node bootstrap before the kubelet mirrors them
+func (s *Source) FindStaticPodManifests() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		entries, err := os.ReadDir(s.manifestDir)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read static pod manifests: %w", err)
+		}
+		var lines []string
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			lines = append(lines, line(info.ModTime(), "static pod manifest %s", filepath.Join(s.manifestDir, entry.Name())))
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("no static pod manifests in %s", s.manifestDir)
+		}
+		return lines, nil
+	}
+}
+
+// FindServingCertificate retrieves the modification time of the kubelet serving certificate. Until it is written, the API server can not
+// reach the kubelet so logs, exec and metrics-server scrapes of the node fail even though it is Ready.
+func (s *Source) FindServingCertificate() sources.FindFunc {
+	return func(_ sources.Source, _ []byte) ([]string, error) {
+		// the current certificate is a symlink to the latest issued certificate which is the one stat follows
+		info, err := os.Stat(ServingCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the kubelet serving certificate: %w", err)
+		}
+		return []string{line(info.ModTime(), "serving certificate %s", ServingCertPath)}, nil
+	}
+}
+
+// Pods lists the pods in the pod namespace known to the kubelet, including static pods
+func (s *Source) Pods(ctx context.Context) ([]corev1.Pod, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/pods", s.endpoint), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create kubelet request: %w", err)
+	}
+	if strings.HasPrefix(s.endpoint, "https://") {
+		if token, err := os.ReadFile(TokenPath); err == nil {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", strings.TrimSpace(string(token))))
+		}
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query kubelet %s: %w", s.endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kubelet %s returned status %s", s.endpoint, resp.Status)
+	}
+	var podList corev1.PodList
+	if err := json.NewDecoder(resp.Body).Decode(&podList); err != nil {
+		return nil, fmt.Errorf("unable to parse kubelet pods: %w", err)
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Namespace == s.podNamespace {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// line formats a kubelet source result prefixed with the RFC3339 timestamp
+func line(ts time.Time, format string, args ...interface{}) string {
+	return fmt.Sprintf("%s %s", ts.UTC().Format(time.RFC3339Nano), fmt.Sprintf(format, args...))
+}
+
+// ParseTimestamp parses the timestamp prefix of a kubelet source result
+func ParseTimestamp(line string) (time.Time, error) {
+	rawTS, _, _ := strings.Cut(line, " ")
+	return time.Parse(time.RFC3339Nano, rawTS)
+}
+
+// Find will use the Event's FindFunc and CommentFunc to search the source and return the results based on the Event's matcher
+func (s *Source) Find(event *sources.Event) ([]sources.FindResult, error) {
+	lines, err := event.FindFn(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []sources.FindResult
+	for _, l := range lines {
+		ts, err := ParseTimestamp(l)
+		comment := ""
+		if event.CommentFn != nil {
+			comment = event.CommentFn(l)
+		}
+		results = append(results, sources.FindResult{
+			Line:      l,
+			Timestamp: ts,
+			Comment:   comment,
+			Err:       err,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Timestamp.UnixMicro() < results[j].Timestamp.UnixMicro()
+	})
+	return sources.SelectMatches(results, event.MatchSelector), nil
+}
@@ -18,6 +18,7 @@ package messages
 import (
 	"regexp"
 	"sort"
+	"time"
 
 	"github.com/awslabs/node-latency-for-k8s/pkg/sources"
 )
@@ -38,10 +39,12 @@ type Source struct {
 func New(path string) *Source {
 	return &Source{
 		logReader: &sources.LogReader{
+			Name:            Name,
 			Path:            path,
 			Glob:            true,
 			TimestampRegex:  TimestampFormat,
 			TimestampLayout: TimestampLayout,
+			Sorted:          true,
 		},
 	}
 }
@@ -51,11 +54,41 @@ func (s Source) ClearCache() {
 	s.logReader.ClearCache()
 }
 
+// Checksum returns the sha256 checksum and byte range of the searched contents of the log file
+func (s Source) Checksum() (*sources.ScanChecksum, error) {
+	return s.logReader.Checksum()
+}
+
+// LastSearch returns the log file and the number of bytes searched by the last Find
+func (s Source) LastSearch() (string, int) {
+	return s.logReader.LastSearch()
+}
+
+// SetSearchWindow restricts the log search to lines between start and end
+func (s Source) SetSearchWindow(start time.Time, end time.Time) {
+	s.logReader.SetSearchWindow(start, end)
+}
+
+// SetLocation sets the location of the syslog timestamps which do not include a zone
+func (s Source) SetLocation(loc *time.Location) {
+	s.logReader.SetLocation(loc)
+}
+
 // String is a human readable string of the source, usually the log file path
 func (s Source) String() string {
 	return s.logReader.Path
 }
 
+// HostPaths is the log file the source reads
+func (s Source) HostPaths() []string {
+	return []string{s.logReader.Path}
+}
+
+// AccessPath is how the log file was last read, directly or through the helper socket
+func (s Source) AccessPath() string {
+	return s.logReader.AccessPath()
+}
+
 // Name is the name of the source
 func (s Source) Name() string {
 	return Name
@@ -90,6 +123,7 @@ func (s Source) Find(event *sources.Event) ([]sources.FindResult, error) {
 			Timestamp: ts,
 			Err:       err,
 			Comment:   comment,
+			Truncated: s.logReader.Truncated(),
 		})
 	}
 	sort.Slice(results, func(i, j int) bool {
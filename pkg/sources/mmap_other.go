@@ -0,0 +1,32 @@
+//go:build !linux && !darwin
+
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile is unsupported on this platform so files are always read into memory
+func mmapFile(_ *os.File, _ int) ([]byte, error) {
+	return nil, errors.New("mmap is not supported on this platform")
+}
+
+// munmap is a noop on this platform
+func munmap(_ []byte) error {
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultOffsetStore is the process-wide offset store used by LogReaders that do not specify their own
+// Incremental reads are disabled when it is nil
+var DefaultOffsetStore *OffsetStore
+
+// OffsetSaveInterval is the minimum time between writes of the state file for offsets that only grew,
+// a new or rotated file is always written immediately
+var OffsetSaveInterval = time.Minute
+
+// ReadOffset is the position up to which a file has been read
+type ReadOffset struct {
+	FileID uint64 `json:"fileID"`
+	Offset int64  `json:"offset"`
+}
+
+// OffsetStore tracks per-file read offsets and persists them to a state file so that
+// files rotated or truncated while the process was not running are detected after a restart
+type OffsetStore struct {
+	mu      sync.Mutex
+	path    string
+	offsets map[string]ReadOffset
+	saved   time.Time
+	dirty   bool
+}
+
+// NewOffsetStore creates an OffsetStore persisted at path and loads any previously saved offsets
+func NewOffsetStore(path string) (*OffsetStore, error) {
+	store := &OffsetStore{
+		path:    path,
+		offsets: map[string]ReadOffset{},
+	}
+	stateBytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read offset state file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(stateBytes, &store.offsets); err != nil {
+		return nil, fmt.Errorf("unable to parse offset state file %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Get returns the read offset for a file path
+func (o *OffsetStore) Get(path string) (ReadOffset, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	offset, ok := o.offsets[path]
+	return offset, ok
+}
+
+// Set updates the read offset for a file path. The offsets are persisted to the state file when the file is new or was
+// rotated, and otherwise at most once per OffsetSaveInterval, so a growing log does not rewrite the state file on every read.
+func (o *OffsetStore) Set(path string, offset ReadOffset) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	current, ok := o.offsets[path]
+	if ok && current == offset {
+		return nil
+	}
+	o.offsets[path] = offset
+	o.dirty = true
+	if ok && current.FileID == offset.FileID && time.Since(o.saved) < OffsetSaveInterval {
+		return nil
+	}
+	return o.save()
+}
+
+// Flush persists offsets that were not written yet because of the OffsetSaveInterval
+func (o *OffsetStore) Flush() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.dirty {
+		return nil
+	}
+	return o.save()
+}
+
+// save writes the offsets to a temp file and renames it over the state file so a crash never leaves a partial state file, the lock must be held
+func (o *OffsetStore) save() error {
+	stateBytes, err := json.Marshal(o.offsets)
+	if err != nil {
+		return fmt.Errorf("unable to marshal read offsets: %w", err)
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(o.path), filepath.Base(o.path))
+	if err != nil {
+		return fmt.Errorf("unable to create offset state file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(stateBytes); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("unable to write offset state file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to write offset state file: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), o.path); err != nil {
+		return fmt.Errorf("unable to replace offset state file %s: %w", o.path, err)
+	}
+	o.saved, o.dirty = time.Now(), false
+	return nil
+}
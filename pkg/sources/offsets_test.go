@@ -0,0 +1,152 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOffsetStore(t *testing.T) {
+	type set struct {
+		path   string
+		offset ReadOffset
+		flush  bool
+	}
+	for _, tc := range []struct {
+		name          string
+		saveInterval  time.Duration
+		sets          []set
+		wantOffsets   map[string]ReadOffset
+		wantPersisted map[string]ReadOffset
+	}{
+		{
+			name:          "a new file is persisted immediately",
+			saveInterval:  time.Hour,
+			sets:          []set{{path: "messages", offset: ReadOffset{FileID: 1, Offset: 10}}},
+			wantOffsets:   map[string]ReadOffset{"messages": {FileID: 1, Offset: 10}},
+			wantPersisted: map[string]ReadOffset{"messages": {FileID: 1, Offset: 10}},
+		},
+		{
+			name:         "growth is not persisted within the save interval",
+			saveInterval: time.Hour,
+			sets: []set{
+				{path: "messages", offset: ReadOffset{FileID: 1, Offset: 10}},
+				{path: "messages", offset: ReadOffset{FileID: 1, Offset: 20}},
+			},
+			wantOffsets:   map[string]ReadOffset{"messages": {FileID: 1, Offset: 20}},
+			wantPersisted: map[string]ReadOffset{"messages": {FileID: 1, Offset: 10}},
+		},
+		{
+			name:         "flush persists the growth",
+			saveInterval: time.Hour,
+			sets: []set{
+				{path: "messages", offset: ReadOffset{FileID: 1, Offset: 10}},
+				{path: "messages", offset: ReadOffset{FileID: 1, Offset: 20}},
+				{flush: true},
+			},
+			wantOffsets:   map[string]ReadOffset{"messages": {FileID: 1, Offset: 20}},
+			wantPersisted: map[string]ReadOffset{"messages": {FileID: 1, Offset: 20}},
+		},
+		{
+			name: "growth is persisted after the save interval",
+			sets: []set{
+				{path: "messages", offset: ReadOffset{FileID: 1, Offset: 10}},
+				{path: "messages", offset: ReadOffset{FileID: 1, Offset: 20}},
+			},
+			wantOffsets:   map[string]ReadOffset{"messages": {FileID: 1, Offset: 20}},
+			wantPersisted: map[string]ReadOffset{"messages": {FileID: 1, Offset: 20}},
+		},
+		{
+			name:         "a rotated file is persisted immediately",
+			saveInterval: time.Hour,
+			sets: []set{
+				{path: "messages", offset: ReadOffset{FileID: 1, Offset: 10}},
+				{path: "messages", offset: ReadOffset{FileID: 2, Offset: 5}},
+			},
+			wantOffsets:   map[string]ReadOffset{"messages": {FileID: 2, Offset: 5}},
+			wantPersisted: map[string]ReadOffset{"messages": {FileID: 2, Offset: 5}},
+		},
+		{
+			name:         "each file is tracked",
+			saveInterval: time.Hour,
+			sets: []set{
+				{path: "messages", offset: ReadOffset{FileID: 1, Offset: 10}},
+				{path: "aws-node.log", offset: ReadOffset{FileID: 3, Offset: 7}},
+			},
+			wantOffsets:   map[string]ReadOffset{"messages": {FileID: 1, Offset: 10}, "aws-node.log": {FileID: 3, Offset: 7}},
+			wantPersisted: map[string]ReadOffset{"messages": {FileID: 1, Offset: 10}, "aws-node.log": {FileID: 3, Offset: 7}},
+		},
+		{
+			name:          "flush without changes does not write the state file",
+			saveInterval:  time.Hour,
+			sets:          []set{{flush: true}},
+			wantOffsets:   map[string]ReadOffset{},
+			wantPersisted: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			saveInterval := OffsetSaveInterval
+			OffsetSaveInterval = tc.saveInterval
+			defer func() { OffsetSaveInterval = saveInterval }()
+			path := filepath.Join(t.TempDir(), "offsets.json")
+			store, err := NewOffsetStore(path)
+			if err != nil {
+				t.Fatalf("NewOffsetStore() error = %v", err)
+			}
+			for _, s := range tc.sets {
+				if s.flush {
+					err = store.Flush()
+				} else {
+					err = store.Set(s.path, s.offset)
+				}
+				if err != nil {
+					t.Fatalf("unable to update the offsets: %v", err)
+				}
+			}
+			for path, want := range tc.wantOffsets {
+				if got, ok := store.Get(path); !ok || got != want {
+					t.Errorf("Get(%s) = %v, %v, want %v", path, got, ok, want)
+				}
+			}
+			if _, err := os.Stat(path); tc.wantPersisted == nil {
+				if !os.IsNotExist(err) {
+					t.Errorf("state file was written, error = %v", err)
+				}
+				return
+			}
+			persisted, err := NewOffsetStore(path)
+			if err != nil {
+				t.Fatalf("NewOffsetStore() of the state file error = %v", err)
+			}
+			if !reflect.DeepEqual(persisted.offsets, tc.wantPersisted) {
+				t.Errorf("persisted offsets = %v, want %v", persisted.offsets, tc.wantPersisted)
+			}
+		})
+	}
+}
+
+func TestNewOffsetStoreInvalidStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "offsets.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewOffsetStore(path); err == nil {
+		t.Error("NewOffsetStore() of an invalid state file did not fail")
+	}
+}
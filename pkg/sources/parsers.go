@@ -0,0 +1,138 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// KlogHeaderRegex matches a klog header, i.e. "I0102 15:04:05.000000"
+	KlogHeaderRegex = regexp.MustCompile(`\b[IWEF]([0-9]{4} [0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{6})\b`)
+	// KlogTimestampLayout is the time layout of a klog header with the severity char stripped and the year appended
+	KlogTimestampLayout = "0102 15:04:05.000000 2006"
+	// LogfmtTimestampKeys are the logfmt keys that are checked, in order, for a timestamp
+	LogfmtTimestampKeys = []string{"ts", "time", "timestamp", "t"}
+	// LogfmtTimestampLayouts are the time layouts attempted when parsing a logfmt timestamp value
+	LogfmtTimestampLayouts = []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"}
+
+	logfmtPairRE = regexp.MustCompile(`([^\s=]+)=("(?:[^"\\]|\\.)*"|[^\s]*)`)
+)
+
+// TimestampParserFunc extracts a timestamp from a log line
+type TimestampParserFunc func(line string) (time.Time, error)
+
+// DefaultLocation is the location of log timestamps that do not include a zone, i.e. syslog timestamps on an image that is not set to UTC
+var DefaultLocation = time.UTC
+
+// RegexTimestampParser is a helper func that returns a TimestampParserFunc which finds a timestamp in the line with the regex and parses it with the layout.
+// The current year is assumed if the timestamp does not include it, and DefaultLocation if it does not include a zone.
+func RegexTimestampParser(re *regexp.Regexp, layout string) TimestampParserFunc {
+	return regexTimestampParser(re, layout, nil)
+}
+
+// regexTimestampParser is RegexTimestampParser in the location, DefaultLocation is used if loc is nil
+func regexTimestampParser(re *regexp.Regexp, layout string, loc *time.Location) TimestampParserFunc {
+	return func(line string) (time.Time, error) {
+		rawTS := re.FindString(line)
+		if rawTS == "" {
+			return time.Time{}, fmt.Errorf("unable to find timestamp on log line matching regex: \"%s\" \"%s\"", re.String(), line)
+		}
+		rawTS = spaceRE.ReplaceAllString(rawTS, " ")
+
+		suffix := ""
+		// Convert timestamp to a time.Time type
+		if !strings.Contains(rawTS, fmt.Sprint(time.Now().Year())) {
+			suffix = fmt.Sprintf(" %d", time.Now().Year())
+		}
+		// the captured location is not overwritten, so a later change of DefaultLocation applies and parses do not race
+		tsLoc := loc
+		if tsLoc == nil {
+			tsLoc = DefaultLocation
+		}
+		ts, err := time.ParseInLocation(layout, fmt.Sprintf("%s%s", rawTS, suffix), tsLoc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return ts, nil
+	}
+}
+
+// ParseKlogTimestamp parses the timestamp of the first klog header found in the line.
+// klog headers do not include the year or zone, so the current year and DefaultLocation are assumed.
+func ParseKlogTimestamp(line string) (time.Time, error) {
+	match := KlogHeaderRegex.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("unable to find klog header on log line: \"%s\"", line)
+	}
+	return time.ParseInLocation(KlogTimestampLayout, fmt.Sprintf("%s %d", match[1], time.Now().Year()), DefaultLocation)
+}
+
+// ParseLogfmtTimestamp parses the timestamp from a logfmt line using the first of the LogfmtTimestampKeys present
+// DefaultLocation is assumed if the timestamp does not include a zone
+func ParseLogfmtTimestamp(line string) (time.Time, error) {
+	fields := ParseLogfmt(line)
+	for _, key := range LogfmtTimestampKeys {
+		value, ok := fields[key]
+		if !ok {
+			continue
+		}
+		for _, layout := range LogfmtTimestampLayouts {
+			if ts, err := time.ParseInLocation(layout, value, DefaultLocation); err == nil {
+				return ts, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unable to parse logfmt timestamp %s=\"%s\"", key, value)
+	}
+	return time.Time{}, fmt.Errorf("unable to find logfmt timestamp keys %v on log line: \"%s\"", LogfmtTimestampKeys, line)
+}
+
+// ParseLogfmt parses the key=value pairs of a logfmt line into a map. Quoted values are unquoted.
+// Any text that is not a key=value pair, like a syslog prefix, is ignored.
+func ParseLogfmt(line string) map[string]string {
+	fields := map[string]string{}
+	for _, pair := range logfmtPairRE.FindAllStringSubmatch(line, -1) {
+		value := pair[2]
+		if len(value) >= 2 && strings.HasPrefix(value, `"`) {
+			value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+		}
+		if _, ok := fields[pair[1]]; !ok {
+			fields[pair[1]] = value
+		}
+	}
+	return fields
+}
+
+// FirstTimestamp is a helper func that returns a TimestampParserFunc which tries each parser in order
+// and returns the first successfully parsed timestamp
+func FirstTimestamp(parsers ...TimestampParserFunc) TimestampParserFunc {
+	return func(line string) (time.Time, error) {
+		var lastErr error
+		for _, parser := range parsers {
+			ts, err := parser(line)
+			if err == nil {
+				return ts, nil
+			}
+			lastErr = err
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no timestamp parsers configured")
+		}
+		return time.Time{}, lastErr
+	}
+}
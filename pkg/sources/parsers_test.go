@@ -0,0 +1,250 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestParseKlogTimestamp(t *testing.T) {
+	year := time.Now().Year()
+	for _, tc := range []struct {
+		name    string
+		line    string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "kubelet line",
+			line: `I0102 15:04:05.123456    1234 kubelet.go:1234] "Started kubelet"`,
+			want: time.Date(year, time.January, 2, 15, 4, 5, 123456000, time.UTC),
+		},
+		{
+			name: "syslog prefixed warning",
+			line: `Nov 28 02:59:10 ip-10-0-0-1 kubelet[1234]: W1128 02:59:10.938873    1234 reflector.go:424] watch closed`,
+			want: time.Date(year, time.November, 28, 2, 59, 10, 938873000, time.UTC),
+		},
+		{
+			name:    "no klog header",
+			line:    `time="2022-11-28T02:59:10.938873079Z" level=info msg="starting"`,
+			wantErr: true,
+		},
+		{
+			name:    "millisecond precision is not a klog header",
+			line:    `I0102 15:04:05.123 started`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseKlogTimestamp(tc.line)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseKlogTimestamp() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("ParseKlogTimestamp() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseLogfmtTimestamp(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		line    string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "quoted RFC3339Nano time",
+			line: `time="2022-11-28T02:59:10.938873079Z" level=info msg="API listen on /run/docker.sock"`,
+			want: time.Date(2022, time.November, 28, 2, 59, 10, 938873079, time.UTC),
+		},
+		{
+			name: "ts is checked before time",
+			line: `time="2022-11-28T02:59:10Z" ts=2022-11-28T03:00:00Z msg=started`,
+			want: time.Date(2022, time.November, 28, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "zone offset",
+			line: `timestamp=2022-11-28T02:59:10+02:00 msg=started`,
+			want: time.Date(2022, time.November, 28, 0, 59, 10, 0, time.UTC),
+		},
+		{
+			name: "no zone uses the default location",
+			line: `t="2022-11-28 02:59:10.5" msg=started`,
+			want: time.Date(2022, time.November, 28, 2, 59, 10, 500000000, time.UTC),
+		},
+		{
+			name: "syslog prefix is ignored",
+			line: `Nov 28 02:59:10 ip-10-0-0-1 dockerd[1234]: time="2022-11-28T02:59:10.938873079Z" level=info`,
+			want: time.Date(2022, time.November, 28, 2, 59, 10, 938873079, time.UTC),
+		},
+		{
+			name:    "unparsable value",
+			line:    `time=yesterday msg=started`,
+			wantErr: true,
+		},
+		{
+			name:    "no timestamp key",
+			line:    `level=info msg=started`,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseLogfmtTimestamp(tc.line)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseLogfmtTimestamp() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("ParseLogfmtTimestamp() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		line string
+		want map[string]string
+	}{
+		{
+			name: "quoted and bare values",
+			line: `level=info msg="API listen on /run/docker.sock" pid=1234`,
+			want: map[string]string{"level": "info", "msg": "API listen on /run/docker.sock", "pid": "1234"},
+		},
+		{
+			name: "escaped quotes are unquoted",
+			line: `msg="pulled \"pause\" image" empty=`,
+			want: map[string]string{"msg": `pulled "pause" image`, "empty": ""},
+		},
+		{
+			name: "the first value of a repeated key is kept",
+			line: `msg=first msg=second`,
+			want: map[string]string{"msg": "first"},
+		},
+		{
+			name: "text without pairs",
+			line: `Nov 28 02:59:10 ip-10-0-0-1 systemd[1]: Started kubelet.`,
+			want: map[string]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ParseLogfmt(tc.line); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseLogfmt() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRegexTimestampParser(t *testing.T) {
+	year := time.Now().Year()
+	parser := RegexTimestampParser(regexp.MustCompile(`^[A-Z][a-z]{2} +[0-9]{1,2} [0-9]{2}:[0-9]{2}:[0-9]{2}`), "Jan 2 15:04:05 2006")
+	for _, tc := range []struct {
+		name    string
+		line    string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name: "current year is assumed",
+			line: "Nov 28 02:59:10 ip-10-0-0-1 systemd[1]: Started kubelet.",
+			want: time.Date(year, time.November, 28, 2, 59, 10, 0, time.UTC),
+		},
+		{
+			name: "padded day",
+			line: "Feb  3 02:59:10 ip-10-0-0-1 systemd[1]: Started kubelet.",
+			want: time.Date(year, time.February, 3, 2, 59, 10, 0, time.UTC),
+		},
+		{
+			name:    "no timestamp",
+			line:    "systemd[1]: Started kubelet.",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parser(tc.line)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parser() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("parser() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRegexTimestampParserLocation(t *testing.T) {
+	defaultLocation := DefaultLocation
+	defer func() { DefaultLocation = defaultLocation }()
+	est := time.FixedZone("EST", -5*3600)
+	re := regexp.MustCompile(`^[A-Z][a-z]{2} +[0-9]{1,2} [0-9]{2}:[0-9]{2}:[0-9]{2}`)
+	layout := "Jan 2 15:04:05 2006"
+	year := time.Now().Year()
+	// the same parsers are used by all cases, so a default location captured by an earlier parse would fail the later cases
+	parser := RegexTimestampParser(re, layout)
+	estParser := regexTimestampParser(re, layout, est)
+	for _, tc := range []struct {
+		name            string
+		defaultLocation *time.Location
+		want            time.Time
+		wantEST         time.Time
+	}{
+		{name: "UTC", defaultLocation: time.UTC, want: time.Date(year, time.January, 15, 10, 0, 0, 0, time.UTC), wantEST: time.Date(year, time.January, 15, 15, 0, 0, 0, time.UTC)},
+		{name: "changed default location", defaultLocation: est, want: time.Date(year, time.January, 15, 15, 0, 0, 0, time.UTC), wantEST: time.Date(year, time.January, 15, 15, 0, 0, 0, time.UTC)},
+		{name: "UTC again", defaultLocation: time.UTC, want: time.Date(year, time.January, 15, 10, 0, 0, 0, time.UTC), wantEST: time.Date(year, time.January, 15, 15, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			DefaultLocation = tc.defaultLocation
+			if got, err := parser("Jan 15 10:00:00 ip-10-0-0-1 systemd[1]: Started kubelet."); err != nil || !got.Equal(tc.want) {
+				t.Errorf("parser() = %v, %v, want %v", got, err, tc.want)
+			}
+			if got, err := estParser("Jan 15 10:00:00 ip-10-0-0-1 systemd[1]: Started kubelet."); err != nil || !got.Equal(tc.wantEST) {
+				t.Errorf("parser() in EST = %v, %v, want %v", got, err, tc.wantEST)
+			}
+		})
+	}
+}
+
+func TestFirstTimestamp(t *testing.T) {
+	failing := func(string) (time.Time, error) { return time.Time{}, errors.New("failed") }
+	ts := time.Date(2022, time.November, 28, 2, 59, 10, 0, time.UTC)
+	succeeding := func(string) (time.Time, error) { return ts, nil }
+	for _, tc := range []struct {
+		name    string
+		parsers []TimestampParserFunc
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "first parser succeeds", parsers: []TimestampParserFunc{succeeding, failing}, want: ts},
+		{name: "falls back to a later parser", parsers: []TimestampParserFunc{failing, succeeding}, want: ts},
+		{name: "all parsers fail", parsers: []TimestampParserFunc{failing, failing}, wantErr: true},
+		{name: "no parsers", wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := FirstTimestamp(tc.parsers...)("line")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("FirstTimestamp() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("FirstTimestamp() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+// DefaultMaxRegexProgramSize is the largest compiled program of a regex, i.e. of a custom event, that is accepted.
+// The built-in patterns compile to less than 100 instructions, while bounded repeats like [\w.-]{1,1000} compile to thousands,
+// which the regexp engine steps through for every byte of a multi-GB log.
+const DefaultMaxRegexProgramSize = 1000
+
+// Regexes is the registry the regexes of custom events are compiled in, the log and journal sources record the match cost of every regex in it
+var Regexes = NewRegexRegistry(DefaultMaxRegexProgramSize)
+
+// RegexRegistry compiles each pattern once, rejects patterns whose program is too large, and records the match cost of each pattern
+type RegexRegistry struct {
+	mu sync.Mutex
+	// MaxProgramSize is the largest compiled program that is accepted, 0 accepts any program
+	MaxProgramSize int
+	compiled       map[string]*regexp.Regexp
+	costs          map[string]*RegexCost
+}
+
+// RegexCost is the match cost of a pattern over all searches
+type RegexCost struct {
+	Pattern     string        `json:"pattern"`
+	ProgramSize int           `json:"programSize"`
+	Searches    int           `json:"searches"`
+	Bytes       int64         `json:"bytes"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// NewRegexRegistry instantiates a regex registry that rejects programs larger than maxProgramSize
+func NewRegexRegistry(maxProgramSize int) *RegexRegistry {
+	return &RegexRegistry{
+		MaxProgramSize: maxProgramSize,
+		compiled:       map[string]*regexp.Regexp{},
+		costs:          map[string]*RegexCost{},
+	}
+}
+
+// Compile returns the compiled regex of the pattern, compiling it on first use
+func (r *RegexRegistry) Compile(expr string) (*regexp.Regexp, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if re, ok := r.compiled[expr]; ok {
+		return re, nil
+	}
+	size, err := ProgramSize(expr)
+	if err != nil {
+		return nil, err
+	}
+	if r.MaxProgramSize > 0 && size > r.MaxProgramSize {
+		return nil, fmt.Errorf("regex \"%s\" compiles to %d instructions, more than the maximum of %d, narrow its bounded repeats or alternations", expr, size, r.MaxProgramSize)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	r.compiled[expr] = re
+	return re, nil
+}
+
+// Observe records a search of the regex over bytes that took elapsed
+func (r *RegexRegistry) Observe(re *regexp.Regexp, bytes int, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cost, ok := r.costs[re.String()]
+	if !ok {
+		size, _ := ProgramSize(re.String())
+		cost = &RegexCost{Pattern: re.String(), ProgramSize: size}
+		r.costs[re.String()] = cost
+	}
+	cost.Searches++
+	cost.Bytes += int64(bytes)
+	cost.Duration += elapsed
+}
+
+// Costs returns the match cost of the searched patterns, most expensive first
+func (r *RegexRegistry) Costs() []*RegexCost {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	costs := lo.Map(lo.Values(r.costs), func(c *RegexCost, _ int) *RegexCost {
+		copied := *c
+		return &copied
+	})
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].Duration != costs[j].Duration {
+			return costs[i].Duration > costs[j].Duration
+		}
+		return costs[i].Pattern < costs[j].Pattern
+	})
+	return costs
+}
+
+// ProgramSize returns the number of instructions the pattern compiles to
+func ProgramSize(expr string) (int, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return 0, err
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return 0, err
+	}
+	return len(prog.Inst), nil
+}
@@ -0,0 +1,69 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegexRegistryCompile(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		maxProgramSize int
+		expr           string
+		wantErr        string
+	}{
+		{name: "built-in pattern", maxProgramSize: DefaultMaxRegexProgramSize, expr: `^(\w+ \d+ \d+:\d+:\d+) .*Reached target Network`},
+		{name: "bounded repeat over the maximum", maxProgramSize: DefaultMaxRegexProgramSize, expr: `[\w.-]{1,1000}`, wantErr: "more than the maximum of 1000"},
+		{name: "bounded repeat within a raised maximum", maxProgramSize: 100000, expr: `[\w.-]{1,1000}`},
+		{name: "no maximum", maxProgramSize: 0, expr: `[\w.-]{1,1000}`},
+		{name: "invalid pattern", maxProgramSize: DefaultMaxRegexProgramSize, expr: `(unclosed`, wantErr: "missing closing )"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := NewRegexRegistry(tc.maxProgramSize)
+			re, err := registry.Compile(tc.expr)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Compile(%q) error = %v, want it to contain %q", tc.expr, err, tc.wantErr)
+				}
+				if _, ok := registry.compiled[tc.expr]; ok {
+					t.Errorf("Compile(%q) cached a rejected pattern", tc.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compile(%q) error = %v", tc.expr, err)
+			}
+			if cached, _ := registry.Compile(tc.expr); cached != re {
+				t.Errorf("Compile(%q) did not return the cached regex on the second call", tc.expr)
+			}
+		})
+	}
+}
+
+func TestRegexRegistryCompileAtMaximum(t *testing.T) {
+	const expr = `^kubelet.*started`
+	size, err := ProgramSize(expr)
+	if err != nil {
+		t.Fatalf("ProgramSize(%q) error = %v", expr, err)
+	}
+	if _, err := NewRegexRegistry(size).Compile(expr); err != nil {
+		t.Errorf("Compile(%q) with a maximum of its program size %d error = %v, want nil", expr, size, err)
+	}
+	if _, err := NewRegexRegistry(size - 1).Compile(expr); err == nil {
+		t.Errorf("Compile(%q) with a maximum one below its program size %d error = nil, want an error", expr, size)
+	}
+}
@@ -16,21 +16,46 @@ package sources
 
 import (
 	"bufio"
+	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
+
+	"github.com/awslabs/node-latency-for-k8s/pkg/logging"
 )
 
 var (
 	spaceRE = regexp.MustCompile(`\s+`)
 )
 
+// DefaultMaxScanBytes limits the bytes searched per Find by LogReaders that do not specify MaxScanBytes
+// Searches are unlimited when it is 0
+var DefaultMaxScanBytes int64
+
+// DefaultMaxFindDuration limits the time spent matching a regex per Find by LogReaders that do not specify MaxFindDuration
+// Searches are unlimited when it is 0
+var DefaultMaxFindDuration time.Duration
+
+// DefaultChecksumScans makes LogReaders checksum the bytes they search, which Checksum returns
+// It is off by default since every search pass hashes the searched bytes
+var DefaultChecksumScans bool
+
+// rotatedLogRegex matches the names of rotated log files, i.e. messages.1 or messages-20230101, which are no longer appended to or truncated
+var rotatedLogRegex = regexp.MustCompile(`(\.[0-9]+|-[0-9]{8,10})$`)
+
+// findChunkBytes is the size of the line aligned chunks a log is searched in
+const findChunkBytes = 1 << 20
+
 // Source is an interface representing a source of events which have a time stamp or latency associated with them.
 // Most often source is a log file or an API.
 type Source interface {
@@ -45,12 +70,77 @@ type Source interface {
 	String() string
 }
 
+// RegexSource is a Source that can be searched with a regex, like a log file or the journal
+type RegexSource interface {
+	Source
+	// FindByRegex returns a FindFunc to search for a regex in the source that can be used in an Event
+	FindByRegex(re *regexp.Regexp) FindFunc
+}
+
+// HostPathSource is a Source that reads files of the host, which must be mounted into the container and readable by it
+type HostPathSource interface {
+	Source
+	// HostPaths are the paths the source reads, which may be globs
+	HostPaths() []string
+}
+
+// WindowedSource is a Source that is able to restrict its search to a time window, usually a time-sorted log
+type WindowedSource interface {
+	Source
+	// SetSearchWindow restricts the search to [start, end], a zero time leaves that side of the window unbounded
+	SetSearchWindow(start time.Time, end time.Time)
+}
+
+// MultiBootSource is a Source that holds the history of multiple boots, usually the systemd journal
+type MultiBootSource interface {
+	Source
+	// Boots returns the boot IDs in the order the boots started
+	Boots() ([]string, error)
+	// SetBoot restricts the search to a boot ID, an empty boot ID searches the current boot
+	SetBoot(bootID string)
+}
+
+// LocatedSource is a Source whose timestamps may not include a zone, usually a syslog formatted log
+type LocatedSource interface {
+	Source
+	// SetLocation sets the location of timestamps without a zone, DefaultLocation is used if it is nil
+	SetLocation(loc *time.Location)
+}
+
+// ChecksummedSource is a Source that is able to checksum the data it is searched in, usually a log file
+type ChecksummedSource interface {
+	Source
+	// Checksum returns the checksum of the source data searched by the last search pass
+	Checksum() (*ScanChecksum, error)
+}
+
+// ScanChecksum is the checksum of the bytes of a log file that were searched
+type ScanChecksum struct {
+	Path string `json:"path"`
+	// SHA256 is the hex encoded sha256 checksum of the searched bytes
+	SHA256 string `json:"sha256"`
+	// Start and End are the byte range of the searched bytes in the log file, or in the uncompressed contents of a gzipped log
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// InspectedSource is a Source that reports what its last search read, which explains why an event did or did not match
+type InspectedSource interface {
+	Source
+	// LastSearch returns the resolved location, usually the log file, and the number of bytes searched by the last Find
+	LastSearch() (location string, scannedBytes int)
+}
+
 // FindResult is all data associated with a find including the raw Line data
 type FindResult struct {
 	Line      string
 	Timestamp time.Time
 	Comment   string
 	Err       error
+	// Truncated is true if the source was not completely searched because of the scan limits
+	Truncated bool
+	// SinceBoot is the monotonic time since kernel boot if the source records it, i.e. the journal
+	SinceBoot *time.Duration
 }
 
 type FindFunc func(s Source, log []byte) ([]string, error)
@@ -66,6 +156,24 @@ type Event struct {
 	Src           Source      `json:"-"`
 	CommentFn     CommentFunc `json:"-"`
 	FindFn        FindFunc    `json:"-"`
+	// Track is the measurement track the event belongs to, a terminal event only closes its own track.
+	// Events without a track are shared by all tracks.
+	Track string `json:"track,omitempty"`
+	// Labels are arbitrary key/values (i.e. component=cni, phase=runtime) that are attached to the emitted metrics
+	Labels map[string]string `json:"labels,omitempty"`
+	// Severity (i.e. SeverityCritical) and Owner (i.e. the team owning the component) are included in the output, metrics and error logs of the event
+	Severity string `json:"severity,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+	// OnlyIf is the name of an event that must have matched for the event to be searched, i.e. GPU driver events only if a GPU was detected.
+	// Skipped events do not have timings and are not errors.
+	OnlyIf string `json:"onlyIf,omitempty"`
+	// Aliases are previous metric names of a renamed event, the event keeps emitting them so existing dashboards do not break
+	Aliases []string `json:"aliases,omitempty"`
+	// Description, Regex and Example document the event in the generated event documentation. Regex is the reference regex of events
+	// that are searched with one, and Example is a line the event matches, i.e. captured during a recording.
+	Description string `json:"-"`
+	Regex       string `json:"-"`
+	Example     string `json:"-"`
 }
 
 // Match Selector consts for an Event's MatchSelector
@@ -75,6 +183,13 @@ const (
 	EventMatchSelectorAll   = "all"
 )
 
+// Severity consts for an Event's Severity
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
 // Timing is a specific instance of an Event timing
 type Timing struct {
 	Event     *Event        `json:"event"`
@@ -82,6 +197,11 @@ type Timing struct {
 	T         time.Duration `json:"seconds"`
 	Comment   string        `json:"comment"`
 	Error     error         `json:"error"`
+	Truncated bool          `json:"truncated,omitempty"`
+	// SinceBoot is the monotonic time since kernel boot which is immune to wall clock steps, if the source records it
+	SinceBoot *time.Duration `json:"sinceBoot,omitempty"`
+	// Line is the matched log line, which is only exported in the evidence artifact
+	Line string `json:"-"`
 }
 
 // SelectMaches will filter raw results based on the provided matchSelector
@@ -108,64 +228,191 @@ func CommentMatchedLine() func(matchedLine string) string {
 	}
 }
 
+// CommentTemplate is a helper func that returns a func that can be used as a CommentFunc in an Event
+// The func executes the template over the named capture groups of the regex in the matched line, i.e. "pulled {{.image}} in {{.duration}}"
+// The matched line is available as {{.line}} and is used as the comment if the template fails.
+func CommentTemplate(re *regexp.Regexp, tmpl *template.Template) func(matchedLine string) string {
+	return func(matchedLine string) string {
+		data := CommentTemplateData(re, matchedLine)
+		var comment strings.Builder
+		if err := tmpl.Execute(&comment, data); err != nil {
+			return matchedLine
+		}
+		return comment.String()
+	}
+}
+
+// CommentTemplateData maps the named capture groups of the regex to their submatch in the line, and line to the line itself
+// Groups that did not participate in the match are empty
+func CommentTemplateData(re *regexp.Regexp, line string) map[string]string {
+	data := map[string]string{"line": line}
+	match := re.FindStringSubmatch(line)
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		data[name] = ""
+		if i < len(match) {
+			data[name] = match[i]
+		}
+	}
+	return data
+}
+
 // LogReader is a base Source helper that can Read file contents, cache, and support Glob file paths
 // Other Sources can be built on-top of the LogSrc
 type LogReader struct {
+	// Name is the name of the source the LogReader belongs to, which selects the source logger
+	Name            string
 	Path            string
 	Glob            bool
 	TimestampRegex  *regexp.Regexp
 	TimestampLayout string
+	// TimestampParser overrides TimestampRegex and TimestampLayout when set (i.e. ParseKlogTimestamp or ParseLogfmtTimestamp)
+	TimestampParser TimestampParserFunc
+	// Location is the location of TimestampRegex timestamps that do not include a zone, DefaultLocation is used if nil
+	Location *time.Location
+	// Sorted indicates the log lines are in chronological order which allows the search window to be found with a binary search
+	Sorted bool
+	// Cache is the read cache for the file contents shared with other sources, DefaultReadCache is used if nil
+	Cache *ReadCache
+	// Offsets enables incremental reads of uncompressed files, DefaultOffsetStore is used if nil
+	Offsets *OffsetStore
+	// MmapMinBytes is the file size at or above which rotated uncompressed files are memory mapped instead of read into the heap,
+	// DefaultMmapMinBytes is used if 0
+	MmapMinBytes int64
+	// MaxScanBytes limits the bytes searched per Find, DefaultMaxScanBytes is used if 0
+	MaxScanBytes int64
+	// MaxFindDuration limits the time spent matching a regex per Find, DefaultMaxFindDuration is used if 0
+	MaxFindDuration time.Duration
+	windowStart     time.Time
+	windowEnd       time.Time
+	resolvedPath    string
 	file            []byte
+	incrementalData []byte
+	incrementalID   uint64
+	mapping         []byte
+	truncated       bool
+	scannedBytes    int
+	accessPath      string
+	scanChecksum    *ScanChecksum
+	scanStale       bool
+}
+
+// SetSearchWindow restricts Find to lines between start and end if the log is Sorted
+// A zero time leaves that side of the window unbounded
+func (l *LogReader) SetSearchWindow(start time.Time, end time.Time) {
+	l.windowStart = start
+	l.windowEnd = end
 }
 
-// ClearCache cleas the cached log
+// SetLocation sets the location of TimestampRegex timestamps that do not include a zone
+func (l *LogReader) SetLocation(loc *time.Location) {
+	l.Location = loc
+}
+
+// ClearCache clears the log contents and resolved log path of the reader so the next Read picks up changes to the file.
+// The read cache is left alone since other sources share it, its entries are only reused while the file is unchanged.
 func (l *LogReader) ClearCache() {
 	l.file = nil
+	if l.mapping != nil {
+		if err := munmap(l.mapping); err != nil {
+			logging.ForSource(l.Name).Warnf("unable to unmap log file %s: %v", l.resolvedPath, err)
+		}
+		l.mapping = nil
+	}
+	l.resolvedPath = ""
+	// the checksum is kept until the next search, so it still describes the last search pass
+	l.scanStale = true
+}
+
+// Checksum returns the checksum of the bytes searched since the last ClearCache(), or by the last search pass if nothing was
+// searched since. The searched bytes are only checksummed if DefaultChecksumScans is set.
+func (l *LogReader) Checksum() (*ScanChecksum, error) {
+	if l.scanChecksum == nil {
+		return nil, fmt.Errorf("no search of %s was checksummed", l.Path)
+	}
+	return l.scanChecksum, nil
+}
+
+// checksumScan extends the checksummed range with the bytes of a search, the range starts over with the first search after
+// ClearCache(). The bytes are hashed when they are searched since mapped contents are unmapped by ClearCache().
+func (l *LogReader) checksumScan(log []byte, start int, end int) {
+	if !DefaultChecksumScans {
+		return
+	}
+	if l.scanChecksum != nil && !l.scanStale {
+		if int64(start) >= l.scanChecksum.Start && int64(end) <= l.scanChecksum.End {
+			return
+		}
+		if int64(start) > l.scanChecksum.Start {
+			start = int(l.scanChecksum.Start)
+		}
+		if int64(end) < l.scanChecksum.End {
+			end = int(l.scanChecksum.End)
+		}
+	}
+	sum := sha256.Sum256(log[start:end])
+	l.scanChecksum = &ScanChecksum{Path: l.resolvedPath, SHA256: hex.EncodeToString(sum[:]), Start: int64(start), End: int64(end)}
+	l.scanStale = false
+}
+
+// incremental returns true if the file is read incrementally, which requires an offset store and an uncompressed file
+func (l *LogReader) incremental(resolvedPath string) bool {
+	return l.offsets() != nil && !strings.HasSuffix(resolvedPath, ".gz")
+}
+
+// cache returns the configured read cache or the DefaultReadCache
+func (l *LogReader) cache() *ReadCache {
+	if l.Cache != nil {
+		return l.Cache
+	}
+	return DefaultReadCache
 }
 
-// Read will open and read all the bytes of a log file into byte slice and then cache it
-// Any further calls to Read() will use the cached byte slice.
-// If the file is being updated and you need the updated contents,
-// you'll need to instantiate a new LogSrc and call Read() again
+// Read will open and read all the bytes of a log file into byte slice and keep it until ClearCache() is called, so all searches
+// of a measurement pass use the same contents. The contents are shared with other sources through the read cache, which is
+// reused as long as the file did not change. Contents that are too large for the read cache are only kept by the reader.
+// Memory mapped files are not cached and the returned byte slice is only valid until ClearCache() is called.
 func (l *LogReader) Read() ([]byte, error) {
+	resolvedPath, err := l.resolvePath()
+	if err != nil {
+		return nil, err
+	}
+	if l.mapping != nil {
+		return l.mapping, nil
+	}
 	if l.file != nil {
 		return l.file, nil
 	}
-	resolvedPath := l.Path
-	if l.Glob {
-		matches, err := filepath.Glob(l.Path)
-		if err != nil || len(matches) == 0 {
-			return nil, fmt.Errorf("unable to find log file %s: %w", l.Path, err)
-		}
-		// sort to find the oldest file for initial startup timings if the logs were rotated
-		sort.Slice(matches, func(i, j int) bool {
-			iFile, err := os.Open(matches[i])
-			if err != nil {
-				return matches[i] < matches[j]
-			}
-			defer iFile.Close()
-			jFile, err := os.Open(matches[j])
-			if err != nil {
-				return matches[i] < matches[j]
-			}
-			defer jFile.Close()
-			iStat, err := iFile.Stat()
-			if err != nil {
-				return matches[i] < matches[j]
-			}
-			jStat, err := jFile.Stat()
-			if err != nil {
-				return matches[i] < matches[j]
-			}
-			return iStat.ModTime().Unix() < jStat.ModTime().Unix()
-		})
-		resolvedPath = matches[0]
-	}
-	file, err := os.Open(resolvedPath)
+	if l.incremental(resolvedPath) {
+		fileBytes, err := l.readIncremental(resolvedPath)
+		if err == nil {
+			l.file = fileBytes
+		}
+		return fileBytes, err
+	}
+	file, err := l.open(resolvedPath)
 	if err != nil {
-		return nil, fmt.Errorf("unable to open log file %s: %w", resolvedPath, err)
+		return nil, err
+	}
+	if file == nil {
+		return l.readFromHelper(resolvedPath)
 	}
 	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat log file %s: %w", resolvedPath, err)
+	}
+	stamp := Stamp(info)
+	if fileBytes, cachedStamp, ok := l.cache().Get(resolvedPath); ok && cachedStamp == stamp {
+		l.file = fileBytes
+		return fileBytes, nil
+	}
+	if mapping, ok := l.mmap(file); ok {
+		l.mapping = mapping
+		return mapping, nil
+	}
 	var reader io.Reader
 	if strings.HasSuffix(resolvedPath, ".gz") {
 		gzReader, err := gzip.NewReader(file)
@@ -183,19 +430,183 @@ func (l *LogReader) Read() ([]byte, error) {
 		return fileBytes, fmt.Errorf("unable to read file %s: %w", file.Name(), err)
 	}
 	l.file = fileBytes
+	if !l.cache().Put(resolvedPath, fileBytes, stamp) {
+		logging.ForSource(l.Name).Debugf("log file %s is too large for the read cache, it is only kept by the reader", resolvedPath)
+	}
+	return fileBytes, nil
+}
+
+// mmap memory maps a rotated uncompressed file if it is at least MmapMinBytes, falling back to a regular read on any error.
+// Live logs are never mapped since accessing a mapping of a file that was truncated, i.e. by logrotate's copytruncate, raises SIGBUS.
+func (l *LogReader) mmap(file *os.File) ([]byte, bool) {
+	minBytes := l.MmapMinBytes
+	if minBytes == 0 {
+		minBytes = DefaultMmapMinBytes
+	}
+	if minBytes <= 0 || !rotatedLogRegex.MatchString(file.Name()) {
+		return nil, false
+	}
+	info, err := file.Stat()
+	if err != nil || info.Size() < minBytes || info.Size() > math.MaxInt {
+		return nil, false
+	}
+	mapping, err := mmapFile(file, int(info.Size()))
+	if err != nil {
+		logging.ForSource(l.Name).Warnf("unable to mmap log file %s, falling back to reading it into memory: %v", file.Name(), err)
+		return nil, false
+	}
+	return mapping, true
+}
+
+// offsets returns the configured offset store or the DefaultOffsetStore
+func (l *LogReader) offsets() *OffsetStore {
+	if l.Offsets != nil {
+		return l.Offsets
+	}
+	return DefaultOffsetStore
+}
+
+// readIncremental reads the whole file on the first read and only the bytes appended since on the following reads. The contents
+// are kept by the reader across ClearCache() calls, so the boot events at the start of the file are always searched. If the file
+// was rotated or truncated, it is read from the beginning. The contents are shared with other incremental readers of the file
+// through the read cache. The persisted offsets do not skip any bytes after a restart since the timings matched before the restart
+// are not persisted, they detect files that were rotated or truncated while the reader was not running.
+func (l *LogReader) readIncremental(resolvedPath string) ([]byte, error) {
+	file, err := l.open(resolvedPath)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		// the helper always sends the whole file, so the offset store is not updated
+		return l.readFromHelper(resolvedPath)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat log file %s: %w", resolvedPath, err)
+	}
+	id := fileID(info)
+	if l.incrementalID != id || info.Size() < int64(len(l.incrementalData)) {
+		l.incrementalData = nil
+	}
+	if cached, stamp, ok := l.cache().Get(resolvedPath); ok && stamp.ID == id && stamp.Size == int64(len(cached)) &&
+		len(cached) > len(l.incrementalData) && info.Size() >= stamp.Size {
+		// another reader already read further
+		l.incrementalData = cached
+	}
+	if l.incrementalData == nil {
+		if readOffset, ok := l.offsets().Get(resolvedPath); ok && readOffset.FileID == id && info.Size() < readOffset.Offset {
+			logging.ForSource(l.Name).Warnf("log file %s was truncated since it was last read, lines before the truncation are lost", resolvedPath)
+		}
+	}
+	start := int64(len(l.incrementalData))
+	l.incrementalID = id
+	if info.Size() == start && l.incrementalData != nil {
+		return l.incrementalData, nil
+	}
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("unable to seek to offset %d in log file %s: %w", start, resolvedPath, err)
+	}
+	appended, err := io.ReadAll(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file %s: %w", resolvedPath, err)
+	}
+	// appending never changes the bytes of slices returned by earlier reads, only their capacity is shared
+	fileBytes := append(l.incrementalData, appended...)
+	l.incrementalData = fileBytes
+	l.cache().Put(resolvedPath, fileBytes, FileStamp{ID: id, Size: int64(len(fileBytes)), ModTime: info.ModTime()})
+	if err := l.offsets().Set(resolvedPath, ReadOffset{FileID: id, Offset: int64(len(fileBytes))}); err != nil {
+		return fileBytes, err
+	}
 	return fileBytes, nil
 }
 
+// resolvePath resolves the log file path, finding the oldest match if the path is a glob
+func (l *LogReader) resolvePath() (string, error) {
+	if l.resolvedPath != "" {
+		return l.resolvedPath, nil
+	}
+	if !l.Glob {
+		l.resolvedPath = l.Path
+		return l.resolvedPath, nil
+	}
+	matches, err := filepath.Glob(l.Path)
+	if err != nil || len(matches) == 0 {
+		return "", fmt.Errorf("unable to find log file %s: %w", l.Path, err)
+	}
+	// sort to find the oldest file for initial startup timings if the logs were rotated
+	sort.Slice(matches, func(i, j int) bool {
+		iFile, err := os.Open(matches[i])
+		if err != nil {
+			return matches[i] < matches[j]
+		}
+		defer iFile.Close()
+		jFile, err := os.Open(matches[j])
+		if err != nil {
+			return matches[i] < matches[j]
+		}
+		defer jFile.Close()
+		iStat, err := iFile.Stat()
+		if err != nil {
+			return matches[i] < matches[j]
+		}
+		jStat, err := jFile.Stat()
+		if err != nil {
+			return matches[i] < matches[j]
+		}
+		return iStat.ModTime().Unix() < jStat.ModTime().Unix()
+	})
+	l.resolvedPath = matches[0]
+	return l.resolvedPath, nil
+}
+
 // Find searches for the passed in regexp from the log references in the LogReader
 func (l *LogReader) Find(re *regexp.Regexp) ([]string, error) {
+	l.truncated = false
 	// Read the log file
-	messages, err := l.Read()
+	log, err := l.Read()
 	if err != nil {
 		return nil, err
 	}
-	// Find all occurrences of the regex in the log file
-	lines := re.FindAll(messages, -1)
+	// Narrow down the bytes to search before running the regex
+	windowStart, windowEnd := l.window(log)
+	messages := log[windowStart:windowEnd]
+	if maxScanBytes := l.maxScanBytes(); maxScanBytes > 0 && int64(len(messages)) > maxScanBytes {
+		cut := lineStart(messages, int(maxScanBytes))
+		if cut == 0 {
+			// the first line is searched even if it is longer than the limit
+			cut = nextLineStart(messages, 0)
+		}
+		messages = messages[:cut]
+		l.truncated = true
+	}
+	l.scannedBytes = len(messages)
+	l.checksumScan(log, windowStart, windowStart+len(messages))
+	// Find all occurrences of the regex in the log file, in line aligned chunks so that the find duration limit can be checked
+	var deadline time.Time
+	if maxFindDuration := l.maxFindDuration(); maxFindDuration > 0 {
+		deadline = time.Now().Add(maxFindDuration)
+	}
+	var lines [][]byte
+	start := time.Now()
+	for offset := 0; offset < len(messages); {
+		end := len(messages)
+		if offset+findChunkBytes < end {
+			end = nextLineStart(messages, offset+findChunkBytes)
+		}
+		lines = append(lines, re.FindAll(messages[offset:end], -1)...)
+		offset = end
+		if !deadline.IsZero() && time.Now().After(deadline) && offset < len(messages) {
+			l.truncated = true
+			break
+		}
+	}
+	Regexes.Observe(re, len(messages), time.Since(start))
+	logging.ForSource(l.Name).Debugw("searched log", "path", l.resolvedPath, "regex", re.String(), "bytes", len(messages), "matches", len(lines), "truncated", l.truncated)
 	if len(lines) == 0 {
+		if l.truncated {
+			return nil, fmt.Errorf("no matches in %s for regex \"%s\" (search was truncated by the scan limits)", l.Path, re.String())
+		}
 		return nil, fmt.Errorf("no matches in %s for regex \"%s\"", l.Path, re.String())
 	}
 	var lineStrs []string
@@ -205,22 +616,103 @@ func (l *LogReader) Find(re *regexp.Regexp) ([]string, error) {
 	return lineStrs, nil
 }
 
+// LastSearch returns the resolved log file path and the number of bytes searched by the last Find
+func (l *LogReader) LastSearch() (string, int) {
+	return l.resolvedPath, l.scannedBytes
+}
+
+// Truncated returns true if the last Find did not search the whole log because of the scan limits
+func (l *LogReader) Truncated() bool {
+	return l.truncated
+}
+
+// maxScanBytes returns the configured max scan bytes or the DefaultMaxScanBytes
+func (l *LogReader) maxScanBytes() int64 {
+	if l.MaxScanBytes != 0 {
+		return l.MaxScanBytes
+	}
+	return DefaultMaxScanBytes
+}
+
+// maxFindDuration returns the configured max find duration or the DefaultMaxFindDuration
+func (l *LogReader) maxFindDuration() time.Duration {
+	if l.MaxFindDuration != 0 {
+		return l.MaxFindDuration
+	}
+	return DefaultMaxFindDuration
+}
+
 // ParseTimestamp usese the configured timestamp regex to find a timestamp from the passed in log line and return as a time.Time
 func (l *LogReader) ParseTimestamp(line string) (time.Time, error) {
-	rawTS := l.TimestampRegex.FindString(line)
-	if rawTS == "" {
-		return time.Time{}, fmt.Errorf("unable to find timestamp on log line matching regex: \"%s\" \"%s\"", l.TimestampRegex.String(), line)
+	if l.TimestampParser != nil {
+		return l.TimestampParser(line)
 	}
-	rawTS = spaceRE.ReplaceAllString(rawTS, " ")
+	return regexTimestampParser(l.TimestampRegex, l.TimestampLayout, l.Location)(line)
+}
 
-	suffix := ""
-	// Convert timestamp to a time.Time type
-	if !strings.Contains(rawTS, fmt.Sprint(time.Now().Year())) {
-		suffix = fmt.Sprintf(" %d", time.Now().Year())
+// window returns the byte range of a Sorted log that is within the search window
+func (l *LogReader) window(log []byte) (int, int) {
+	if !l.Sorted || (l.windowStart.IsZero() && l.windowEnd.IsZero()) {
+		return 0, len(log)
 	}
-	ts, err := time.Parse(l.TimestampLayout, fmt.Sprintf("%s%s", rawTS, suffix))
-	if err != nil {
-		return time.Time{}, err
+	start, end := 0, len(log)
+	if !l.windowStart.IsZero() {
+		start = l.searchTimestamp(log, func(ts time.Time) bool { return !ts.Before(l.windowStart) })
+	}
+	if !l.windowEnd.IsZero() {
+		end = l.searchTimestamp(log, func(ts time.Time) bool { return ts.After(l.windowEnd) })
+		// the lines without a timestamp before the first line past the window continue the last line in the window, i.e. stack traces
+		if next, _, ok := l.nextTimestamp(log, end, len(log)); ok {
+			end = next
+		} else {
+			end = len(log)
+		}
+	}
+	if end < start {
+		return start, start
+	}
+	return start, end
+}
+
+// searchTimestamp binary searches the log for the offset of the first line where pred is true for the line's timestamp
+// pred must be false for all lines before the offset and true for all lines after.
+// The offset is the start of the lines without a parsable timestamp that precede the first line where pred is true,
+// so the window start keeps the continuation lines of the line before the window rather than risk dropping a match.
+func (l *LogReader) searchTimestamp(log []byte, pred func(time.Time) bool) int {
+	low, high := 0, len(log)
+	for low < high {
+		mid := lineStart(log, low+(high-low)/2)
+		offset, ts, ok := l.nextTimestamp(log, mid, high)
+		if !ok || pred(ts) {
+			high = mid
+			continue
+		}
+		low = nextLineStart(log, offset)
+	}
+	return low
+}
+
+// nextTimestamp returns the offset and timestamp of the first line within [offset, limit) with a parsable timestamp
+func (l *LogReader) nextTimestamp(log []byte, offset int, limit int) (int, time.Time, bool) {
+	for offset < limit {
+		next := nextLineStart(log, offset)
+		if ts, err := l.ParseTimestamp(string(log[offset:next])); err == nil {
+			return offset, ts, true
+		}
+		offset = next
+	}
+	return 0, time.Time{}, false
+}
+
+// lineStart returns the offset of the start of the line containing offset
+func lineStart(log []byte, offset int) int {
+	return bytes.LastIndexByte(log[:offset], '\n') + 1
+}
+
+// nextLineStart returns the offset of the start of the line after the line containing offset
+func nextLineStart(log []byte, offset int) int {
+	if i := bytes.IndexByte(log[offset:], '\n'); i >= 0 {
+		return offset + i + 1
 	}
-	return ts, nil
+	return len(log)
 }
@@ -0,0 +1,239 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogReaderWindow(t *testing.T) {
+	base := time.Date(2022, time.November, 28, 2, 0, 0, 0, time.UTC)
+	// one line per minute from 02:00 to 02:09
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf("time=%s msg=line%d", base.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), i))
+	}
+	log := strings.Join(lines, "\n") + "\n"
+	at := func(minute int) time.Time { return base.Add(time.Duration(minute) * time.Minute) }
+	join := func(from, to int) string { return strings.Join(lines[from:to], "\n") + "\n" }
+	for _, tc := range []struct {
+		name   string
+		log    string
+		sorted bool
+		start  time.Time
+		end    time.Time
+		want   string
+	}{
+		{name: "no window", log: log, sorted: true, want: log},
+		{name: "unsorted logs are not windowed", log: log, start: at(3), end: at(5), want: log},
+		{name: "start and end are inclusive", log: log, sorted: true, start: at(3), end: at(5), want: join(3, 6)},
+		{name: "start only", log: log, sorted: true, start: at(8), want: join(8, 10)},
+		{name: "end only", log: log, sorted: true, end: at(1), want: join(0, 2)},
+		{name: "between lines", log: log, sorted: true, start: at(3).Add(30 * time.Second), end: at(5).Add(30 * time.Second), want: join(4, 6)},
+		{name: "window before the log", log: log, sorted: true, start: at(-10), end: at(-5), want: ""},
+		{name: "window after the log", log: log, sorted: true, start: at(20), want: ""},
+		{name: "end before start", log: log, sorted: true, start: at(5), end: at(3), want: ""},
+		{
+			name:   "no trailing newline",
+			log:    strings.TrimSuffix(log, "\n"),
+			sorted: true,
+			start:  at(8),
+			want:   strings.TrimSuffix(join(8, 10), "\n"),
+		},
+		{
+			name:   "lines without a timestamp are kept with the following line",
+			log:    join(0, 4) + "continued stack trace\n" + join(4, 10),
+			sorted: true,
+			start:  at(4),
+			end:    at(4),
+			want:   "continued stack trace\n" + join(4, 5),
+		},
+		{
+			name:   "lines without a timestamp after the last line in the window are kept",
+			log:    join(0, 5) + "continued stack trace\nat main.go:42\n" + join(5, 10),
+			sorted: true,
+			start:  at(4),
+			end:    at(4),
+			want:   join(4, 5) + "continued stack trace\nat main.go:42\n",
+		},
+		{
+			name:   "lines without a timestamp at the end of the log are kept",
+			log:    log + "continued stack trace\n",
+			sorted: true,
+			start:  at(9),
+			end:    at(9),
+			want:   join(9, 10) + "continued stack trace\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := &LogReader{TimestampParser: ParseLogfmtTimestamp, Sorted: tc.sorted}
+			reader.SetSearchWindow(tc.start, tc.end)
+			start, end := reader.window([]byte(tc.log))
+			if got := tc.log[start:end]; got != tc.want {
+				t.Errorf("window() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLineStart(t *testing.T) {
+	log := []byte("first\nsecond\nthird")
+	for _, tc := range []struct {
+		offset    int
+		wantStart int
+		wantNext  int
+	}{
+		{offset: 0, wantStart: 0, wantNext: 6},
+		{offset: 5, wantStart: 0, wantNext: 6},
+		{offset: 6, wantStart: 6, wantNext: 13},
+		{offset: 9, wantStart: 6, wantNext: 13},
+		{offset: 15, wantStart: 13, wantNext: 18},
+	} {
+		t.Run(fmt.Sprint(tc.offset), func(t *testing.T) {
+			if got := lineStart(log, tc.offset); got != tc.wantStart {
+				t.Errorf("lineStart() = %d, want %d", got, tc.wantStart)
+			}
+			if got := nextLineStart(log, tc.offset); got != tc.wantNext {
+				t.Errorf("nextLineStart() = %d, want %d", got, tc.wantNext)
+			}
+		})
+	}
+}
+
+func TestLogReaderFindMaxScanBytes(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		log           string
+		maxScanBytes  int64
+		re            string
+		wantLines     []string
+		wantScanned   int
+		wantTruncated bool
+	}{
+		{
+			name:         "within the limit",
+			log:          "first match\nsecond match\n",
+			maxScanBytes: 100,
+			re:           "[a-z]+ match",
+			wantLines:    []string{"first match", "second match"},
+			wantScanned:  25,
+		},
+		{
+			name:          "cut at the last line that fits",
+			log:           "first match\nsecond match\n",
+			maxScanBytes:  20,
+			re:            "[a-z]+ match",
+			wantLines:     []string{"first match"},
+			wantScanned:   12,
+			wantTruncated: true,
+		},
+		{
+			name:          "the first line is searched even if it is longer than the limit",
+			log:           "a long first line with a match\nsecond match\n",
+			maxScanBytes:  10,
+			re:            "[a-z]+ match",
+			wantLines:     []string{"a match"},
+			wantScanned:   31,
+			wantTruncated: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "messages")
+			if err := os.WriteFile(path, []byte(tc.log), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			reader := &LogReader{Path: path, MaxScanBytes: tc.maxScanBytes, Cache: NewReadCache(0, 0), TimestampParser: ParseLogfmtTimestamp}
+			lines, err := reader.Find(regexp.MustCompile(tc.re))
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if strings.Join(lines, ",") != strings.Join(tc.wantLines, ",") {
+				t.Errorf("Find() = %v, want %v", lines, tc.wantLines)
+			}
+			if _, scanned := reader.LastSearch(); scanned != tc.wantScanned {
+				t.Errorf("LastSearch() scanned %d bytes, want %d", scanned, tc.wantScanned)
+			}
+			if reader.Truncated() != tc.wantTruncated {
+				t.Errorf("Truncated() = %v, want %v", reader.Truncated(), tc.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestLogReaderChecksum(t *testing.T) {
+	DefaultChecksumScans = true
+	defer func() { DefaultChecksumScans = false }()
+	base := time.Date(2022, time.November, 28, 2, 0, 0, 0, time.UTC)
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf("time=%s msg=line%d", base.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), i))
+	}
+	log := strings.Join(lines, "\n") + "\n"
+	// offset returns the byte offset of the start of a line
+	offset := func(line int) int64 { return int64(strings.Index(log, lines[line])) }
+	at := func(minute int) time.Time { return base.Add(time.Duration(minute) * time.Minute) }
+	path := filepath.Join(t.TempDir(), "messages")
+	if err := os.WriteFile(path, []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reader := &LogReader{Path: path, Sorted: true, Cache: NewReadCache(0, 0), TimestampParser: ParseLogfmtTimestamp}
+	if _, err := reader.Checksum(); err == nil {
+		t.Error("Checksum() before a search error = nil, want an error")
+	}
+	for _, tc := range []struct {
+		name       string
+		clear      bool
+		start, end time.Time
+		wantStart  int64
+		wantEnd    int64
+	}{
+		{name: "the searched window", start: at(3), end: at(5), wantStart: offset(3), wantEnd: offset(6)},
+		{name: "a search within the range", start: at(4), end: at(4), wantStart: offset(3), wantEnd: offset(6)},
+		{name: "searches of a pass extend the range", start: at(5), end: at(7), wantStart: offset(3), wantEnd: offset(8)},
+		{name: "the first search after clearing the cache starts over", clear: true, start: at(0), end: at(1), wantStart: 0, wantEnd: offset(2)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.clear {
+				reader.ClearCache()
+			}
+			reader.SetSearchWindow(tc.start, tc.end)
+			if _, err := reader.Find(regexp.MustCompile("msg=")); err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			checksum, err := reader.Checksum()
+			if err != nil {
+				t.Fatalf("Checksum() error = %v", err)
+			}
+			sum := sha256.Sum256([]byte(log[tc.wantStart:tc.wantEnd]))
+			want := ScanChecksum{Path: path, SHA256: hex.EncodeToString(sum[:]), Start: tc.wantStart, End: tc.wantEnd}
+			if *checksum != want {
+				t.Errorf("Checksum() = %+v, want %+v", *checksum, want)
+			}
+		})
+	}
+	// the checksum of the last pass is kept after the cache is cleared
+	reader.ClearCache()
+	if checksum, err := reader.Checksum(); err != nil || checksum.End != offset(2) {
+		t.Errorf("Checksum() after ClearCache() = %+v, %v, want the checksum of the last pass", checksum, err)
+	}
+}